@@ -0,0 +1,301 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package calib implements tools to calibrate the parameters of material models against
+// (laboratory) data; i.e. the minimisation of residuals by means of least-squares or
+// evolutionary methods
+//  References:
+//   [1] Pedroso DM and Williams DJ (2011) Automatic Calibration of soil-water characteristic
+//       curves using genetic algorithms. Computers and Geotechnics, 38(3), 330-340,
+//       http://dx.doi.org/10.1016/j.compgeo.2010.12.004
+//   [2] Storn R and Price K (1997) Differential evolution - a simple and efficient heuristic for
+//       global optimization over continuous spaces. Journal of Global Optimization, 11, 341-359
+package calib
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/cpmech/gosl/chk"
+)
+
+// Problem defines a bounded least-squares problem:
+//
+//   find x in [Lower, Upper] minimising cost(x) = ½ Σ r_i(x)²
+//
+type Problem struct {
+	Lower []float64                  // [nx] lower bounds
+	Upper []float64                  // [nx] upper bounds
+	Nr    int                        // number of residuals
+	Resid func(r, x []float64) error // computes residuals r(x)
+	Hrel  float64                    // relative step for numerical Jacobian; e.g. larger for noisy residuals. default = 1e-7
+}
+
+// Cost computes cost = ½ Σ r_i² with r = r(x)
+//  Note: +Inf is returned if the residuals cannot be computed; e.g. x is not admissible
+func (o *Problem) Cost(r, x []float64) float64 {
+	if o.Resid(r, x) != nil {
+		return math.Inf(1)
+	}
+	c := 0.0
+	for _, v := range r {
+		c += v * v
+	}
+	if math.IsNaN(c) {
+		return math.Inf(1)
+	}
+	return c / 2.0
+}
+
+// LevMar minimises the cost by means of the Levenberg-Marquardt method with a numerical Jacobian.
+// Steps are projected onto the bounds.
+//  Input:
+//   x     -- [nx] initial values
+//   tol   -- tolerance on the relative change of the cost
+//   maxit -- maximum number of iterations
+//  Output:
+//   x    -- [nx] optimal values
+//   cost -- minimum cost
+func (o *Problem) LevMar(x []float64, tol float64, maxit int) (cost float64, nit int, err error) {
+
+	// check
+	nx := len(x)
+	if len(o.Lower) != nx || len(o.Upper) != nx {
+		return 0, 0, chk.Err("calib: sizes of bounds (%d,%d) must be equal to the number of unknowns (%d)", len(o.Lower), len(o.Upper), nx)
+	}
+	o.clip(x)
+
+	// workspace
+	r := make([]float64, o.Nr)
+	rp := make([]float64, o.Nr)
+	xnew := make([]float64, nx)
+	J := make([][]float64, o.Nr)
+	for i := 0; i < o.Nr; i++ {
+		J[i] = make([]float64, nx)
+	}
+	A := make([][]float64, nx)
+	for i := 0; i < nx; i++ {
+		A[i] = make([]float64, nx)
+	}
+	g := make([]float64, nx)
+	δ := make([]float64, nx)
+
+	// initial cost
+	cost = o.Cost(r, x)
+	if math.IsInf(cost, 1) {
+		return cost, 0, chk.Err("calib: cannot compute residuals with initial values x = %v", x)
+	}
+
+	// iterations
+	hrel := o.Hrel
+	if hrel <= 0 {
+		hrel = 1e-7
+	}
+	μ := 1e-3
+	for nit = 0; nit < maxit; nit++ {
+
+		// Jacobian: forward differences (backward if at upper bound)
+		for j := 0; j < nx; j++ {
+			h := hrel * math.Max(math.Abs(x[j]), 1e-3*(o.Upper[j]-o.Lower[j]))
+			if x[j]+h > o.Upper[j] {
+				h = -h
+			}
+			xj := x[j]
+			x[j] += h
+			err = o.Resid(rp, x)
+			x[j] = xj
+			if err != nil {
+				return
+			}
+			for i := 0; i < o.Nr; i++ {
+				J[i][j] = (rp[i] - r[i]) / h
+			}
+		}
+
+		// A = Jt*J and g = Jt*r
+		for j := 0; j < nx; j++ {
+			g[j] = 0
+			for i := 0; i < o.Nr; i++ {
+				g[j] += J[i][j] * r[i]
+			}
+			for k := 0; k < nx; k++ {
+				A[j][k] = 0
+				for i := 0; i < o.Nr; i++ {
+					A[j][k] += J[i][j] * J[i][k]
+				}
+			}
+		}
+
+		// find step reducing the cost
+		accepted := false
+		for μ < 1e15 {
+			for j := 0; j < nx; j++ {
+				δ[j] = -g[j]
+			}
+			if !solve_damped(δ, A, μ) {
+				μ *= 10
+				continue
+			}
+			for j := 0; j < nx; j++ {
+				xnew[j] = x[j] + δ[j]
+			}
+			o.clip(xnew)
+			cnew := o.Cost(rp, xnew)
+			if cnew < cost {
+				copy(x, xnew)
+				copy(r, rp)
+				converged := cost-cnew <= tol*cost
+				cost = cnew
+				μ = math.Max(μ/10, 1e-12)
+				accepted = true
+				if converged {
+					return cost, nit + 1, nil
+				}
+				break
+			}
+			μ *= 10
+		}
+		if !accepted {
+			return cost, nit + 1, nil // no further improvement is possible
+		}
+	}
+	return cost, nit, chk.Err("calib: Levenberg-Marquardt did not converge after %d iterations", nit)
+}
+
+// DiffEvol minimises the cost by means of the differential evolution method (DE/rand/1/bin) [2].
+// The initial population is randomly generated within the bounds and includes x.
+//  Input:
+//   x    -- [nx] initial values
+//   npop -- size of population; e.g. 10 * nx
+//   ngen -- number of generations
+//   seed -- seed for the random numbers generator
+//  Output:
+//   x    -- [nx] best values
+//   cost -- minimum cost
+func (o *Problem) DiffEvol(x []float64, npop, ngen int, seed int64) (cost float64, err error) {
+
+	// check
+	nx := len(x)
+	if len(o.Lower) != nx || len(o.Upper) != nx {
+		return 0, chk.Err("calib: sizes of bounds (%d,%d) must be equal to the number of unknowns (%d)", len(o.Lower), len(o.Upper), nx)
+	}
+	if npop < 4 {
+		return 0, chk.Err("calib: size of population must be at least 4. %d is invalid", npop)
+	}
+	o.clip(x)
+
+	// initial population
+	rnd := rand.New(rand.NewSource(seed))
+	r := make([]float64, o.Nr)
+	pop := make([][]float64, npop)
+	costs := make([]float64, npop)
+	for k := 0; k < npop; k++ {
+		pop[k] = make([]float64, nx)
+		for j := 0; j < nx; j++ {
+			pop[k][j] = o.Lower[j] + rnd.Float64()*(o.Upper[j]-o.Lower[j])
+		}
+		if k == 0 {
+			copy(pop[k], x)
+		}
+		costs[k] = o.Cost(r, pop[k])
+	}
+
+	// generations
+	const F, CR = 0.7, 0.9 // differential weight and crossover probability
+	trial := make([]float64, nx)
+	for gen := 0; gen < ngen; gen++ {
+		for k := 0; k < npop; k++ {
+
+			// select three distinct members (different from k)
+			a, b, c := k, k, k
+			for a == k {
+				a = rnd.Intn(npop)
+			}
+			for b == k || b == a {
+				b = rnd.Intn(npop)
+			}
+			for c == k || c == a || c == b {
+				c = rnd.Intn(npop)
+			}
+
+			// mutation and crossover
+			jr := rnd.Intn(nx)
+			for j := 0; j < nx; j++ {
+				if j == jr || rnd.Float64() < CR {
+					trial[j] = pop[a][j] + F*(pop[b][j]-pop[c][j])
+				} else {
+					trial[j] = pop[k][j]
+				}
+			}
+			o.clip(trial)
+
+			// selection
+			ct := o.Cost(r, trial)
+			if ct <= costs[k] {
+				copy(pop[k], trial)
+				costs[k] = ct
+			}
+		}
+	}
+
+	// best member
+	best := 0
+	for k := 1; k < npop; k++ {
+		if costs[k] < costs[best] {
+			best = k
+		}
+	}
+	copy(x, pop[best])
+	cost = costs[best]
+	if math.IsInf(cost, 1) {
+		err = chk.Err("calib: differential evolution could not find any admissible set of values")
+	}
+	return
+}
+
+// clip forces x to be within bounds
+func (o *Problem) clip(x []float64) {
+	for j := range x {
+		x[j] = math.Min(math.Max(x[j], o.Lower[j]), o.Upper[j])
+	}
+}
+
+// solve_damped solves (A + μ diag(A)) δ = b by Gaussian elimination with partial pivoting
+//  Input: δ = b. Output: δ = solution. Returns false if the matrix is singular
+func solve_damped(δ []float64, A [][]float64, μ float64) bool {
+	n := len(δ)
+	M := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		M[i] = make([]float64, n+1)
+		copy(M[i], A[i])
+		M[i][i] += μ * math.Max(A[i][i], 1e-14)
+		M[i][n] = δ[i]
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(M[i][k]) > math.Abs(M[p][k]) {
+				p = i
+			}
+		}
+		if M[p][k] == 0 {
+			return false
+		}
+		M[k], M[p] = M[p], M[k]
+		for i := k + 1; i < n; i++ {
+			m := M[i][k] / M[k][k]
+			for j := k; j <= n; j++ {
+				M[i][j] -= m * M[k][j]
+			}
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		s := M[i][n]
+		for j := i + 1; j < n; j++ {
+			s -= M[i][j] * δ[j]
+		}
+		δ[i] = s / M[i][i]
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package calib
+
+import (
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+)
+
+// Prm holds the name and bounds of a parameter to be calibrated
+type Prm struct {
+	N   string  `json:"n"`   // name of parameter
+	Min float64 `json:"min"` // lower bound
+	Max float64 `json:"max"` // upper bound
+}
+
+// Options holds the options of the minimisation; e.g. read from calibration files
+type Options struct {
+	Method string  `json:"method"` // "lsq": least squares (default); "evol": differential evolution followed by least squares
+	Tol    float64 `json:"tol"`    // tolerance for least squares; default = 1e-10
+	MaxIt  int     `json:"maxit"`  // max number of least squares iterations; default = 200
+	Npop   int     `json:"npop"`   // size of population for evolutionary search; default = 10 * nfree
+	Ngen   int     `json:"ngen"`   // number of generations for evolutionary search; default = 100
+	Seed   int64   `json:"seed"`   // seed for evolutionary search
+}
+
+// Init checks options and sets defaults
+//  nfree -- number of parameters to be calibrated
+func (o *Options) Init(nfree int) (err error) {
+	if o.Method == "" {
+		o.Method = "lsq"
+	}
+	if o.Method != "lsq" && o.Method != "evol" {
+		return chk.Err("calib: method %q is invalid; options: lsq, evol", o.Method)
+	}
+	if o.Tol <= 0 {
+		o.Tol = 1e-10
+	}
+	if o.MaxIt < 1 {
+		o.MaxIt = 200
+	}
+	if o.Npop < 4 {
+		o.Npop = 10 * nfree
+		if o.Npop < 4 {
+			o.Npop = 4
+		}
+	}
+	if o.Ngen < 1 {
+		o.Ngen = 100
+	}
+	return
+}
+
+// GetPrms returns the parameters in prms to be calibrated and checks their bounds
+func GetPrms(prms fun.Prms, free []*Prm) (res []*fun.Prm, err error) {
+	if len(free) < 1 {
+		return nil, chk.Err("calib: at least one parameter must be calibrated")
+	}
+	res = make([]*fun.Prm, len(free))
+	for i, f := range free {
+		res[i] = prms.Find(f.N)
+		if res[i] == nil {
+			return nil, chk.Err("calib: cannot find parameter %q", f.N)
+		}
+		if f.Min > f.Max {
+			return nil, chk.Err("calib: bounds of parameter %q are invalid: min=%g > max=%g", f.N, f.Min, f.Max)
+		}
+	}
+	return
+}
+
+// Solve minimises the cost of prob with the selected method. The bounds of prob are set with free
+// and the initial values are taken from prms, which receive the optimal values at the end
+//  Input:
+//   free -- [nx] parameters to be calibrated
+//   prms -- [nx] parameters corresponding to free; see GetPrms
+func (o *Options) Solve(prob *Problem, free []*Prm, prms []*fun.Prm) (cost float64, err error) {
+	nx := len(free)
+	prob.Lower, prob.Upper = make([]float64, nx), make([]float64, nx)
+	x := make([]float64, nx)
+	for i, f := range free {
+		prob.Lower[i], prob.Upper[i] = f.Min, f.Max
+		x[i] = prms[i].V
+	}
+	if o.Method == "evol" {
+		_, err = prob.DiffEvol(x, o.Npop, o.Ngen, o.Seed)
+		if err != nil {
+			return
+		}
+	}
+	cost, _, err = prob.LevMar(x, o.Tol, o.MaxIt)
+	for i, prm := range prms {
+		prm.V = x[i]
+	}
+	return
+}
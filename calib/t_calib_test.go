@@ -0,0 +1,130 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package calib
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_calib01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("calib01. exponential decay: y = a exp(-b t) + c")
+
+	// data
+	a, b, c := 2.5, 0.7, 0.3
+	T := []float64{0, 1, 2, 3, 4, 5}
+	Y := make([]float64, len(T))
+	for i, t := range T {
+		Y[i] = a*math.Exp(-b*t) + c
+	}
+
+	// problem
+	prob := Problem{
+		Lower: []float64{0, 0, -1},
+		Upper: []float64{10, 5, 1},
+		Nr:    len(T),
+		Resid: func(r, x []float64) error {
+			for i, t := range T {
+				r[i] = x[0]*math.Exp(-x[1]*t) + x[2] - Y[i]
+			}
+			return nil
+		},
+	}
+
+	// least-squares
+	x := []float64{1, 0.1, 0}
+	cost, nit, err := prob.LevMar(x, 1e-15, 100)
+	if err != nil {
+		tst.Errorf("LevMar failed: %v\n", err)
+		return
+	}
+	io.Pforan("LevMar: x = %v  cost = %g  nit = %d\n", x, cost, nit)
+	chk.Vector(tst, "x (LevMar)", 1e-8, x, []float64{a, b, c})
+
+	// evolutionary
+	x = []float64{1, 0.1, 0}
+	cost, err = prob.DiffEvol(x, 30, 300, 1)
+	if err != nil {
+		tst.Errorf("DiffEvol failed: %v\n", err)
+		return
+	}
+	io.Pforan("DiffEvol: x = %v  cost = %g\n", x, cost)
+	chk.Vector(tst, "x (DiffEvol)", 1e-6, x, []float64{a, b, c})
+}
+
+func Test_options01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("options01. defaults, parameters to be calibrated and solution")
+
+	// defaults
+	var opt Options
+	err := opt.Init(3)
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+	if opt.Method != "lsq" {
+		tst.Errorf("default method must be lsq. %q is incorrect\n", opt.Method)
+		return
+	}
+	chk.Scalar(tst, "tol", 1e-17, opt.Tol, 1e-10)
+	chk.IntAssert(opt.MaxIt, 200)
+	chk.IntAssert(opt.Npop, 30)
+	chk.IntAssert(opt.Ngen, 100)
+	opt.Method = "ga"
+	if opt.Init(3) == nil {
+		tst.Errorf("Init must fail with invalid method\n")
+		return
+	}
+
+	// parameters
+	prms := fun.Prms{&fun.Prm{N: "a", V: 1}, &fun.Prm{N: "b", V: 0.1}, &fun.Prm{N: "c", V: 7}}
+	free := []*Prm{{N: "b", Min: 0, Max: 5}, {N: "a", Min: 0, Max: 10}}
+	vals, err := GetPrms(prms, free)
+	if err != nil {
+		tst.Errorf("GetPrms failed: %v\n", err)
+		return
+	}
+	if vals[0] != prms[1] || vals[1] != prms[0] {
+		tst.Errorf("GetPrms returned wrong parameters\n")
+		return
+	}
+	for _, f := range [][]*Prm{{{N: "d"}}, {{N: "a", Min: 1, Max: 0}}, nil} {
+		if _, err = GetPrms(prms, f); err == nil {
+			tst.Errorf("GetPrms must fail with %v\n", f)
+			return
+		}
+	}
+
+	// solve: y = a exp(-b t) with the bounds of free and optimal values set in prms
+	T := []float64{0, 1, 2, 3}
+	prob := Problem{
+		Nr: len(T),
+		Resid: func(r, x []float64) error {
+			for i, t := range T {
+				r[i] = x[1]*math.Exp(-x[0]*t) - 2.5*math.Exp(-0.7*t)
+			}
+			return nil
+		},
+	}
+	opt = Options{Tol: 1e-15}
+	opt.Init(len(free))
+	_, err = opt.Solve(&prob, free, vals)
+	if err != nil {
+		tst.Errorf("Solve failed: %v\n", err)
+		return
+	}
+	chk.Vector(tst, "bounds", 1e-17, append(prob.Lower, prob.Upper...), []float64{0, 0, 5, 10})
+	chk.Scalar(tst, "a", 1e-8, prms.Find("a").V, 2.5)
+	chk.Scalar(tst, "b", 1e-8, prms.Find("b").V, 0.7)
+	chk.Scalar(tst, "c", 1e-17, prms.Find("c").V, 7)
+}
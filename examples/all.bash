@@ -7,7 +7,8 @@ examples="rjoint_ex06_pullout \
           spo751_pressurised_cylinder \
           spo754_strip_footing_collapse \
           up_3mcolumn_desiccation \
-          up_indentation2d_unsat"
+          up_indentation2d_unsat \
+          up_indentation2d_contact"
 
 for ex in $examples; do
     echo
@@ -0,0 +1,10 @@
+#!/bin/bash
+
+# contact pairs are not available in parallel runs; thus the simulations are serial
+
+FILES="a-indenter-elast-d2-q9 b-indenter-elast-d2-q9"
+
+for f in $FILES; do
+    gofem $f
+    GenVtu $f
+done
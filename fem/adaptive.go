@@ -0,0 +1,254 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"log"
+	"math"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+// AdaptiveDt implements an adaptive time stepping controller for all domains. The new time step is
+//   Δt_new = m Δt   with   m = min(m_it, m_err)   and   mmin ≤ m ≤ mmax
+// where m_it = nopt / nit depends on the largest number of iterations (nit) among all domains and
+// m_err = mfac sqrt(1 / err) depends on the largest local error estimate (err) among all domains.
+// The local error is estimated by comparing the converged solution with an explicit predictor
+// (computed with dy/dt and d²y/dt²) and considers the t1 and t2 variables only; thus m_err = mmax
+// for steady simulations. Steps with diverging iterations or err > 1 are rejected.
+//  Note: DtFunc gives the initial Δt of each stage only
+type AdaptiveDt struct {
+
+	// auxiliary variables
+	nsteps  int // total number of steps
+	naccept int // number of accepted steps
+	nreject int // number of rejected steps
+	ndiverg int // number of diverging steps (in a row)
+
+	// time loop
+	Δt    float64     // time step
+	Ypred [][]float64 // [ndom][ny] predicted y values (for local error estimate)
+}
+
+// Init initialises adaptive time stepping structure
+func (o *AdaptiveDt) Init(domains []*Domain, Dt fun.Func, t float64) {
+
+	// auxiliary variables
+	o.nsteps = 0
+	o.naccept = 0
+	o.nreject = 0
+	o.ndiverg = 0
+
+	// time loop
+	o.Δt = Dt.F(t, nil)
+	if Global.Sim.Solver.AdDtMax > 0 {
+		o.Δt = min(o.Δt, Global.Sim.Solver.AdDtMax)
+	}
+	o.Ypred = make([][]float64, len(domains))
+	if !Global.Sim.Data.Steady {
+		for i, d := range domains {
+			o.Ypred[i] = make([]float64, d.Ny)
+		}
+	}
+}
+
+// Run runs the time loop with adaptive time steps
+func (o *AdaptiveDt) Run(domains []*Domain, s *Summary, DtOut fun.Func, time *float64, tf, tout float64, tidx *int) (ok bool) {
+
+	// stat
+	defer func() {
+		if Global.Root {
+			log.Printf("adaptive Δt: total number of steps    = %d\n", o.nsteps)
+			log.Printf("adaptive Δt: number of accepted steps = %d\n", o.naccept)
+			log.Printf("adaptive Δt: number of rejected steps = %d\n", o.nreject)
+		}
+	}()
+
+	// constants
+	nopt := float64(Global.Sim.Solver.AdNopt)
+	mmin := Global.Sim.Solver.AdMmin
+	mmax := Global.Sim.Solver.AdMmax
+	mfac := Global.Sim.Solver.AdMfac
+	dtmax := Global.Sim.Solver.AdDtMax
+
+	// time loop
+	t := *time
+	defer func() { *time = t }()
+	var Δt float64
+	var lasttimestep bool
+	for t < tf {
+
+		// check for continued divergence
+		if LogErrCond(o.ndiverg >= Global.Sim.Solver.NdvgMax, "continuous divergence after %d steps reached", o.ndiverg) {
+			return false
+		}
+
+		// time increment
+		Δt = o.Δt
+		lasttimestep = false
+		if t+Δt >= tf {
+			Δt = tf - t
+			lasttimestep = true
+		}
+		if LogErrCond(Δt < Global.Sim.Solver.DtMin, "Δt increment is too small: %g < %g", Δt, Global.Sim.Solver.DtMin) {
+			return false
+		}
+
+		// dynamic coefficients
+		if LogErr(Global.DynCoefs.CalcBoth(Δt), "cannot compute dynamic coefficients") {
+			return false
+		}
+
+		// backup domains and compute predictors
+		o.nsteps += 1
+		for i, d := range domains {
+			d.backup()
+			o.predictor(i, d, Δt)
+		}
+
+		// time update
+		t += Δt
+		for _, d := range domains {
+			d.Sol.T = t
+		}
+
+		// message
+		if Global.Verbose {
+			if !Global.Sim.Data.ShowR && !Global.Debug {
+				io.PfWhite("%30.15f%30.15f\r", t, Δt)
+			}
+		}
+
+		// run iterations for all domains
+		nitmax := 0
+		diverging := false
+		for _, d := range domains {
+			var nit int
+			diverging, nit, ok = run_iterations(t, Δt, d, s)
+			if !ok {
+				return false
+			}
+			if diverging {
+				break
+			}
+			if nit > nitmax {
+				nitmax = nit
+			}
+		}
+
+		// restore solution and reduce time step if iterations are diverging
+		if diverging {
+			if Global.Verbose {
+				io.Pfred(". . . adaptive Δt: iterations diverging (%2d) . . .\n", o.ndiverg+1)
+			}
+			o.restore(domains)
+			t -= Δt
+			o.Δt = mmin * Δt
+			o.ndiverg += 1
+			o.nreject += 1
+			continue
+		}
+		o.ndiverg = 0
+
+		// local error estimate and step size multiplier
+		err := 0.0
+		for i, d := range domains {
+			err = max(err, o.local_error(i, d))
+		}
+		m := min(mmax, max(mmin, nopt/max(1, float64(nitmax))))
+		if err > 0 {
+			m = min(m, max(mmin, mfac*math.Sqrt(1.0/err)))
+		}
+
+		// rejected
+		if err > 1 {
+			o.restore(domains)
+			t -= Δt
+			o.Δt = m * Δt
+			o.nreject += 1
+			continue
+		}
+
+		// accepted
+		o.naccept += 1
+		s.StepDts = append(s.StepDts, Δt)
+		if len(Global.Sim.Data.Sens) > 0 {
+			for _, d := range domains {
+				if !d.Sensitivities() {
+					return false
+				}
+			}
+		}
+		if lasttimestep {
+			m = max(m, 1) // do not reduce Δt because of a truncated last step
+		}
+		o.Δt = m * Δt
+		if dtmax > 0 {
+			o.Δt = min(o.Δt, dtmax)
+		}
+
+		// perform output
+		if t >= tout || lasttimestep {
+			s.AddOutTime(t)
+			for _, d := range domains {
+				if !d.Out(*tidx) {
+					break
+				}
+			}
+			if Stop() {
+				return false
+			}
+			if !s.Save() {
+				return false
+			}
+			tout += DtOut.F(t, nil)
+			*tidx += 1
+		}
+	}
+	return true
+}
+
+// predictor computes the explicit predictor of y for the t1 and t2 variables
+func (o *AdaptiveDt) predictor(idx int, d *Domain, Δt float64) {
+	if Global.Sim.Data.Steady {
+		return
+	}
+	for _, I := range d.T1eqs {
+		o.Ypred[idx][I] = d.Sol.Y[I] + Δt*d.Sol.Dydt[I]
+	}
+	for _, I := range d.T2eqs {
+		o.Ypred[idx][I] = d.Sol.Y[I] + Δt*d.Sol.Dydt[I] + Δt*Δt*d.Sol.D2ydt2[I]/2.0
+	}
+}
+
+// local_error computes the RMS norm of the local error estimate err = |y - ypred| / 2
+// scaled by atol + rtol |y| considering the t1 and t2 variables only
+func (o *AdaptiveDt) local_error(idx int, d *Domain) (err float64) {
+	if Global.Sim.Data.Steady {
+		return
+	}
+	atol := Global.Sim.Solver.AdAtol
+	rtol := Global.Sim.Solver.AdRtol
+	n := len(d.T1eqs) + len(d.T2eqs)
+	if n == 0 {
+		return
+	}
+	var e float64
+	for _, eqs := range [][]int{d.T1eqs, d.T2eqs} {
+		for _, I := range eqs {
+			e = (d.Sol.Y[I] - o.Ypred[idx][I]) / (2.0 * (atol + rtol*math.Abs(d.Sol.Y[I])))
+			err += e * e
+		}
+	}
+	return math.Sqrt(err / float64(n))
+}
+
+// restore restores all domains
+func (o *AdaptiveDt) restore(domains []*Domain) {
+	for _, d := range domains {
+		d.restore()
+	}
+}
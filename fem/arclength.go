@@ -0,0 +1,480 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"log"
+	"math"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// ArcLength implements the arc-length continuation method (Riks/Crisfield) where the natural
+// boundary conditions evaluated at the final time of the stage (reference load pattern q) are
+// scaled by the load factor λ stored in Solution.LoadFac. The equilibrium equations are
+//   R(y, λ) = fint(y) - λ q = 0
+// together with one of the following constraints on the increments Δy and Δλ of a step:
+//   Crisfield (spherical):  Δy・Δy + ψ² Δλ² q・q = Δl²
+//   Riks (normal plane):    Δy・δy + ψ² Δλ δλ q・q = 0
+// The time variable works as a pseudo-time that controls the maximum number of steps and the
+// output. Essential boundary conditions are evaluated at the final time of the stage as well.
+// Distributed loads on beams are scaled as well; however, body forces such as gravity are not
+// scaled and thus act as dead loads.
+//  Note: the load factor starts from zero at the beginning of the stage
+type ArcLength struct {
+
+	// auxiliary variables
+	nsteps  int // total number of steps
+	naccept int // number of accepted steps
+	nreject int // number of rejected steps
+	ndiverg int // number of diverging steps (in a row)
+	eqctrl  int // equation of control displacement; -1 => use largest absolute value of y
+
+	// arc-length data
+	Δl     float64   // arc-length
+	Δλ0    float64   // load factor increment defining the arc-length of the first step
+	Δλ     float64   // load factor increment in current step
+	q      []float64 // reference load vector (augmented) [nyb]
+	w2     []float64 // K⁻¹ q (augmented) [nyb]
+	ΔYold  []float64 // increments of y from last converged step [ny]
+	largQ  float64   // largest absolute component of q
+	qq     float64   // q・q
+	lamMax float64   // maximum load factor
+
+	// time loop
+	Dt fun.Func // pseudo-time increment function
+}
+
+// Init initialises arc-length structure
+func (o *ArcLength) Init(d *Domain, Dt fun.Func) (ok bool) {
+
+	// check
+	if LogErrCond(!Global.Sim.Data.Steady, "arc-length method works with steady simulations only") {
+		return
+	}
+	if LogErrCond(Global.Distr, "arc-length method does not work in parallel yet") {
+		return
+	}
+
+	// auxiliary variables
+	o.nsteps = 0
+	o.naccept = 0
+	o.nreject = 0
+	o.ndiverg = 0
+	o.eqctrl = -1
+	key := Global.Sim.Solver.ArcKey
+	if key != "" {
+		vid := Global.Sim.Solver.ArcVert
+		if LogErrCond(vid < 0 || vid >= len(d.Vid2node), "arc-length: vertex of control displacement is invalid. vid = %d", vid) {
+			return
+		}
+		nod := d.Vid2node[vid]
+		if LogErrCond(nod == nil, "arc-length: vertex %d of control displacement is not active", vid) {
+			return
+		}
+		o.eqctrl = nod.GetEq(key)
+		if LogErrCond(o.eqctrl < 0, "arc-length: cannot find dof %q at vertex %d", key, vid) {
+			return
+		}
+	}
+
+	// arc-length data
+	o.Δl = 0
+	o.Δλ = 0
+	o.Δλ0 = Global.Sim.Solver.ArcDlam0
+	o.q = make([]float64, d.Nyb)
+	o.w2 = make([]float64, d.Nyb)
+	o.ΔYold = make([]float64, d.Ny)
+	o.lamMax = Global.Sim.Solver.ArcLamMax
+
+	// time loop
+	o.Dt = Dt
+
+	// load factor starts from zero
+	d.Sol.LoadFac = 0
+	return true
+}
+
+// Run runs the continuation steps
+func (o *ArcLength) Run(d *Domain, s *Summary, DtOut fun.Func, time *float64, tf, tout float64, tidx *int) (ok bool) {
+
+	// stat
+	defer func() {
+		if Global.Root {
+			log.Printf("arc-length: total number of steps    = %d\n", o.nsteps)
+			log.Printf("arc-length: number of accepted steps = %d\n", o.naccept)
+			log.Printf("arc-length: number of rejected steps = %d\n", o.nreject)
+		}
+	}()
+
+	// initial point of equilibrium path
+	s.LoadFacs = append(s.LoadFacs, d.Sol.LoadFac)
+	s.CtrlDisp = append(s.CtrlDisp, o.ctrl_disp(d))
+
+	// pseudo-time loop
+	t := *time
+	defer func() { *time = t }()
+	var Δt float64
+	var nit int
+	var converged, lasttimestep bool
+	for t < tf {
+
+		// check for continued divergence
+		if LogErrCond(o.ndiverg >= Global.Sim.Solver.NdvgMax, "arc-length: continuous divergence after %d steps reached", o.ndiverg) {
+			return false
+		}
+
+		// pseudo-time increment
+		Δt = o.Dt.F(t, nil)
+		if t+Δt >= tf {
+			Δt = tf - t
+			lasttimestep = true
+		}
+		if LogErrCond(Δt < Global.Sim.Solver.DtMin, "arc-length: Δt increment is too small: %g < %g", Δt, Global.Sim.Solver.DtMin) {
+			return false
+		}
+
+		// backup domain
+		o.nsteps += 1
+		d.backup()
+
+		// run iterations with natural boundary conditions @ tf
+		d.Sol.T = tf
+		converged, nit, ok = o.run_iterations(t+Δt, d, s)
+		if !ok {
+			return false
+		}
+
+		// restore solution and reduce arc-length if not converged
+		if !converged {
+			if Global.Verbose {
+				io.Pfred(". . . arc-length: iterations diverging (%2d) . . .\n", o.ndiverg+1)
+			}
+			d.restore()
+			if o.naccept == 0 {
+				o.Δλ0 *= 0.5
+			} else {
+				o.Δl *= 0.5
+			}
+			o.ndiverg += 1
+			o.nreject += 1
+			lasttimestep = false
+			continue
+		}
+		o.ndiverg = 0
+		o.naccept += 1
+
+		// update pseudo-time and record point of equilibrium path
+		t += Δt
+		d.Sol.T = t
+		s.LoadFacs = append(s.LoadFacs, d.Sol.LoadFac)
+		s.CtrlDisp = append(s.CtrlDisp, o.ctrl_disp(d))
+		reachedmax := o.lamMax > 0 && d.Sol.LoadFac >= o.lamMax
+
+		// message
+		if Global.Verbose {
+			if !Global.Sim.Data.ShowR && !Global.Debug {
+				io.PfWhite("%30.15f%30.15f\r", t, d.Sol.LoadFac)
+			}
+		}
+
+		// perform output
+		if t >= tout || lasttimestep || reachedmax {
+			s.AddOutTime(t)
+			if !d.Out(*tidx) {
+				return false
+			}
+			if !s.Save() {
+				return false
+			}
+			tout += DtOut.F(t, nil)
+			*tidx += 1
+		}
+
+		// reached maximum load factor
+		if reachedmax {
+			break
+		}
+
+		// next arc-length: Δl_new = Δl * sqrt(nopt / nit)
+		copy(o.ΔYold, d.Sol.ΔY)
+		m := math.Sqrt(float64(Global.Sim.Solver.ArcNopt) / float64(nit))
+		o.Δl *= min(Global.Sim.Solver.ArcMmax, max(Global.Sim.Solver.ArcMmin, m))
+	}
+	return true
+}
+
+// run_iterations computes predictor and corrector (iterations) of one arc-length step
+func (o *ArcLength) run_iterations(t float64, d *Domain, sum *Summary) (converged bool, it int, ok bool) {
+
+	// zero accumulated increments
+	la.VecFill(d.Sol.ΔY, 0)
+
+	// reference load vector: q = fb(λ=1) - fb(λ=0)
+	λ := d.Sol.LoadFac
+	d.Sol.LoadFac = 1
+	if !o.assemble_fb(d, o.q) {
+		return
+	}
+	d.Sol.LoadFac = 0
+	if !o.assemble_fb(d, d.Fb) {
+		return
+	}
+	d.Sol.LoadFac = λ
+	for i := 0; i < d.Nyb; i++ {
+		if i < d.Ny {
+			o.q[i] -= d.Fb[i]
+		} else {
+			o.q[i] = 0
+		}
+	}
+	o.qq = la.VecDot(o.q[:d.Ny], o.q[:d.Ny])
+	o.largQ = la.VecLargest(o.q[:d.Ny], 1)
+	if LogErrCond(o.qq < Global.Sim.Solver.FbMin, "arc-length: reference load vector is zero. natural boundary conditions must be given") {
+		return
+	}
+
+	// auxiliary variables
+	ψ := Global.Sim.Solver.ArcPsi
+	ψ2qq := ψ * ψ * o.qq
+	var largFb, Lδu, prevFb float64
+
+	// message
+	if Global.Sim.Data.ShowR {
+		io.Pf("\n%13s%4s%23s%23s%23s\n", "t", "it", "largFb", "Lδu", "λ")
+		defer func() {
+			io.Pf("%13.6e%4d%23.15e%23.15e%23.15e\n", t, it, largFb, Lδu, d.Sol.LoadFac)
+		}()
+	}
+
+	// predictor: δy_t = K⁻¹ q
+	//  Note: as with Lagrange multipliers, the corrections of constrained equations are only
+	//        applied by the corrector; thus they are discarded here in case of elimination
+	if d.EssenBcs.Elim != nil {
+		d.EssenBcs.Elim.discard()
+	}
+	if !o.assemble_kb(d, true) {
+		return
+	}
+	LogErr(d.EssenBcs.SolveR(d.LinSol, o.w2, o.q), "arc-length: solve for predictor")
+	if Stop() {
+		return
+	}
+	yy := la.VecDot(o.w2[:d.Ny], o.w2[:d.Ny])
+	if o.naccept == 0 { // first step
+		o.Δl = o.Δλ0 * math.Sqrt(yy+ψ2qq)
+	}
+	o.Δλ = o.Δl / math.Sqrt(yy+ψ2qq)
+	if la.VecDot(o.ΔYold, o.w2[:d.Ny]) < 0 { // follow the path: same direction as last step
+		o.Δλ = -o.Δλ
+	}
+	o.update_y(d, o.Δλ, false)
+
+	// create backup copy of all secondary variables
+	for _, e := range d.ElemIntvars {
+		e.BackupIvs(false)
+	}
+
+	// update secondary variables
+	d.elems_update()
+	if Stop() {
+		return
+	}
+
+	// corrector iterations
+	var a, b, c, δλ float64
+	for it = 1; it <= Global.Sim.Solver.NmaxIt; it++ {
+
+		// assemble right-hand side vector (fb) with negative of residuals
+		if !o.assemble_fb(d, d.Fb) {
+			return
+		}
+
+		// find largest absolute component of fb
+		largFb = la.VecLargest(d.Fb, 1)
+
+		// save residual
+		if Global.Stat {
+			sum.Resids.Append(it == 1, largFb)
+		}
+
+		// check convergence on fb
+		if largFb < Global.Sim.Solver.FbTol*o.largQ || largFb < Global.Sim.Solver.FbMin {
+			converged, ok = true, true
+			return
+		}
+
+		// check divergence on fb
+		if it > 2 && Global.Sim.Solver.DvgCtrl {
+			if largFb > prevFb {
+				ok = true
+				return
+			}
+		}
+		prevFb = largFb
+
+		// assemble Jacobian matrix
+		if !Global.Sim.Data.CteTg {
+			if !o.assemble_kb(d, false) {
+				return
+			}
+		}
+
+		// solve for wb := δy_I = K⁻¹ fb and w2 := δy_II = K⁻¹ q
+		//  Note: with elimination, the corrections of constrained equations are consumed by the
+		//        first solution; thus δy_I must be computed first
+		LogErr(d.EssenBcs.SolveR(d.LinSol, d.Wb, d.Fb), "arc-length: solve for δy_I")
+		if Stop() {
+			return
+		}
+		LogErr(d.EssenBcs.SolveR(d.LinSol, o.w2, o.q), "arc-length: solve for δy_II")
+		if Stop() {
+			return
+		}
+
+		// load factor correction
+		if Global.Sim.Solver.ArcRiks {
+			δλ = -la.VecDot(d.Sol.ΔY, d.Wb[:d.Ny]) / (la.VecDot(d.Sol.ΔY, o.w2[:d.Ny]) + ψ2qq*o.Δλ)
+		} else {
+			a = la.VecDot(o.w2[:d.Ny], o.w2[:d.Ny]) + ψ2qq
+			b, c = 2.0*ψ2qq*o.Δλ, ψ2qq*o.Δλ*o.Δλ-o.Δl*o.Δl
+			for i := 0; i < d.Ny; i++ {
+				r := d.Sol.ΔY[i] + d.Wb[i]
+				b += 2.0 * r * o.w2[i]
+				c += r * r
+			}
+			disc := b*b - 4.0*a*c
+			if disc < 0 { // cannot satisfy constraint => reduce arc-length
+				ok = true
+				return
+			}
+			δλ = o.select_root(d, (-b+math.Sqrt(disc))/(2.0*a), (-b-math.Sqrt(disc))/(2.0*a))
+		}
+
+		// update primary variables and load factor
+		o.Δλ += δλ
+		o.update_y(d, δλ, true)
+
+		// recover last converged state from backup copy
+		for _, e := range d.ElemIntvars {
+			e.RestoreIvs(false)
+		}
+
+		// update secondary variables
+		d.elems_update()
+		if Stop() {
+			return
+		}
+
+		// compute RMS norm of δy (in Wb) and check convegence on δy
+		Lδu = la.VecRmsErr(d.Wb[:d.Ny], Global.Sim.Solver.Atol, Global.Sim.Solver.Rtol, d.Sol.Y[:d.Ny])
+
+		// message
+		if Global.Sim.Data.ShowR {
+			io.Pf("%13.6e%4d%23.15e%23.15e%23.15e\n", t, it, largFb, Lδu, d.Sol.LoadFac)
+		}
+
+		// stop if converged on δy
+		if Lδu < Global.Sim.Solver.Itol {
+			converged, ok = true, true
+			return
+		}
+	}
+
+	// max number of iterations reached => reduce arc-length
+	if Global.Verbose {
+		io.PfMag("arc-length: max number of iterations reached: it = %d\n", it-1)
+	}
+	ok = true
+	return
+}
+
+// update_y updates y, Δy, Lagrange multipliers and load factor with δy = δy_I + δλ δy_II
+//  Note: δy_I is in Wb and δy_II is in w2. Wb is replaced by δy
+func (o *ArcLength) update_y(d *Domain, δλ float64, withWb bool) {
+	if !withWb {
+		la.VecFill(d.Wb, 0)
+	}
+	for i := 0; i < d.Nyb; i++ {
+		d.Wb[i] += δλ * o.w2[i]
+	}
+	for i := 0; i < d.Ny; i++ {
+		d.Sol.Y[i] += d.Wb[i]  // y += δy
+		d.Sol.ΔY[i] += d.Wb[i] // Δy += δy
+	}
+	if d.Nyb > d.Ny { // otherwise λ is computed in EssenBcs.AddToRhs
+		for i := 0; i < d.Nlam; i++ {
+			d.Sol.L[i] += d.Wb[d.Ny+i] // λ += δλ (Lagrange multipliers)
+		}
+	}
+	d.Sol.LoadFac += δλ
+}
+
+// select_root selects the root of the spherical constraint that gives the smallest angle between
+// the new and old increments of y
+func (o *ArcLength) select_root(d *Domain, δλ1, δλ2 float64) float64 {
+	var cos1, cos2 float64
+	for i := 0; i < d.Ny; i++ {
+		cos1 += d.Sol.ΔY[i] * (d.Sol.ΔY[i] + d.Wb[i] + δλ1*o.w2[i])
+		cos2 += d.Sol.ΔY[i] * (d.Sol.ΔY[i] + d.Wb[i] + δλ2*o.w2[i])
+	}
+	if cos1 > cos2 {
+		return δλ1
+	}
+	return δλ2
+}
+
+// ctrl_disp returns the control displacement
+func (o *ArcLength) ctrl_disp(d *Domain) float64 {
+	if o.eqctrl < 0 {
+		return la.VecLargest(d.Sol.Y[:d.Ny], 1)
+	}
+	return d.Sol.Y[o.eqctrl]
+}
+
+// assemble_fb assembles the augmented right-hand side vector (negative of residuals) in fb
+func (o *ArcLength) assemble_fb(d *Domain, fb []float64) (ok bool) {
+	la.VecFill(fb, 0)
+	d.elems_add_to_rhs(fb)
+	if Stop() {
+		return
+	}
+	d.PtNatBcs.AddToRhs(fb, d.Sol)
+	d.EssenBcs.AddToRhs(fb, d.Sol)
+	return true
+}
+
+// assemble_kb assembles and factorises the augmented (or reduced) Jacobian matrix
+func (o *ArcLength) assemble_kb(d *Domain, firstIt bool) (ok bool) {
+
+	// assemble element matrices
+	kb := d.EssenBcs.Assembler(d.StartKb())
+	d.elems_add_to_kb(kb, firstIt)
+	if Stop() {
+		return
+	}
+
+	// join A and tr(A) matrices into Kb (or penalty terms)
+	if Global.Root {
+		d.EssenBcs.AddToKb(kb)
+	}
+
+	// initialise linear solver
+	if d.InitLSol {
+		if LogErr(d.LinSol.InitR(d.Kb, Global.Sim.LinSol.Symmetric, Global.Sim.LinSol.Verbose, Global.Sim.LinSol.Timing), "cannot initialise linear solver") {
+			return
+		}
+		d.InitLSol = false
+	}
+
+	// perform factorisation
+	LogErr(d.LinSol.Fact(), "factorisation")
+	if Stop() {
+		return
+	}
+	return true
+}
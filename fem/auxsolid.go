@@ -40,6 +40,26 @@ func IpAddToKt(Kt [][]float64, nne, ndim int, coef float64, G, D [][]float64) {
 	}
 }
 
+// IpAddToKtLarge adds the contribution of the spatial tangent modulus A to the stiffness matrix
+//  Note: gs are the spatial gradients of shape functions [nne][ndim]
+func IpAddToKtLarge(Kt [][]float64, nne, ndim int, coef float64, gs [][]float64, A [][][][]float64) {
+	for m := 0; m < nne; m++ {
+		for i := 0; i < ndim; i++ {
+			r := i + m*ndim
+			for n := 0; n < nne; n++ {
+				for k := 0; k < ndim; k++ {
+					c := k + n*ndim
+					for j := 0; j < ndim; j++ {
+						for l := 0; l < ndim; l++ {
+							Kt[r][c] += coef * gs[m][j] * A[i][j][k][l] * gs[n][l]
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
 func IpStrains(εs []float64, nne, ndim int, u []float64, Umap []int, G [][]float64) {
 	var r, c int
 	var εsij float64
@@ -230,3 +250,11 @@ func FlowKeys() []string {
 	}
 	return []string{"nwlx", "nwly", "nwlz"}
 }
+
+func GasFlowKeys() []string {
+	// nwg == ng・wg == gas filter velocity
+	if Global.Ndim == 2 {
+		return []string{"nwgx", "nwgy"}
+	}
+	return []string{"nwgx", "nwgy", "nwgz"}
+}
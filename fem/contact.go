@@ -0,0 +1,774 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"log"
+	"math"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/shp"
+
+	"github.com/cpmech/gosl/la"
+)
+
+// ContactState holds the state of one vertex on the slave face
+type ContactState struct {
+
+	// Lagrange multipliers (updated by augmentations)
+	Ln float64   // normal force multiplier
+	Lt []float64 // [ndim] tangential force multiplier
+	Wa []float64 // [ndim] relative displacement increment at the last augmentation
+
+	// projection onto the master surface
+	Face int       // index of master face; -1 => the vertex is not over the master surface
+	Xi   []float64 // [ndim-1] natural coordinates of projection onto the master face
+
+	// kinematics and forces
+	Gap    float64   // normal gap; negative means penetration
+	Gt     float64   // norm of tangential slip since the last augmentation
+	Dw     []float64 // [ndim] relative displacement increment (slave minus master)
+	Fn     float64   // normal contact force
+	Ft     []float64 // [ndim] tangential (friction) contact force
+	Ftr    float64   // norm of trial tangential force
+	Active bool      // the vertex is in contact
+	Slip   bool      // the vertex is slipping
+}
+
+// NewContactState allocates a new state
+func NewContactState(ndim int) *ContactState {
+	return &ContactState{
+		Lt:   make([]float64, ndim),
+		Wa:   make([]float64, ndim),
+		Face: -1,
+		Xi:   make([]float64, ndim-1),
+		Dw:   make([]float64, ndim),
+		Ft:   make([]float64, ndim),
+	}
+}
+
+// Set copies state
+//  Note: other and this state must have been pre-allocated with the same sizes
+func (o *ContactState) Set(other *ContactState) {
+	o.Ln, o.Face, o.Gap, o.Gt, o.Fn, o.Ftr = other.Ln, other.Face, other.Gap, other.Gt, other.Fn, other.Ftr
+	o.Active, o.Slip = other.Active, other.Slip
+	copy(o.Lt, other.Lt)
+	copy(o.Wa, other.Wa)
+	copy(o.Xi, other.Xi)
+	copy(o.Dw, other.Dw)
+	copy(o.Ft, other.Ft)
+}
+
+// contactFace holds data of one face on the master surface
+type contactFace struct {
+	Cid   int         // id of cell owning this face
+	Shp   *shp.Shape  // shape of face
+	Nodes []*Node     // [nf] nodes on face
+	Eqs   [][]int     // [nf][ndim] equations of displacements
+	S     []float64   // [nf] shape functions
+	dSdR  [][]float64 // [nf][ndim-1] derivatives of shape functions
+}
+
+// Contact implements the frictional contact between two faces of deformable bodies: each vertex on
+// the slave face is projected onto the closest face of the master surface (node-to-segment in 2D
+// and node-to-face in 3D) where the normal gap g = (xs - xm)・n is computed with the outward normal
+// n of the master face. The contact forces acting on the slave vertex are
+//
+//   fn = ⟨λn - kn A g⟩ n    and    ft = λt - kt A P・(Δw - Δwa)   with   |ft| ≤ μ fn
+//
+// where A is the tributary area of the slave vertex, P = I - n⊗n, Δw the relative displacement
+// increment and Δwa its value at the last augmentation. The Lagrange multipliers λn and λt are
+// updated (augmented) after the equilibrium iterations have converged until the penetration and
+// the tangential slip of sticking vertices are smaller than the tolerance. Opposite forces are
+// distributed to the vertices of the master face.
+//  Notes:
+//   1) the geometric terms of the tangent due to friction are neglected
+//   2) the augmentations are performed by run_iterations; the arc-length method only uses the
+//      penalty terms with the current multipliers
+type Contact struct {
+
+	// input data
+	Dat    *inp.ContactData // contact data
+	Kt     float64          // tangential penalty coefficient
+	Tol    float64          // tolerance on penetration and stick slip
+	MaxAug int              // maximum number of augmentations per time step
+
+	// slave face
+	Slaves []*Node        // [nslaves] nodes on slave face
+	Seqs   [][]int        // [nslaves][ndim] equations of displacements of slave nodes
+	Scids  []int          // [nslaves] ids of cells owning the slave vertices (for output)
+	Area   []float64      // [nslaves] tributary areas of slave vertices
+	Faces  []*contactFace // faces on master surface
+
+	// states
+	States    []*ContactState // [nslaves] states
+	StatesBkp []*ContactState // [nslaves] backup states
+	StatesAux []*ContactState // [nslaves] auxiliary backup states
+
+	// scratchpad
+	nfmax int         // maximum number of vertices on master faces
+	xs    []float64   // [ndim] current coordinates of slave vertex
+	xm    []float64   // [ndim] current coordinates of projection onto master face
+	a     [][]float64 // [ndim-1][ndim] tangent vectors of master face
+	M     [][]float64 // [ndim-1][ndim-1] inverse of metric tensor
+	n     []float64   // [ndim] unit outward normal of master face
+	ξ     []float64   // [ndim-1] natural coordinates
+	Umap  []int       // [nl] assembly map of current pair
+	fc    []float64   // [nl] contact forces of current pair
+	K     [][]float64 // [nl][nl] tangent matrix of current pair
+}
+
+// NewContact allocates a new contact pair in domain d
+//  Note: returns nil and ok=true if the faces do not belong to the region of d
+func NewContact(d *Domain, dat *inp.ContactData) (o *Contact, ok bool) {
+
+	// faces
+	mfaces, okm := d.Msh.FaceTag2cells[dat.Master]
+	sfaces, oks := d.Msh.FaceTag2cells[dat.Slave]
+	if !okm && !oks {
+		return nil, true
+	}
+	if LogErrCond(!okm || !oks, "contact: master face %d and slave face %d must belong to the same region. contact between regions is not available", dat.Master, dat.Slave) {
+		return
+	}
+	if LogErrCond(dat.Kn <= 0 || dat.Kt < 0 || dat.Mu < 0, "contact: kn=%g must be positive and kt=%g and mu=%g must be non-negative", dat.Kn, dat.Kt, dat.Mu) {
+		return
+	}
+	if LogErrCond(Global.Sim.Data.Axisym, "contact: axisymmetric analyses are not available") {
+		return
+	}
+
+	// input data
+	o = new(Contact)
+	o.Dat = dat
+	o.Kt, o.Tol, o.MaxAug = dat.Kt, dat.Tol, dat.MaxAug
+	if o.Kt == 0 {
+		o.Kt = dat.Kn
+	}
+	if o.Tol <= 0 {
+		o.Tol = 1e-8
+	}
+	if o.MaxAug <= 0 {
+		o.MaxAug = 20
+	}
+
+	// equations of displacements
+	ndim := Global.Ndim
+	ukeys := []string{"ux", "uy", "uz"}[:ndim]
+	ueqs := func(nod *Node) (eqs []int) {
+		eqs = make([]int, ndim)
+		for i, key := range ukeys {
+			eqs[i] = nod.GetEq(key)
+			if LogErrCond(eqs[i] < 0, "contact: vertex %d does not have displacement %q", nod.Vert.Id, key) {
+				return nil
+			}
+		}
+		return
+	}
+
+	// master faces
+	for _, pair := range mfaces {
+		c := pair.C
+		if d.Cid2elem[c.Id] == nil {
+			continue
+		}
+		f := &contactFace{Cid: c.Id, Shp: shp.Get(c.Shp.FaceType)}
+		if LogErrCond(f.Shp == nil, "contact: cannot find shape of faces of cell %d", c.Id) {
+			return nil, false
+		}
+		for _, l := range c.Shp.FaceLocalV[pair.Fid] {
+			nod := d.Vid2node[c.Verts[l]]
+			eqs := ueqs(nod)
+			if eqs == nil {
+				return nil, false
+			}
+			f.Nodes = append(f.Nodes, nod)
+			f.Eqs = append(f.Eqs, eqs)
+		}
+		f.S = make([]float64, f.Shp.Nverts)
+		f.dSdR = la.MatAlloc(f.Shp.Nverts, ndim-1)
+		if f.Shp.Nverts > o.nfmax {
+			o.nfmax = f.Shp.Nverts
+		}
+		o.Faces = append(o.Faces, f)
+	}
+
+	// slave vertices and tributary areas
+	vid2slave := make(map[int]int)
+	for _, pair := range sfaces {
+		c := pair.C
+		if d.Cid2elem[c.Id] == nil {
+			continue
+		}
+		x := la.MatAlloc(ndim, len(c.Verts))
+		for j, v := range c.Verts {
+			for i := 0; i < ndim; i++ {
+				x[i][j] = d.Msh.Verts[v].C[i]
+			}
+		}
+		ipsf, err := shp.GetIps(c.Shp.FaceType, 0)
+		if LogErr(err, "contact: cannot get integration points of faces") {
+			return nil, false
+		}
+		for _, ipf := range ipsf {
+			c.Shp.CalcAtFaceIp(x, ipf, pair.Fid)
+			jf := la.VecNorm(c.Shp.Fnvec)
+			for k, l := range c.Shp.FaceLocalV[pair.Fid] {
+				vid := c.Verts[l]
+				idx, found := vid2slave[vid]
+				if !found {
+					nod := d.Vid2node[vid]
+					eqs := ueqs(nod)
+					if eqs == nil {
+						return nil, false
+					}
+					idx = len(o.Slaves)
+					vid2slave[vid] = idx
+					o.Slaves = append(o.Slaves, nod)
+					o.Seqs = append(o.Seqs, eqs)
+					o.Scids = append(o.Scids, c.Id)
+					o.Area = append(o.Area, 0)
+				}
+				o.Area[idx] += ipf.W * c.Shp.Sf[k] * jf
+			}
+		}
+	}
+	if LogErrCond(len(o.Faces) == 0 || len(o.Slaves) == 0, "contact: master face %d and slave face %d must have active cells", dat.Master, dat.Slave) {
+		return nil, false
+	}
+
+	// states
+	nslaves := len(o.Slaves)
+	o.States = make([]*ContactState, nslaves)
+	o.StatesBkp = make([]*ContactState, nslaves)
+	o.StatesAux = make([]*ContactState, nslaves)
+	for i := 0; i < nslaves; i++ {
+		o.States[i] = NewContactState(ndim)
+		o.StatesBkp[i] = NewContactState(ndim)
+		o.StatesAux[i] = NewContactState(ndim)
+	}
+
+	// scratchpad
+	nl := ndim * (1 + o.nfmax)
+	o.xs = make([]float64, ndim)
+	o.xm = make([]float64, ndim)
+	o.a = la.MatAlloc(ndim-1, ndim)
+	o.M = la.MatAlloc(ndim-1, ndim-1)
+	o.n = make([]float64, ndim)
+	o.ξ = make([]float64, ndim-1)
+	o.Umap = make([]int, 0, nl)
+	o.fc = make([]float64, nl)
+	o.K = la.MatAlloc(nl, nl)
+	return o, true
+}
+
+// Nnz returns the maximum number of non-zero entries added to Kb
+func (o *Contact) Nnz() int {
+	nl := Global.Ndim * (1 + o.nfmax)
+	return len(o.Slaves) * nl * nl
+}
+
+// implementation ///////////////////////////////////////////////////////////////////////////////////
+
+// AddToRhs adds -R to global residual vector fb
+func (o *Contact) AddToRhs(fb []float64, sol *Solution) (ok bool) {
+	for i, s := range o.States {
+		if !s.Active {
+			continue
+		}
+		o.calc_forces(sol, i)
+		for k, I := range o.Umap {
+			fb[I] += o.fc[k]
+		}
+	}
+	return true
+}
+
+// AddToKb adds the contact tangent matrices to global Jacobian matrix Kb
+func (o *Contact) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
+	for i, s := range o.States {
+		if !s.Active {
+			continue
+		}
+		o.calc_K(sol, i)
+		for k, I := range o.Umap {
+			for l, J := range o.Umap {
+				Kb.Put(I, J, o.K[k][l])
+			}
+		}
+	}
+	return true
+}
+
+// Update finds the projections of slave vertices onto the master surface and computes the contact
+// forces with the multipliers of the last augmentation
+func (o *Contact) Update(sol *Solution) (ok bool) {
+	ndim := Global.Ndim
+	for i, s := range o.States {
+
+		// find closest master face
+		o.slave_coords(sol, i)
+		s.Face, s.Active, s.Slip = -1, false, false
+		s.Gap, s.Gt, s.Fn, s.Ftr = 0, 0, 0, 0
+		la.VecFill(s.Dw, 0)
+		la.VecFill(s.Ft, 0)
+		dmin := math.MaxFloat64
+		for k, f := range o.Faces {
+			if f.has(o.Slaves[i]) || !o.project(sol, f) {
+				continue
+			}
+			dist := 0.0
+			for j := 0; j < ndim; j++ {
+				dist += (o.xs[j] - o.xm[j]) * (o.xs[j] - o.xm[j])
+			}
+			if dist < dmin {
+				dmin = dist
+				s.Face = k
+				copy(s.Xi, o.ξ)
+			}
+		}
+		if s.Face < 0 {
+			continue
+		}
+
+		// normal gap and force
+		f := o.Faces[s.Face]
+		o.face_geometry(sol, f, s.Xi)
+		s.Gap = 0
+		for j := 0; j < ndim; j++ {
+			s.Gap += (o.xs[j] - o.xm[j]) * o.n[j]
+		}
+		if -s.Gap > o.face_size() { // too deep: vertex is on the other side of a body
+			continue
+		}
+		s.Fn = s.Ln - o.Dat.Kn*o.Area[i]*s.Gap
+		if s.Fn < 0 {
+			s.Fn = 0
+			continue
+		}
+		s.Active = true
+
+		// relative displacement increment
+		for j := 0; j < ndim; j++ {
+			s.Dw[j] = sol.ΔY[o.Seqs[i][j]]
+			for m := range f.Nodes {
+				s.Dw[j] -= f.S[m] * sol.ΔY[f.Eqs[m][j]]
+			}
+		}
+
+		// friction: trial tangential force
+		if o.Dat.Mu == 0 {
+			continue
+		}
+		var Lnn, Wn float64
+		for j := 0; j < ndim; j++ {
+			Lnn += s.Lt[j] * o.n[j]
+			Wn += (s.Dw[j] - s.Wa[j]) * o.n[j]
+		}
+		for j := 0; j < ndim; j++ {
+			gt := s.Dw[j] - s.Wa[j] - Wn*o.n[j]
+			s.Gt += gt * gt
+			s.Ft[j] = s.Lt[j] - Lnn*o.n[j] - o.Kt*o.Area[i]*gt
+			s.Ftr += s.Ft[j] * s.Ft[j]
+		}
+		s.Gt, s.Ftr = math.Sqrt(s.Gt), math.Sqrt(s.Ftr)
+
+		// friction: slip
+		if s.Ftr > o.Dat.Mu*s.Fn {
+			s.Slip = true
+			for j := 0; j < ndim; j++ {
+				s.Ft[j] *= o.Dat.Mu * s.Fn / s.Ftr
+			}
+		}
+	}
+	return true
+}
+
+// Satisfied returns whether the contact constraints are satisfied within tolerance
+func (o *Contact) Satisfied() bool {
+	for _, s := range o.States {
+		if !s.Active {
+			continue
+		}
+		if -s.Gap > o.Tol {
+			return false
+		}
+		if o.Dat.Mu > 0 && !s.Slip && s.Gt > o.Tol {
+			return false
+		}
+	}
+	return true
+}
+
+// Augment updates the Lagrange multipliers with the current contact forces and recomputes the
+// forces. The backup states are updated as well since they are restored at each iteration
+func (o *Contact) Augment(sol *Solution) (ok bool) {
+	for i, s := range o.States {
+		s.Ln = s.Fn
+		copy(s.Lt, s.Ft)
+		copy(s.Wa, s.Dw)
+		if !s.Active {
+			la.VecFill(s.Wa, 0)
+		}
+		b := o.StatesBkp[i]
+		b.Ln = s.Ln
+		copy(b.Lt, s.Lt)
+		copy(b.Wa, s.Wa)
+	}
+	return o.Update(sol)
+}
+
+// internal variables ///////////////////////////////////////////////////////////////////////////////
+
+// Ipoints returns the real coordinates of slave vertices
+func (o *Contact) Ipoints() (coords [][]float64) {
+	coords = la.MatAlloc(len(o.Slaves), Global.Ndim)
+	for i, nod := range o.Slaves {
+		copy(coords[i], nod.Vert.C)
+	}
+	return
+}
+
+// SetIniIvs resets the states and finds the initial contact conditions
+//  Note: ivs are ignored
+func (o *Contact) SetIniIvs(sol *Solution, ivs map[string][]float64) (ok bool) {
+	for i, s := range o.States {
+		s.Set(NewContactState(Global.Ndim))
+		o.StatesBkp[i].Set(s)
+	}
+	return o.Update(sol)
+}
+
+// BackupIvs create copy of internal variables
+//  Note: at the beginning of a time step (aux == false), the last converged forces become the
+//        Lagrange multipliers and the relative displacement at the last augmentation is zeroed
+func (o *Contact) BackupIvs(aux bool) (ok bool) {
+	if aux {
+		for i, s := range o.StatesAux {
+			s.Set(o.States[i])
+		}
+		return true
+	}
+	for i, s := range o.States {
+		s.Ln = s.Fn
+		copy(s.Lt, s.Ft)
+		la.VecFill(s.Wa, 0)
+		o.StatesBkp[i].Set(s)
+	}
+	return true
+}
+
+// RestoreIvs restore internal variables from copies
+func (o *Contact) RestoreIvs(aux bool) (ok bool) {
+	if aux {
+		for i, s := range o.States {
+			s.Set(o.StatesAux[i])
+		}
+		return true
+	}
+	for i, s := range o.States {
+		s.Set(o.StatesBkp[i])
+	}
+	return true
+}
+
+// Ureset fixes internal variables after u (displacements) have been zeroed
+func (o *Contact) Ureset(sol *Solution) (ok bool) {
+	return true
+}
+
+// writer ///////////////////////////////////////////////////////////////////////////////////////////
+
+// Encode encodes internal variables
+func (o Contact) Encode(enc Encoder) (ok bool) {
+	return !LogErr(enc.Encode(o.States), "Encode")
+}
+
+// Decode decodes internal variables
+func (o *Contact) Decode(dec Decoder) (ok bool) {
+	if LogErr(dec.Decode(&o.States), "Decode") {
+		return
+	}
+	for i, s := range o.StatesBkp {
+		s.Set(o.States[i])
+	}
+	return true
+}
+
+// OutIpsData returns the contact pressure "pn", the tangential traction "tn" and the gap "gap" at
+// the slave vertices
+func (o *Contact) OutIpsData() (data []*OutIpData) {
+	for idx, nod := range o.Slaves {
+		i := idx
+		calc := func(sol *Solution) (vals map[string]float64) {
+			s := o.States[i]
+			vals = map[string]float64{
+				"pn":  s.Fn / o.Area[i],
+				"tn":  la.VecNorm(s.Ft) / o.Area[i],
+				"gap": s.Gap,
+			}
+			return
+		}
+		x := make([]float64, Global.Ndim)
+		copy(x, nod.Vert.C)
+		data = append(data, &OutIpData{o.Scids[i], x, calc})
+	}
+	return
+}
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// has returns whether the face has the given node
+func (o *contactFace) has(nod *Node) bool {
+	for _, n := range o.Nodes {
+		if n == nod {
+			return true
+		}
+	}
+	return false
+}
+
+// slave_coords computes the current coordinates of slave vertex i
+func (o *Contact) slave_coords(sol *Solution, i int) {
+	for j := 0; j < Global.Ndim; j++ {
+		o.xs[j] = o.Slaves[i].Vert.C[j] + sol.Y[o.Seqs[i][j]]
+	}
+}
+
+// face_geometry computes the shape functions, the point xm, the tangent vectors, the inverse of the
+// metric tensor and the unit outward normal vector at the natural coordinates ξ of face f
+func (o *Contact) face_geometry(sol *Solution, f *contactFace, ξ []float64) {
+
+	// shape functions and point on face
+	ndim := Global.Ndim
+	r := []float64{0, 0, 0}
+	copy(r, ξ)
+	f.Shp.Func(f.S, f.dSdR, r[0], r[1], r[2], true)
+	la.VecFill(o.xm, 0)
+	for α := 0; α < ndim-1; α++ {
+		la.VecFill(o.a[α], 0)
+	}
+	for m, nod := range f.Nodes {
+		for j := 0; j < ndim; j++ {
+			x := nod.Vert.C[j] + sol.Y[f.Eqs[m][j]]
+			o.xm[j] += f.S[m] * x
+			for α := 0; α < ndim-1; α++ {
+				o.a[α][j] += f.dSdR[m][α] * x
+			}
+		}
+	}
+
+	// normal and inverse of metric
+	if ndim == 2 {
+		a := o.a[0]
+		aa := a[0]*a[0] + a[1]*a[1]
+		o.M[0][0] = 1.0 / aa
+		o.n[0], o.n[1] = a[1]/math.Sqrt(aa), -a[0]/math.Sqrt(aa)
+		return
+	}
+	a1, a2 := o.a[0], o.a[1]
+	o.n[0] = a1[1]*a2[2] - a1[2]*a2[1]
+	o.n[1] = a1[2]*a2[0] - a1[0]*a2[2]
+	o.n[2] = a1[0]*a2[1] - a1[1]*a2[0]
+	nn := la.VecNorm(o.n)
+	for j := 0; j < 3; j++ {
+		o.n[j] /= nn
+	}
+	m11, m12, m22 := la.VecDot(a1, a1), la.VecDot(a1, a2), la.VecDot(a2, a2)
+	det := m11*m22 - m12*m12
+	o.M[0][0], o.M[0][1] = m22/det, -m12/det
+	o.M[1][0], o.M[1][1] = -m12/det, m11/det
+}
+
+// face_size returns a characteristic size of the face computed by face_geometry
+func (o *Contact) face_size() (l float64) {
+	for α := 0; α < Global.Ndim-1; α++ {
+		l = max(l, 2.0*la.VecNorm(o.a[α]))
+	}
+	return
+}
+
+// project computes the closest point projection ξ of the slave vertex (xs) onto face f
+//  Output: ok -- projection is within face
+func (o *Contact) project(sol *Solution, f *contactFace) (ok bool) {
+	ndim := Global.Ndim
+	for α := 0; α < ndim-1; α++ {
+		o.ξ[α] = 0
+		if f.Shp.IsSimplex() {
+			o.ξ[α] = 1.0 / 3.0
+		}
+	}
+	for it := 0; it < shp.INVMAP_NIT; it++ {
+		o.face_geometry(sol, f, o.ξ)
+		var δξnorm float64
+		for α := 0; α < ndim-1; α++ {
+			δξ := 0.0
+			for β := 0; β < ndim-1; β++ {
+				for j := 0; j < ndim; j++ {
+					δξ += o.M[α][β] * o.a[β][j] * (o.xs[j] - o.xm[j])
+				}
+			}
+			o.ξ[α] += δξ
+			δξnorm += δξ * δξ
+		}
+		if math.Sqrt(δξnorm) < shp.INVMAP_TOL {
+			o.face_geometry(sol, f, o.ξ)
+			r := []float64{0, 0, 0}
+			copy(r, o.ξ)
+			return f.Shp.IsInside(r, shp.INSIDE_TOL)
+		}
+	}
+	return false
+}
+
+// calc_forces computes the assembly map and the contact forces of slave vertex i
+func (o *Contact) calc_forces(sol *Solution, i int) {
+	ndim := Global.Ndim
+	s := o.States[i]
+	f := o.Faces[s.Face]
+	o.face_geometry(sol, f, s.Xi)
+	o.Umap = append(o.Umap[:0], o.Seqs[i]...)
+	for m := range f.Nodes {
+		o.Umap = append(o.Umap, f.Eqs[m]...)
+	}
+	for j := 0; j < ndim; j++ {
+		F := s.Fn*o.n[j] + s.Ft[j]
+		o.fc[j] = F
+		for m := range f.Nodes {
+			o.fc[ndim+m*ndim+j] = -f.S[m] * F
+		}
+	}
+}
+
+// calc_K computes the assembly map and the tangent matrix of slave vertex i
+func (o *Contact) calc_K(sol *Solution, i int) {
+
+	// geometry and assembly map
+	o.calc_forces(sol, i)
+	ndim := Global.Ndim
+	s := o.States[i]
+	f := o.Faces[s.Face]
+	nl := len(o.Umap)
+	A := o.Area[i]
+
+	// derivatives w.r.t u of the relative displacement Bw := dw/du and of the tangent vectors
+	// Ba[α] := da_α/du, with components [i][J]; J = j for the slave vertex and J = ndim*(1+m)+j
+	// for vertex m of master face
+	Bw := func(j, J int) float64 {
+		if J < ndim {
+			if J == j {
+				return 1
+			}
+			return 0
+		}
+		if J%ndim == j {
+			return -f.S[J/ndim-1]
+		}
+		return 0
+	}
+	nBa := func(α, J int) float64 { // n・Ba[α]
+		if J < ndim {
+			return 0
+		}
+		return o.n[J%ndim] * f.dSdR[J/ndim-1][α]
+	}
+
+	// derivatives of normal force, unit normal and natural coordinates
+	dFn := make([]float64, nl)
+	dn := la.MatAlloc(ndim, nl)
+	dξ := la.MatAlloc(ndim-1, nl)
+	for J := 0; J < nl; J++ {
+		for j := 0; j < ndim; j++ {
+			dFn[J] -= o.Dat.Kn * A * o.n[j] * Bw(j, J)
+		}
+		for α := 0; α < ndim-1; α++ {
+			for β := 0; β < ndim-1; β++ {
+				aBw := 0.0
+				for j := 0; j < ndim; j++ {
+					aBw += o.a[β][j] * Bw(j, J)
+					dn[j][J] -= o.M[α][β] * o.a[β][j] * nBa(α, J)
+				}
+				dξ[α][J] += o.M[α][β] * (aBw + s.Gap*nBa(β, J))
+			}
+		}
+	}
+
+	// derivatives of tangential force (frozen geometry)
+	dFt := la.MatAlloc(ndim, nl)
+	if o.Dat.Mu > 0 {
+		PBw := func(j, J int) float64 {
+			res := Bw(j, J)
+			for l := 0; l < ndim; l++ {
+				res -= o.n[j] * o.n[l] * Bw(l, J)
+			}
+			return res
+		}
+		for J := 0; J < nl; J++ {
+			for j := 0; j < ndim; j++ {
+				dFt[j][J] = -o.Kt * A * PBw(j, J)
+			}
+		}
+		if s.Slip && s.Fn == 0 {
+			la.MatFill(dFt, 0)
+		}
+		if s.Slip && s.Fn > 0 {
+			m := make([]float64, ndim)
+			for j := 0; j < ndim; j++ {
+				m[j] = s.Ft[j] / (o.Dat.Mu * s.Fn)
+			}
+			c := o.Dat.Mu * s.Fn / s.Ftr
+			for J := 0; J < nl; J++ {
+				mdFt := 0.0
+				for j := 0; j < ndim; j++ {
+					mdFt += m[j] * dFt[j][J]
+				}
+				for j := 0; j < ndim; j++ {
+					dFt[j][J] = c*(dFt[j][J]-m[j]*mdFt) + o.Dat.Mu*m[j]*dFn[J]
+				}
+			}
+		}
+	}
+
+	// K = -d(fc)/du
+	for j := 0; j < ndim; j++ {
+		for J := 0; J < nl; J++ {
+			dF := o.n[j]*dFn[J] + s.Fn*dn[j][J] + dFt[j][J]
+			o.K[j][J] = -dF
+			for m := range f.Nodes {
+				dS := 0.0
+				for α := 0; α < ndim-1; α++ {
+					dS += f.dSdR[m][α] * dξ[α][J]
+				}
+				o.K[ndim+m*ndim+j][J] = f.S[m]*dF + s.Fn*o.n[j]*dS
+			}
+		}
+	}
+}
+
+// domain: contact pairs ////////////////////////////////////////////////////////////////////////////
+
+// contacts_augment checks the contact constraints after the equilibrium iterations have converged
+// and augments the Lagrange multipliers of pairs not satisfying them
+//  Output: done -- all constraints are satisfied or the maximum number of augmentations was reached
+func (o *Domain) contacts_augment(naug *int) (done bool) {
+	done = true
+	for _, c := range o.Contacts {
+		if c.Satisfied() {
+			continue
+		}
+		if *naug >= c.MaxAug {
+			log.Printf("contact: max number of augmentations (%d) reached for faces %d (master) and %d (slave)", c.MaxAug, c.Dat.Master, c.Dat.Slave)
+			continue
+		}
+		if LogErrCond(!c.Augment(o.Sol), "contact: augmentation failed") {
+			return
+		}
+		done = false
+	}
+	if !done {
+		*naug += 1
+	}
+	return
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gofem/msolid"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+// Dashpot holds the coefficients of viscous (Lysmer and Kuhlemeyer 1969) boundaries, which absorb
+// plane waves reaching truncated boundaries. The traction is
+//
+//   t = - ρ・(cp・(w・n)・n + cs・(w - (w・n)・n))
+//
+//  where n is the unit outward normal and w is the velocity relative to the free-field (w = v on
+//  "dashpot" faces). The velocities of waves are computed from the elastic constants of the
+//  material; e.g. {E, nu} or {K, G}, unless given in the extra data of faces as "!cp:" and "!cs:".
+//  The density "rho" may also be given as "!rho:"
+type Dashpot struct {
+	Rho float64 // density
+	Cp  float64 // velocity of compression (P) waves
+	Cs  float64 // velocity of shear (S) waves
+	L   float64 // Lamé's coefficient λ = ρ・cp² - 2・ρ・cs²
+	G   float64 // shear modulus G = ρ・cs²
+
+	// auxiliary
+	cpg bool    // cp is given
+	csg bool    // cs is given
+	lam float64 // λ from elastic constants
+	mu  float64 // G from elastic constants
+}
+
+// NewDashpot returns a new dashpot structure
+//  prms  -- parameters of solid model
+//  rho   -- density; it can be zero (to be set later with SetRho)
+//  extra -- extra data of face condition
+func NewDashpot(prms fun.Prms, rho float64, extra string) (o *Dashpot, ok bool) {
+	o = new(Dashpot)
+	o.Rho = rho
+	if val, found := io.Keycode(extra, "rho"); found {
+		o.Rho = io.Atof(val)
+	}
+	if val, found := io.Keycode(extra, "cp"); found {
+		o.Cp, o.cpg = io.Atof(val), true
+	}
+	if val, found := io.Keycode(extra, "cs"); found {
+		o.Cs, o.csg = io.Atof(val), true
+	}
+	if !o.cpg || !o.csg {
+		var elast msolid.SmallElasticity
+		err := elast.Init(Global.Ndim, false, prms)
+		if LogErr(err, "dashpot: cannot compute velocities of waves; \"!cp:\" and \"!cs:\" may be given instead") {
+			return
+		}
+		o.lam, o.mu = elast.L, elast.G
+	}
+	o.SetRho(o.Rho)
+	return o, true
+}
+
+// SetRho sets density and computes the velocities of waves, unless they are given
+func (o *Dashpot) SetRho(rho float64) {
+	o.Rho = rho
+	if o.Rho <= 0 {
+		return
+	}
+	if !o.cpg {
+		o.Cp = math.Sqrt((o.lam + 2.0*o.mu) / o.Rho)
+	}
+	if !o.csg {
+		o.Cs = math.Sqrt(o.mu / o.Rho)
+	}
+	o.G = o.Rho * o.Cs * o.Cs
+	o.L = o.Rho*o.Cp*o.Cp - 2.0*o.G
+}
+
+// Traction computes t = ρ・(cp・(w・n)・n + cs・(w - (w・n)・n)) for the unit normal n
+//  Note: the viscous traction is -t
+func (o *Dashpot) Traction(t, w, n []float64) {
+	wn := 0.0
+	for i := 0; i < len(n); i++ {
+		wn += w[i] * n[i]
+	}
+	for i := 0; i < len(n); i++ {
+		t[i] = o.Rho * (o.Cp*wn*n[i] + o.Cs*(w[i]-wn*n[i]))
+	}
+}
+
+// Coef returns the coefficient C_ij = ρ・(cp・ni・nj + cs・(δij - ni・nj)) for the unit normal n
+func (o *Dashpot) Coef(i, j int, n []float64) float64 {
+	δ := 0.0
+	if i == j {
+		δ = 1
+	}
+	return o.Rho * (o.Cp*n[i]*n[j] + o.Cs*(δ-n[i]*n[j]))
+}
@@ -40,6 +40,7 @@ import (
 
 type FaceCond struct {
 	FaceId      int      // msh: cell's face local id
+	FaceTag     int      // msh: face tag
 	LocalVerts  []int    // msh: cell's face local vertices ids (sorted)
 	GlobalVerts []int    // msh: global vertices ids (sorted)
 	Cond        string   // sim: condition; e.g. "qn" or "seepH"
@@ -90,6 +91,13 @@ type Solution struct {
 	Zet []float64 // t2 star vars; e.g. ζ* = α1.u + α2.v + α3.a
 	Chi []float64 // t2 star vars; e.g. χ* = α4.u + α5.v + α6.a
 	L   []float64 // Lagrange multipliers
+	R   []float64 // reaction forces at constrained equations; computed if Data.React
+
+	// sensitivity analysis
+	Sens [][]float64 // [nsens][ny] sensitivities dy/dp w.r.t material parameters in Data.Sens
+
+	// load control
+	LoadFac float64 // load factor multiplying natural boundary conditions; 1 unless arc-length is on
 }
 
 // Domain holds all Nodes and Elements active during a stage in addition to the Solution at nodes.
@@ -112,6 +120,7 @@ type Domain struct {
 
 	// stage: auxiliary maps for dofs and equation types
 	F2Y      map[string]string // converts f-keys to y-keys; e.g.: "ux" => "fx"
+	Y2R      map[string]string // converts y-keys to reaction keys; e.g.: "ux" => "rx", "pl" => "rql"
 	YandC    map[string]bool   // y and constraints keys; e.g. "ux", "pl", "H", "incsup", "rigid"
 	Dof2Tnum map[string]int    // {t1,t2}-types: dof => t_number; e.g. "ux" => 2, "pl" => 1
 
@@ -123,6 +132,9 @@ type Domain struct {
 	// stage: subsets of elements
 	ElemIntvars []ElemIntvars   // elements with internal vars in this processor
 	ElemConnect []ElemConnector // connector elements in this processor
+	ElemSerial  []Elem          // elements that are not computed by workers
+	Workers     []*Worker       // workers computing elements concurrently; nil => serial
+	Contacts    []*Contact      // contact pairs between faces
 
 	// stage: coefficients and prescribed forces
 	EssenBcs EssentialBcs // constraints (Lagrange multipliers)
@@ -137,7 +149,7 @@ type Domain struct {
 	Ny    int // total number of dofs, except λ
 	Nlam  int // total number of Lagrange multipliers
 	NnzA  int // number of nonzeros in A (constraints) matrix
-	Nyb   int // total number of equations: ny + nλ
+	Nyb   int // total number of equations: ny + nλ (with Lagrange multipliers) or ny
 
 	// stage: solution and linear solver
 	Sol      *Solution   // solution state
@@ -148,6 +160,15 @@ type Domain struct {
 
 	// for divergence control
 	bkpSol *Solution // backup solution
+
+	// sensitivity analysis: perturbed states at the end of the last converged step
+	sensIvs    [][]byte    // [nsens] encoded internal variables
+	sensDydt   [][]float64 // [nsens][ny] sensitivities of dy/dt (transient)
+	sensD2ydt2 [][]float64 // [nsens][ny] sensitivities of d²y/dt² (transient)
+
+	// indexed results files
+	resOut *ResFile            // results file of this processor (for writing)
+	resIn  map[string]*ResFile // results files (for reading); path => file
 }
 
 // NewDomain returns a new domain
@@ -156,14 +177,32 @@ func NewDomain(reg *inp.Region, distr bool) *Domain {
 	dom.Reg = reg
 	dom.Msh = reg.Msh
 	if distr {
-		if LogErrCond(Global.Nproc != len(dom.Msh.Part2cells), "number of processors must be equal to the number of partitions defined in mesh file. %d != %d", Global.Nproc, len(dom.Msh.Part2cells)) {
-			return nil
+		if !dom.Msh.HasPartitions(Global.Nproc) {
+			log.Printf("dom: number of partitions defined in mesh file (%d) is different than the number of processors (%d). partitioning mesh\n", len(dom.Msh.Part2cells), Global.Nproc)
+			if LogErrCond(!dom.Msh.Partition(Global.Nproc), "cannot partition mesh") {
+				return nil
+			}
 		}
 	}
-	dom.LinSol = la.GetSolver(Global.Sim.LinSol.Name)
+	if Global.Sim.LinSol.Iterative() {
+		dom.LinSol = NewKrylov(&Global.Sim.LinSol)
+	} else {
+		dom.LinSol = la.GetSolver(Global.Sim.LinSol.Name)
+	}
 	return &dom
 }
 
+// StartKb starts the assembly of the Jacobian matrix and returns the structure receiving the
+// contributions; i.e. Kb or the iterative solver which holds its own matrix
+func (o *Domain) StartKb() Assembler {
+	if kry, ok := o.LinSol.(*Krylov); ok {
+		kry.Start()
+		return kry
+	}
+	o.Kb.Start()
+	return o.Kb
+}
+
 // SetStage set nodes, equation numbers and auxiliary data for given stage
 func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok bool) {
 
@@ -180,6 +219,14 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 	// auxiliary maps for setting boundary conditions
 	o.FaceConds = make(map[int][]*FaceCond) // cid => conditions
 
+	// input (earthquake) motion
+	if !SetInputMotion(stg) {
+		return
+	}
+	if !o.check_freefield(stg) {
+		return
+	}
+
 	// nodes (active) and elements (active AND in this processor)
 	o.Nodes = make([]*Node, 0)
 	o.Elems = make([]Elem, 0)
@@ -187,6 +234,7 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 
 	// auxiliary maps for dofs and equation types
 	o.F2Y = make(map[string]string)
+	o.Y2R = make(map[string]string)
 	o.YandC = GetIsEssenKeyMap()
 	o.Dof2Tnum = make(map[string]int)
 
@@ -228,7 +276,7 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 						if LogErrCond(fcn == nil, "cannot find function named %q corresponding to face tag %d (@ element %d)", faceBc.Funcs[j], faceTag, c.Id) {
 							return
 						}
-						fcond := &FaceCond{faceId, lverts, gverts, key, fcn, faceBc.Extra}
+						fcond := &FaceCond{faceId, faceTag, lverts, gverts, key, fcn, faceBc.Extra}
 						fconds := o.FaceConds[c.Id]
 						o.FaceConds[c.Id] = append(fconds, fcond)
 					}
@@ -248,6 +296,7 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 			// store y and f information
 			for ykey, fkey := range info.Y2F {
 				o.F2Y[fkey] = ykey
+				o.Y2R[ykey] = ReactionKey(fkey)
 				o.YandC[ykey] = true
 			}
 
@@ -356,6 +405,7 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 	}
 
 	// face boundary conditions
+	fnormals := make(map[incsupKey][]float64) // sum of unit normals of faces with inclined supports
 	for cidx, fcs := range o.FaceConds {
 		c := o.Msh.Cells[cidx]
 		for _, fc := range fcs {
@@ -365,6 +415,27 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 			for _, v := range gverts {
 				enodes = append(enodes, o.Vid2node[v])
 			}
+			if fc.Cond == "incsup" {
+				n, fnormal := incsup_normal(fc.Extra)
+				if fnormal {
+					n = o.face_normal(c, fc.FaceId)
+					norm := la.VecNorm(n)
+					for _, v := range gverts {
+						key := incsupKey{fc.FaceTag, v}
+						if fnormals[key] == nil {
+							fnormals[key] = make([]float64, Global.Ndim)
+						}
+						for i := 0; i < Global.Ndim; i++ {
+							fnormals[key][i] += n[i] / norm
+						}
+					}
+					continue
+				}
+				if !o.EssenBcs.SetIncSup(enodes, n) {
+					return
+				}
+				continue
+			}
 			if o.YandC[fc.Cond] {
 				if !o.EssenBcs.Set(fc.Cond, enodes, fc.Func, fc.Extra) {
 					return
@@ -374,6 +445,22 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 		}
 	}
 
+	// inclined supports normal to faces: normals are averaged at vertices shared by faces
+	keys := make([]incsupKey, 0, len(fnormals))
+	for key := range fnormals {
+		keys = append(keys, key)
+	}
+	sort.Sort(incsupKeys(keys))
+	for _, key := range keys {
+		n := fnormals[key]
+		if LogErrCond(la.VecNorm(n) < 1e-10, "inclined support: normals of faces with tag %d at vertex %d cancel each other", key.tag, key.vid) {
+			return
+		}
+		if !o.EssenBcs.SetIncSup([]*Node{o.Vid2node[key.vid]}, n) {
+			return
+		}
+	}
+
 	// vertex bounday conditions
 	for _, nc := range stg.NodeBcs {
 		verts, ok := o.Msh.VertTag2verts[nc.Tag]
@@ -398,6 +485,71 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 		}
 	}
 
+	// multi-point constraints
+	for _, mpc := range stg.Mpcs {
+		var nodes []*Node
+		for _, tag := range mpc.Tags {
+			verts := o.Msh.VertTag2verts[tag]
+			if LogErrCond(len(verts) != 1, "mpc: tag %d must correspond to one vertex only; %d vertices found", tag, len(verts)) {
+				return
+			}
+			nod := o.Vid2node[verts[0].Id]
+			if LogErrCond(nod == nil, "mpc: vertex %d with tag %d is not active", verts[0].Id, tag) {
+				return
+			}
+			nodes = append(nodes, nod)
+		}
+		fcn := Global.Sim.Functions.Get(mpc.Func)
+		if LogErrCond(fcn == nil, "Functions.Get failed\n") {
+			return
+		}
+		if !o.EssenBcs.SetMpc(nodes, mpc.Keys, mpc.Coefs, fcn) {
+			return
+		}
+	}
+
+	// periodic constraints
+	for _, pc := range stg.Periodic {
+		mverts, sverts := o.Msh.PeriodicPairs(pc.Master, pc.Slave)
+		if LogErrCond(mverts == nil, "cannot pair vertices on periodic faces %d (master) and %d (slave)", pc.Master, pc.Slave) {
+			return
+		}
+		var masters, slaves []*Node
+		for i, m := range mverts {
+			if o.Vid2node[m] != nil && o.Vid2node[sverts[i]] != nil { // set constraints only for active nodes
+				masters = append(masters, o.Vid2node[m])
+				slaves = append(slaves, o.Vid2node[sverts[i]])
+			}
+		}
+		for j, key := range pc.Keys {
+			fcn := Global.Sim.Functions.Get(pc.Funcs[j])
+			if LogErrCond(fcn == nil, "Functions.Get failed\n") {
+				return
+			}
+			if !o.EssenBcs.SetPeriodic(masters, slaves, key, fcn) {
+				return
+			}
+		}
+	}
+
+	// contact pairs
+	o.Contacts = make([]*Contact, 0)
+	for _, dat := range stg.Contact {
+		if LogErrCond(distr, "contact: parallel computations are not available") {
+			return
+		}
+		c, ok := NewContact(o, dat)
+		if !ok {
+			return
+		}
+		if c == nil { // faces belong to another region
+			continue
+		}
+		o.Contacts = append(o.Contacts, c)
+		o.ElemIntvars = append(o.ElemIntvars, c)
+		o.NnzKb += c.Nnz()
+	}
+
 	// resize slices --------------------------------------------------------------------------------
 
 	// t1 and t2 equations
@@ -419,21 +571,40 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 
 	// size of arrays
 	o.Ny = eq
-	o.Nlam, o.NnzA = o.EssenBcs.Build(o.Ny)
-	o.Nyb = o.Ny + o.Nlam
+	var ok bool
+	o.Nlam, o.NnzA, ok = o.EssenBcs.Build(o.Ny)
+	if !ok {
+		return
+	}
+	var nkb, nnzKb int
+	o.Nyb, nkb, nnzKb = o.EssenBcs.Sizes(o.Ny, o.NnzKb)
 
 	// solution structure and linear solver
 	o.Sol = new(Solution)
 	o.Kb = new(la.Triplet)
 	o.Fb = make([]float64, o.Nyb)
 	o.Wb = make([]float64, o.Nyb)
-	o.Kb.Init(o.Nyb, o.Nyb, o.NnzKb+2*o.NnzA)
+	if kry, ok := o.LinSol.(*Krylov); ok {
+		o.Kb.Init(nkb, nkb, 1) // not used
+		kry.Init(nkb, nnzKb)
+		kry.SetBlock(o.second_block(nkb))
+	} else {
+		o.Kb.Init(nkb, nkb, nnzKb)
+	}
 	o.InitLSol = true // tell solver that lis has to be initialised before use
 
 	// allocate arrays
 	o.Sol.Y = make([]float64, o.Ny)
 	o.Sol.ΔY = make([]float64, o.Ny)
 	o.Sol.L = make([]float64, o.Nlam)
+	o.Sol.LoadFac = 1
+	if Global.Sim.Data.React {
+		o.Sol.R = make([]float64, o.Ny)
+	}
+	if len(Global.Sim.Data.Sens) > 0 {
+		o.Sol.Sens = la.MatAlloc(len(Global.Sim.Data.Sens), o.Ny)
+		o.sensIvs, o.sensDydt, o.sensD2ydt2 = nil, nil, nil
+	}
 	if !Global.Sim.Data.Steady {
 		o.Sol.Dydt = make([]float64, o.Ny)
 		o.Sol.D2ydt2 = make([]float64, o.Ny)
@@ -494,6 +665,11 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 		}
 	}
 
+	// workers
+	if LogErrCond(!o.set_workers(), "cannot set workers for concurrent computation of elements") {
+		return
+	}
+
 	// logging
 	if Global.LogBcs {
 		log.Printf("dom: essential boundary conditions:%v", o.EssenBcs.List(stg.Control.Tf))
@@ -507,6 +683,29 @@ func (o *Domain) SetStage(idxstg int, stg *inp.Stage, distr bool) (setstageisok
 
 // auxiliary functions //////////////////////////////////////////////////////////////////////////////
 
+// second_block returns the equations of Kb in the second block of block preconditioners;
+// i.e. t1 equations such as pl and the Lagrange multipliers
+func (o *Domain) second_block(nkb int) (pblk []bool) {
+	pblk = make([]bool, nkb)
+	for i := o.Ny; i < nkb; i++ {
+		pblk[i] = true
+	}
+	if o.EssenBcs.Elim == nil {
+		for _, eq := range o.T1eqs {
+			pblk[eq] = true
+		}
+		return
+	}
+	t1 := make(map[int]bool)
+	for _, eq := range o.T1eqs {
+		t1[eq] = true
+	}
+	for i, eq := range o.EssenBcs.Elim.Free {
+		pblk[i] = t1[eq]
+	}
+	return
+}
+
 // add_element_to_subsets adds an Elem to many subsets as it fits
 func (o *Domain) add_element_to_subsets(ele Elem) {
 	if e, ok := ele.(ElemIntvars); ok {
@@ -577,6 +776,42 @@ func (o Domain) faceLocal2globalVerts(faceLverts []int, cell *inp.Cell) (faceGve
 	return
 }
 
+// face_normal computes the (non-unit) normal vector at the centre of a face of cell c
+func (o Domain) face_normal(c *inp.Cell, faceId int) (n []float64) {
+	ndim := Global.Ndim
+	x := la.MatAlloc(ndim, len(c.Verts))
+	for j, v := range c.Verts {
+		for i := 0; i < ndim; i++ {
+			x[i][j] = o.Msh.Verts[v].C[i]
+		}
+	}
+	ipf := new(shp.Ipoint)
+	if shp.Get(c.Shp.FaceType).IsSimplex() {
+		ipf.R, ipf.S = 1.0/3.0, 1.0/3.0
+	}
+	c.Shp.CalcAtFaceIp(x, ipf, faceId)
+	n = make([]float64, ndim)
+	copy(n, c.Shp.Fnvec)
+	return
+}
+
+// incsupKey identifies the vertex of a face with inclined support
+type incsupKey struct {
+	tag int // face tag
+	vid int // vertex id
+}
+
+type incsupKeys []incsupKey
+
+func (o incsupKeys) Len() int      { return len(o) }
+func (o incsupKeys) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o incsupKeys) Less(i, j int) bool {
+	if o[i].tag == o[j].tag {
+		return o[i].vid < o[j].vid
+	}
+	return o[i].tag > o[j].tag
+}
+
 // backup saves a copy of solution
 func (o *Domain) backup() {
 	if o.bkpSol == nil {
@@ -593,6 +828,7 @@ func (o *Domain) backup() {
 		}
 	}
 	o.bkpSol.T = o.Sol.T
+	o.bkpSol.LoadFac = o.Sol.LoadFac
 	copy(o.bkpSol.Y, o.Sol.Y)
 	copy(o.bkpSol.ΔY, o.Sol.ΔY)
 	copy(o.bkpSol.L, o.Sol.L)
@@ -611,6 +847,7 @@ func (o *Domain) backup() {
 // restore restores solution
 func (o *Domain) restore() {
 	o.Sol.T = o.bkpSol.T
+	o.Sol.LoadFac = o.bkpSol.LoadFac
 	copy(o.Sol.Y, o.bkpSol.Y)
 	copy(o.Sol.ΔY, o.bkpSol.ΔY)
 	copy(o.Sol.L, o.bkpSol.L)
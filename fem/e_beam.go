@@ -10,28 +10,63 @@ import (
 	"github.com/cpmech/gofem/inp"
 
 	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/la"
 )
 
-// Beam represents a structural beam element (Euler-Bernoulli, linear elastic)
+// Beam represents a structural beam element (Euler-Bernoulli or Timoshenko, linear elastic)
+//
+//  The local x axis goes from the first to the second node. In 3D, the local y axis is in the plane
+//  defined by the local x axis and an orientation vector v given in the extra flags of the element
+//  as "!vx:0 !vy:0 !vz:1". If v is not given, the local y axis is horizontal (perpendicular to the
+//  global z axis) or, for vertical beams, parallel to the global y axis. In 2D, the local z axis is
+//  the global z axis.
+//
+//  Extra flags:
+//   !vx:, !vy:, !vz: -- orientation vector (3D only)
+//   !timo:1         -- use Timoshenko formulation (shear deformable)
+//
+//  Material parameters:
+//   E     -- Young's modulus
+//   G     -- shear modulus; or nu (Poisson's coefficient). Required in 3D and by Timoshenko beams
+//   A     -- cross-sectional area
+//   Izz   -- moment of inertia for bending in the local x-y plane
+//   Iyy   -- moment of inertia for bending in the local x-z plane (3D)
+//   J     -- torsional constant (3D)
+//   Ay,Az -- shear areas along local y and z (Timoshenko); default = A
+//   rho   -- density
+//
+//  Distributed loads (in local axes):
+//   qn, qnL, qnR -- along local y axis; L and R denote the values at the first and second nodes
+//   qz, qzL, qzR -- along local z axis (3D)
+//   qt           -- along local x axis
 type Beam struct {
 
 	// basic data
-	Cid int         // cell/element id
-	X   [][]float64 // matrix of nodal coordinates [ndim][nnode]
-	Nu  int         // total number of unknowns == 2 * nsn
+	Cid  int         // cell/element id
+	X    [][]float64 // matrix of nodal coordinates [ndim][nnode]
+	Ndof int         // number of dofs per node: 3 in 2D; 6 in 3D
+	Nu   int         // total number of unknowns == 2 * ndof
+	L    float64     // length of element
+	Timo bool        // Timoshenko formulation
 
 	// parameters
 	E   float64 // Young's modulus
+	G   float64 // shear modulus
 	A   float64 // cross-sectional area
 	Izz float64 // Inertia zz
+	Iyy float64 // Inertia yy
+	Jtt float64 // torsional constant
+	Ay  float64 // shear area along local y
+	Az  float64 // shear area along local z
 
 	// variables for dynamics
 	Rho  float64  // density of solids
 	Gfcn fun.Func // gravity function
 
 	// vectors and matrices
-	T   [][]float64 // global-to-local transformation matrix [nnode*ndim][nnode*ndim]
+	R   [][]float64 // rotation matrix; rows are the local axes in global components [ndim][ndim]
+	T   [][]float64 // global-to-local transformation matrix [nnode*ndof][nnode*ndof]
 	Kl  [][]float64 // local K matrix
 	K   [][]float64 // global K matrix
 	Ml  [][]float64 // local M matrices
@@ -43,6 +78,8 @@ type Beam struct {
 	Hasq bool     // has distributed loads
 	QnL  fun.Func // distributed normal load functions: left
 	QnR  fun.Func // distributed normal load functions: right
+	QzL  fun.Func // distributed load along local z: left
+	QzR  fun.Func // distributed load along local z: right
 	Qt   fun.Func // distributed tangential load
 
 	// scratchpad. computed @ each ip
@@ -83,18 +120,18 @@ func init() {
 	// element allocator
 	eallocators["beam"] = func(cellType string, faceConds []*FaceCond, cid int, edat *inp.ElemData, x [][]float64) Elem {
 
-		// check
-		if LogErrCond(Global.Ndim == 3, "beam is not implemented for 3D yet") {
-			return nil
-		}
-
 		// basic data
 		var o Beam
 		o.Cid = cid
 		o.X = x
 		ndim := Global.Ndim
-		ndof := 3 * (ndim - 1)
-		o.Nu = ndof * ndim
+		o.Ndof = 3 * (ndim - 1)
+		o.Nu = 2 * o.Ndof
+
+		// flags
+		if s_timo, found := io.Keycode(edat.Extra, "timo"); found {
+			o.Timo = io.Atob(s_timo)
+		}
 
 		// parameters
 		matname := edat.Mat
@@ -102,18 +139,46 @@ func init() {
 		if LogErrCond(matdata == nil, "materials database failed on getting %q material\n", matname) {
 			return nil
 		}
+		ν := -1.0
 		for _, p := range matdata.Prms {
 			switch p.N {
 			case "E":
 				o.E = p.V
+			case "G":
+				o.G = p.V
+			case "nu":
+				ν = p.V
 			case "A":
 				o.A = p.V
 			case "Izz":
 				o.Izz = p.V
+			case "Iyy":
+				o.Iyy = p.V
+			case "J":
+				o.Jtt = p.V
+			case "Ay":
+				o.Ay = p.V
+			case "Az":
+				o.Az = p.V
 			case "rho":
 				o.Rho = p.V
 			}
 		}
+		if o.G == 0 && ν >= 0 {
+			o.G = o.E / (2.0 * (1.0 + ν))
+		}
+		if o.Ay == 0 {
+			o.Ay = o.A
+		}
+		if o.Az == 0 {
+			o.Az = o.A
+		}
+		if LogErrCond((ndim == 3 || o.Timo) && o.G <= 0, "beam: shear modulus G (or nu) must be given for 3D or Timoshenko beams. material = %q", matname) {
+			return nil
+		}
+		if LogErrCond(o.Timo && (o.Ay <= 0 || o.Az <= 0), "beam: shear areas must be positive for Timoshenko beams. material = %q", matname) {
+			return nil
+		}
 
 		// vectors and matrices
 		o.T = la.MatAlloc(o.Nu, o.Nu)
@@ -127,72 +192,14 @@ func init() {
 		o.Rus = make([]float64, o.Nu)
 
 		// T
-		dx := o.X[0][1] - o.X[0][0]
-		dy := o.X[1][1] - o.X[1][0]
-		l := math.Sqrt(dx*dx + dy*dy)
-		c := dx / l
-		s := dy / l
-		o.T[0][0] = c
-		o.T[0][1] = s
-		o.T[1][0] = -s
-		o.T[1][1] = c
-		o.T[2][2] = 1
-		o.T[3][3] = c
-		o.T[3][4] = s
-		o.T[4][3] = -s
-		o.T[4][4] = c
-		o.T[5][5] = 1
-
-		// aux vars
-		ll := l * l
-		m := o.E * o.A / l
-		n := o.E * o.Izz / (ll * l)
-
-		// K
-		o.Kl[0][0] = m
-		o.Kl[0][3] = -m
-		o.Kl[1][1] = 12 * n
-		o.Kl[1][2] = 6 * l * n
-		o.Kl[1][4] = -12 * n
-		o.Kl[1][5] = 6 * l * n
-		o.Kl[2][1] = 6 * l * n
-		o.Kl[2][2] = 4 * ll * n
-		o.Kl[2][4] = -6 * l * n
-		o.Kl[2][5] = 2 * ll * n
-		o.Kl[3][0] = -m
-		o.Kl[3][3] = m
-		o.Kl[4][1] = -12 * n
-		o.Kl[4][2] = -6 * l * n
-		o.Kl[4][4] = 12 * n
-		o.Kl[4][5] = -6 * l * n
-		o.Kl[5][1] = 6 * l * n
-		o.Kl[5][2] = 2 * ll * n
-		o.Kl[5][4] = -6 * l * n
-		o.Kl[5][5] = 4 * ll * n
-		la.MatTrMul3(o.K, 1, o.T, o.Kl, o.T) // K := 1 * trans(T) * Kl * T
+		if !o.calcT(edat.Extra) {
+			return nil
+		}
 
-		// M
-		m = o.Rho * o.A * l / 420.0
-		o.Ml[0][0] = 140.0 * m
-		o.Ml[0][3] = 70.0 * m
-		o.Ml[1][1] = 156.0 * m
-		o.Ml[1][2] = 22.0 * l * m
-		o.Ml[1][4] = 54.0 * m
-		o.Ml[1][5] = -13.0 * l * m
-		o.Ml[2][1] = 22.0 * l * m
-		o.Ml[2][2] = 4.0 * ll * m
-		o.Ml[2][4] = 13.0 * l * m
-		o.Ml[2][5] = -3.0 * ll * m
-		o.Ml[3][0] = 70.0 * m
-		o.Ml[3][3] = 140.0 * m
-		o.Ml[4][1] = 54.0 * m
-		o.Ml[4][2] = 13.0 * l * m
-		o.Ml[4][4] = 156.0 * m
-		o.Ml[4][5] = -22.0 * l * m
-		o.Ml[5][1] = -13.0 * l * m
-		o.Ml[5][2] = -3.0 * ll * m
-		o.Ml[5][4] = -22.0 * l * m
-		o.Ml[5][5] = 4.0 * ll * m
+		// K and M
+		o.calcKl()
+		o.calcMl()
+		la.MatTrMul3(o.K, 1, o.T, o.Kl, o.T) // K := 1 * trans(T) * Kl * T
 		la.MatTrMul3(o.M, 1, o.T, o.Ml, o.T) // M := 1 * trans(T) * Ml * T
 
 		// scratchpad. computed @ each ip
@@ -209,11 +216,10 @@ func (o Beam) Id() int { return o.Cid }
 
 // SetEqs set equations [2][?]. Format of eqs == format of info.Dofs
 func (o *Beam) SetEqs(eqs [][]int, mixedform_eqs []int) (ok bool) {
-	ndof := 3 * (Global.Ndim - 1)
 	o.Umap = make([]int, o.Nu)
 	for m := 0; m < 2; m++ {
-		for i := 0; i < ndof; i++ {
-			r := i + m*ndof
+		for i := 0; i < o.Ndof; i++ {
+			r := i + m*o.Ndof
 			o.Umap[r] = eqs[m][i]
 		}
 	}
@@ -239,6 +245,18 @@ func (o *Beam) SetEleConds(key string, f fun.Func, extra string) (ok bool) {
 		o.Hasq, o.QnR = true, f
 	case "qt":
 		o.Hasq, o.Qt = true, f
+	case "qz", "qzL", "qzR":
+		if LogErrCond(o.Ndof != 6, "beam: distributed load %q is only available in 3D", key) {
+			return false
+		}
+		switch key {
+		case "qz":
+			o.Hasq, o.QzL, o.QzR = true, f, f
+		case "qzL":
+			o.Hasq, o.QzL = true, f
+		case "qzR":
+			o.Hasq, o.QzR = true, f
+		}
 	default:
 		LogErrCond(true, "cannot handle boundary condition named %q", key)
 		return false
@@ -284,18 +302,7 @@ func (o Beam) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 
 	// distributed loads
 	if o.Hasq {
-		dx := o.X[0][1] - o.X[0][0]
-		dy := o.X[1][1] - o.X[1][0]
-		l := math.Sqrt(dx*dx + dy*dy)
-		qnL := o.QnL.F(sol.T, nil)
-		qnR := o.QnR.F(sol.T, nil)
-		qt := o.Qt.F(sol.T, nil)
-		o.fxl[0] = qt * l / 2.0
-		o.fxl[1] = l * (7.0*qnL + 3.0*qnR) / 20.0
-		o.fxl[2] = l * l * (3.0*qnL + 2.0*qnR) / 60.0
-		o.fxl[3] = qt * l / 2.0
-		o.fxl[4] = l * (3.0*qnL + 7.0*qnR) / 20.0
-		o.fxl[5] = -l * l * (2.0*qnL + 3.0*qnR) / 60.0
+		o.calcFxl(sol)
 		la.MatTrVecMulAdd(o.fi, -1.0, o.T, o.fxl) // Rus -= fx; fx = trans(T) * fxl
 	}
 
@@ -307,7 +314,7 @@ func (o Beam) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o Beam) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o Beam) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 	if Global.Sim.Data.Steady {
 		for i, I := range o.Umap {
 			for j, J := range o.Umap {
@@ -340,7 +347,257 @@ func (o Beam) Decode(dec Decoder) (ok bool) {
 	return true
 }
 
-// OutIpsData returns data from all integration points for output
+// OutIpsData returns the section forces at both ends of the beam for output
+//  The section forces are the resultants acting on the face with outward normal along the local x
+//  axis, in local axes: N, Vy and Mz in 2D; N, Vy, Vz, T, My and Mz in 3D
 func (o Beam) OutIpsData() (data []*OutIpData) {
+	ndim := Global.Ndim
+	keys := []string{"N", "Vy", "Mz"}
+	if ndim == 3 {
+		keys = []string{"N", "Vy", "Vz", "T", "My", "Mz"}
+	}
+	for m := 0; m < 2; m++ {
+		x := make([]float64, ndim)
+		for i := 0; i < ndim; i++ {
+			x[i] = o.X[i][m]
+		}
+		sgn, off := -1.0, 0 // first node: face with outward normal along -x
+		if m == 1 {
+			sgn, off = 1.0, o.Ndof
+		}
+		calc := func(sol *Solution) (vals map[string]float64) {
+			fl := o.SectionForces(sol)
+			vals = make(map[string]float64)
+			for i, key := range keys {
+				vals[key] = sgn * fl[off+i]
+			}
+			return
+		}
+		data = append(data, &OutIpData{o.Id(), x, calc})
+	}
+	return
+}
+
+// SectionForces computes the forces applied by the nodes onto the element in local axes
+//  fl = Kl * T * u - fxl   [nu]
+func (o Beam) SectionForces(sol *Solution) (fl []float64) {
+	ul := make([]float64, o.Nu)
+	fl = make([]float64, o.Nu)
+	for i, I := range o.Umap {
+		o.ue[i] = sol.Y[I]
+	}
+	la.MatVecMul(ul, 1, o.T, o.ue)
+	la.MatVecMul(fl, 1, o.Kl, ul)
+	if o.Hasq {
+		o.calcFxl(sol)
+		for i := 0; i < o.Nu; i++ {
+			fl[i] -= o.fxl[i]
+		}
+	}
 	return
 }
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// calcT computes the local axes and the transformation matrix T
+func (o *Beam) calcT(extra string) (ok bool) {
+
+	// local x axis
+	ndim := Global.Ndim
+	e1 := make([]float64, 3)
+	for i := 0; i < ndim; i++ {
+		e1[i] = o.X[i][1] - o.X[i][0]
+	}
+	o.L = la.VecNorm(e1)
+	if LogErrCond(o.L < 1e-14, "beam: length of element %d is zero", o.Cid) {
+		return
+	}
+	for i := 0; i < 3; i++ {
+		e1[i] /= o.L
+	}
+
+	// 2D
+	if ndim == 2 {
+		c, s := e1[0], e1[1]
+		o.R = [][]float64{{c, s}, {-s, c}}
+		for m := 0; m < 2; m++ {
+			k := m * o.Ndof
+			o.T[k][k], o.T[k][k+1] = c, s
+			o.T[k+1][k], o.T[k+1][k+1] = -s, c
+			o.T[k+2][k+2] = 1
+		}
+		return true
+	}
+
+	// orientation vector
+	v := []float64{-e1[1], e1[0], 0}
+	if math.Abs(e1[2]) > 1.0-1e-10 {
+		v = []float64{0, 1, 0}
+	}
+	given := false
+	for i, key := range []string{"vx", "vy", "vz"} {
+		if s_v, found := io.Keycode(extra, key); found {
+			if !given {
+				v = []float64{0, 0, 0}
+				given = true
+			}
+			v[i] = io.Atof(s_v)
+		}
+	}
+
+	// local z and y axes
+	e3 := make([]float64, 3)
+	e2 := make([]float64, 3)
+	cross3(e3, e1, v)
+	n3 := la.VecNorm(e3)
+	if LogErrCond(n3 < 1e-10, "beam: orientation vector v=%v is parallel to the axis of element %d", v, o.Cid) {
+		return
+	}
+	for i := 0; i < 3; i++ {
+		e3[i] /= n3
+	}
+	cross3(e2, e3, e1)
+	o.R = [][]float64{e1, e2, e3}
+
+	// T := diag(R, R, R, R)
+	for k := 0; k < o.Nu; k += 3 {
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				o.T[k+i][k+j] = o.R[i][j]
+			}
+		}
+	}
+	return true
+}
+
+// calcKl computes the local stiffness matrix
+func (o *Beam) calcKl() {
+	l := o.L
+	if o.Ndof == 3 {
+		addBar(o.Kl, 0, 3, o.E*o.A/l)
+		addBending(o.Kl, []int{1, 2, 4, 5}, 1, o.E*o.Izz, o.phi(o.Izz, o.Ay), l)
+		return
+	}
+	addBar(o.Kl, 0, 6, o.E*o.A/l)
+	addBar(o.Kl, 3, 9, o.G*o.Jtt/l)
+	addBending(o.Kl, []int{1, 5, 7, 11}, 1, o.E*o.Izz, o.phi(o.Izz, o.Ay), l)
+	addBending(o.Kl, []int{2, 4, 8, 10}, -1, o.E*o.Iyy, o.phi(o.Iyy, o.Az), l)
+}
+
+// calcMl computes the local consistent mass matrix (Euler-Bernoulli shape functions)
+func (o *Beam) calcMl() {
+	l := o.L
+	m := o.Rho * o.A * l
+	if o.Ndof == 3 {
+		addBarMass(o.Ml, 0, 3, m)
+		addBendingMass(o.Ml, []int{1, 2, 4, 5}, 1, m, l)
+		return
+	}
+	addBarMass(o.Ml, 0, 6, m)
+	addBarMass(o.Ml, 3, 9, o.Rho*(o.Iyy+o.Izz)*l)
+	addBendingMass(o.Ml, []int{1, 5, 7, 11}, 1, m, l)
+	addBendingMass(o.Ml, []int{2, 4, 8, 10}, -1, m, l)
+}
+
+// calcFxl computes the local vector of external forces due to distributed loads at time sol.T
+// multiplied by the load factor sol.LoadFac
+func (o *Beam) calcFxl(sol *Solution) {
+	l := o.L
+	qt := beamLoad(o.Qt, sol)
+	if o.Ndof == 3 {
+		o.fxl[0], o.fxl[3] = qt*l/2.0, qt*l/2.0
+		addBendingLoad(o.fxl, []int{1, 2, 4, 5}, 1, beamLoad(o.QnL, sol), beamLoad(o.QnR, sol), l)
+		return
+	}
+	o.fxl[0], o.fxl[6] = qt*l/2.0, qt*l/2.0
+	addBendingLoad(o.fxl, []int{1, 5, 7, 11}, 1, beamLoad(o.QnL, sol), beamLoad(o.QnR, sol), l)
+	addBendingLoad(o.fxl, []int{2, 4, 8, 10}, -1, beamLoad(o.QzL, sol), beamLoad(o.QzR, sol), l)
+}
+
+// phi returns the shear deformation parameter Φ = 12 E I / (G As l²) or zero if Euler-Bernoulli
+func (o Beam) phi(I, As float64) float64 {
+	if o.Timo {
+		return 12.0 * o.E * I / (o.G * As * o.L * o.L)
+	}
+	return 0
+}
+
+// addBar adds the stiffness (or mass) of a bar with coefficient k to K
+func addBar(K [][]float64, i, j int, k float64) {
+	K[i][i] += k
+	K[i][j] -= k
+	K[j][i] -= k
+	K[j][j] += k
+}
+
+// addBarMass adds the consistent mass of a bar (or torsion member) with total mass m to M
+func addBarMass(M [][]float64, i, j int, m float64) {
+	M[i][i] += m / 3.0
+	M[i][j] += m / 6.0
+	M[j][i] += m / 6.0
+	M[j][j] += m / 3.0
+}
+
+// addBending adds the bending stiffness to K. idx holds the indices of (v1, θ1, v2, θ2) and sr is
+// the sign of rotations; i.e. sr = 1 for bending in the x-y plane and sr = -1 for the x-z plane
+func addBending(K [][]float64, idx []int, sr, EI, Φ, l float64) {
+	c := EI / (l * l * l * (1.0 + Φ))
+	kb := [][]float64{
+		{12, 6 * l, -12, 6 * l},
+		{6 * l, (4 + Φ) * l * l, -6 * l, (2 - Φ) * l * l},
+		{-12, -6 * l, 12, -6 * l},
+		{6 * l, (2 - Φ) * l * l, -6 * l, (4 + Φ) * l * l},
+	}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			K[idx[i]][idx[j]] += c * kb[i][j] * beamSign(i, j, sr)
+		}
+	}
+}
+
+// addBendingMass adds the consistent mass related to bending. See addBending
+func addBendingMass(M [][]float64, idx []int, sr, m, l float64) {
+	c := m / 420.0
+	mb := [][]float64{
+		{156, 22 * l, 54, -13 * l},
+		{22 * l, 4 * l * l, 13 * l, -3 * l * l},
+		{54, 13 * l, 156, -22 * l},
+		{-13 * l, -3 * l * l, -22 * l, 4 * l * l},
+	}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			M[idx[i]][idx[j]] += c * mb[i][j] * beamSign(i, j, sr)
+		}
+	}
+}
+
+// addBendingLoad sets the forces due to a linearly distributed load (qL, qR). See addBending
+func addBendingLoad(f []float64, idx []int, sr, qL, qR, l float64) {
+	f[idx[0]] = l * (7.0*qL + 3.0*qR) / 20.0
+	f[idx[1]] = sr * l * l * (3.0*qL + 2.0*qR) / 60.0
+	f[idx[2]] = l * (3.0*qL + 7.0*qR) / 20.0
+	f[idx[3]] = -sr * l * l * (2.0*qL + 3.0*qR) / 60.0
+}
+
+// beamSign returns sr if only one of the (v1, θ1, v2, θ2) indices i and j corresponds to a rotation
+func beamSign(i, j int, sr float64) float64 {
+	if i%2 != j%2 {
+		return sr
+	}
+	return 1
+}
+
+// beamLoad returns the value of a distributed load multiplied by the load factor or zero if it is not set
+func beamLoad(f fun.Func, sol *Solution) float64 {
+	if f == nil {
+		return 0
+	}
+	return f.F(sol.T, nil) * sol.LoadFac
+}
+
+// cross3 computes the cross product u := a × b
+func cross3(u, a, b []float64) {
+	u[0] = a[1]*b[2] - a[2]*b[1]
+	u[1] = a[2]*b[0] - a[0]*b[2]
+	u[2] = a[0]*b[1] - a[1]*b[0]
+}
@@ -0,0 +1,621 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/msolid"
+	"github.com/cpmech/gofem/shp"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/tsr"
+)
+
+// Ijoint implements the zero-thickness interface element (Goodman joint) between faces of solids.
+//
+//  Cells: "jlin2" and "jlin3" in 2D; "jtri3", "jtri6", "jqua4" and "jqua8" in 3D. The vertices of
+//  the face on side A come first, followed by the corresponding vertices of the face on side B;
+//  these vertices are coincident initially but belong to different nodes. The normal vector n of
+//  the face on side A must point towards side B; i.e. n = e_z × a1 in 2D and n = a1 × a2 in 3D,
+//  where a1 and a2 are the tangent vectors along the natural coordinates of the face.
+//
+//  The relative displacement w = uB - uA is written in the local system {n, t1, t2} and the
+//  tractions are computed by the Mohr-Coulomb slip/gap model msolid.IjointMC.
+//
+//  Element types:
+//   ijoint  -- mechanical interface (ux, uy, uz)
+//   ijointp -- interface with hydraulic coupling to the liquid pressure pl of u-p elements; the
+//              normal traction is reduced by the average of pl on both sides and liquid leaks
+//              across the interface: mass flux from A to B == kl (plA - plB)
+//
+//  Material parameters:
+//   kn, ks, c, phi, psi, sigt -- see msolid.IjointMC
+//   kl                        -- leakage coefficient (ijointp)
+type Ijoint struct {
+
+	// basic data
+	Cid  int         // cell/element id
+	X    [][]float64 // matrix of nodal coordinates [ndim][nnode]
+	Shp  *shp.Shape  // shape structure of faces
+	Nf   int         // number of vertices on each face
+	Nu   int         // total number of unknowns == 2 * Nf * ndim
+	HasP bool        // has liquid pressure (ijointp)
+
+	// liquid pressure
+	ShpP *shp.Shape // shape structure of faces for pl
+	Nfp  int        // number of pl vertices on each face
+	Np   int        // total number of pl unknowns == 2 * Nfp
+	Kl   float64    // leakage coefficient
+
+	// integration points
+	IpsElem []*shp.Ipoint // integration points on face
+
+	// material model and internal variables
+	Mdl       msolid.IjointMC // material model
+	States    []*msolid.State // [nip] states
+	StatesBkp []*msolid.State // [nip] backup states
+	StatesAux []*msolid.State // [nip] auxiliary backup states
+
+	// problem variables
+	Umap []int // assembly map (location array/element equations)
+	Pmap []int // assembly map for pl (ijointp)
+
+	// geometry @ ips
+	Xm [][]float64   // [ndim][nf] coordinates of mid-plane
+	S  [][]float64   // [nip][nf] shape functions
+	Sp [][]float64   // [nip][nfp] shape functions for pl
+	Jf []float64     // [nip] Jacobian of face
+	R  [][][]float64 // [nip][ndim][ndim] rotation matrix; rows are n, t1 and t2
+
+	// scratchpad. computed @ each ip
+	Δw  []float64   // [ndim] relative displacement increment in global system
+	Δwl []float64   // [ndim] relative displacement increment in local system
+	σg  []float64   // [ndim] traction in global system
+	D   [][]float64 // [ndim][ndim] constitutive consistent tangent matrix
+	RtD [][]float64 // [ndim][ndim] Rᵀ・D・R
+	fi  []float64   // [nu] internal forces
+	K   [][]float64 // [nu][nu] consistent tangent (stiffness) matrix
+	fp  []float64   // [np] leakage flow terms
+	Kup [][]float64 // [nu][np] Kup := dRus/dpl
+	Kpp [][]float64 // [np][np] Kpp := dRpl/dpl
+}
+
+// initialisation ///////////////////////////////////////////////////////////////////////////////////
+
+// register element
+func init() {
+
+	// information allocators
+	infogetters["ijoint"] = func(cellType string, faceConds []*FaceCond) *Info {
+		return ijoint_info(cellType, false)
+	}
+	infogetters["ijointp"] = func(cellType string, faceConds []*FaceCond) *Info {
+		return ijoint_info(cellType, true)
+	}
+
+	// element allocators
+	eallocators["ijoint"] = func(cellType string, faceConds []*FaceCond, cid int, edat *inp.ElemData, x [][]float64) Elem {
+		return ijoint_alloc(cellType, cid, edat, x, false)
+	}
+	eallocators["ijointp"] = func(cellType string, faceConds []*FaceCond, cid int, edat *inp.ElemData, x [][]float64) Elem {
+		return ijoint_alloc(cellType, cid, edat, x, true)
+	}
+}
+
+// ijoint_ptype returns the face type used to interpolate pl
+func ijoint_ptype(ftype string) string {
+	if Global.Sim.Data.NoLBB {
+		return ftype
+	}
+	return shp.GetBasicType(ftype)
+}
+
+// ijoint_info returns the information structure of interface elements
+func ijoint_info(cellType string, hasP bool) *Info {
+
+	// new info
+	var info Info
+
+	// face type
+	ftype := inp.IjointFaceType(cellType)
+	if LogErrCond(ftype == "", "ijoint: cell type %q is not an interface cell", cellType) {
+		return nil
+	}
+	nf := shp.GetNverts(ftype)
+	nfp := 0
+	if hasP {
+		nfp = shp.GetNverts(ijoint_ptype(ftype))
+	}
+
+	// solution variables
+	ykeys := []string{"ux", "uy"}
+	if Global.Ndim == 3 {
+		ykeys = []string{"ux", "uy", "uz"}
+	}
+	info.Dofs = make([][]string, 2*nf)
+	for j := 0; j < 2*nf; j++ {
+		info.Dofs[j] = ykeys
+		if j%nf < nfp {
+			info.Dofs[j] = append([]string{}, ykeys...)
+			info.Dofs[j] = append(info.Dofs[j], "pl")
+		}
+	}
+
+	// maps
+	info.Y2F = map[string]string{"ux": "fx", "uy": "fy", "uz": "fz"}
+	if hasP {
+		info.Y2F["pl"] = "ql"
+		info.T1vars = []string{"pl"}
+	}
+
+	// t2 variables
+	info.T2vars = ykeys
+	return &info
+}
+
+// ijoint_alloc allocates a new interface element
+func ijoint_alloc(cellType string, cid int, edat *inp.ElemData, x [][]float64, hasP bool) Elem {
+
+	// basic data
+	var o Ijoint
+	o.Cid = cid
+	o.X = x
+	o.HasP = hasP
+	ndim := Global.Ndim
+	if LogErrCond(Global.Sim.Data.Axisym, "ijoint: axisymmetric analyses are not available") {
+		return nil
+	}
+	ftype := inp.IjointFaceType(cellType)
+	o.Shp = shp.Get(ftype)
+	if LogErrCond(o.Shp == nil, "ijoint: cell type %q is not an interface cell", cellType) {
+		return nil
+	}
+	o.Nf = o.Shp.Nverts
+	o.Nu = 2 * o.Nf * ndim
+
+	// integration points
+	var err error
+	o.IpsElem, err = shp.GetIps(ftype, edat.Nip)
+	if LogErr(err, "ijoint: cannot get integration points") {
+		return nil
+	}
+	nip := len(o.IpsElem)
+
+	// material model
+	matdata := Global.Sim.Mdb.Get(edat.Mat)
+	if LogErrCond(matdata == nil, "materials database failed on getting %q material\n", edat.Mat) {
+		return nil
+	}
+	if LogErr(o.Mdl.Init(ndim, matdata.Prms), "cannot initialise model for Ijoint element") {
+		return nil
+	}
+	for _, p := range matdata.Prms {
+		switch p.N {
+		case "kl":
+			o.Kl = p.V
+		}
+	}
+
+	// liquid pressure
+	if o.HasP {
+		o.ShpP = shp.Get(ijoint_ptype(ftype))
+		o.Nfp = o.ShpP.Nverts
+		o.Np = 2 * o.Nfp
+	}
+
+	// mid-plane coordinates
+	o.Xm = la.MatAlloc(ndim, o.Nf)
+	for i := 0; i < ndim; i++ {
+		for m := 0; m < o.Nf; m++ {
+			o.Xm[i][m] = (x[i][m] + x[i][m+o.Nf]) / 2.0
+		}
+	}
+
+	// geometry @ ips
+	o.S = la.MatAlloc(nip, o.Nf)
+	o.Jf = make([]float64, nip)
+	o.R = make([][][]float64, nip)
+	dSdR := la.MatAlloc(o.Nf, o.Shp.Gndim)
+	a1 := make([]float64, 3)
+	a2 := make([]float64, 3)
+	for idx, ip := range o.IpsElem {
+		o.Shp.Func(o.S[idx], dSdR, ip.R, ip.S, ip.T, true)
+		for i := 0; i < 3; i++ {
+			a1[i], a2[i] = 0, 0
+		}
+		for i := 0; i < ndim; i++ {
+			for m := 0; m < o.Nf; m++ {
+				a1[i] += dSdR[m][0] * o.Xm[i][m]
+				if ndim == 3 {
+					a2[i] += dSdR[m][1] * o.Xm[i][m]
+				}
+			}
+		}
+		o.R[idx] = la.MatAlloc(ndim, ndim)
+		n, t1 := o.R[idx][0], o.R[idx][1]
+		if ndim == 2 {
+			o.Jf[idx] = math.Sqrt(a1[0]*a1[0] + a1[1]*a1[1])
+			t1[0], t1[1] = a1[0]/o.Jf[idx], a1[1]/o.Jf[idx]
+			n[0], n[1] = -t1[1], t1[0]
+		} else {
+			n[0] = a1[1]*a2[2] - a1[2]*a2[1]
+			n[1] = a1[2]*a2[0] - a1[0]*a2[2]
+			n[2] = a1[0]*a2[1] - a1[1]*a2[0]
+			o.Jf[idx] = la.VecNorm(n)
+			na1 := la.VecNorm(a1)
+			t2 := o.R[idx][2]
+			for i := 0; i < 3; i++ {
+				n[i] /= o.Jf[idx]
+				t1[i] = a1[i] / na1
+			}
+			t2[0] = n[1]*t1[2] - n[2]*t1[1]
+			t2[1] = n[2]*t1[0] - n[0]*t1[2]
+			t2[2] = n[0]*t1[1] - n[1]*t1[0]
+		}
+		if LogErrCond(o.Jf[idx] < 1e-14, "ijoint: face of interface element %d is degenerated", cid) {
+			return nil
+		}
+	}
+	if o.HasP {
+		o.Sp = la.MatAlloc(nip, o.Nfp)
+		dSpdR := la.MatAlloc(o.Nfp, o.ShpP.Gndim)
+		for idx, ip := range o.IpsElem {
+			o.ShpP.Func(o.Sp[idx], dSpdR, ip.R, ip.S, ip.T, false)
+		}
+	}
+
+	// scratchpad. computed @ each ip
+	o.Δw = make([]float64, ndim)
+	o.Δwl = make([]float64, ndim)
+	o.σg = make([]float64, ndim)
+	o.D = la.MatAlloc(ndim, ndim)
+	o.RtD = la.MatAlloc(ndim, ndim)
+	o.fi = make([]float64, o.Nu)
+	o.K = la.MatAlloc(o.Nu, o.Nu)
+	if o.HasP {
+		o.fp = make([]float64, o.Np)
+		o.Kup = la.MatAlloc(o.Nu, o.Np)
+		o.Kpp = la.MatAlloc(o.Np, o.Np)
+	}
+
+	// return new element
+	return &o
+}
+
+// implementation ///////////////////////////////////////////////////////////////////////////////////
+
+// Id returns the cell Id
+func (o Ijoint) Id() int { return o.Cid }
+
+// SetEqs set equations
+func (o *Ijoint) SetEqs(eqs [][]int, mixedform_eqs []int) (ok bool) {
+	ndim := Global.Ndim
+	o.Umap = make([]int, o.Nu)
+	o.Pmap = make([]int, o.Np)
+	for j := 0; j < 2*o.Nf; j++ {
+		for i := 0; i < ndim; i++ {
+			o.Umap[i+j*ndim] = eqs[j][i]
+		}
+		if o.HasP && j%o.Nf < o.Nfp {
+			o.Pmap[j%o.Nf+(j/o.Nf)*o.Nfp] = eqs[j][ndim]
+		}
+	}
+	return true
+}
+
+// SetEleConds set element conditions
+func (o *Ijoint) SetEleConds(key string, f fun.Func, extra string) (ok bool) {
+	return true
+}
+
+// InterpStarVars interpolates star variables to integration points
+func (o *Ijoint) InterpStarVars(sol *Solution) (ok bool) {
+	return true
+}
+
+// AddToRhs adds -R to global residual vector fb
+func (o *Ijoint) AddToRhs(fb []float64, sol *Solution) (ok bool) {
+
+	// clear variables
+	ndim := Global.Ndim
+	la.VecFill(o.fi, 0)
+	if o.HasP {
+		la.VecFill(o.fp, 0)
+	}
+
+	// loop over integration points
+	var coef, pl, q float64
+	for idx, ip := range o.IpsElem {
+
+		// auxiliary
+		coef = ip.W * o.Jf[idx]
+		S, R := o.S[idx], o.R[idx]
+		σ := o.States[idx].Sig
+
+		// liquid pressure and leakage
+		if o.HasP {
+			pl, q = o.plvars(idx, sol)
+			for m := 0; m < o.Nfp; m++ {
+				o.fp[m] += coef * o.Sp[idx][m] * q
+				o.fp[m+o.Nfp] -= coef * o.Sp[idx][m] * q
+			}
+		}
+
+		// total traction in global system
+		for i := 0; i < ndim; i++ {
+			o.σg[i] = -R[0][i] * pl
+			for k := 0; k < ndim; k++ {
+				o.σg[i] += R[k][i] * σ[k]
+			}
+		}
+
+		// internal forces: side A (-) and side B (+)
+		for m := 0; m < o.Nf; m++ {
+			for i := 0; i < ndim; i++ {
+				o.fi[i+m*ndim] -= coef * S[m] * o.σg[i]
+				o.fi[i+(m+o.Nf)*ndim] += coef * S[m] * o.σg[i]
+			}
+		}
+	}
+
+	// add to fb
+	for i, I := range o.Umap {
+		fb[I] -= o.fi[i]
+	}
+	for i, I := range o.Pmap {
+		fb[I] -= o.fp[i]
+	}
+	return true
+}
+
+// AddToKb adds element K to global Jacobian matrix Kb
+func (o *Ijoint) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
+
+	// clear matrices
+	ndim := Global.Ndim
+	la.MatFill(o.K, 0)
+	if o.HasP {
+		la.MatFill(o.Kup, 0)
+		la.MatFill(o.Kpp, 0)
+	}
+
+	// loop over integration points
+	var coef float64
+	for idx, ip := range o.IpsElem {
+
+		// auxiliary
+		coef = ip.W * o.Jf[idx]
+		S, R := o.S[idx], o.R[idx]
+
+		// consistent tangent matrix in global system: Rᵀ・D・R
+		if LogErr(o.Mdl.CalcD(o.D, o.States[idx], firstIt), "AddToKb") {
+			return
+		}
+		for i := 0; i < ndim; i++ {
+			for j := 0; j < ndim; j++ {
+				o.RtD[i][j] = 0
+				for a := 0; a < ndim; a++ {
+					for b := 0; b < ndim; b++ {
+						o.RtD[i][j] += R[a][i] * o.D[a][b] * R[b][j]
+					}
+				}
+			}
+		}
+
+		// Kuu
+		for m := 0; m < 2*o.Nf; m++ {
+			sm := ijoint_sign(m, o.Nf) * S[m%o.Nf]
+			for n := 0; n < 2*o.Nf; n++ {
+				sn := ijoint_sign(n, o.Nf) * S[n%o.Nf]
+				for i := 0; i < ndim; i++ {
+					for j := 0; j < ndim; j++ {
+						o.K[i+m*ndim][j+n*ndim] += coef * sm * sn * o.RtD[i][j]
+					}
+				}
+			}
+		}
+
+		// Kup and Kpp
+		if o.HasP {
+			Sp := o.Sp[idx]
+			for m := 0; m < 2*o.Nf; m++ {
+				sm := ijoint_sign(m, o.Nf) * S[m%o.Nf]
+				for i := 0; i < ndim; i++ {
+					for n := 0; n < o.Np; n++ {
+						o.Kup[i+m*ndim][n] -= coef * sm * R[0][i] * Sp[n%o.Nfp] / 2.0
+					}
+				}
+			}
+			for m := 0; m < o.Np; m++ {
+				for n := 0; n < o.Np; n++ {
+					sgn := ijoint_sign(m, o.Nfp) * ijoint_sign(n, o.Nfp)
+					o.Kpp[m][n] += coef * sgn * o.Kl * Sp[m%o.Nfp] * Sp[n%o.Nfp]
+				}
+			}
+		}
+	}
+
+	// add K to sparse matrix Kb
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			Kb.Put(I, J, o.K[i][j])
+		}
+		for j, J := range o.Pmap {
+			Kb.Put(I, J, o.Kup[i][j])
+		}
+	}
+	for i, I := range o.Pmap {
+		for j, J := range o.Pmap {
+			Kb.Put(I, J, o.Kpp[i][j])
+		}
+	}
+	return true
+}
+
+// Update perform (tangent) update
+func (o *Ijoint) Update(sol *Solution) (ok bool) {
+	ndim := Global.Ndim
+	for idx, _ := range o.IpsElem {
+
+		// relative displacement increment
+		S, R := o.S[idx], o.R[idx]
+		for i := 0; i < ndim; i++ {
+			o.Δw[i] = 0
+			for m := 0; m < o.Nf; m++ {
+				o.Δw[i] += S[m] * (sol.ΔY[o.Umap[i+(m+o.Nf)*ndim]] - sol.ΔY[o.Umap[i+m*ndim]])
+			}
+		}
+		la.MatVecMul(o.Δwl, 1, R, o.Δw)
+
+		// update model
+		if LogErr(o.Mdl.Update(o.States[idx], o.Δwl), "Update") {
+			return
+		}
+	}
+	return true
+}
+
+// internal variables ///////////////////////////////////////////////////////////////////////////////
+
+// Ipoints returns the real coordinates of integration points [nip][ndim]
+func (o Ijoint) Ipoints() (coords [][]float64) {
+	coords = la.MatAlloc(len(o.IpsElem), Global.Ndim)
+	for idx, ip := range o.IpsElem {
+		coords[idx] = o.Shp.IpRealCoords(o.Xm, ip)
+	}
+	return
+}
+
+// SetIniIvs sets initial ivs for given values in sol and ivs map
+//  Note: the initial tractions are computed by projecting the (effective) stresses "sx", "sy", ...
+//        onto the interface
+func (o *Ijoint) SetIniIvs(sol *Solution, ivs map[string][]float64) (ok bool) {
+
+	// allocate slices of states
+	ndim := Global.Ndim
+	nip := len(o.IpsElem)
+	o.States = make([]*msolid.State, nip)
+	o.StatesBkp = make([]*msolid.State, nip)
+	o.StatesAux = make([]*msolid.State, nip)
+
+	// has specified stresses?
+	_, has_sig := ivs["sx"]
+
+	// for each integration point
+	σ := make([]float64, 2*ndim)
+	σl := make([]float64, ndim)
+	var err error
+	for idx := 0; idx < nip; idx++ {
+		if has_sig {
+			Ivs2sigmas(σ, idx, ivs)
+			R := o.R[idx]
+			for k := 0; k < ndim; k++ {
+				σl[k] = 0
+				for i := 0; i < ndim; i++ {
+					for j := 0; j < ndim; j++ {
+						σl[k] += R[k][i] * tsr.M2T(σ, i, j) * R[0][j]
+					}
+				}
+			}
+		}
+		o.States[idx], err = o.Mdl.InitIntVars(σl)
+		if LogErr(err, "SetIniIvs") {
+			return
+		}
+		o.StatesBkp[idx] = o.States[idx].GetCopy()
+		o.StatesAux[idx] = o.States[idx].GetCopy()
+	}
+	return true
+}
+
+// BackupIvs create copy of internal variables
+func (o *Ijoint) BackupIvs(aux bool) (ok bool) {
+	if aux {
+		for i, s := range o.StatesAux {
+			s.Set(o.States[i])
+		}
+		return true
+	}
+	for i, s := range o.StatesBkp {
+		s.Set(o.States[i])
+	}
+	return true
+}
+
+// RestoreIvs restore internal variables from copies
+func (o *Ijoint) RestoreIvs(aux bool) (ok bool) {
+	if aux {
+		for i, s := range o.States {
+			s.Set(o.StatesAux[i])
+		}
+		return true
+	}
+	for i, s := range o.States {
+		s.Set(o.StatesBkp[i])
+	}
+	return true
+}
+
+// Ureset fixes internal variables after u (displacements) have been zeroed
+func (o *Ijoint) Ureset(sol *Solution) (ok bool) {
+	return true
+}
+
+// writer ///////////////////////////////////////////////////////////////////////////////////////////
+
+// Encode encodes internal variables
+func (o Ijoint) Encode(enc Encoder) (ok bool) {
+	return !LogErr(enc.Encode(o.States), "Encode")
+}
+
+// Decode decodes internal variables
+func (o Ijoint) Decode(dec Decoder) (ok bool) {
+	if LogErr(dec.Decode(&o.States), "Decode") {
+		return
+	}
+	return o.BackupIvs(false)
+}
+
+// OutIpsData returns data from all integration points for output
+func (o Ijoint) OutIpsData() (data []*OutIpData) {
+	keys := []string{"sn", "tau1", "tau2"}
+	coords := o.Ipoints()
+	for idx, _ := range o.IpsElem {
+		s := o.States[idx]
+		calc := func(sol *Solution) (vals map[string]float64) {
+			vals = make(map[string]float64)
+			for i, σ := range s.Sig {
+				vals[keys[i]] = σ
+			}
+			vals["slip"] = s.Alp[0]
+			return
+		}
+		data = append(data, &OutIpData{o.Id(), coords[idx], calc})
+	}
+	return
+}
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// ijoint_sign returns -1 for vertices on side A and +1 for vertices on side B
+func ijoint_sign(j, nf int) float64 {
+	if j < nf {
+		return -1
+	}
+	return 1
+}
+
+// plvars computes the average liquid pressure and the leakage flux @ ip
+func (o Ijoint) plvars(idx int, sol *Solution) (pl, q float64) {
+	var plA, plB float64
+	for m := 0; m < o.Nfp; m++ {
+		plA += o.Sp[idx][m] * sol.Y[o.Pmap[m]]
+		plB += o.Sp[idx][m] * sol.Y[o.Pmap[m+o.Nfp]]
+	}
+	return (plA + plB) / 2.0, o.Kl * (plA - plB)
+}
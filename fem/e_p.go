@@ -35,6 +35,7 @@ type ElemP struct {
 	IpsFace []*shp.Ipoint // integration points corresponding to faces
 
 	// material model
+	Mat string         // material name
 	Mdl *mporous.Model // model
 
 	// problem variables
@@ -144,6 +145,7 @@ func init() {
 		nip := len(o.IpsElem)
 
 		// models
+		o.Mat = edat.Mat
 		o.Mdl = GetAndInitPorousModel(edat.Mat)
 		if o.Mdl == nil {
 			return nil
@@ -333,7 +335,7 @@ func (o ElemP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o ElemP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o ElemP) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 
 	// clear matrices
 	la.MatFill(o.Kpp, 0)
@@ -460,6 +462,15 @@ func (o *ElemP) Update(sol *Solution) (ok bool) {
 	return true
 }
 
+// concurrency //////////////////////////////////////////////////////////////////////////////////////
+
+// SetWorker replaces the shared shape structure and porous model by private copies of worker
+func (o *ElemP) SetWorker(w *Worker) (ok bool) {
+	o.Shp = w.Shape(o.Shp.Type)
+	o.Mdl = w.PorousModel(o.Mat)
+	return o.Mdl != nil
+}
+
 // internal variables ///////////////////////////////////////////////////////////////////////////////
 
 // Ipoints returns the real coordinates of integration points [nip][ndim]
@@ -704,7 +715,7 @@ func (o ElemP) add_natbcs_to_rhs(fb []float64, sol *Solution) (ok bool) {
 					ρl += Sf[i] * o.ρl_ex[m]
 				}
 				for i, m := range o.Shp.FaceLocalV[iface] {
-					fb[o.Pmap[m]] -= coef * ρl * tmp * Sf[i]
+					fb[o.Pmap[m]] -= coef * ρl * tmp * sol.LoadFac * Sf[i]
 				}
 			case "seep":
 
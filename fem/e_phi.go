@@ -169,7 +169,7 @@ func (o *ElemPhi) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *ElemPhi) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o *ElemPhi) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 
 	// auxiliary
 	β1 := Global.DynCoefs.β1
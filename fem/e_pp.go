@@ -0,0 +1,582 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/mporous"
+	"github.com/cpmech/gofem/shp"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// ElemPP implements an element for transient liquid-gas flow analyses in porous media
+//  Note: the balance equations are
+//    Cpl・dpl/dt + Cpg・dpg/dt - div(ρl・wl) = 0
+//    Dpl・dpl/dt + Dpg・dpg/dt - div(ρg・wg) = 0
+type ElemPP struct {
+
+	// basic data
+	Cid int         // cell/element id
+	X   [][]float64 // matrix of nodal coordinates [ndim][nnode]
+	Shp *shp.Shape  // shape structure
+	Np  int         // total number of unknowns == number of vertices
+
+	// integration points
+	IpsElem []*shp.Ipoint // integration points of element
+	IpsFace []*shp.Ipoint // integration points corresponding to faces
+
+	// material model
+	Mdl *mporous.Model // model
+
+	// problem variables
+	PLmap []int // assembly map (location array/element equations) of pl
+	PGmap []int // assembly map (location array/element equations) of pg
+
+	// internal variables
+	States    []*mporous.State
+	StatesBkp []*mporous.State
+	StatesAux []*mporous.State
+
+	// gravity
+	Gfcn fun.Func // gravity function
+
+	// natural boundary conditions
+	NatBcs []*NaturalBc // natural boundary conditions
+
+	// local starred variables
+	ψl []float64 // [nip] ψl* = β1.pl + β2.dpldt
+	ψg []float64 // [nip] ψg* = β1.pg + β2.dpgdt
+
+	// scratchpad. computed @ each ip
+	g   []float64        // [ndim] gravity vector
+	pl  float64          // pl: liquid pressure
+	pg  float64          // pg: gas pressure
+	gpl []float64        // [ndim] ∇pl: gradient of liquid pressure
+	gpg []float64        // [ndim] ∇pg: gradient of gas pressure
+	ρwl []float64        // [ndim] ρl*wl: weighted liquid relative velocity
+	ρwg []float64        // [ndim] ρg*wg: weighted gas relative velocity
+	tmp []float64        // [ndim] temporary (auxiliary) vector
+	Kll [][]float64      // [np][np] Kll := dRpl/dpl consistent tangent matrix
+	Klg [][]float64      // [np][np] Klg := dRpl/dpg consistent tangent matrix
+	Kgl [][]float64      // [np][np] Kgl := dRpg/dpl consistent tangent matrix
+	Kgg [][]float64      // [np][np] Kgg := dRpg/dpg consistent tangent matrix
+	res *mporous.LgsVars // variable to hold results from CalcLgs
+}
+
+// initialisation ///////////////////////////////////////////////////////////////////////////////////
+
+// register element
+func init() {
+
+	// information allocator
+	infogetters["pp"] = func(cellType string, faceConds []*FaceCond) *Info {
+
+		// new info
+		var info Info
+
+		// number of nodes in element
+		nverts := shp.GetNverts(cellType)
+
+		// solution variables
+		ykeys := []string{"pl", "pg"}
+		info.Dofs = make([][]string, nverts)
+		for m := 0; m < nverts; m++ {
+			info.Dofs[m] = ykeys
+		}
+
+		// maps
+		info.Y2F = map[string]string{"pl": "ql", "pg": "qg"}
+
+		// t1 and t2 variables
+		info.T1vars = ykeys
+		return &info
+	}
+
+	// element allocator
+	eallocators["pp"] = func(cellType string, faceConds []*FaceCond, cid int, edat *inp.ElemData, x [][]float64) Elem {
+
+		// basic data
+		var o ElemPP
+		o.Cid = cid
+		o.X = x
+		o.Shp = shp.Get(cellType)
+		o.Np = o.Shp.Nverts
+
+		// integration points
+		o.IpsElem, o.IpsFace = GetIntegrationPoints(edat.Nip, edat.Nipf, cellType)
+		if o.IpsElem == nil || o.IpsFace == nil {
+			return nil
+		}
+		nip := len(o.IpsElem)
+
+		// models
+		o.Mdl = GetAndInitPorousModel(edat.Mat)
+		if o.Mdl == nil {
+			return nil
+		}
+
+		// local starred variables
+		o.ψl = make([]float64, nip)
+		o.ψg = make([]float64, nip)
+
+		// scratchpad. computed @ each ip
+		ndim := Global.Ndim
+		o.g = make([]float64, ndim)
+		o.gpl = make([]float64, ndim)
+		o.gpg = make([]float64, ndim)
+		o.ρwl = make([]float64, ndim)
+		o.ρwg = make([]float64, ndim)
+		o.tmp = make([]float64, ndim)
+		o.Kll = la.MatAlloc(o.Np, o.Np)
+		o.Klg = la.MatAlloc(o.Np, o.Np)
+		o.Kgl = la.MatAlloc(o.Np, o.Np)
+		o.Kgg = la.MatAlloc(o.Np, o.Np)
+		o.res = new(mporous.LgsVars)
+
+		// set natural boundary conditions
+		for _, fc := range faceConds {
+			o.NatBcs = append(o.NatBcs, &NaturalBc{fc.Cond, fc.FaceId, fc.Func, fc.Extra})
+		}
+
+		// return new element
+		return &o
+	}
+}
+
+// implementation ///////////////////////////////////////////////////////////////////////////////////
+
+// Id returns the cell Id
+func (o ElemPP) Id() int { return o.Cid }
+
+// SetEqs sets equations
+func (o *ElemPP) SetEqs(eqs [][]int, mixedform_eqs []int) (ok bool) {
+	o.PLmap = make([]int, o.Np)
+	o.PGmap = make([]int, o.Np)
+	for m := 0; m < o.Shp.Nverts; m++ {
+		o.PLmap[m] = eqs[m][0]
+		o.PGmap[m] = eqs[m][1]
+	}
+	return true
+}
+
+// SetEleConds sets element conditions
+func (o *ElemPP) SetEleConds(key string, f fun.Func, extra string) (ok bool) {
+	if key == "g" { // gravity
+		o.Gfcn = f
+	}
+	return true
+}
+
+// InterpStarVars interpolates star variables to integration points
+func (o *ElemPP) InterpStarVars(sol *Solution) (ok bool) {
+
+	// for each integration point
+	for idx, ip := range o.IpsElem {
+
+		// interpolation functions and gradients
+		if LogErr(o.Shp.CalcAtIp(o.X, ip, true), "InterpStarVars") {
+			return
+		}
+
+		// interpolate starred variables
+		o.ψl[idx], o.ψg[idx] = 0, 0
+		for m := 0; m < o.Shp.Nverts; m++ {
+			o.ψl[idx] += o.Shp.S[m] * sol.Psi[o.PLmap[m]]
+			o.ψg[idx] += o.Shp.S[m] * sol.Psi[o.PGmap[m]]
+		}
+	}
+	return true
+}
+
+// AddToRhs adds -R to global residual vector fb
+func (o ElemPP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
+
+	// for each integration point
+	β1 := Global.DynCoefs.β1
+	ndim := Global.Ndim
+	nverts := o.Shp.Nverts
+	var coef, plt, pgt, klr, kgr, ρL, ρG float64
+	for idx, ip := range o.IpsElem {
+
+		// interpolation functions, gradients and variables @ ip
+		if !o.ipvars(idx, sol) {
+			return
+		}
+		coef = o.Shp.J * ip.W
+		S := o.Shp.S
+		G := o.Shp.G
+
+		// tpm variables
+		plt = β1*o.pl - o.ψl[idx]
+		pgt = β1*o.pg - o.ψg[idx]
+		klr = o.Mdl.Cnd.Klr(o.States[idx].A_sl)
+		kgr = o.Mdl.Cnd.Kgr(1.0 - o.States[idx].A_sl)
+		ρL = o.States[idx].A_ρL
+		ρG = o.States[idx].A_ρG
+		if LogErr(o.Mdl.CalcLgs(o.res, o.States[idx], o.pl, o.pg, 0, false), "AddToRhs") {
+			return
+		}
+
+		// compute ρwl and ρwg
+		for i := 0; i < ndim; i++ {
+			o.ρwl[i], o.ρwg[i] = 0, 0
+			for j := 0; j < ndim; j++ {
+				o.ρwl[i] += klr * o.Mdl.Klsat[i][j] * (ρL*o.g[j] - o.gpl[j])
+				o.ρwg[i] += kgr * o.Mdl.Kgsat[i][j] * (ρG*o.g[j] - o.gpg[j])
+			}
+		}
+
+		// add negative of residual term to fb
+		for m := 0; m < nverts; m++ {
+			rl := o.PLmap[m]
+			rg := o.PGmap[m]
+			fb[rl] -= coef * S[m] * (o.res.Cpl*plt + o.res.Cpg*pgt)
+			fb[rg] -= coef * S[m] * (o.res.Dpl*plt + o.res.Dpg*pgt)
+			for i := 0; i < ndim; i++ {
+				fb[rl] += coef * G[m][i] * o.ρwl[i] // += coef * div(ρl*wl)
+				fb[rg] += coef * G[m][i] * o.ρwg[i] // += coef * div(ρg*wg)
+			}
+		}
+	}
+
+	// contribution from natural boundary conditions
+	if len(o.NatBcs) > 0 {
+		return o.add_natbcs_to_rhs(fb, sol)
+	}
+	return true
+}
+
+// AddToKb adds element K to global Jacobian matrix Kb
+func (o ElemPP) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
+
+	// clear matrices
+	la.MatFill(o.Kll, 0)
+	la.MatFill(o.Klg, 0)
+	la.MatFill(o.Kgl, 0)
+	la.MatFill(o.Kgg, 0)
+
+	// for each integration point
+	Cl := o.Mdl.Cl
+	Cg := o.Mdl.Cg
+	β1 := Global.DynCoefs.β1
+	ndim := Global.Ndim
+	nverts := o.Shp.Nverts
+	var coef, plt, pgt, klr, kgr, ρL, ρG float64
+	for idx, ip := range o.IpsElem {
+
+		// interpolation functions, gradients and variables @ ip
+		if !o.ipvars(idx, sol) {
+			return
+		}
+		coef = o.Shp.J * ip.W
+		S := o.Shp.S
+		G := o.Shp.G
+
+		// tpm variables
+		plt = β1*o.pl - o.ψl[idx]
+		pgt = β1*o.pg - o.ψg[idx]
+		klr = o.Mdl.Cnd.Klr(o.States[idx].A_sl)
+		kgr = o.Mdl.Cnd.Kgr(1.0 - o.States[idx].A_sl)
+		ρL = o.States[idx].A_ρL
+		ρG = o.States[idx].A_ρG
+		if LogErr(o.Mdl.CalcLgs(o.res, o.States[idx], o.pl, o.pg, 0, true), "AddToKb") {
+			return
+		}
+		r := o.res
+
+		// Kll, Klg, Kgl and Kgg
+		for n := 0; n < nverts; n++ {
+			for m := 0; m < nverts; m++ {
+				o.Kll[m][n] += coef * S[m] * S[n] * (r.DCpldpl*plt + r.DCpgdpl*pgt + β1*r.Cpl)
+				o.Klg[m][n] += coef * S[m] * S[n] * (r.DCpldpg*plt + r.DCpgdpg*pgt + β1*r.Cpg)
+				o.Kgl[m][n] += coef * S[m] * S[n] * (r.DDpldpl*plt + r.DDpgdpl*pgt + β1*r.Dpl)
+				o.Kgg[m][n] += coef * S[m] * S[n] * (r.DDpldpg*plt + r.DDpgdpg*pgt + β1*r.Dpg)
+				for i := 0; i < ndim; i++ {
+					for j := 0; j < ndim; j++ {
+						o.Kll[m][n] -= coef * G[m][i] * o.Mdl.Klsat[i][j] * (S[n]*r.Dklrdpl*(ρL*o.g[j]-o.gpl[j]) + klr*(S[n]*Cl*o.g[j]-G[n][j]))
+						o.Klg[m][n] -= coef * G[m][i] * o.Mdl.Klsat[i][j] * S[n] * r.Dklrdpg * (ρL*o.g[j] - o.gpl[j])
+						o.Kgl[m][n] -= coef * G[m][i] * o.Mdl.Kgsat[i][j] * S[n] * r.Dkgrdpl * (ρG*o.g[j] - o.gpg[j])
+						o.Kgg[m][n] -= coef * G[m][i] * o.Mdl.Kgsat[i][j] * (S[n]*r.Dkgrdpg*(ρG*o.g[j]-o.gpg[j]) + kgr*(S[n]*Cg*o.g[j]-G[n][j]))
+					}
+				}
+			}
+		}
+	}
+
+	// add to sparse matrix Kb
+	for i, I := range o.PLmap {
+		for j, J := range o.PLmap {
+			Kb.Put(I, J, o.Kll[i][j])
+		}
+		for j, J := range o.PGmap {
+			Kb.Put(I, J, o.Klg[i][j])
+		}
+	}
+	for i, I := range o.PGmap {
+		for j, J := range o.PLmap {
+			Kb.Put(I, J, o.Kgl[i][j])
+		}
+		for j, J := range o.PGmap {
+			Kb.Put(I, J, o.Kgg[i][j])
+		}
+	}
+	return true
+}
+
+// Update performs (tangent) update
+func (o *ElemPP) Update(sol *Solution) (ok bool) {
+
+	// for each integration point
+	var pl, pg, Δpl, Δpg float64
+	for idx, ip := range o.IpsElem {
+
+		// interpolation functions and gradients
+		if LogErr(o.Shp.CalcAtIp(o.X, ip, false), "Update") {
+			return
+		}
+
+		// compute pl, pg, Δpl and Δpg @ ip by means of interpolating from nodes
+		pl, pg, Δpl, Δpg = 0, 0, 0, 0
+		for m := 0; m < o.Shp.Nverts; m++ {
+			rl := o.PLmap[m]
+			rg := o.PGmap[m]
+			pl += o.Shp.S[m] * sol.Y[rl]
+			pg += o.Shp.S[m] * sol.Y[rg]
+			Δpl += o.Shp.S[m] * sol.ΔY[rl]
+			Δpg += o.Shp.S[m] * sol.ΔY[rg]
+		}
+
+		// update state
+		if LogErr(o.Mdl.Update(o.States[idx], Δpl, Δpg, pl, pg), "Update") {
+			return
+		}
+	}
+	return true
+}
+
+// internal variables ///////////////////////////////////////////////////////////////////////////////
+
+// Ipoints returns the real coordinates of integration points [nip][ndim]
+func (o ElemPP) Ipoints() (coords [][]float64) {
+	coords = la.MatAlloc(len(o.IpsElem), Global.Ndim)
+	for idx, ip := range o.IpsElem {
+		coords[idx] = o.Shp.IpRealCoords(o.X, ip)
+	}
+	return
+}
+
+// SetIniIvs sets initial ivs for given values in sol and ivs map
+func (o *ElemPP) SetIniIvs(sol *Solution, ignored map[string][]float64) (ok bool) {
+
+	// auxiliary
+	nip := len(o.IpsElem)
+	ndim := Global.Ndim
+	var ρL, ρG float64
+	var err error
+
+	// allocate slices of states
+	o.States = make([]*mporous.State, nip)
+	o.StatesBkp = make([]*mporous.State, nip)
+	o.StatesAux = make([]*mporous.State, nip)
+
+	// for each integration point
+	for idx := range o.IpsElem {
+
+		// interpolation functions, gradients and variables @ ip
+		if !o.ipvars(idx, sol) {
+			return
+		}
+
+		// compute liquid density from hydrostatic condition => enforce initial ρwl = 0
+		ρL = o.Mdl.RhoL0
+		if math.Abs(o.g[ndim-1]) > 0 {
+			ρL = o.gpl[ndim-1] / o.g[ndim-1]
+		}
+
+		// gas density from current gas pressure
+		ρG = o.Mdl.RhoG0 + o.Mdl.Cg*o.pg
+
+		// state initialisation
+		o.States[idx], err = o.Mdl.NewState(ρL, ρG, o.pl, o.pg)
+		if LogErr(err, "SetIniIvs") {
+			return
+		}
+
+		// backup copy
+		o.StatesBkp[idx] = o.States[idx].GetCopy()
+		o.StatesAux[idx] = o.States[idx].GetCopy()
+	}
+	return true
+}
+
+// BackupIvs creates copy of internal variables
+func (o *ElemPP) BackupIvs(aux bool) (ok bool) {
+	if aux {
+		for i, s := range o.StatesAux {
+			s.Set(o.States[i])
+		}
+		return true
+	}
+	for i, s := range o.StatesBkp {
+		s.Set(o.States[i])
+	}
+	return true
+}
+
+// RestoreIvs restores internal variables from copies
+func (o *ElemPP) RestoreIvs(aux bool) (ok bool) {
+	if aux {
+		for i, s := range o.States {
+			s.Set(o.StatesAux[i])
+		}
+		return true
+	}
+	for i, s := range o.States {
+		s.Set(o.StatesBkp[i])
+	}
+	return true
+}
+
+// Ureset fixes internal variables after u (displacements) have been zeroed
+func (o *ElemPP) Ureset(sol *Solution) (ok bool) {
+	return true
+}
+
+// writer ///////////////////////////////////////////////////////////////////////////////////////////
+
+// Encode encodes internal variables
+func (o ElemPP) Encode(enc Encoder) (ok bool) {
+	return !LogErr(enc.Encode(o.States), "Encode")
+}
+
+// Decode decodes internal variables
+func (o ElemPP) Decode(dec Decoder) (ok bool) {
+	if LogErr(dec.Decode(&o.States), "Decode") {
+		return
+	}
+	return o.BackupIvs(false)
+}
+
+// OutIpsData returns data from all integration points for output
+func (o ElemPP) OutIpsData() (data []*OutIpData) {
+	ndim := Global.Ndim
+	flowl := FlowKeys()
+	flowg := GasFlowKeys()
+	for idx, ip := range o.IpsElem {
+		s := o.States[idx]
+		x := o.Shp.IpRealCoords(o.X, ip)
+		calc := func(sol *Solution) (vals map[string]float64) {
+			if !o.ipvars(idx, sol) {
+				return
+			}
+			ρL := s.A_ρL
+			ρG := s.A_ρG
+			klr := o.Mdl.Cnd.Klr(s.A_sl)
+			kgr := o.Mdl.Cnd.Kgr(1.0 - s.A_sl)
+			vals = map[string]float64{
+				"sl": s.A_sl,
+				"sg": 1.0 - s.A_sl,
+				"pl": o.pl,
+				"pg": o.pg,
+				"pc": o.pg - o.pl,
+				"nf": 1.0 - s.A_ns0,
+			}
+			for i := 0; i < ndim; i++ {
+				for j := 0; j < ndim; j++ {
+					vals[flowl[i]] += klr * o.Mdl.Klsat[i][j] * (o.g[j] - o.gpl[j]/ρL)
+					vals[flowg[i]] += kgr * o.Mdl.Kgsat[i][j] * (o.g[j] - o.gpg[j]/ρG)
+				}
+			}
+			return
+		}
+		data = append(data, &OutIpData{o.Id(), x, calc})
+	}
+	return
+}
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// ipvars computes current values @ integration points. idx == index of integration point
+func (o *ElemPP) ipvars(idx int, sol *Solution) (ok bool) {
+
+	// interpolation functions and gradients
+	if LogErr(o.Shp.CalcAtIp(o.X, o.IpsElem[idx], true), "ipvars") {
+		return
+	}
+
+	// auxiliary
+	ndim := Global.Ndim
+	o.compute_gvec(sol.T)
+
+	// clear pl, pg and gradients @ ip
+	o.pl, o.pg = 0, 0
+	for i := 0; i < ndim; i++ {
+		o.gpl[i], o.gpg[i] = 0, 0
+	}
+
+	// compute pl, pg and gradients @ ip by means of interpolating from nodes
+	for m := 0; m < o.Shp.Nverts; m++ {
+		rl := o.PLmap[m]
+		rg := o.PGmap[m]
+		o.pl += o.Shp.S[m] * sol.Y[rl]
+		o.pg += o.Shp.S[m] * sol.Y[rg]
+		for i := 0; i < ndim; i++ {
+			o.gpl[i] += o.Shp.G[m][i] * sol.Y[rl]
+			o.gpg[i] += o.Shp.G[m][i] * sol.Y[rg]
+		}
+	}
+	return true
+}
+
+// add_natbcs_to_rhs adds natural boundary conditions to rhs
+//  Note: prescribed fluxes are converted to mass fluxes with the reference intrinsic densities
+func (o ElemPP) add_natbcs_to_rhs(fb []float64, sol *Solution) (ok bool) {
+
+	// compute surface integral
+	var qb float64
+	for _, nbc := range o.NatBcs {
+
+		// prescribed flux
+		qb = nbc.Fcn.F(sol.T, nil) * sol.LoadFac
+
+		// loop over ips of face
+		for _, ipf := range o.IpsFace {
+
+			// interpolation functions and gradients @ face
+			iface := nbc.IdxFace
+			if LogErr(o.Shp.CalcAtFaceIp(o.X, ipf, iface), "add_natbcs_to_rhs") {
+				return
+			}
+			Sf := o.Shp.Sf
+			Jf := la.VecNorm(o.Shp.Fnvec)
+			coef := ipf.W * Jf
+
+			// select natural boundary condition type
+			switch nbc.Key {
+			case "ql":
+				for i, m := range o.Shp.FaceLocalV[iface] {
+					fb[o.PLmap[m]] -= coef * o.Mdl.RhoL0 * qb * Sf[i]
+				}
+			case "qg":
+				for i, m := range o.Shp.FaceLocalV[iface] {
+					fb[o.PGmap[m]] -= coef * o.Mdl.RhoG0 * qb * Sf[i]
+				}
+			}
+		}
+	}
+	return true
+}
+
+// compute_gvec computes gravity vector @ time t
+func (o ElemPP) compute_gvec(t float64) {
+	o.g[Global.Ndim-1] = 0
+	if o.Gfcn != nil {
+		o.g[Global.Ndim-1] = -o.Gfcn.F(t, nil)
+	}
+}
@@ -7,6 +7,7 @@ package fem
 import (
 	"github.com/cpmech/gofem/inp"
 	"github.com/cpmech/gofem/msolid"
+	"github.com/cpmech/gofem/shp"
 
 	"github.com/cpmech/gosl/fun"
 	"github.com/cpmech/gosl/io"
@@ -41,6 +42,8 @@ import (
 //   rodRp    -- natural coordinates of rod's integration point w.r.t to solid's system
 //   Nmat     -- solid shape functions evaluated at rod nodes
 //   Pmat     -- solid shape functions evaluated at rod integration points
+//  Note: the solid can be any of the shapes available in package shp; e.g. qua4, tri6, hex8, tet4
+//        or tet10. Rod nodes and integration points may lie on faces, edges or vertices of the solid
 //  References:
 //   [1] R Durand, MM Farias, DM Pedroso. Modelling the strengthening of solids with
 //       incompatible line finite elements, Computers and Structures (2014). Submitted.
@@ -130,8 +133,8 @@ func (o *Rjoint) Connect(cid2elem []Elem, c *inp.Cell) (nnzK int, ok bool) {
 	// get rod and solid elements
 	rodId := c.JlinId
 	sldId := c.JsldId
-	o.Rod = cid2elem[rodId].(*Rod)
-	o.Sld = cid2elem[sldId].(*ElemU)
+	o.Rod, _ = cid2elem[rodId].(*Rod)
+	o.Sld, _ = cid2elem[sldId].(*ElemU)
 	if LogErrCond(o.Rod == nil, "cannot find joint's rod cell with id == %d", rodId) {
 		return
 	}
@@ -195,7 +198,7 @@ func (o *Rjoint) Connect(cid2elem []Elem, c *inp.Cell) (nnzK int, ok bool) {
 		for i := 0; i < ndim; i++ {
 			rodYn[i] = o.Rod.X[i][m]
 		}
-		if LogErr(sldH.InvMap(rodRn, rodYn, o.Sld.X), "inverse map failed") {
+		if !o.locate(rodRn, rodYn) {
 			return
 		}
 		if LogErr(sldH.CalcAtR(o.Sld.X, rodRn, false), "shape functions calculation failed") {
@@ -226,7 +229,7 @@ func (o *Rjoint) Connect(cid2elem []Elem, c *inp.Cell) (nnzK int, ok bool) {
 		// shape function of solid @ ips of rod
 		for idx, ip := range o.Rod.IpsElem {
 			rodYp := rodH.IpRealCoords(o.Rod.X, ip)
-			if LogErr(sldH.InvMap(o.rodRp[idx], rodYp, o.Sld.X), "inverse map failed") {
+			if !o.locate(o.rodRp[idx], rodYp) {
 				return
 			}
 			if LogErr(sldH.CalcAtR(o.Sld.X, o.rodRp[idx], false), "shape functions calculation failed") {
@@ -311,6 +314,21 @@ func (o *Rjoint) Connect(cid2elem []Elem, c *inp.Cell) (nnzK int, ok bool) {
 	return o.Ny * o.Ny, true
 }
 
+// locate computes the natural coordinates (r) w.r.t the solid's system of a point of the rod with
+// real coordinates y and checks whether the point is inside the solid. Points on faces, edges or
+// vertices of the solid are accepted; thus rods crossing many cells must be split into one rod
+// element (and one Rjoint) per solid cell, with nodes at the intersections with cell boundaries
+func (o *Rjoint) locate(r, y []float64) (ok bool) {
+	sldH := o.Sld.Shp
+	if LogErr(sldH.InvMap(r, y, o.Sld.X), io.Sf("Rjoint(%d): inverse map of rod(%d) point %v in solid(%d) failed", o.Cid, o.Rod.Cid, y, o.Sld.Cid)) {
+		return
+	}
+	if LogErrCond(!sldH.IsInside(r, shp.INSIDE_TOL), "Rjoint(%d): rod(%d) point %v is outside solid(%d) %q; natural coordinates = %v", o.Cid, o.Rod.Cid, y, o.Sld.Cid, sldH.Type, r) {
+		return
+	}
+	return true
+}
+
 // implementation ///////////////////////////////////////////////////////////////////////////////////
 
 // SetEqs set equations
@@ -387,7 +405,7 @@ func (o *Rjoint) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o *Rjoint) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o *Rjoint) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 
 	// auxiliary
 	ndim := Global.Ndim
@@ -219,7 +219,7 @@ func (o Rod) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o Rod) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o Rod) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 
 	// zero K matrix
 	la.MatFill(o.K, 0)
@@ -30,6 +30,13 @@ type ElemU struct {
 	Cdam float64  // coefficient for damping
 	Gfcn fun.Func // gravity function
 
+	// Rayleigh damping C = αM・M + βK・K and mass matrix
+	AlpM float64     // αM: coefficient of mass-proportional damping
+	BetK float64     // βK: coefficient of stiffness-proportional damping
+	Mass string      // mass matrix: "consistent", "rowsum" or "hrz"
+	Chrz float64     // scaling factor of HRZ lumped mass: Chrz = V / Σ_m ∫ Sm² dV
+	D0   [][]float64 // [nsig][nsig] initial (elastic) stiffness for the βK term
+
 	// optional data
 	UseB      bool    // use B matrix
 	Thickness float64 // thickness (for plane-stress)
@@ -40,6 +47,7 @@ type ElemU struct {
 	IpsFace []*shp.Ipoint // integration points corresponding to faces
 
 	// material model and internal variables
+	Mat      string       // material name
 	Model    msolid.Model // material model
 	MdlSmall msolid.Small // model specialisation for small strains
 	MdlLarge msolid.Large // model specialisation for large deformations
@@ -55,6 +63,12 @@ type ElemU struct {
 	// natural boundary conditions
 	NatBcs []*NaturalBc
 
+	// absorbing and free-field boundaries
+	Dpots  []*Dashpot    // [nnatbcs] coefficients of "dashpot" and "freefield" faces; nil for other keys
+	HasDpt bool          // has "dashpot", "freefield" or incident wave ("aix", "aiy", "aiz") faces
+	HasFfd bool          // has "freefield" faces
+	ffσ0   [][][]float64 // [nnatbcs][nipf][ndim] static free-field tractions σ0・n; computed once per stage
+
 	// local starred variables
 	ζs    [][]float64 // [nip][ndim] t2 star vars: ζ* = α1.u + α2.v + α3.a
 	χs    [][]float64 // [nip][ndim] t2 star vars: χ* = α4.u + α5.v + α6.a
@@ -67,11 +81,31 @@ type ElemU struct {
 	K    [][]float64 // [nu][nu] consistent tangent (stiffness) matrix
 	B    [][]float64 // [nsig][nu] B matrix for axisymetric case
 	D    [][]float64 // [nsig][nsig] constitutive consistent tangent matrix
+	bm   [][]float64 // [nverts][ndim] inertial and αM terms per unit density: M_ip・(a + αM・v - g)
+	bc   [][]float64 // [nverts][ndim] damping terms per unit Cdam: M_ip・v
+	εv   []float64   // [nsig] strains computed with u for the βK term
+	εχ   []float64   // [nsig] strains computed with χ* for the βK term
+	σv   []float64   // [nsig] viscous stresses of the βK term: σv = βK・D0・ε(α4・u - χ*)
+
+	// scratchpad. computed @ each face ip of absorbing and free-field boundaries
+	nf []float64 // [ndim] unit normal
+	vf []float64 // [ndim] velocity
+	wf []float64 // [ndim] velocity relative to free-field
+	tf []float64 // [ndim] traction
 
 	// strains
 	ε  []float64 // total (updated) strains
 	Δε []float64 // incremental strains leading to updated strains
 
+	// for large deformations
+	F    [][]float64     // [3][3] deformation gradient @ ip
+	Fold [][]float64     // [3][3] deformation gradient @ ip at the beginning of the time step
+	FΔ   [][]float64     // [3][3] incremental deformation gradient: FΔ = F・inv(Fold)
+	Fi   [][]float64     // [3][3] inverse of F or Fold
+	A    [][][][]float64 // [3][3][3][3] spatial consistent tangent modulus
+	gs   [][]float64     // [nverts][ndim] spatial gradients of shape functions: gs = G・inv(F)
+	Jdef float64         // det(F) @ ip
+
 	// for debugging
 	fex []float64 // x-components of external surface forces
 	fey []float64 // y-components of external syrface forces
@@ -133,6 +167,7 @@ func init() {
 
 		// model
 		var prms fun.Prms
+		o.Mat = edat.Mat
 		o.Model, prms = GetAndInitSolidModel(edat.Mat, ndim)
 		if o.Model == nil {
 			return nil
@@ -158,6 +193,13 @@ func init() {
 			}
 		}
 
+		// Rayleigh damping and mass matrix
+		var ok bool
+		o.AlpM, o.BetK, o.Mass, ok = GetDynFlags(prms, edat.Extra)
+		if !ok {
+			return nil
+		}
+
 		// local starred variables
 		o.ζs = la.MatAlloc(nip, ndim)
 		o.χs = la.MatAlloc(nip, ndim)
@@ -173,11 +215,31 @@ func init() {
 		if o.UseB {
 			o.B = la.MatAlloc(nsig, o.Nu)
 		}
+		o.bm = la.MatAlloc(o.Shp.Nverts, ndim)
+		o.bc = la.MatAlloc(o.Shp.Nverts, ndim)
+
+		// lumped mass and stiffness-proportional damping
+		if !o.init_damping(prms) {
+			return nil
+		}
 
 		// strains
 		o.ε = make([]float64, nsig)
 		o.Δε = make([]float64, nsig)
 
+		// large deformations
+		if o.MdlLarge != nil {
+			if LogErrCond(o.UseB || Global.Sim.Data.Axisym, "large deformation analyses cannot use the B matrix or axisymmetric elements") {
+				return nil
+			}
+			o.F = tsr.Alloc2()
+			o.Fold = tsr.Alloc2()
+			o.FΔ = tsr.Alloc2()
+			o.Fi = tsr.Alloc2()
+			o.A = tsr.Alloc4()
+			o.gs = la.MatAlloc(o.Shp.Nverts, ndim)
+		}
+
 		// variables for debugging
 		if o.Debug {
 			o.fex = make([]float64, o.Shp.Nverts)
@@ -192,6 +254,11 @@ func init() {
 			o.NatBcs = append(o.NatBcs, &NaturalBc{fc.Cond, fc.FaceId, fc.Func, fc.Extra})
 		}
 
+		// absorbing and free-field boundaries. Note: coupled elements set the density of the mixture
+		if !o.set_dashpots(prms, edat.Type == "u") {
+			return nil
+		}
+
 		// return new element
 		return &o
 	}
@@ -265,7 +332,6 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 	}
 
 	// for each integration point
-	dc := Global.DynCoefs
 	ndim := Global.Ndim
 	nverts := o.Shp.Nverts
 	for idx, ip := range o.IpsElem {
@@ -281,7 +347,19 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 		G := o.Shp.G
 
 		// add internal forces to fb
-		if o.UseB {
+		if o.MdlLarge != nil {
+			if !o.ipdefgrad(o.F, sol.Y, nil) {
+				return
+			}
+			for m := 0; m < nverts; m++ {
+				for i := 0; i < ndim; i++ {
+					r := o.Umap[i+m*ndim]
+					for j := 0; j < ndim; j++ {
+						fb[r] -= coef * o.Jdef * tsr.M2T(o.States[idx].Sig, i, j) * o.gs[m][j] // -fi (spatial)
+					}
+				}
+			}
+		} else if o.UseB {
 			radius := 1.0
 			if Global.Sim.Data.Axisym {
 				radius = o.Shp.AxisymGetRadius(o.X)
@@ -302,12 +380,16 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 
 		// dynamic term
 		if !Global.Sim.Data.Steady {
+			o.ipmass(idx, o.grav, sol)
 			for m := 0; m < nverts; m++ {
 				for i := 0; i < ndim; i++ {
 					r := o.Umap[i+m*ndim]
-					fb[r] -= coef * S[m] * (o.Rho*(dc.α1*o.us[i]-o.ζs[idx][i]-o.grav[i]) + o.Cdam*(dc.α4*o.us[i]-o.χs[idx][i])) // -RuBar
+					fb[r] -= coef * (o.Rho*o.bm[m][i] + o.Cdam*o.bc[m][i]) // -RuBar
 				}
 			}
+			if o.BetK > 0 {
+				o.add_stiffdamp_to_rhs(fb, coef, sol)
+			}
 		}
 	}
 
@@ -323,7 +405,7 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *ElemU) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o *ElemU) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 
 	// zero K matrix
 	la.MatFill(o.K, 0)
@@ -350,38 +432,51 @@ func (o *ElemU) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
 		S := o.Shp.S
 		G := o.Shp.G
 
-		// consistent tangent model matrix
-		if LogErr(o.MdlSmall.CalcD(o.D, o.States[idx], firstIt), "AddToKb") {
-			return
-		}
-
-		// add contribution to consistent tangent matrix
-		if o.UseB {
-			radius := 1.0
-			if Global.Sim.Data.Axisym {
-				radius = o.Shp.AxisymGetRadius(o.X)
-				coef *= radius
+		// large deformations: material and geometric stiffness from spatial tangent modulus
+		if o.MdlLarge != nil {
+			if !o.ipdefgrad(o.F, sol.Y, nil) {
+				return
 			}
-			IpBmatrix(o.B, ndim, nverts, G, radius, S)
-			la.MatTrMulAdd3(o.K, coef, o.B, o.D, o.B) // K += coef * tr(B) * D * B
+			if LogErr(o.MdlLarge.CalcA(o.A, o.States[idx], firstIt), "AddToKb") {
+				return
+			}
+			IpAddToKtLarge(o.K, nverts, ndim, coef*o.Jdef, o.gs, o.A)
+
 		} else {
-			IpAddToKt(o.K, nverts, ndim, coef, G, o.D)
+
+			// consistent tangent model matrix
+			if LogErr(o.MdlSmall.CalcD(o.D, o.States[idx], firstIt), "AddToKb") {
+				return
+			}
+
+			// add contribution to consistent tangent matrix
+			if o.UseB {
+				radius := 1.0
+				if Global.Sim.Data.Axisym {
+					radius = o.Shp.AxisymGetRadius(o.X)
+					coef *= radius
+				}
+				IpBmatrix(o.B, ndim, nverts, G, radius, S)
+				la.MatTrMulAdd3(o.K, coef, o.B, o.D, o.B) // K += coef * tr(B) * D * B
+			} else {
+				IpAddToKt(o.K, nverts, ndim, coef, G, o.D)
+			}
 		}
 
 		// dynamic term
 		if !Global.Sim.Data.Steady {
-			for m := 0; m < nverts; m++ {
-				for i := 0; i < ndim; i++ {
-					r := i + m*ndim
-					for n := 0; n < nverts; n++ {
-						c := i + n*ndim
-						o.K[r][c] += coef * S[m] * S[n] * (o.Rho*dc.α1 + o.Cdam*dc.α4)
-					}
-				}
+			o.add_ipmass_to_kb(coef * (o.Rho*(dc.α1+o.AlpM*dc.α4) + o.Cdam*dc.α4))
+			if o.BetK > 0 {
+				o.add_stiffdamp_to_kb(coef)
 			}
 		}
 	}
 
+	// absorbing and free-field boundaries
+	if !o.add_surfloads_to_jac(sol) {
+		return
+	}
+
 	// add K to sparse matrix Kb
 	for i, I := range o.Umap {
 		for j, J := range o.Umap {
@@ -406,6 +501,25 @@ func (o *ElemU) Update(sol *Solution) (ok bool) {
 		S := o.Shp.S
 		G := o.Shp.G
 
+		// large deformations: compute F and FΔ and call model update
+		if o.MdlLarge != nil {
+			if !o.ipdefgrad(o.Fold, sol.Y, sol.ΔY) {
+				return
+			}
+			if !o.ipdefgrad(o.F, sol.Y, nil) {
+				return
+			}
+			_, err := tsr.Inv(o.Fi, o.Fold)
+			if LogErr(err, "Update") {
+				return
+			}
+			la.MatMul(o.FΔ, 1, o.F, o.Fi)
+			if LogErr(o.MdlLarge.Update(o.States[idx], o.F, o.FΔ), io.Sf("Update (eid=%d, ip=%d)\nERROR: Update J=%v\nERROR: Update", o.Id(), idx, o.Jdef)) {
+				return
+			}
+			continue
+		}
+
 		// compute strains
 		if o.UseB {
 			radius := 1.0
@@ -426,6 +540,24 @@ func (o *ElemU) Update(sol *Solution) (ok bool) {
 	return true
 }
 
+// concurrency //////////////////////////////////////////////////////////////////////////////////////
+
+// SetWorker replaces the shared shape structure and material model by private copies of worker
+func (o *ElemU) SetWorker(w *Worker) (ok bool) {
+	o.Shp = w.Shape(o.Shp.Type)
+	o.Model, _ = w.SolidModel(o.Mat, Global.Ndim)
+	if o.Model == nil {
+		return
+	}
+	switch m := o.Model.(type) {
+	case msolid.Small:
+		o.MdlSmall = m
+	case msolid.Large:
+		o.MdlLarge = m
+	}
+	return true
+}
+
 // internal variables ///////////////////////////////////////////////////////////////////////////////
 
 // Ipoints returns the real coordinates of integration points [nip][ndim]
@@ -500,6 +632,10 @@ func (o *ElemU) Ureset(sol *Solution) (ok bool) {
 		if len(o.States[idx].F) > 0 {
 			la.MatFill(o.States[idx].F, 0)
 			la.MatFill(o.StatesBkp[idx].F, 0)
+			for i := 0; i < 3; i++ {
+				o.States[idx].F[i][i] = 1
+				o.StatesBkp[idx].F[i][i] = 1
+			}
 		}
 	}
 	return true
@@ -569,9 +705,227 @@ func (o *ElemU) ipvars(idx int, sol *Solution) (ok bool) {
 	return true
 }
 
+// ipdefgrad computes the deformation gradient F @ ip, its determinant Jdef and the spatial
+// gradients of shape functions gs = G・inv(F)
+//  Note: 1) F is computed with u - Δu if Δu != nil; i.e. at the beginning of the time step
+//        2) CalcAtIp must be called first
+func (o *ElemU) ipdefgrad(F [][]float64, u, Δu []float64) (ok bool) {
+
+	// F = I + Σ_m u^m ⊗ G^m
+	ndim := Global.Ndim
+	nverts := o.Shp.Nverts
+	G := o.Shp.G
+	la.MatFill(F, 0)
+	for i := 0; i < 3; i++ {
+		F[i][i] = 1
+	}
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < ndim; i++ {
+			r := o.Umap[i+m*ndim]
+			ui := u[r]
+			if Δu != nil {
+				ui -= Δu[r]
+			}
+			for j := 0; j < ndim; j++ {
+				F[i][j] += ui * G[m][j]
+			}
+		}
+	}
+
+	// inverse and determinant
+	var err error
+	o.Jdef, err = tsr.Inv(o.Fi, F)
+	if LogErr(err, "ipdefgrad") {
+		return
+	}
+	if LogErrCond(o.Jdef <= 0, "ElemU: eid=%d: determinant of deformation gradient is not positive = %g\n", o.Id(), o.Jdef) {
+		return
+	}
+
+	// spatial gradients
+	for m := 0; m < nverts; m++ {
+		for j := 0; j < ndim; j++ {
+			o.gs[m][j] = 0
+			for k := 0; k < ndim; k++ {
+				o.gs[m][j] += G[m][k] * o.Fi[k][j]
+			}
+		}
+	}
+	return true
+}
+
+// mass and damping /////////////////////////////////////////////////////////////////////////////////
+
+// init_damping computes the scaling factor of the HRZ lumped mass matrix, checks that the row-sum
+// lumped masses are positive and computes the initial (elastic) stiffness matrix of the
+// stiffness-proportional damping term
+func (o *ElemU) init_damping(prms fun.Prms) (ok bool) {
+
+	// row-sum: sum of rows of consistent mass matrix; e.g. zero or negative at corners of tri6,
+	// tet10, qua8 and hex20 cells. HRZ: diagonal of consistent mass matrix scaled such that the
+	// total mass is preserved
+	if o.Mass != "consistent" {
+		var vol, sum float64
+		L := make([]float64, o.Shp.Nverts)
+		for _, ip := range o.IpsElem {
+			if LogErr(o.Shp.CalcAtIp(o.X, ip, true), "init_damping") {
+				return
+			}
+			coef := o.Shp.J * ip.W
+			if Global.Sim.Data.Axisym {
+				coef *= o.Shp.AxisymGetRadius(o.X)
+			}
+			vol += coef
+			for m := 0; m < o.Shp.Nverts; m++ {
+				L[m] += coef * o.Shp.S[m]
+				sum += coef * o.Shp.S[m] * o.Shp.S[m]
+			}
+		}
+		if o.Mass == "rowsum" {
+			for m, Lm := range L {
+				if LogErrCond(Lm <= 1e-12*vol, "ElemU: eid=%d: row-sum lumped mass of node %d of %q cell is not positive (%g); use \"!mass:hrz\" instead", o.Cid, m, o.Shp.Type, Lm) {
+					return
+				}
+			}
+		}
+		o.Chrz = vol / sum
+	}
+
+	// βK: initial stiffness computed with the elastic constants of material
+	if o.BetK > 0 {
+		if LogErrCond(o.MdlLarge != nil, "ElemU: eid=%d: stiffness-proportional damping is not available for large deformations", o.Cid) {
+			return
+		}
+		var elast msolid.SmallElasticity
+		err := elast.Init(Global.Ndim, Global.Sim.Data.Pstress, prms)
+		if LogErr(err, "stiffness-proportional damping requires the elastic constants of material") {
+			return
+		}
+		elast.Kgc = nil // initial values of K and G
+		nsig := 2 * Global.Ndim
+		o.D0 = la.MatAlloc(nsig, nsig)
+		if LogErr(elast.CalcD(o.D0, nil), "init_damping") {
+			return
+		}
+		o.εv = make([]float64, nsig)
+		o.εχ = make([]float64, nsig)
+		o.σv = make([]float64, nsig)
+	}
+	return true
+}
+
+// lumpw returns the weight of node m @ ip in the lumped mass matrix per unit density; i.e.
+// Lm = Sm (row-sum) or Lm = Chrz・Sm² (HRZ)
+func (o *ElemU) lumpw(m int) float64 {
+	if o.Mass == "hrz" {
+		return o.Chrz * o.Shp.S[m] * o.Shp.S[m]
+	}
+	return o.Shp.S[m]
+}
+
+// ipmass computes the inertial and damping terms of nodes @ ip per unit density (bm) and per unit
+// Cdam (bc). With a = α1・u - ζ* and v = α4・u - χ*:
+//   consistent mass: bm = Sm・(a + αM・v - g)   and   bc = Sm・v   with a and v @ ip
+//   lumped mass:     bm = Lm・(a + αM・v) - Sm・g   and   bc = Lm・v   with a and v @ node m
+//  Note: ipvars must be called first. In steady simulations, v = 0 and the mass is consistent
+func (o *ElemU) ipmass(idx int, g []float64, sol *Solution) {
+	dc := Global.DynCoefs
+	ndim := Global.Ndim
+	S := o.Shp.S
+	lumped := o.Mass != "consistent" && !Global.Sim.Data.Steady
+	var a, v, w float64
+	for m := 0; m < o.Shp.Nverts; m++ {
+		if lumped {
+			w = o.lumpw(m)
+		}
+		for i := 0; i < ndim; i++ {
+			if lumped {
+				r := o.Umap[i+m*ndim]
+				a = dc.α1*sol.Y[r] - sol.Zet[r]
+				v = dc.α4*sol.Y[r] - sol.Chi[r]
+				o.bm[m][i] = w*(a+o.AlpM*v) - S[m]*g[i]
+				o.bc[m][i] = w * v
+				continue
+			}
+			a, v = dc.α1*o.us[i]-o.ζs[idx][i], 0
+			if !Global.Sim.Data.Steady {
+				v = dc.α4*o.us[i] - o.χs[idx][i]
+			}
+			o.bm[m][i] = S[m] * (a + o.AlpM*v - g[i])
+			o.bc[m][i] = S[m] * v
+		}
+	}
+}
+
+// add_ipmass_to_kb adds cm・M_ip to K, where M_ip is the contribution of ip to the (consistent or
+// lumped) mass matrix per unit density
+func (o *ElemU) add_ipmass_to_kb(cm float64) {
+	ndim := Global.Ndim
+	nverts := o.Shp.Nverts
+	S := o.Shp.S
+	lumped := o.Mass != "consistent" && !Global.Sim.Data.Steady
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < ndim; i++ {
+			r := i + m*ndim
+			if lumped {
+				o.K[r][r] += cm * o.lumpw(m)
+				continue
+			}
+			for n := 0; n < nverts; n++ {
+				c := i + n*ndim
+				o.K[r][c] += cm * S[m] * S[n]
+			}
+		}
+	}
+}
+
+// add_stiffdamp_to_rhs adds the stiffness-proportional damping term -βK・K0・v to fb by means of the
+// viscous stresses σv = βK・D0・ε(v), with v = α4・u - χ*
+//  Note: the B matrix must be computed first if UseB; then, fi is used
+func (o *ElemU) add_stiffdamp_to_rhs(fb []float64, coef float64, sol *Solution) {
+	dc := Global.DynCoefs
+	ndim := Global.Ndim
+	nverts := o.Shp.Nverts
+	nsig := len(o.σv)
+	if o.UseB {
+		IpStrainsAndIncB(o.εv, o.εχ, nsig, o.Nu, o.B, sol.Y, sol.Chi, o.Umap)
+	} else {
+		IpStrainsAndInc(o.εv, o.εχ, nverts, ndim, sol.Y, sol.Chi, o.Umap, o.Shp.G)
+	}
+	for i := 0; i < nsig; i++ {
+		o.σv[i] = 0
+		for j := 0; j < nsig; j++ {
+			o.σv[i] += o.BetK * o.D0[i][j] * (dc.α4*o.εv[j] - o.εχ[j])
+		}
+	}
+	if o.UseB {
+		la.MatTrVecMulAdd(o.fi, coef, o.B, o.σv) // fi += coef * tr(B) * σv
+		return
+	}
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < ndim; i++ {
+			r := o.Umap[i+m*ndim]
+			for j := 0; j < ndim; j++ {
+				fb[r] -= coef * tsr.M2T(o.σv, i, j) * o.Shp.G[m][j]
+			}
+		}
+	}
+}
+
+// add_stiffdamp_to_kb adds the stiffness-proportional damping term α4・βK・K0 to K
+//  Note: the B matrix must be computed first if UseB
+func (o *ElemU) add_stiffdamp_to_kb(coef float64) {
+	c := coef * o.BetK * Global.DynCoefs.α4
+	if o.UseB {
+		la.MatTrMulAdd3(o.K, c, o.B, o.D0, o.B) // K += c * tr(B) * D0 * B
+		return
+	}
+	IpAddToKt(o.K, o.Shp.Nverts, Global.Ndim, c, o.Shp.G, o.D0)
+}
+
 // surfloads_keys returns the keys that can be used to specify surface loads
 func (o *ElemU) surfloads_keys() map[string]bool {
-	return map[string]bool{"qn": true, "qn0": true, "aqn": true}
+	return map[string]bool{"qn": true, "qn0": true, "aqn": true, "dashpot": true, "freefield": true, "aix": true, "aiy": true, "aiz": true}
 }
 
 // add_surfloads_to_rhs adds surfaces loads to rhs
@@ -587,15 +941,22 @@ func (o *ElemU) add_surfloads_to_rhs(fb []float64, sol *Solution) (ok bool) {
 		}
 	}
 
+	// static free-field tractions
+	if o.HasFfd && o.ffσ0 == nil {
+		if !o.init_freefield(nil) {
+			return
+		}
+	}
+
 	// compute surface integral
-	for _, load := range o.NatBcs {
-		for _, ip := range o.IpsFace {
+	for b, load := range o.NatBcs {
+		for idx, ip := range o.IpsFace {
 			if LogErr(o.Shp.CalcAtFaceIp(o.X, ip, load.IdxFace), "add_surfloads_to_rhs") {
 				return
 			}
 			switch load.Key {
 			case "qn", "qn0", "aqn":
-				coef := ip.W * load.Fcn.F(sol.T, nil) * o.Thickness
+				coef := ip.W * load.Fcn.F(sol.T, nil) * sol.LoadFac * o.Thickness
 				nvec := o.Shp.Fnvec
 				Sf := o.Shp.Sf
 				if Global.Sim.Data.Axisym && load.Key == "aqn" {
@@ -614,6 +975,268 @@ func (o *ElemU) add_surfloads_to_rhs(fb []float64, sol *Solution) (ok bool) {
 						}
 					}
 				}
+			case "dashpot", "freefield", "aix", "aiy", "aiz":
+				if !o.boundary_traction(b, idx, sol) {
+					return
+				}
+				coef := ip.W * o.face_coef(load.IdxFace)
+				Sf := o.Shp.Sf
+				for j, m := range o.Shp.FaceLocalV[load.IdxFace] {
+					for i := 0; i < ndim; i++ {
+						r := o.Umap[i+m*ndim]
+						fb[r] += coef * Sf[j] * o.tf[i] // +fe
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// add_surfloads_to_jac adds the contribution of absorbing and free-field boundaries to K
+//  Note: the relative velocity w = v - vff = α4・u - χ* - vff thus ∂w/∂u = α4
+func (o *ElemU) add_surfloads_to_jac(sol *Solution) (ok bool) {
+
+	// skip if there are no viscous boundaries or if steady
+	if !o.HasDpt || Global.Sim.Data.Steady {
+		return true
+	}
+
+	// compute surface integral
+	dc := Global.DynCoefs
+	ndim := Global.Ndim
+	for b, load := range o.NatBcs {
+		if o.Dpots[b] == nil {
+			continue
+		}
+		for _, ip := range o.IpsFace {
+			if LogErr(o.Shp.CalcAtFaceIp(o.X, ip, load.IdxFace), "add_surfloads_to_jac") {
+				return
+			}
+			o.face_normal()
+			coef := ip.W * o.face_coef(load.IdxFace) * load.Fcn.F(sol.T, nil) * dc.α4
+			Sf := o.Shp.Sf
+			for j, m := range o.Shp.FaceLocalV[load.IdxFace] {
+				for k, n := range o.Shp.FaceLocalV[load.IdxFace] {
+					for i := 0; i < ndim; i++ {
+						r := i + m*ndim
+						for l := 0; l < ndim; l++ {
+							c := l + n*ndim
+							o.K[r][c] += coef * Sf[j] * Sf[k] * o.Dpots[b].Coef(i, l, o.nf)
+						}
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// set_dashpots allocates the coefficients of "dashpot" and "freefield" faces and checks that the
+// faces with incident waves ("aix", "aiy", "aiz") also have "dashpot" conditions
+//  chkrho -- check whether the density is positive
+func (o *ElemU) set_dashpots(prms fun.Prms, chkrho bool) (ok bool) {
+	o.Dpots = make([]*Dashpot, len(o.NatBcs))
+	for b, load := range o.NatBcs {
+		switch load.Key {
+		case "dashpot", "freefield":
+			o.Dpots[b], ok = NewDashpot(prms, o.Rho, load.Extra)
+			if !ok {
+				return
+			}
+			if LogErrCond(chkrho && o.Dpots[b].Rho <= 0, "ElemU: eid=%d: %q boundaries require positive density; \"rho\" or \"!rho:\" must be given", o.Id(), load.Key) {
+				return false
+			}
+			o.HasDpt = true
+			o.HasFfd = o.HasFfd || load.Key == "freefield"
+		}
+	}
+	for _, load := range o.NatBcs {
+		if idx, rigid := motion_key(load.Key); idx >= 0 && !rigid {
+			if LogErrCond(idx >= Global.Ndim, "ElemU: eid=%d: incident wave %q is not available in %dD", o.Id(), load.Key, Global.Ndim) {
+				return false
+			}
+			if LogErrCond(o.face_dashpot(load.IdxFace) == nil, "ElemU: eid=%d: face %d with incident wave %q requires a \"dashpot\" condition", o.Id(), load.IdxFace, load.Key) {
+				return false
+			}
+			o.HasDpt = true
+		}
+	}
+	if o.HasDpt {
+		ndim := Global.Ndim
+		o.nf = make([]float64, ndim)
+		o.vf = make([]float64, ndim)
+		o.wf = make([]float64, ndim)
+		o.tf = make([]float64, ndim)
+	}
+	return true
+}
+
+// face_dashpot returns the dashpot of "dashpot" condition at face; nil if not found
+func (o *ElemU) face_dashpot(idxface int) *Dashpot {
+	for b, load := range o.NatBcs {
+		if load.Key == "dashpot" && load.IdxFace == idxface {
+			return o.Dpots[b]
+		}
+	}
+	return nil
+}
+
+// face_normal computes the unit normal nf and returns Jf; CalcAtFaceIp must be called first
+func (o *ElemU) face_normal() (Jf float64) {
+	Jf = la.VecNorm(o.Shp.Fnvec)
+	for i := 0; i < Global.Ndim; i++ {
+		o.nf[i] = o.Shp.Fnvec[i] / Jf
+	}
+	return
+}
+
+// face_coef returns Jf times thickness (and radius if axisymmetric); CalcAtFaceIp must be called first
+func (o *ElemU) face_coef(idxface int) (coef float64) {
+	coef = la.VecNorm(o.Shp.Fnvec) * o.Thickness
+	if Global.Sim.Data.Axisym {
+		coef *= o.Shp.AxisymGetRadiusF(o.X, idxface)
+	}
+	return
+}
+
+// boundary_traction computes the traction tf at face integration point idx of boundary condition b
+// with "dashpot", "freefield" or incident wave key. CalcAtFaceIp must be called first
+//
+//   dashpot:   t = - fcn・ρ・(cp・(v・n)・n + cs・(v - (v・n)・n))
+//   freefield: t = σ0・n + σff・n - fcn・ρ・(cp・(w・n)・n + cs・(w - (w・n)・n))  with  w = v - vff
+//   aix, ...:  t = 2・ρ・(cp・(vinc・n)・n + cs・(vinc - (vinc・n)・n))  with  vinc = vinc_x・ex, ...
+//
+//  where σ0 are the (static) stresses at the beginning of the stage and (vff, σff) the free-field
+//  velocity and stress from the input motion, which are computed analytically for a homogeneous
+//  elastic column with the wave velocities of the face; see InputMotion. Thus, layered sites are
+//  rejected by Domain.check_freefield
+func (o *ElemU) boundary_traction(b, idx int, sol *Solution) (ok bool) {
+
+	// auxiliary
+	ndim := Global.Ndim
+	load := o.NatBcs[b]
+	o.face_normal()
+	la.VecFill(o.tf, 0)
+	dynamic := !Global.Sim.Data.Steady
+
+	// incident wave
+	if k, rigid := motion_key(load.Key); k >= 0 && !rigid {
+		if !dynamic || Global.Motion == nil {
+			return true
+		}
+		la.VecFill(o.vf, 0)
+		o.vf[k] = 2.0 * Global.Motion.Comps[k].G(sol.T, nil)
+		o.face_dashpot(load.IdxFace).Traction(o.tf, o.vf, o.nf)
+		return true
+	}
+
+	// static free-field tractions
+	dpt := o.Dpots[b]
+	if load.Key == "freefield" {
+		copy(o.tf, o.ffσ0[b][idx])
+	}
+	if !dynamic {
+		return true
+	}
+
+	// velocity @ face ip
+	dc := Global.DynCoefs
+	Sf := o.Shp.Sf
+	la.VecFill(o.vf, 0)
+	for j, m := range o.Shp.FaceLocalV[load.IdxFace] {
+		for i := 0; i < ndim; i++ {
+			r := o.Umap[i+m*ndim]
+			o.vf[i] += Sf[j] * (dc.α4*sol.Y[r] - sol.Chi[r])
+		}
+	}
+	copy(o.wf, o.vf)
+
+	// free-field velocities and stresses
+	if load.Key == "freefield" && Global.Motion != nil {
+		iv := ndim - 1 // index of vertical direction
+		z := 0.0
+		for j, m := range o.Shp.FaceLocalV[load.IdxFace] {
+			z += Sf[j] * o.X[iv][m]
+		}
+		for k := 0; k < ndim; k++ {
+			if Global.Motion.Comps[k] == nil {
+				continue
+			}
+			if k == iv {
+				vff, dudz := Global.Motion.FreeField(k, z, sol.T, dpt.Cp)
+				o.wf[k] -= vff
+				for i := 0; i < ndim; i++ {
+					o.tf[i] += dpt.L * dudz * o.nf[i] // σff = λ・dudz・(I - ev⊗ev) + (λ+2G)・dudz・ev⊗ev
+				}
+				o.tf[iv] += 2.0 * dpt.G * dudz * o.nf[iv]
+				continue
+			}
+			vff, dudz := Global.Motion.FreeField(k, z, sol.T, dpt.Cs)
+			o.wf[k] -= vff
+			o.tf[k] += dpt.G * dudz * o.nf[iv] // σff = G・dudz・(ek⊗ev + ev⊗ek)
+			o.tf[iv] += dpt.G * dudz * o.nf[k]
+		}
+	}
+
+	// viscous traction
+	fcn := load.Fcn.F(sol.T, nil)
+	dpt.Traction(o.vf, o.wf, o.nf)
+	for i := 0; i < ndim; i++ {
+		o.tf[i] -= fcn * o.vf[i]
+	}
+	return true
+}
+
+// init_freefield computes the static tractions σ0・n at "freefield" faces from the stresses σ0 at
+// integration points, which are extrapolated to nodes and then interpolated to face points
+//  sig -- [nip][nsig] (total) stresses; nil means the stresses of States
+func (o *ElemU) init_freefield(sig [][]float64) (ok bool) {
+
+	// stresses at integration points
+	nip := len(o.IpsElem)
+	if sig == nil {
+		sig = make([][]float64, nip)
+		for idx, s := range o.States {
+			sig[idx] = s.Sig
+		}
+	}
+
+	// extrapolate stresses to nodes
+	ndim := Global.Ndim
+	nsig := 2 * ndim
+	nverts := o.Shp.Nverts
+	E := la.MatAlloc(nverts, nip)
+	if LogErr(o.Shp.Extrapolator(E, o.IpsElem), "init_freefield") {
+		return
+	}
+	σ := la.MatAlloc(nverts, nsig)
+	for m := 0; m < nverts; m++ {
+		for idx := 0; idx < nip; idx++ {
+			for k := 0; k < nsig; k++ {
+				σ[m][k] += E[m][idx] * sig[idx][k]
+			}
+		}
+	}
+
+	// tractions at face integration points
+	o.ffσ0 = make([][][]float64, len(o.NatBcs))
+	for b, load := range o.NatBcs {
+		if load.Key != "freefield" {
+			continue
+		}
+		o.ffσ0[b] = la.MatAlloc(len(o.IpsFace), ndim)
+		for idx, ip := range o.IpsFace {
+			if LogErr(o.Shp.CalcAtFaceIp(o.X, ip, load.IdxFace), "init_freefield") {
+				return
+			}
+			o.face_normal()
+			for j, m := range o.Shp.FaceLocalV[load.IdxFace] {
+				for i := 0; i < ndim; i++ {
+					for k := 0; k < ndim; k++ {
+						o.ffσ0[b][idx][i] += o.Shp.Sf[j] * tsr.M2T(σ[m], i, k) * o.nf[k]
+					}
+				}
 			}
 		}
 	}
@@ -113,6 +113,9 @@ func init() {
 			return nil
 		}
 		o.U = u_elem.(*ElemU)
+		if LogErrCond(o.U.MdlLarge != nil, "large deformation models are not available in up elements") {
+			return nil
+		}
 
 		// make sure p-element uses the same nubmer of integration points than u-element
 		edat.Nip = len(o.U.IpsElem)
@@ -126,6 +129,16 @@ func init() {
 		}
 		o.P = p_elem.(*ElemP)
 
+		// absorbing and free-field boundaries: density of saturated mixture
+		if o.U.HasDpt {
+			ρ := (1.0-o.P.Mdl.Nf0)*o.P.Mdl.RhoS0 + o.P.Mdl.Nf0*o.P.Mdl.RhoL0
+			for _, dpt := range o.U.Dpots {
+				if dpt != nil && dpt.Rho <= 0 {
+					dpt.SetRho(ρ)
+				}
+			}
+		}
+
 		// scratchpad. computed @ each ip
 		ndim := Global.Ndim
 		o.bs = make([]float64, ndim)
@@ -315,13 +328,14 @@ func (o ElemUP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 		}
 
 		// u: add negative of residual term to fb; see Eqs. (38b) and (45b) [1]
+		o.U.ipmass(idx, o.P.g, sol)
 		if o.U.UseB {
 			IpBmatrix(o.U.B, ndim, u_nverts, G, radius, S)
 			la.MatTrVecMulAdd(o.U.fi, coef, o.U.B, σe) // fi += coef * tr(B) * σ
 			for m := 0; m < u_nverts; m++ {
 				for i := 0; i < ndim; i++ {
 					r = o.U.Umap[i+m*ndim]
-					fb[r] -= coef * S[m] * ρ * o.bs[i]
+					fb[r] -= coef * (ρ*o.U.bm[m][i] + o.U.Cdam*o.U.bc[m][i])
 					fb[r] += coef * p * G[m][i]
 				}
 			}
@@ -329,7 +343,7 @@ func (o ElemUP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 			for m := 0; m < u_nverts; m++ {
 				for i := 0; i < ndim; i++ {
 					r = o.U.Umap[i+m*ndim]
-					fb[r] -= coef * S[m] * ρ * o.bs[i]
+					fb[r] -= coef * (ρ*o.U.bm[m][i] + o.U.Cdam*o.U.bc[m][i])
 					for j := 0; j < ndim; j++ {
 						fb[r] -= coef * tsr.M2T(σe, i, j) * G[m][j]
 					}
@@ -337,6 +351,11 @@ func (o ElemUP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 				}
 			}
 		}
+
+		// u: stiffness-proportional damping
+		if o.U.BetK > 0 && !Global.Sim.Data.Steady {
+			o.U.add_stiffdamp_to_rhs(fb, coef, sol)
+		}
 	}
 
 	// add fi term to fb, if using B matrix
@@ -348,6 +367,11 @@ func (o ElemUP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 
 	// external forces
 	if len(o.U.NatBcs) > 0 {
+		if o.U.HasFfd && o.U.ffσ0 == nil {
+			if !o.init_freefield(sol) {
+				return
+			}
+		}
 		if !o.U.add_surfloads_to_rhs(fb, sol) {
 			return
 		}
@@ -361,7 +385,7 @@ func (o ElemUP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
+func (o ElemUP) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
 
 	// clear matrices
 	ndim := Global.Ndim
@@ -437,6 +461,9 @@ func (o ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
 		dρldusM = o.P.res.DρldusM
 		dρdusM = o.P.res.DρdusM
 
+		// inertial and damping terms
+		o.U.ipmass(idx, o.P.g, sol)
+
 		// Kpu, Kup and Kpp
 		for n := 0; n < p_nverts; n++ {
 			for j := 0; j < ndim; j++ {
@@ -454,7 +481,7 @@ func (o ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
 					}
 
 					// add ∂rl/∂pl^n and ∂p/∂pl^n: Eqs (A.9) and (A.11) of [1]
-					o.Kup[c][n] += coef * (S[m]*Sb[n]*dρdpl*o.bs[j] - G[m][j]*Sb[n]*dpdpl)
+					o.Kup[c][n] += coef * (o.U.bm[m][j]*Sb[n]*dρdpl - G[m][j]*Sb[n]*dpdpl)
 
 					// for seepage face
 					if o.P.DoExtrap {
@@ -498,11 +525,16 @@ func (o ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
 				for n := 0; n < u_nverts; n++ {
 					for j := 0; j < ndim; j++ {
 						c = j + n*ndim
-						o.U.K[r][c] += coef * S[m] * (S[n]*dc.α1*ρ*tsr.It[i][j] + dρdusM*o.bs[i]*G[n][j])
+						o.U.K[r][c] += coef * dρdusM * o.U.bm[m][i] * G[n][j]
 					}
 				}
 			}
 		}
+		cm := ρ * dc.α1
+		if !Global.Sim.Data.Steady {
+			cm += (ρ*o.U.AlpM + o.U.Cdam) * dc.α4
+		}
+		o.U.add_ipmass_to_kb(coef * cm)
 
 		// consistent tangent model matrix
 		if LogErr(o.U.MdlSmall.CalcD(o.U.D, o.U.States[idx], firstIt), "AddToKb") {
@@ -516,6 +548,11 @@ func (o ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
 		} else {
 			IpAddToKt(o.U.K, u_nverts, ndim, coef, G, o.U.D)
 		}
+
+		// Kuu: stiffness-proportional damping
+		if o.U.BetK > 0 && !Global.Sim.Data.Steady {
+			o.U.add_stiffdamp_to_kb(coef)
+		}
 	}
 
 	// contribution from natural boundary conditions
@@ -528,6 +565,11 @@ func (o ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) {
 		}
 	}
 
+	// absorbing and free-field boundaries
+	if !o.U.add_surfloads_to_jac(sol) {
+		return
+	}
+
 	// add K to sparse matrix Kb
 	//    _             _
 	//   |  Kuu Kup  0   |
@@ -568,6 +610,16 @@ func (o *ElemUP) Update(sol *Solution) (ok bool) {
 	return o.P.Update(sol)
 }
 
+// concurrency //////////////////////////////////////////////////////////////////////////////////////
+
+// SetWorker replaces shared structures of underlying elements by private copies of worker
+func (o *ElemUP) SetWorker(w *Worker) (ok bool) {
+	if !o.U.SetWorker(w) {
+		return
+	}
+	return o.P.SetWorker(w)
+}
+
 // internal variables ///////////////////////////////////////////////////////////////////////////////
 
 // Ipoints returns the real coordinates of integration points [nip][ndim]
@@ -828,6 +880,27 @@ func (o ElemUP) add_natbcs_to_jac(sol *Solution) (ok bool) {
 	return true
 }
 
+// init_freefield computes the static tractions at "freefield" faces from total stresses σ = σe - p・I
+func (o ElemUP) init_freefield(sol *Solution) (ok bool) {
+	nip := len(o.U.IpsElem)
+	sig := make([][]float64, nip)
+	for idx, ip := range o.U.IpsElem {
+		if LogErr(o.P.Shp.CalcAtIp(o.P.X, ip, false), "init_freefield") {
+			return
+		}
+		pl := 0.0
+		for m := 0; m < o.P.Shp.Nverts; m++ {
+			pl += o.P.Shp.S[m] * sol.Y[o.P.Pmap[m]]
+		}
+		p := pl * o.P.States[idx].A_sl
+		sig[idx] = make([]float64, len(o.U.States[idx].Sig))
+		for k, σ := range o.U.States[idx].Sig {
+			sig[idx][k] = σ - p*tsr.Im[k]
+		}
+	}
+	return o.U.init_freefield(sig)
+}
+
 func (o ElemUP) debug_print_K() {
 	la.PrintMat("Kpp", o.P.Kpp, "%20.10f", false)
 	la.PrintMat("Kpf", o.P.Kpf, "%20.10f", false)
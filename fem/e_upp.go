@@ -0,0 +1,746 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/shp"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/tsr"
+)
+
+// ElemUPP represents an element for unsaturated porous media based on the u-pl-pg formulation
+//  Note: the balance equations are
+//    Cpl・dpl/dt + Cpg・dpg/dt + Cvs・div(vs) - div(ρl・wl) = 0
+//    Dpl・dpl/dt + Dpg・dpg/dt + Dvs・div(vs) - div(ρg・wg) = 0
+//    ρ・as - div(σe) + ∇p - ρ・g = 0, with p = sl・pl + sg・pg
+type ElemUPP struct {
+
+	// auxiliary
+	Fconds []*FaceCond // face conditions
+	CtypeU string      // u: cell type
+	CtypeP string      // p: cell type
+
+	// underlying elements
+	U *ElemU  // u-element
+	P *ElemPP // pp-element
+
+	// scratchpad. computed @ each ip
+	divus float64     // divus
+	bs    []float64   // bs = as - g = α1・u - ζs - g; with 'as' being the acceleration of solids and g, gravity
+	hl    []float64   // hl = -ρL・bs - ∇pl
+	hg    []float64   // hg = -ρG・bs - ∇pg
+	Kul   [][]float64 // [nu][np] Kul := dRus/dpl consistent tangent matrix
+	Kug   [][]float64 // [nu][np] Kug := dRus/dpg consistent tangent matrix
+	Klu   [][]float64 // [np][nu] Klu := dRpl/dus consistent tangent matrix
+	Kgu   [][]float64 // [np][nu] Kgu := dRpg/dus consistent tangent matrix
+}
+
+// initialisation ///////////////////////////////////////////////////////////////////////////////////
+
+// register element
+func init() {
+
+	// information allocator
+	infogetters["upp"] = func(cellType string, faceConds []*FaceCond) *Info {
+
+		// new info
+		var info Info
+
+		// p-element cell type
+		p_cellType := cellType
+		lbb := !Global.Sim.Data.NoLBB
+		if lbb {
+			p_cellType = shp.GetBasicType(cellType)
+		}
+
+		// underlying cells info
+		u_info := infogetters["u"](cellType, faceConds)
+		p_info := infogetters["pp"](p_cellType, faceConds)
+
+		// solution variables
+		nverts := shp.GetNverts(cellType)
+		info.Dofs = make([][]string, nverts)
+		for i, dofs := range u_info.Dofs {
+			info.Dofs[i] = append(info.Dofs[i], dofs...)
+		}
+		for i, dofs := range p_info.Dofs {
+			info.Dofs[i] = append(info.Dofs[i], dofs...)
+		}
+
+		// maps
+		info.Y2F = u_info.Y2F
+		for key, val := range p_info.Y2F {
+			info.Y2F[key] = val
+		}
+
+		// t1 and t2 variables
+		info.T1vars = p_info.T1vars
+		info.T2vars = u_info.T2vars
+		return &info
+	}
+
+	// element allocator
+	eallocators["upp"] = func(cellType string, faceConds []*FaceCond, cid int, edat *inp.ElemData, x [][]float64) Elem {
+
+		// basic data
+		var o ElemUPP
+		o.Fconds = faceConds
+
+		// p-element cell type
+		p_cellType := cellType
+		lbb := !Global.Sim.Data.NoLBB
+		if lbb {
+			p_cellType = shp.GetBasicType(cellType)
+		}
+
+		// cell types
+		o.CtypeU = cellType
+		o.CtypeP = p_cellType
+
+		// allocate u element
+		u_allocator := eallocators["u"]
+		u_elem := u_allocator(cellType, faceConds, cid, edat, x)
+		if LogErrCond(u_elem == nil, "cannot allocate underlying u-element") {
+			return nil
+		}
+		o.U = u_elem.(*ElemU)
+		if LogErrCond(o.U.MdlLarge != nil, "large deformation models are not available in upp elements") {
+			return nil
+		}
+		if LogErrCond(o.U.HasDpt, "absorbing and free-field boundaries are not available in upp elements") {
+			return nil
+		}
+		if LogErrCond(o.U.AlpM > 0 || o.U.BetK > 0 || o.U.Mass != "consistent", "Rayleigh damping and lumped mass matrices are not available in upp elements") {
+			return nil
+		}
+
+		// make sure pp-element uses the same nubmer of integration points than u-element
+		edat.Nip = len(o.U.IpsElem)
+
+		// allocate pp-element
+		p_allocator := eallocators["pp"]
+		p_elem := p_allocator(p_cellType, faceConds, cid, edat, x)
+		if LogErrCond(p_elem == nil, "cannot allocate underlying pp-element") {
+			return nil
+		}
+		o.P = p_elem.(*ElemPP)
+
+		// scratchpad. computed @ each ip
+		ndim := Global.Ndim
+		o.bs = make([]float64, ndim)
+		o.hl = make([]float64, ndim)
+		o.hg = make([]float64, ndim)
+		o.Kul = la.MatAlloc(o.U.Nu, o.P.Np)
+		o.Kug = la.MatAlloc(o.U.Nu, o.P.Np)
+		o.Klu = la.MatAlloc(o.P.Np, o.U.Nu)
+		o.Kgu = la.MatAlloc(o.P.Np, o.U.Nu)
+
+		// return new element
+		return &o
+	}
+}
+
+// implementation ///////////////////////////////////////////////////////////////////////////////////
+
+// Id returns the cell Id
+func (o ElemUPP) Id() int { return o.U.Id() }
+
+// SetEqs set equations
+func (o *ElemUPP) SetEqs(eqs [][]int, mixedform_eqs []int) (ok bool) {
+
+	// u: equations
+	u_getter := infogetters["u"]
+	u_info := u_getter(o.CtypeU, o.Fconds)
+	u_nverts := len(u_info.Dofs)
+	u_eqs := make([][]int, u_nverts)
+	for i := 0; i < u_nverts; i++ {
+		nkeys := len(u_info.Dofs[i])
+		u_eqs[i] = make([]int, nkeys)
+		for j := 0; j < nkeys; j++ {
+			u_eqs[i][j] = eqs[i][j]
+		}
+	}
+
+	// p: equations
+	p_getter := infogetters["pp"]
+	p_info := p_getter(o.CtypeP, o.Fconds)
+	p_nverts := len(p_info.Dofs)
+	p_eqs := make([][]int, p_nverts)
+	for i := 0; i < p_nverts; i++ {
+		start := len(u_info.Dofs[i])
+		nkeys := len(p_info.Dofs[i])
+		p_eqs[i] = make([]int, nkeys)
+		for j := 0; j < nkeys; j++ {
+			p_eqs[i][j] = eqs[i][start+j]
+		}
+	}
+
+	// set equations
+	if !o.U.SetEqs(u_eqs, mixedform_eqs) {
+		return
+	}
+	return o.P.SetEqs(p_eqs, nil)
+}
+
+// SetEleConds set element conditions
+func (o *ElemUPP) SetEleConds(key string, f fun.Func, extra string) (ok bool) {
+	if !o.U.SetEleConds(key, f, extra) {
+		return
+	}
+	return o.P.SetEleConds(key, f, extra)
+}
+
+// InterpStarVars interpolates star variables to integration points
+func (o *ElemUPP) InterpStarVars(sol *Solution) (ok bool) {
+
+	// for each integration point
+	ndim := Global.Ndim
+	u_nverts := o.U.Shp.Nverts
+	p_nverts := o.P.Shp.Nverts
+	var r int
+	for idx, ip := range o.U.IpsElem {
+
+		// interpolation functions and gradients
+		if LogErr(o.P.Shp.CalcAtIp(o.P.X, ip, true), "InterpStarVars") {
+			return
+		}
+		if LogErr(o.U.Shp.CalcAtIp(o.U.X, ip, true), "InterpStarVars") {
+			return
+		}
+		S := o.U.Shp.S
+		G := o.U.Shp.G
+		Sb := o.P.Shp.S
+
+		// clear local variables
+		o.P.ψl[idx], o.P.ψg[idx], o.U.divχs[idx] = 0, 0, 0
+		for i := 0; i < ndim; i++ {
+			o.U.ζs[idx][i], o.U.χs[idx][i] = 0, 0
+		}
+
+		// p-variables
+		for m := 0; m < p_nverts; m++ {
+			o.P.ψl[idx] += Sb[m] * sol.Psi[o.P.PLmap[m]]
+			o.P.ψg[idx] += Sb[m] * sol.Psi[o.P.PGmap[m]]
+		}
+
+		// u-variables
+		for m := 0; m < u_nverts; m++ {
+			for i := 0; i < ndim; i++ {
+				r = o.U.Umap[i+m*ndim]
+				o.U.ζs[idx][i] += S[m] * sol.Zet[r]
+				o.U.χs[idx][i] += S[m] * sol.Chi[r]
+				o.U.divχs[idx] += G[m][i] * sol.Chi[r]
+			}
+		}
+	}
+	return true
+}
+
+// adds -R to global residual vector fb
+func (o ElemUPP) AddToRhs(fb []float64, sol *Solution) (ok bool) {
+
+	// clear variables
+	if o.U.UseB {
+		la.VecFill(o.U.fi, 0)
+	}
+
+	// for each integration point
+	dc := Global.DynCoefs
+	ndim := Global.Ndim
+	u_nverts := o.U.Shp.Nverts
+	p_nverts := o.P.Shp.Nverts
+	var coef, plt, pgt, klr, kgr, ρ, p, divvs float64
+	var r int
+	for idx, ip := range o.U.IpsElem {
+
+		// interpolation functions, gradients and variables @ ip
+		if !o.ipvars(idx, sol) {
+			return
+		}
+		coef = o.U.Shp.J * ip.W
+		S := o.U.Shp.S
+		G := o.U.Shp.G
+		Sb := o.P.Shp.S
+		Gb := o.P.Shp.G
+
+		// axisymmetric case
+		radius := 1.0
+		if Global.Sim.Data.Axisym {
+			radius = o.U.Shp.AxisymGetRadius(o.U.X)
+			coef *= radius
+		}
+
+		// auxiliary
+		σe := o.U.States[idx].Sig
+		divvs = dc.α4*o.divus - o.U.divχs[idx]
+
+		// tpm variables
+		plt = dc.β1*o.P.pl - o.P.ψl[idx]
+		pgt = dc.β1*o.P.pg - o.P.ψg[idx]
+		klr = o.P.Mdl.Cnd.Klr(o.P.States[idx].A_sl)
+		kgr = o.P.Mdl.Cnd.Kgr(1.0 - o.P.States[idx].A_sl)
+		if LogErr(o.P.Mdl.CalcLgs(o.P.res, o.P.States[idx], o.P.pl, o.P.pg, o.divus, false), "AddToRhs") {
+			return
+		}
+		ρ = o.P.res.A_ρ
+		p = o.P.res.A_p
+
+		// compute ρwl and ρwg
+		for i := 0; i < ndim; i++ {
+			o.P.ρwl[i], o.P.ρwg[i] = 0, 0
+			for j := 0; j < ndim; j++ {
+				o.P.ρwl[i] += klr * o.P.Mdl.Klsat[i][j] * o.hl[j]
+				o.P.ρwg[i] += kgr * o.P.Mdl.Kgsat[i][j] * o.hg[j]
+			}
+		}
+
+		// p: add negative of residual term to fb
+		for m := 0; m < p_nverts; m++ {
+			rl := o.P.PLmap[m]
+			rg := o.P.PGmap[m]
+			fb[rl] -= coef * Sb[m] * (o.P.res.Cpl*plt + o.P.res.Cpg*pgt + o.P.res.Cvs*divvs)
+			fb[rg] -= coef * Sb[m] * (o.P.res.Dpl*plt + o.P.res.Dpg*pgt + o.P.res.Dvs*divvs)
+			for i := 0; i < ndim; i++ {
+				fb[rl] += coef * Gb[m][i] * o.P.ρwl[i] // += coef * div(ρl*wl)
+				fb[rg] += coef * Gb[m][i] * o.P.ρwg[i] // += coef * div(ρg*wg)
+			}
+		}
+
+		// u: add negative of residual term to fb
+		if o.U.UseB {
+			IpBmatrix(o.U.B, ndim, u_nverts, G, radius, S)
+			la.MatTrVecMulAdd(o.U.fi, coef, o.U.B, σe) // fi += coef * tr(B) * σ
+			for m := 0; m < u_nverts; m++ {
+				for i := 0; i < ndim; i++ {
+					r = o.U.Umap[i+m*ndim]
+					fb[r] -= coef * S[m] * ρ * o.bs[i]
+					fb[r] += coef * p * G[m][i]
+				}
+			}
+		} else {
+			for m := 0; m < u_nverts; m++ {
+				for i := 0; i < ndim; i++ {
+					r = o.U.Umap[i+m*ndim]
+					fb[r] -= coef * S[m] * ρ * o.bs[i]
+					for j := 0; j < ndim; j++ {
+						fb[r] -= coef * tsr.M2T(σe, i, j) * G[m][j]
+					}
+					fb[r] += coef * p * G[m][i]
+				}
+			}
+		}
+	}
+
+	// add fi term to fb, if using B matrix
+	if o.U.UseB {
+		for i, I := range o.U.Umap {
+			fb[I] -= o.U.fi[i]
+		}
+	}
+
+	// external forces
+	if len(o.U.NatBcs) > 0 {
+		if !o.U.add_surfloads_to_rhs(fb, sol) {
+			return
+		}
+	}
+
+	// contribution from natural boundary conditions
+	if len(o.P.NatBcs) > 0 {
+		return o.P.add_natbcs_to_rhs(fb, sol)
+	}
+	return true
+}
+
+// adds element K to global Jacobian matrix Kb
+func (o ElemUPP) AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) {
+
+	// clear matrices
+	ndim := Global.Ndim
+	u_nverts := o.U.Shp.Nverts
+	p_nverts := o.P.Shp.Nverts
+	la.MatFill(o.P.Kll, 0)
+	la.MatFill(o.P.Klg, 0)
+	la.MatFill(o.P.Kgl, 0)
+	la.MatFill(o.P.Kgg, 0)
+	for i := 0; i < o.U.Nu; i++ {
+		for j := 0; j < o.P.Np; j++ {
+			o.Kul[i][j], o.Kug[i][j] = 0, 0
+			o.Klu[j][i], o.Kgu[j][i] = 0, 0
+		}
+		for j := 0; j < o.U.Nu; j++ {
+			o.U.K[i][j] = 0
+		}
+	}
+
+	// for each integration point
+	dc := Global.DynCoefs
+	Cl := o.P.Mdl.Cl
+	Cg := o.P.Mdl.Cg
+	var coef, plt, pgt, klr, kgr, ρL, ρG, ρ, divvs float64
+	var r, c int
+	for idx, ip := range o.U.IpsElem {
+
+		// interpolation functions, gradients and variables @ ip
+		if !o.ipvars(idx, sol) {
+			return
+		}
+		coef = o.U.Shp.J * ip.W
+		S := o.U.Shp.S
+		G := o.U.Shp.G
+		Sb := o.P.Shp.S
+		Gb := o.P.Shp.G
+
+		// axisymmetric case
+		radius := 1.0
+		if Global.Sim.Data.Axisym {
+			radius = o.U.Shp.AxisymGetRadius(o.U.X)
+			coef *= radius
+		}
+
+		// auxiliary
+		divvs = dc.α4*o.divus - o.U.divχs[idx]
+
+		// tpm variables
+		plt = dc.β1*o.P.pl - o.P.ψl[idx]
+		pgt = dc.β1*o.P.pg - o.P.ψg[idx]
+		klr = o.P.Mdl.Cnd.Klr(o.P.States[idx].A_sl)
+		kgr = o.P.Mdl.Cnd.Kgr(1.0 - o.P.States[idx].A_sl)
+		ρL = o.P.States[idx].A_ρL
+		ρG = o.P.States[idx].A_ρG
+		if LogErr(o.P.Mdl.CalcLgs(o.P.res, o.P.States[idx], o.P.pl, o.P.pg, o.divus, true), "AddToKb") {
+			return
+		}
+		ρ = o.P.res.A_ρ
+		res := o.P.res
+
+		// Klu, Kgu, Kul, Kug, Kll, Klg, Kgl and Kgg
+		for n := 0; n < p_nverts; n++ {
+			for j := 0; j < ndim; j++ {
+
+				// Klu := ∂Rl^n/∂us^m, Kgu := ∂Rg^n/∂us^m, Kul := ∂Rus^m/∂pl^n and Kug := ∂Rus^m/∂pg^n
+				for m := 0; m < u_nverts; m++ {
+					c = j + m*ndim
+					o.Klu[n][c] += coef * Sb[n] * (res.DCpldusM*plt + res.DCpgdusM*pgt + dc.α4*res.Cvs) * G[m][j]
+					o.Kgu[n][c] += coef * Sb[n] * (res.DDpldusM*plt + res.DDpgdusM*pgt + dc.α4*res.Dvs) * G[m][j]
+					for i := 0; i < ndim; i++ {
+						o.Klu[n][c] += coef * Gb[n][i] * S[m] * dc.α1 * ρL * klr * o.P.Mdl.Klsat[i][j]
+						o.Kgu[n][c] += coef * Gb[n][i] * S[m] * dc.α1 * ρG * kgr * o.P.Mdl.Kgsat[i][j]
+					}
+					o.Kul[c][n] += coef * (S[m]*Sb[n]*res.Dρdpl*o.bs[j] - G[m][j]*Sb[n]*res.Dpdpl)
+					o.Kug[c][n] += coef * (S[m]*Sb[n]*res.Dρdpg*o.bs[j] - G[m][j]*Sb[n]*res.Dpdpg)
+				}
+			}
+
+			// Kll := ∂Rl^m/∂pl^n, Klg := ∂Rl^m/∂pg^n, Kgl := ∂Rg^m/∂pl^n and Kgg := ∂Rg^m/∂pg^n
+			for m := 0; m < p_nverts; m++ {
+				o.P.Kll[m][n] += coef * Sb[m] * Sb[n] * (res.DCpldpl*plt + res.DCpgdpl*pgt + res.DCvsdpl*divvs + dc.β1*res.Cpl)
+				o.P.Klg[m][n] += coef * Sb[m] * Sb[n] * (res.DCpldpg*plt + res.DCpgdpg*pgt + res.DCvsdpg*divvs + dc.β1*res.Cpg)
+				o.P.Kgl[m][n] += coef * Sb[m] * Sb[n] * (res.DDpldpl*plt + res.DDpgdpl*pgt + res.DDvsdpl*divvs + dc.β1*res.Dpl)
+				o.P.Kgg[m][n] += coef * Sb[m] * Sb[n] * (res.DDpldpg*plt + res.DDpgdpg*pgt + res.DDvsdpg*divvs + dc.β1*res.Dpg)
+				for i := 0; i < ndim; i++ {
+					for j := 0; j < ndim; j++ {
+						o.P.Kll[m][n] -= coef * Gb[m][i] * o.P.Mdl.Klsat[i][j] * (Sb[n]*res.Dklrdpl*o.hl[j] - klr*(Sb[n]*Cl*o.bs[j]+Gb[n][j]))
+						o.P.Klg[m][n] -= coef * Gb[m][i] * o.P.Mdl.Klsat[i][j] * Sb[n] * res.Dklrdpg * o.hl[j]
+						o.P.Kgl[m][n] -= coef * Gb[m][i] * o.P.Mdl.Kgsat[i][j] * Sb[n] * res.Dkgrdpl * o.hg[j]
+						o.P.Kgg[m][n] -= coef * Gb[m][i] * o.P.Mdl.Kgsat[i][j] * (Sb[n]*res.Dkgrdpg*o.hg[j] - kgr*(Sb[n]*Cg*o.bs[j]+Gb[n][j]))
+					}
+				}
+			}
+		}
+
+		// Kuu: add ∂rub^m/∂us^n
+		for m := 0; m < u_nverts; m++ {
+			for i := 0; i < ndim; i++ {
+				r = i + m*ndim
+				for n := 0; n < u_nverts; n++ {
+					for j := 0; j < ndim; j++ {
+						c = j + n*ndim
+						o.U.K[r][c] += coef * S[m] * (S[n]*dc.α1*ρ*tsr.It[i][j] + res.DρdusM*o.bs[i]*G[n][j])
+					}
+				}
+			}
+		}
+
+		// consistent tangent model matrix
+		if LogErr(o.U.MdlSmall.CalcD(o.U.D, o.U.States[idx], firstIt), "AddToKb") {
+			return
+		}
+
+		// Kuu: add stiffness term ∂(σe・G^m)/∂us^n
+		if o.U.UseB {
+			IpBmatrix(o.U.B, ndim, u_nverts, G, radius, S)
+			la.MatTrMulAdd3(o.U.K, coef, o.U.B, o.U.D, o.U.B) // K += coef * tr(B) * D * B
+		} else {
+			IpAddToKt(o.U.K, u_nverts, ndim, coef, G, o.U.D)
+		}
+	}
+
+	// add K to sparse matrix Kb
+	//    _             _
+	//   |  Kuu Kul Kug  |
+	//   |  Klu Kll Klg  |
+	//   |_ Kgu Kgl Kgg _|
+	//
+	for i, I := range o.P.PLmap {
+		for j, J := range o.P.PLmap {
+			Kb.Put(I, J, o.P.Kll[i][j])
+		}
+		for j, J := range o.P.PGmap {
+			Kb.Put(I, J, o.P.Klg[i][j])
+		}
+		for j, J := range o.U.Umap {
+			Kb.Put(I, J, o.Klu[i][j])
+			Kb.Put(J, I, o.Kul[j][i])
+		}
+	}
+	for i, I := range o.P.PGmap {
+		for j, J := range o.P.PLmap {
+			Kb.Put(I, J, o.P.Kgl[i][j])
+		}
+		for j, J := range o.P.PGmap {
+			Kb.Put(I, J, o.P.Kgg[i][j])
+		}
+		for j, J := range o.U.Umap {
+			Kb.Put(I, J, o.Kgu[i][j])
+			Kb.Put(J, I, o.Kug[j][i])
+		}
+	}
+	for i, I := range o.U.Umap {
+		for j, J := range o.U.Umap {
+			Kb.Put(I, J, o.U.K[i][j])
+		}
+	}
+	return true
+}
+
+// Update perform (tangent) update
+func (o *ElemUPP) Update(sol *Solution) (ok bool) {
+	if !o.U.Update(sol) {
+		return
+	}
+	return o.P.Update(sol)
+}
+
+// internal variables ///////////////////////////////////////////////////////////////////////////////
+
+// Ipoints returns the real coordinates of integration points [nip][ndim]
+func (o ElemUPP) Ipoints() (coords [][]float64) {
+	coords = la.MatAlloc(len(o.U.IpsElem), Global.Ndim)
+	for idx, ip := range o.U.IpsElem {
+		coords[idx] = o.U.Shp.IpRealCoords(o.U.X, ip)
+	}
+	return
+}
+
+// SetIniIvs sets initial ivs for given values in sol and ivs map
+func (o *ElemUPP) SetIniIvs(sol *Solution, ivs map[string][]float64) (ok bool) {
+
+	// set pp-element first
+	if !o.P.SetIniIvs(sol, nil) {
+		return
+	}
+
+	// initial stresses given
+	if _, okk := ivs["svT"]; okk {
+
+		// total vertical stresses and K0
+		nip := len(o.U.IpsElem)
+		svT := ivs["svT"]
+		K0s := ivs["K0"]
+		chk.IntAssert(len(svT), nip)
+		chk.IntAssert(len(K0s), 1)
+		K0 := K0s[0]
+
+		// for each integration point
+		sx := make([]float64, nip)
+		sy := make([]float64, nip)
+		sz := make([]float64, nip)
+		for i, ip := range o.U.IpsElem {
+
+			// compute pl and pg @ ip
+			if LogErr(o.P.Shp.CalcAtIp(o.P.X, ip, false), "SetIniIvs") {
+				return
+			}
+			pl, pg := 0.0, 0.0
+			for m := 0; m < o.P.Shp.Nverts; m++ {
+				pl += o.P.Shp.S[m] * sol.Y[o.P.PLmap[m]]
+				pg += o.P.Shp.S[m] * sol.Y[o.P.PGmap[m]]
+			}
+
+			// compute effective stresses
+			sl := o.P.States[i].A_sl
+			p := sl*pl + (1.0-sl)*pg
+			svE := svT[i] + p
+			shE := K0 * svE
+			sx[i], sy[i], sz[i] = shE, svE, shE
+			if Global.Ndim == 3 {
+				sx[i], sy[i], sz[i] = shE, shE, svE
+			}
+		}
+		ivs = map[string][]float64{"sx": sx, "sy": sy, "sz": sz}
+	}
+
+	// set u-element
+	return o.U.SetIniIvs(sol, ivs)
+}
+
+// BackupIvs create copy of internal variables
+func (o *ElemUPP) BackupIvs(aux bool) (ok bool) {
+	if !o.U.BackupIvs(aux) {
+		return
+	}
+	return o.P.BackupIvs(aux)
+}
+
+// RestoreIvs restore internal variables from copies
+func (o *ElemUPP) RestoreIvs(aux bool) (ok bool) {
+	if !o.U.RestoreIvs(aux) {
+		return
+	}
+	return o.P.RestoreIvs(aux)
+}
+
+// Ureset fixes internal variables after u (displacements) have been zeroed
+func (o *ElemUPP) Ureset(sol *Solution) (ok bool) {
+	ndim := Global.Ndim
+	u_nverts := o.U.Shp.Nverts
+	for idx, ip := range o.U.IpsElem {
+		if LogErr(o.U.Shp.CalcAtIp(o.U.X, ip, true), "Update") {
+			return
+		}
+		G := o.U.Shp.G
+		var divus float64
+		for m := 0; m < u_nverts; m++ {
+			for i := 0; i < ndim; i++ {
+				r := o.U.Umap[i+m*ndim]
+				divus += G[m][i] * sol.Y[r]
+			}
+		}
+		o.P.States[idx].A_ns0 = (1.0 - divus) * (1.0 - o.P.Mdl.Nf0)
+		o.P.StatesBkp[idx].A_ns0 = o.P.States[idx].A_ns0
+	}
+	if !o.U.Ureset(sol) {
+		return
+	}
+	return o.P.Ureset(sol)
+}
+
+// writer ///////////////////////////////////////////////////////////////////////////////////////////
+
+// Encode encodes internal variables
+func (o ElemUPP) Encode(enc Encoder) (ok bool) {
+	if !o.U.Encode(enc) {
+		return
+	}
+	return o.P.Encode(enc)
+}
+
+// Decode decodes internal variables
+func (o ElemUPP) Decode(dec Decoder) (ok bool) {
+	if !o.U.Decode(dec) {
+		return
+	}
+	return o.P.Decode(dec)
+}
+
+// OutIpsData returns data from all integration points for output
+func (o ElemUPP) OutIpsData() (data []*OutIpData) {
+	ndim := Global.Ndim
+	flowl := FlowKeys()
+	flowg := GasFlowKeys()
+	sigs := StressKeys()
+	for idx, ip := range o.U.IpsElem {
+		r := o.P.States[idx]
+		s := o.U.States[idx]
+		x := o.U.Shp.IpRealCoords(o.U.X, ip)
+		calc := func(sol *Solution) (vals map[string]float64) {
+			if !o.ipvars(idx, sol) {
+				return
+			}
+			ns := (1.0 - o.divus) * r.A_ns0
+			klr := o.P.Mdl.Cnd.Klr(r.A_sl)
+			kgr := o.P.Mdl.Cnd.Kgr(1.0 - r.A_sl)
+			vals = map[string]float64{
+				"sl": r.A_sl,
+				"sg": 1.0 - r.A_sl,
+				"pl": o.P.pl,
+				"pg": o.P.pg,
+				"pc": o.P.pg - o.P.pl,
+				"nf": 1.0 - ns,
+			}
+			for i := 0; i < ndim; i++ {
+				for j := 0; j < ndim; j++ {
+					vals[flowl[i]] += klr * o.P.Mdl.Klsat[i][j] * o.hl[j] / r.A_ρL
+					vals[flowg[i]] += kgr * o.P.Mdl.Kgsat[i][j] * o.hg[j] / r.A_ρG
+				}
+			}
+			for i, _ := range sigs {
+				vals[sigs[i]] = s.Sig[i]
+			}
+			return
+		}
+		data = append(data, &OutIpData{o.Id(), x, calc})
+	}
+	return
+}
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// ipvars computes current values @ integration points. idx == index of integration point
+func (o *ElemUPP) ipvars(idx int, sol *Solution) (ok bool) {
+
+	// interpolation functions and gradients
+	if LogErr(o.P.Shp.CalcAtIp(o.P.X, o.U.IpsElem[idx], true), "ipvars") {
+		return
+	}
+	if LogErr(o.U.Shp.CalcAtIp(o.U.X, o.U.IpsElem[idx], true), "ipvars") {
+		return
+	}
+
+	// auxiliary
+	ndim := Global.Ndim
+	dc := Global.DynCoefs
+	ρL := o.P.States[idx].A_ρL
+	ρG := o.P.States[idx].A_ρG
+	o.P.compute_gvec(sol.T)
+
+	// clear gpl, gpg and recover u-variables @ ip
+	o.divus = 0
+	for i := 0; i < ndim; i++ {
+		o.P.gpl[i], o.P.gpg[i] = 0, 0 // clear gpl and gpg here
+		o.U.us[i] = 0
+		for m := 0; m < o.U.Shp.Nverts; m++ {
+			r := o.U.Umap[i+m*ndim]
+			o.U.us[i] += o.U.Shp.S[m] * sol.Y[r]
+			o.divus += o.U.Shp.G[m][i] * sol.Y[r]
+		}
+	}
+
+	// recover p-variables @ ip
+	o.P.pl, o.P.pg = 0, 0
+	for m := 0; m < o.P.Shp.Nverts; m++ {
+		rl := o.P.PLmap[m]
+		rg := o.P.PGmap[m]
+		o.P.pl += o.P.Shp.S[m] * sol.Y[rl]
+		o.P.pg += o.P.Shp.S[m] * sol.Y[rg]
+		for i := 0; i < ndim; i++ {
+			o.P.gpl[i] += o.P.Shp.G[m][i] * sol.Y[rl]
+			o.P.gpg[i] += o.P.Shp.G[m][i] * sol.Y[rg]
+		}
+	}
+
+	// compute bs, hl and hg
+	for i := 0; i < ndim; i++ {
+		o.bs[i] = dc.α1*o.U.us[i] - o.U.ζs[idx][i] - o.P.g[i]
+		o.hl[i] = -ρL*o.bs[i] - o.P.gpl[i]
+		o.hg[i] = -ρG*o.bs[i] - o.P.gpg[i]
+	}
+	return true
+}
@@ -18,6 +18,11 @@ type OutIpData struct {
 	Calc func(sol *Solution) map[string]float64 // [nkeys] function to calculate secondary values
 }
 
+// Assembler defines global matrices receiving the contributions of elements; e.g. *la.Triplet
+type Assembler interface {
+	Put(i, j int, x float64) // adds x to the (i,j) component
+}
+
 // Elem defines what elements must calculate
 type Elem interface {
 
@@ -32,9 +37,9 @@ type Elem interface {
 	InterpStarVars(sol *Solution) (ok bool) // interpolate star variables to integration points
 
 	// called for each iteration
-	AddToRhs(fb []float64, sol *Solution) (ok bool)                // adds -R to global residual vector fb
-	AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (ok bool) // adds element K to global Jacobian matrix Kb
-	Update(sol *Solution) (ok bool)                                // perform (tangent) update
+	AddToRhs(fb []float64, sol *Solution) (ok bool)              // adds -R to global residual vector fb
+	AddToKb(Kb Assembler, sol *Solution, firstIt bool) (ok bool) // adds element K to global Jacobian matrix Kb
+	Update(sol *Solution) (ok bool)                              // perform (tangent) update
 
 	// reading and writing of element data
 	Encode(enc Encoder) (ok bool) // encodes internal variables
@@ -50,6 +55,11 @@ type ElemConnector interface {
 	Connect(cid2elem []Elem, c *inp.Cell) (nnzK int, ok bool) // connect multiple elements; e.g.: connect rod/solid elements in Rjoints
 }
 
+// ElemConcurrent defines elements that can be computed concurrently by many workers (goroutines)
+type ElemConcurrent interface {
+	SetWorker(w *Worker) (ok bool) // replaces structures shared among elements by private copies of worker
+}
+
 // ElemIntvars defines elements with {z,q} internal variables
 type ElemIntvars interface {
 	Ipoints() (coords [][]float64)                               // returns the real coordinates of integration points [nip][ndim]
@@ -0,0 +1,296 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/mpi"
+)
+
+// eliminator implements the direct elimination of essential bcs / constraints
+//  Each constraint k is solved for one 'slave' equation s_k; thus:
+//
+//      y = T * yr + g(t)
+//
+//  where yr holds the free (not eliminated) equations and g(t) = G * c(t). The reduced system is
+//
+//      (Tt * K * T) * δyr = Tt * (fb - K * δg)
+//
+//  where δg corrects the slave equations such that A * y = c (e.g. when c changes with time).
+//  The multipliers λ (reactions) are recovered such that fb - At * λ is zero at slave equations.
+type eliminator struct {
+	Kr     Assembler  // reduced Jacobian (set by EssentialBcs.Assembler)
+	Free   []int      // [nr] free equations
+	T      [][]tcoef  // [ny] rows of T: reduced equation and coefficient
+	G      [][]tcoef  // [ny] rows of G: constraint index and coefficient
+	Slave  []int      // [nλ] slave equation of each constraint
+	Aslave []float64  // [nλ] coefficient of slave equation in each constraint
+	Deps   [][]tcoef  // [nλ] other constraints (index and coefficient) containing the slave equation
+	Fcns   []fun.Func // [nλ] functions c(t)
+
+	// workspace
+	δg    []float64 // [ny] corrections of slave equations
+	kg    []float64 // [ny] K * δg
+	hasδg bool      // δg is non-zero
+	fr    []float64 // [nr] reduced right-hand side
+	wr    []float64 // [nr] reduced solution
+	λdone []bool    // [nλ] multiplier has been computed
+	c     []float64 // [nλ] values of c(t)
+}
+
+// tcoef holds an index and a coefficient
+type tcoef struct {
+	k int     // index
+	c float64 // coefficient
+}
+
+// tcoefs sorts tcoef by index
+type tcoefs []tcoef
+
+func (o tcoefs) Len() int           { return len(o) }
+func (o tcoefs) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o tcoefs) Less(i, j int) bool { return o[i].k < o[j].k }
+
+// Init initialises eliminator
+func (o *eliminator) Init(bcs []*EssentialBc, ny int) (ok bool) {
+
+	// select slave equations: largest coefficient not yet taken
+	nλ := len(bcs)
+	o.Slave = make([]int, nλ)
+	o.Aslave = make([]float64, nλ)
+	o.Fcns = make([]fun.Func, nλ)
+	slaveof := make([]int, ny) // constraint index + 1; 0 means free
+	for k, c := range bcs {
+		best := -1
+		for j, eq := range c.Eqs {
+			if slaveof[eq] > 0 || c.ValsA[j] == 0 {
+				continue
+			}
+			if best < 0 || math.Abs(c.ValsA[j]) > math.Abs(c.ValsA[best]) {
+				best = j
+			}
+		}
+		if LogErrCond(best < 0, "elimination: constraint %q with equations %v is redundant or conflicts with other constraints", c.Key, c.Eqs) {
+			return
+		}
+		o.Slave[k] = c.Eqs[best]
+		o.Aslave[k] = c.ValsA[best]
+		o.Fcns[k] = c.Fcn
+		slaveof[c.Eqs[best]] = k + 1
+	}
+
+	// free equations
+	ridx := make([]int, ny)
+	for eq := 0; eq < ny; eq++ {
+		if slaveof[eq] == 0 {
+			ridx[eq] = len(o.Free)
+			o.Free = append(o.Free, eq)
+		}
+	}
+
+	// rows of T and G
+	o.T = make([][]tcoef, ny)
+	o.G = make([][]tcoef, ny)
+	state := make([]int, ny) // 0:new, 1:visiting, 2:done
+	var expand func(eq int) bool
+	expand = func(eq int) bool {
+		switch state[eq] {
+		case 1:
+			return false // cycle
+		case 2:
+			return true
+		}
+		k := slaveof[eq] - 1
+		if k < 0 {
+			o.T[eq] = []tcoef{{ridx[eq], 1}}
+			state[eq] = 2
+			return true
+		}
+		state[eq] = 1
+		c := bcs[k]
+		t := make(map[int]float64)
+		g := map[int]float64{k: 1.0 / o.Aslave[k]}
+		for j, e := range c.Eqs {
+			if e == eq {
+				continue
+			}
+			if !expand(e) {
+				return false
+			}
+			m := -c.ValsA[j] / o.Aslave[k]
+			for _, a := range o.T[e] {
+				t[a.k] += m * a.c
+			}
+			for _, a := range o.G[e] {
+				g[a.k] += m * a.c
+			}
+		}
+		o.T[eq] = map2tcoefs(t)
+		o.G[eq] = map2tcoefs(g)
+		state[eq] = 2
+		return true
+	}
+	for _, eq := range o.Slave {
+		if LogErrCond(!expand(eq), "elimination: constraints involving equation %d are cyclic", eq) {
+			return
+		}
+	}
+
+	// constraints containing slave equations
+	eq2cons := make(map[int][]tcoef)
+	for m, c := range bcs {
+		for j, eq := range c.Eqs {
+			eq2cons[eq] = append(eq2cons[eq], tcoef{m, c.ValsA[j]})
+		}
+	}
+	o.Deps = make([][]tcoef, nλ)
+	for k, s := range o.Slave {
+		for _, a := range eq2cons[s] {
+			if a.k != k {
+				o.Deps[k] = append(o.Deps[k], a)
+			}
+		}
+	}
+
+	// workspace
+	o.δg = make([]float64, ny)
+	o.kg = make([]float64, ny)
+	o.fr = make([]float64, len(o.Free))
+	o.wr = make([]float64, len(o.Free))
+	o.λdone = make([]bool, nλ)
+	o.c = make([]float64, nλ)
+	return true
+}
+
+// MaxRow returns the maximum number of non-zeros in rows of T
+func (o *eliminator) MaxRow() (m int) {
+	for _, row := range o.T {
+		if len(row) > m {
+			m = len(row)
+		}
+	}
+	return
+}
+
+// Put adds the (i,j) component of K to the reduced system; i.e. Kr += Tt * K * T
+func (o *eliminator) Put(i, j int, x float64) {
+	if o.hasδg {
+		o.kg[i] += x * o.δg[j]
+	}
+	for _, a := range o.T[i] {
+		for _, b := range o.T[j] {
+			o.Kr.Put(a.k, b.k, a.c*b.c*x)
+		}
+	}
+}
+
+// Rhs computes the corrections δg of slave equations and the multipliers λ (stored in sol.L)
+//  Note: fb must not yet contain the -At*λ term
+func (o *eliminator) Rhs(fb []float64, sol *Solution) {
+
+	// corrections
+	for k, f := range o.Fcns {
+		o.c[k] = f.F(sol.T, nil)
+	}
+	o.hasδg = false
+	for _, s := range o.Slave {
+		y := 0.0
+		for _, a := range o.T[s] {
+			y += a.c * sol.Y[o.Free[a.k]]
+		}
+		for _, a := range o.G[s] {
+			y += a.c * o.c[a.k]
+		}
+		o.δg[s] = y - sol.Y[s]
+		if o.δg[s] != 0 {
+			o.hasδg = true
+		}
+	}
+	if o.hasδg {
+		la.VecFill(o.kg, 0)
+	}
+
+	// multipliers
+	for k := range o.λdone {
+		o.λdone[k] = false
+	}
+	for k := range o.Slave {
+		o.multiplier(k, fb, sol.L)
+	}
+}
+
+// SolveR solves the reduced system and computes wb = T * wr + δg
+func (o *eliminator) SolveR(lis la.LinSol, wb, fb []float64) (err error) {
+
+	// join K * δg from all processors
+	if o.hasδg && Global.Distr {
+		mpi.AllReduceSum(o.kg, wb) // wb is used as workspace
+	}
+
+	// fr := Tt * (fb - K * δg)
+	la.VecFill(o.fr, 0)
+	for i, row := range o.T {
+		v := fb[i]
+		if o.hasδg {
+			v -= o.kg[i]
+		}
+		for _, a := range row {
+			o.fr[a.k] += a.c * v
+		}
+	}
+
+	// solve
+	err = lis.SolveR(o.wr, o.fr, false)
+	if err != nil {
+		return
+	}
+
+	// wb := T * wr + δg
+	for i, row := range o.T {
+		wb[i] = o.δg[i]
+		for _, a := range row {
+			wb[i] += a.c * o.wr[a.k]
+		}
+	}
+	la.VecFill(o.δg, 0)
+	o.hasδg = false
+	return
+}
+
+// discard discards the corrections δg of slave equations computed by Rhs; thus the next solution
+// corresponds to fb only
+func (o *eliminator) discard() {
+	la.VecFill(o.δg, 0)
+	o.hasδg = false
+}
+
+// multiplier computes λ_k from the slave equation of constraint k
+func (o *eliminator) multiplier(k int, fb, L []float64) float64 {
+	if o.λdone[k] {
+		return L[k]
+	}
+	o.λdone[k] = true // avoid infinite recursion; cycles are rejected by Init
+	r := fb[o.Slave[k]]
+	for _, a := range o.Deps[k] {
+		r -= a.c * o.multiplier(a.k, fb, L)
+	}
+	L[k] = r / o.Aslave[k]
+	return L[k]
+}
+
+// map2tcoefs converts map to sorted slice of tcoef, skipping zero coefficients
+func map2tcoefs(m map[int]float64) (res []tcoef) {
+	for k, c := range m {
+		if c != 0 {
+			res = append(res, tcoef{k, c})
+		}
+	}
+	sort.Sort(tcoefs(res))
+	return
+}
@@ -6,17 +6,21 @@ package fem
 
 import (
 	"log"
+	"sync"
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/mpi"
 )
 
+// stopMutex protects the stop flags because errors may be logged by concurrent workers
+var stopMutex sync.Mutex
+
 func LogErr(err error, msg string) (stop bool) {
 	if err != nil {
 		fullmsg := "ERROR: " + msg + " : " + err.Error()
 		log.Printf(fullmsg)
-		Global.WspcStop[Global.Rank] = 1
+		set_stop()
 		return true
 	}
 	return
@@ -26,7 +30,7 @@ func LogErrCond(condition bool, msg string, prm ...interface{}) (stop bool) {
 	if condition {
 		fullmsg := "ERROR: " + io.Sf(msg, prm...)
 		log.Printf(fullmsg)
-		Global.WspcStop[Global.Rank] = 1
+		set_stop()
 		return true
 	}
 	return
@@ -34,7 +38,10 @@ func LogErrCond(condition bool, msg string, prm ...interface{}) (stop bool) {
 
 func Stop() bool {
 	if !Global.Distr {
-		if Global.WspcStop[Global.Rank] > 0 {
+		stopMutex.Lock()
+		stop := Global.WspcStop[Global.Rank] > 0
+		stopMutex.Unlock()
+		if stop {
 			chk.CallerInfo(3)
 			chk.CallerInfo(2)
 			io.PfRed("simulation stopped due to errors. see log files\n")
@@ -55,3 +62,10 @@ func Stop() bool {
 	}
 	return false
 }
+
+// set_stop sets the stop flag of this processor
+func set_stop() {
+	stopMutex.Lock()
+	Global.WspcStop[Global.Rank] = 1
+	stopMutex.Unlock()
+}
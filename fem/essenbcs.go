@@ -8,6 +8,7 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strings"
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
@@ -29,8 +30,12 @@ import (
 //     |_ A   0 _| \ δλ /   \  c - A*y  /
 //         Kb       δyb          fb
 //
+//  Alternatively, the constraints can be enforced by means of penalty or direct elimination;
+//  see Global.Sim.Solver.EnfMode. With penalty, λ = α * (A*y - c) and Kb = K + α * At*A.
+//  With elimination, see the eliminator structure.
+//
 type EssentialBc struct {
-	Key   string    // ux, uy, rigid, incsup
+	Key   string    // ux, uy, rigid, incsup, mpc, periodic
 	Eqs   []int     // equations
 	ValsA []float64 // values for matrix A
 	Fcn   fun.Func  // function that implements the "c" in A * y = c
@@ -45,6 +50,11 @@ type EssentialBcs struct {
 	A      la.Triplet     // matrix of coefficients 'A'
 	Am     *la.CCMatrix   // compressed form of A matrix
 
+	// enforcement
+	Ny   int         // number of equations, excluding Lagrange multipliers
+	Mode string      // "lagrange", "elimination" or "penalty"
+	Elim *eliminator // structure for direct elimination
+
 	// temporary
 	BcsTmp eqbcpairs // temporary essential bcs / constraints, including inactive ones. maps the first equation number to bcs
 }
@@ -54,12 +64,17 @@ func (o *EssentialBcs) Reset() {
 	o.BcsTmp = make([]eqbcpair, 0)
 	o.Eq2idx = make(map[int][]int)
 	o.Bcs = make([]*EssentialBc, 0)
+	o.Elim = nil
 }
 
 // Build builds this structure and its iternal data
 //  nλ -- is the number of essential bcs / constraints == number of Lagrange multipliers
 //  nnzA -- is the number of non-zeros in matrix 'A'
-func (o *EssentialBcs) Build(ny int) (nλ, nnzA int) {
+func (o *EssentialBcs) Build(ny int) (nλ, nnzA int, ok bool) {
+
+	// enforcement mode
+	o.Ny = ny
+	o.Mode = Global.Sim.Solver.EnfMode
 
 	// sort bcs to make sure all processors will number Lagrange multipliers in the same order
 	sort.Sort(o.BcsTmp)
@@ -75,6 +90,7 @@ func (o *EssentialBcs) Build(ny int) (nλ, nnzA int) {
 
 	// skip if there are no constraints
 	if nλ == 0 {
+		ok = true
 		return
 	}
 
@@ -87,21 +103,124 @@ func (o *EssentialBcs) Build(ny int) (nλ, nnzA int) {
 	}
 	o.Am = o.A.ToMatrix(nil)
 
+	// eliminator
+	if o.Mode == "elimination" {
+		o.Elim = new(eliminator)
+		if !o.Elim.Init(o.Bcs, ny) {
+			return
+		}
+	}
+
 	// debug
 	if false {
 		log.Printf("\n\nAm=%v\n", o.Am)
 	}
-	return
+	return nλ, nnzA, true
+}
+
+// Sizes returns the dimensions of the global system
+//  ny     -- number of equations (dofs)
+//  nnzK   -- number of non-zeros in K matrix (from elements)
+//  nyb    -- length of fb and wb vectors
+//  nkb    -- number of rows/columns of Kb matrix
+//  nnzKb  -- maximum number of non-zeros in Kb matrix
+func (o *EssentialBcs) Sizes(ny, nnzK int) (nyb, nkb, nnzKb int) {
+	switch o.Mode {
+	case "elimination":
+		if o.Elim == nil {
+			return ny, ny, nnzK
+		}
+		m := o.Elim.MaxRow()
+		return ny, len(o.Elim.Free), nnzK * m * m
+	case "penalty":
+		nnzKb = nnzK
+		for _, c := range o.Bcs {
+			nnzKb += len(c.Eqs) * len(c.Eqs)
+		}
+		return ny, ny, nnzKb
+	}
+	nnzA := 0
+	for _, c := range o.Bcs {
+		nnzA += len(c.Eqs)
+	}
+	nyb = ny + len(o.Bcs)
+	return nyb, nyb, nnzK + 2*nnzA
+}
+
+// Assembler returns the structure receiving the element contributions to Kb.
+// With elimination, entries are transformed to the reduced system first.
+func (o *EssentialBcs) Assembler(Kb Assembler) Assembler {
+	if o.Elim != nil {
+		o.Elim.Kr = Kb
+		return o.Elim
+	}
+	return Kb
+}
+
+// AddToKb adds the essential bcs / constraints terms to Kb
+func (o *EssentialBcs) AddToKb(Kb Assembler) {
+	switch o.Mode {
+	case "elimination":
+		return
+	case "penalty":
+		α := Global.Sim.Solver.Penalty
+		for _, c := range o.Bcs {
+			for i, I := range c.Eqs {
+				for j, J := range c.Eqs {
+					Kb.Put(I, J, α*c.ValsA[i]*c.ValsA[j])
+				}
+			}
+		}
+		return
+	}
+
+	// A and tr(A); i.e. Kb.PutMatAndMatT(&o.A)
+	for k, c := range o.Bcs {
+		for i, I := range c.Eqs {
+			Kb.Put(o.Ny+k, I, c.ValsA[i])
+			Kb.Put(I, o.Ny+k, c.ValsA[i])
+		}
+	}
+}
+
+// SolveR solves the linear system Kb * wb = fb, with the reduced system in case of elimination
+func (o *EssentialBcs) SolveR(lis la.LinSol, wb, fb []float64) (err error) {
+	if o.Elim != nil {
+		return o.Elim.SolveR(lis, wb, fb)
+	}
+	return lis.SolveR(wb, fb, false)
 }
 
 // AddtoRhs adds the essential bcs / constraints terms to the augmented fb vector
-func (o EssentialBcs) AddToRhs(fb []float64, sol *Solution) {
+//  Note: with penalty or elimination, λ is computed here and stored in sol.L
+func (o *EssentialBcs) AddToRhs(fb []float64, sol *Solution) {
 
 	// skip if there are no constraints
 	if len(o.Bcs) == 0 {
 		return
 	}
 
+	// penalty: λ = α * (A*y - c)
+	switch o.Mode {
+	case "penalty":
+		α := Global.Sim.Solver.Penalty
+		for i, c := range o.Bcs {
+			sol.L[i] = -c.Fcn.F(sol.T, nil)
+		}
+		la.SpMatVecMulAdd(sol.L, 1, o.Am, sol.Y) // λ += A * y
+		for i := 0; i < len(sol.L); i++ {
+			sol.L[i] *= α
+		}
+		la.SpMatTrVecMulAdd(fb, -1, o.Am, sol.L) // fb += -1 * At * λ
+		return
+
+	// elimination: λ such that fb - At*λ is zero at slave equations
+	case "elimination":
+		o.Elim.Rhs(fb, sol)
+		la.SpMatTrVecMulAdd(fb, -1, o.Am, sol.L) // fb += -1 * At * λ
+		return
+	}
+
 	// add -At*λ to fb
 	la.SpMatTrVecMulAdd(fb, -1, o.Am, sol.L) // fb += -1 * At * λ
 
@@ -113,6 +232,24 @@ func (o EssentialBcs) AddToRhs(fb []float64, sol *Solution) {
 	la.SpMatVecMulAdd(fb[ny:], -1, o.Am, sol.Y) // fb += -1 * A * y
 }
 
+// Reactions computes the reaction forces R = -At*λ; i.e. the forces exerted by the constraints
+//  Note: with multi-point constraints, reactions are distributed among the constrained equations
+func (o *EssentialBcs) Reactions(R []float64, sol *Solution) {
+	la.VecFill(R, 0)
+	if len(o.Bcs) == 0 {
+		return
+	}
+	la.SpMatTrVecMulAdd(R, -1, o.Am, sol.L) // R += -1 * At * λ
+}
+
+// ReactionKey returns the key of reaction forces corresponding to a f-key; e.g. "fx" => "rx", "ql" => "rql"
+func ReactionKey(fkey string) string {
+	if strings.HasPrefix(fkey, "f") {
+		return "r" + fkey[1:]
+	}
+	return "r" + fkey
+}
+
 // add adds new essential bcs / constraint and sets map eq2idx
 func (o *EssentialBcs) add(key string, eqs []int, valsA []float64, fcn fun.Func) {
 	idx := len(o.BcsTmp)
@@ -126,7 +263,8 @@ func (o *EssentialBcs) add(key string, eqs []int, valsA []float64, fcn fun.Func)
 func (o *EssentialBcs) add_single(key string, eq int, fcn fun.Func) {
 	for _, idx := range o.Eq2idx[eq] {
 		pair := o.BcsTmp[idx]
-		if pair.bc.Key == "rigid" || pair.bc.Key == "incsup" {
+		switch pair.bc.Key {
+		case "rigid", "incsup", "mpc", "periodic":
 			return
 		}
 		pair.bc.Inact = true
@@ -138,15 +276,18 @@ func (o *EssentialBcs) add_single(key string, eq int, fcn fun.Func) {
 //  rigid  -- define rigid element constraints
 //  incsup -- inclined support constraints
 //  hst    -- set hydrostatic pressures
+//  ax, ay, az -- rigid base input motion (prescribed accelerations)
 func GetIsEssenKeyMap() map[string]bool {
-	return map[string]bool{"rigid": true, "incsup": true, "hst": true}
+	return map[string]bool{"rigid": true, "incsup": true, "hst": true, "ax": true, "ay": true, "az": true}
 }
 
 // Set sets a constraint if it does NOT exist yet.
-//  key   -- can be Dof key such as "ux", "uy" or constraint type such as "mpc" or "rigid"
-//  extra -- is a keycode-style data. e.g. "!type:incsup2d !alp:30"
+//  key   -- can be Dof key such as "ux", "uy" or constraint type such as "incsup" or "rigid"
+//  extra -- is a keycode-style data. e.g. "!alp:30" or "!nx:1 !ny:0 !nz:1" for "incsup"
 //  Notes: 1) the default for key is single point constraint; e.g. "ux", "uy", ...
 //         2) hydraulic head can be set with key == "H"
+//         3) multi-point and periodic constraints are set with SetMpc and SetPeriodic
+//         4) accelerations given with "ax", "ay" or "az" are prescribed as displacements; see InputMotion
 func (o *EssentialBcs) Set(key string, nodes []*Node, fcn fun.Func, extra string) (setisok bool) {
 
 	// len(nod) must be greater than 0
@@ -165,38 +306,19 @@ func (o *EssentialBcs) Set(key string, nodes []*Node, fcn fun.Func, extra string
 
 	// inclined support
 	if key == "incsup" {
-
-		// check
-		if LogErrCond(Global.Ndim != 2, "inclined support works only in 2D for now") {
+		n, fnormal := incsup_normal(extra)
+		if LogErrCond(fnormal, "inclined support normal to faces can only be set with face boundary conditions") {
 			return false // problem
 		}
+		return o.SetIncSup(nodes, n)
+	}
 
-		// get data
-		var α float64
-		if val, found := io.Keycode(extra, "alp"); found {
-			α = io.Atof(val) * math.Pi / 180.0
-		}
-		co, si := math.Cos(α), math.Sin(α)
-
-		// set for all nodes
-		for _, nod := range nodes {
-
-			// find existent constraints and deactivate them
-			eqx := nod.Dofs[0].Eq
-			eqy := nod.Dofs[1].Eq
-			for _, eq := range []int{eqx, eqy} {
-				for _, idx := range o.Eq2idx[eq] {
-					pair := o.BcsTmp[idx]
-					if pair.bc.Key != "rigid" {
-						pair.bc.Inact = true
-					}
-				}
-			}
-
-			// set constraint
-			o.add(key, []int{eqx, eqy}, []float64{co, si}, &fun.Zero)
+	// rigid base input motion
+	if idx, rigid := motion_key(key); idx >= 0 && rigid {
+		if LogErrCond(Global.Motion == nil || Global.Motion.Comps[idx] == nil, "input motion %q has not been set", key) {
+			return
 		}
-		return true // success
+		return o.Set("u"+key[1:], nodes, Global.Motion.Comps[idx], extra)
 	}
 
 	// hydraulic head
@@ -243,8 +365,144 @@ func (o *EssentialBcs) Set(key string, nodes []*Node, fcn fun.Func, extra string
 	return true
 }
 
+// SetIncSup sets inclined (skew) support constraints n · u = 0 at nodes
+//  n -- normal direction of constraint [ndim]; it doesn't need to be a unit vector
+func (o *EssentialBcs) SetIncSup(nodes []*Node, n []float64) (ok bool) {
+
+	// unit normal
+	ndim := Global.Ndim
+	norm := 0.0
+	for i := 0; i < ndim; i++ {
+		norm += n[i] * n[i]
+	}
+	norm = math.Sqrt(norm)
+	if LogErrCond(norm < 1e-12, "inclined support: normal vector must not be zero. n = %v", n) {
+		return
+	}
+
+	// set for all nodes
+	ukeys := []string{"ux", "uy", "uz"}
+	for _, nod := range nodes {
+
+		// find existent constraints and deactivate them
+		var eqs []int
+		var vals []float64
+		for i := 0; i < ndim; i++ {
+			d := nod.GetDof(ukeys[i])
+			if LogErrCond(d == nil, "inclined support: cannot find %q dof of node %d", ukeys[i], nod.Vert.Id) {
+				return
+			}
+			if math.Abs(n[i]) < 1e-15*norm {
+				continue
+			}
+			for _, idx := range o.Eq2idx[d.Eq] {
+				pair := o.BcsTmp[idx]
+				if pair.bc.Key != "rigid" {
+					pair.bc.Inact = true
+				}
+			}
+			eqs = append(eqs, d.Eq)
+			vals = append(vals, n[i]/norm)
+		}
+
+		// set constraint
+		o.add("incsup", eqs, vals, &fun.Zero)
+	}
+	return true
+}
+
+// SetMpc sets a linear multi-point constraint
+//   Σ coefs[i] * y[keys[i]] @ nodes[i] = c(t)
+//  fcn -- function implementing c(t)
+func (o *EssentialBcs) SetMpc(nodes []*Node, keys []string, coefs []float64, fcn fun.Func) (ok bool) {
+	if LogErrCond(len(keys) != len(nodes) || len(coefs) != len(nodes), "mpc: the numbers of nodes (%d), keys (%d) and coefficients (%d) must be equal", len(nodes), len(keys), len(coefs)) {
+		return
+	}
+	eqs := make([]int, len(nodes))
+	for i, nod := range nodes {
+		d := nod.GetDof(keys[i])
+		if LogErrCond(d == nil, "mpc: cannot find %q dof of node %d", keys[i], nod.Vert.Id) {
+			return
+		}
+		eqs[i] = d.Eq
+	}
+	o.add("mpc", eqs, coefs, fcn)
+	return true
+}
+
+// SetPeriodic sets periodic constraints between pairs of nodes
+//   y[key] @ slave - y[key] @ master = c(t)
+//  Notes: 1) each equation can be the slave of one periodic constraint only; thus, for instance,
+//            the corners of periodic boxes are handled automatically
+//         2) constraints between equations already prescribed by single-point constraints are
+//            skipped since they would be redundant
+func (o *EssentialBcs) SetPeriodic(masters, slaves []*Node, key string, fcn fun.Func) (ok bool) {
+	chk.IntAssert(len(masters), len(slaves))
+	for i, slv := range slaves {
+		a := masters[i].GetDof(key)
+		b := slv.GetDof(key)
+		if a == nil || b == nil {
+			continue // nodes don't have key. ex: pl in qua8/qua4 elements
+		}
+		if a.Eq == b.Eq {
+			continue
+		}
+		if o.has_active(b.Eq, "periodic") || (o.has_active(b.Eq, "") && o.has_active(a.Eq, "")) {
+			continue
+		}
+		o.add("periodic", []int{b.Eq, a.Eq}, []float64{1, -1}, fcn)
+	}
+	return true
+}
+
 // auxiliary /////////////////////////////////////////////////////////////////////////////////////////
 
+// has_active checks whether eq is the first equation of an active constraint with key; single-point
+// constraints (any key) are considered if key == ""
+func (o *EssentialBcs) has_active(eq int, key string) bool {
+	for _, idx := range o.Eq2idx[eq] {
+		bc := o.BcsTmp[idx].bc
+		if bc.Inact || bc.Eqs[0] != eq {
+			continue
+		}
+		if key == "" && len(bc.Eqs) == 1 {
+			return true
+		}
+		if key != "" && bc.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// incsup_normal returns the normal direction of inclined supports given in extra as
+//  "!alp:30" -- 2D only: angle (degrees) between the normal and the x-axis
+//  "!nx:1 !ny:1 !nz:0" -- components of normal vector (missing components are zero)
+//  "!fnormal:true" -- face boundary conditions only: normal to the faces. fnormal = true and n = nil
+//  Note: without any of the above, the normal is along the x-axis (alp = 0)
+func incsup_normal(extra string) (n []float64, fnormal bool) {
+	if val, ok := io.Keycode(extra, "fnormal"); ok && io.Atob(val) {
+		return nil, true
+	}
+	n = make([]float64, 3)
+	if val, ok := io.Keycode(extra, "alp"); ok {
+		α := io.Atof(val) * math.Pi / 180.0
+		n[0], n[1] = math.Cos(α), math.Sin(α)
+		return
+	}
+	found := false
+	for i, key := range []string{"nx", "ny", "nz"} {
+		if val, ok := io.Keycode(extra, key); ok {
+			n[i] = io.Atof(val)
+			found = true
+		}
+	}
+	if !found {
+		n[0] = 1
+	}
+	return
+}
+
 type eqbcpair struct {
 	eq int
 	bc *EssentialBc
@@ -13,6 +13,7 @@ import (
 	"path"
 
 	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/utl"
 )
 
 // Encoder defines encoders; e.g. gob or json
@@ -66,6 +67,21 @@ func (o Domain) SaveSol(tidx int) (ok bool) {
 	if LogErr(enc.Encode(o.Sol.D2ydt2), "SaveSol") {
 		return
 	}
+	if Global.Sim.Data.React {
+		if LogErr(enc.Encode(o.Sol.R), "SaveSol") {
+			return
+		}
+	}
+	if len(Global.Sim.Data.Sens) > 0 {
+		if LogErr(enc.Encode(o.Sol.Sens), "SaveSol") {
+			return
+		}
+	}
+	if len(o.Sol.L) > 0 {
+		if LogErr(enc.Encode(o.Sol.L), "SaveSol") {
+			return
+		}
+	}
 
 	// save file
 	fn := out_nod_path(Global.Dirout, Global.Fnkey, tidx, Global.Rank)
@@ -101,24 +117,31 @@ func (o *Domain) ReadSol(dir, fnkey string, tidx int) (ok bool) {
 	if LogErr(dec.Decode(&o.Sol.D2ydt2), "ReadSol") {
 		return
 	}
+	if Global.Sim.Data.React {
+		if LogErr(dec.Decode(&o.Sol.R), "ReadSol") {
+			return
+		}
+	}
+	if len(Global.Sim.Data.Sens) > 0 {
+		if LogErr(dec.Decode(&o.Sol.Sens), "ReadSol") {
+			return
+		}
+	}
+	if len(o.Sol.L) > 0 {
+		if LogErr(dec.Decode(&o.Sol.L), "ReadSol") {
+			return
+		}
+	}
 	return true
 }
 
 // SaveIvs saves elements's internal values to a file which name is set with tidx (time output index)
 func (o Domain) SaveIvs(tidx int) (ok bool) {
 
-	// buffer and encoder
+	// buffer
 	var buf bytes.Buffer
-	enc := GetEncoder(&buf)
-
-	// elements that go to file
-	enc.Encode(o.MyCids)
-
-	// encode internal variables
-	for _, e := range o.Elems {
-		if !e.Encode(enc) {
-			return
-		}
+	if !o.encode_ivs(&buf) {
+		return
 	}
 
 	// save file
@@ -139,31 +162,131 @@ func (o *Domain) ReadIvs(dir, fnkey string, tidx, proc int) (ok bool) {
 		LogErr(fil.Close(), "ReadIvs: cannot close file")
 	}()
 
-	// decoder
-	dec := GetDecoder(fil)
+	// decode
+	return o.decode_ivs(fil)
+}
 
-	// elements that are in file
-	dec.Decode(&o.MyCids)
+// SaveRes saves Solution (root only) and elements's internal values to the indexed results file
+// of this processor
+func (o *Domain) SaveRes(tidx int) (ok bool) {
 
-	// decode internal variables
-	for _, cid := range o.MyCids {
-		elem := o.Cid2elem[cid]
-		if LogErrCond(elem == nil, "ReadIvs: cannot find element with cid=%d", cid) {
+	// open file
+	if o.resOut == nil {
+		o.resOut = OpenResFile(ResFilePath(Global.Dirout, Global.Fnkey, Global.Rank), true)
+		if o.resOut == nil {
 			return
 		}
-		if !elem.Decode(dec) {
+	}
+
+	// solution
+	var fields []string
+	var chunks [][]byte
+	if Global.Root {
+		fields = []string{"Y", "Dydt", "D2ydt2"}
+		chunks = [][]byte{floats2bytes(o.Sol.Y), floats2bytes(o.Sol.Dydt), floats2bytes(o.Sol.D2ydt2)}
+		if Global.Sim.Data.React {
+			fields = append(fields, "R")
+			chunks = append(chunks, floats2bytes(o.Sol.R))
+		}
+		for k, dydp := range o.Sol.Sens {
+			fields = append(fields, SensField(k))
+			chunks = append(chunks, floats2bytes(dydp))
+		}
+		if len(o.Sol.L) > 0 {
+			fields = append(fields, "L")
+			chunks = append(chunks, floats2bytes(o.Sol.L))
+		}
+	}
+
+	// internal values
+	var buf bytes.Buffer
+	if !o.encode_ivs(&buf) {
+		return
+	}
+	fields = append(fields, "ivs")
+	chunks = append(chunks, buf.Bytes())
+
+	// save chunks
+	return o.resOut.Append(tidx, o.Sol.T, fields, chunks)
+}
+
+// ReadRes reads Solution and elements's internal values from indexed results files
+//  allInOne -- see In
+func (o *Domain) ReadRes(sum *Summary, tidx int, allInOne bool) (ok bool) {
+
+	// internal values
+	procs := []int{Global.Rank}
+	if allInOne {
+		procs = utl.IntRange(sum.Nproc)
+	}
+	for _, proc := range procs {
+		res := o.res_file(ResFilePath(sum.Dirout, sum.Fnkey, proc))
+		if res == nil {
 			return
 		}
+		b, ok := res.Read(tidx, "ivs")
+		if !ok {
+			return false
+		}
+		if !o.decode_ivs(bytes.NewReader(b)) {
+			return false
+		}
+	}
+
+	// solution: always from proc # 0
+	res := o.res_file(ResFilePath(sum.Dirout, sum.Fnkey, 0))
+	if res == nil {
+		return
+	}
+	fields := []string{"Y", "Dydt", "D2ydt2"}
+	vecs := []*[]float64{&o.Sol.Y, &o.Sol.Dydt, &o.Sol.D2ydt2}
+	if Global.Sim.Data.React {
+		fields = append(fields, "R")
+		vecs = append(vecs, &o.Sol.R)
+	}
+	if len(Global.Sim.Data.Sens) > 0 {
+		o.Sol.Sens = make([][]float64, len(Global.Sim.Data.Sens))
+		for k := range o.Sol.Sens {
+			fields = append(fields, SensField(k))
+			vecs = append(vecs, &o.Sol.Sens[k])
+		}
+	}
+	if len(o.Sol.L) > 0 {
+		fields = append(fields, "L")
+		vecs = append(vecs, &o.Sol.L)
+	}
+	for i, Y := range vecs {
+		b, ok := res.Read(tidx, fields[i])
+		if !ok {
+			return false
+		}
+		*Y = bytes2floats(b)
 	}
+	o.Sol.T = res.Index.Times[res.Pos(tidx)]
 	return true
 }
 
 // Out performs output of Solution and Internal values to files
 func (o *Domain) Out(tidx int) (ok bool) {
-	if !o.SaveSol(tidx) {
-		return
+	if Global.Sim.Data.React {
+		o.EssenBcs.Reactions(o.Sol.R, o.Sol)
+	}
+	if Global.Sim.Data.Indexed {
+		if !o.SaveRes(tidx) {
+			return
+		}
+	} else {
+		if !o.SaveSol(tidx) {
+			return
+		}
+		if !o.SaveIvs(tidx) {
+			return
+		}
+	}
+	if Global.OutHook != nil {
+		return Global.OutHook(o, tidx)
 	}
-	return o.SaveIvs(tidx)
+	return true
 }
 
 // In performes the inverse operation from Out
@@ -176,6 +299,11 @@ func (o *Domain) Out(tidx int) (ok bool) {
 //
 func (o *Domain) In(sum *Summary, tidx int, allInOne bool) (ok bool) {
 
+	// indexed results files
+	if Global.Sim.Data.Indexed {
+		return o.ReadRes(sum, tidx, allInOne)
+	}
+
 	// serial run
 	if allInOne {
 		for i := 0; i < sum.Nproc; i++ {
@@ -195,6 +323,76 @@ func (o *Domain) In(sum *Summary, tidx int, allInOne bool) (ok bool) {
 
 // auxiliary ///////////////////////////////////////////////////////////////////////////////////////
 
+// encode_ivs encodes elements's internal values
+func (o Domain) encode_ivs(w goio.Writer) (ok bool) {
+
+	// encoder
+	enc := GetEncoder(w)
+
+	// elements that go to file
+	enc.Encode(o.MyCids)
+
+	// encode internal variables
+	for _, e := range o.Elems {
+		if !e.Encode(enc) {
+			return
+		}
+	}
+
+	// encode states of contact pairs
+	for _, c := range o.Contacts {
+		if !c.Encode(enc) {
+			return
+		}
+	}
+	return true
+}
+
+// decode_ivs decodes elements's internal values
+func (o *Domain) decode_ivs(r goio.Reader) (ok bool) {
+
+	// decoder
+	dec := GetDecoder(r)
+
+	// elements that are in file
+	dec.Decode(&o.MyCids)
+
+	// decode internal variables
+	for _, cid := range o.MyCids {
+		elem := o.Cid2elem[cid]
+		if LogErrCond(elem == nil, "ReadIvs: cannot find element with cid=%d", cid) {
+			return
+		}
+		if !elem.Decode(dec) {
+			return
+		}
+	}
+
+	// decode states of contact pairs
+	for _, c := range o.Contacts {
+		if !c.Decode(dec) {
+			return
+		}
+	}
+	return true
+}
+
+// res_file returns a results file for reading; files are kept open (i.e. the index is cached)
+//  Note: returns nil on errors
+func (o *Domain) res_file(fnpath string) *ResFile {
+	if o.resIn == nil {
+		o.resIn = make(map[string]*ResFile)
+	}
+	if res, ok := o.resIn[fnpath]; ok {
+		return res
+	}
+	res := OpenResFile(fnpath, false)
+	if res != nil {
+		o.resIn[fnpath] = res
+	}
+	return res
+}
+
 func out_nod_path(dir, fnkey string, tidx, proc int) string {
 	return path.Join(dir, io.Sf("%s_p%d_nod_%010d.%s", fnkey, proc, tidx, Global.Enc))
 }
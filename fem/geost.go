@@ -188,11 +188,40 @@ func (o *Domain) SetGeoSt(stg *inp.Stage) (ok bool) {
 		}
 	}
 
-	// make sure all elements tags were handled
-	for tag, _ := range o.Msh.CellTag2cells {
-		if LogErrCond(!ctaghandled[tag], "geost: there are cells not included in any layer: ctag=%d", tag) {
+	// make sure all elements tags were handled. Only cells of contact bodies (i.e. with tags of
+	// cells owning contact faces) without liquid pressure may be left out of layers; e.g. an
+	// indenter resting on the ground. Their initial stresses are zero
+	contactftags := make(map[int]bool)
+	for _, dat := range stg.Contact {
+		contactftags[dat.Master], contactftags[dat.Slave] = true, true
+	}
+	contactctags := make(map[int]bool)
+	for _, c := range o.Msh.Cells {
+		for _, ftag := range c.FTags {
+			if ftag < 0 && contactftags[ftag] {
+				contactctags[c.Tag] = true
+			}
+		}
+	}
+	var others []ElemIntvars
+	for tag, cells := range o.Msh.CellTag2cells {
+		if ctaghandled[tag] {
+			continue
+		}
+		if LogErrCond(!contactctags[tag], "geost: there are cells not included in any layer: ctag=%d", tag) {
 			return
 		}
+		for _, c := range cells {
+			for _, v := range c.Verts {
+				nod := o.Vid2node[v]
+				if LogErrCond(nod != nil && nod.GetDof("pl") != nil, "geost: cells of contact body with liquid pressure must be included in a layer: ctag=%d", tag) {
+					return
+				}
+			}
+			if ele, okk := o.Cid2elem[c.Id].(ElemIntvars); okk {
+				others = append(others, ele)
+			}
+		}
 	}
 
 	// sort layers from top to bottom
@@ -263,6 +292,16 @@ func (o *Domain) SetGeoSt(stg *inp.Stage) (ok bool) {
 			}
 		}
 	}
+
+	// elements out of layers and contact pairs
+	for _, c := range o.Contacts {
+		others = append(others, c)
+	}
+	for _, ele := range others {
+		if LogErrCond(!ele.SetIniIvs(o.Sol, nil), "geost: element's internal values setting failed") {
+			return
+		}
+	}
 	return true
 }
 
@@ -0,0 +1,360 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gofem/inp"
+
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/shp"
+)
+
+// InputMotion holds the (earthquake) input motion of a stage; i.e. acceleration time histories
+// prescribed at the base of the mesh. The motion is given by means of the following keys:
+//
+//   "ax", "ay", "az"    -- rigid base: the accelerations are integrated twice and prescribed as
+//                          displacements (essential boundary conditions)
+//   "aix", "aiy", "aiz" -- compliant base: accelerations of the incident (upward propagating) wave;
+//                          these face keys must be combined with "dashpot" and add the traction
+//                          2・ρ・c・v_inc to the base (Joyner and Chen 1975). Note: for an outcrop
+//                          record, the incident motion is half of the outcrop motion
+//
+//  The motion is also used to compute the free-field velocities and stresses at "freefield" faces,
+//  which correspond to vertically propagating waves in a column between the base elevation zb
+//  and the free surface zt. The column is assumed to be homogeneous and elastic; thus, with the
+//  wave velocity c and ζ = z - zb, H = zt - zb:
+//
+//   compliant base: u(ζ,t) = uinc(t - ζ/c) + uinc(t - (2H-ζ)/c)
+//   rigid base:     u(ζ,t) = Σ_k (-1)^k [ub(t - (2kH+ζ)/c) + ub(t - (2(k+1)H-ζ)/c)]   k = 0, 1, ...
+//
+//  Horizontal components propagate as shear waves (cs) and the vertical component as compression
+//  waves (cp). Note that this analytical free field does not account for layers, heterogeneity or
+//  nonlinearity; thus, "freefield" faces are rejected if the column below them, between zb and
+//  zt, crosses more than one material. At layered sites, "dashpot" faces placed far from the
+//  region of interest must be used instead.
+//
+//  The extra data of face or node conditions may specify:
+//   "!dta:0.001" -- time step for the integration of accelerations; default = dt/10 or tf/10000
+//   "!zb:0 !zt:10" -- elevations of base and free surface; defaults = min and max elevations of mesh
+type InputMotion struct {
+	Rigid bool          // rigid base; otherwise compliant base with incident wave
+	Zb    float64       // elevation of base
+	Zt    float64       // elevation of free surface
+	Comps []*MotionComp // [3] components; nil if not given
+}
+
+// MotionComp holds one component of the input motion. Accelerations are sampled at a regular grid
+// and linearly interpolated; thus, velocities and displacements are integrated exactly. MotionComp
+// implements fun.Func with F = displacement, G = velocity and H = acceleration
+type MotionComp struct {
+	Fname string    // name of acceleration function
+	Acc   fun.Func  // acceleration function
+	Dt    float64   // time step of grid
+	A     []float64 // accelerations at grid points
+	V     []float64 // velocities at grid points
+	U     []float64 // displacements at grid points
+}
+
+// SetInputMotion collects the input motion keys of face and node conditions of stage and sets
+// Global.Motion; which will be nil if the stage has no input motion
+func SetInputMotion(stg *inp.Stage) (ok bool) {
+
+	// all conditions
+	var keys, funcs, extras []string
+	for _, fc := range stg.FaceBcs {
+		for j, key := range fc.Keys {
+			keys, funcs, extras = append(keys, key), append(funcs, fc.Funcs[j]), append(extras, fc.Extra)
+		}
+	}
+	for _, nc := range stg.NodeBcs {
+		for j, key := range nc.Keys {
+			keys, funcs, extras = append(keys, key), append(funcs, nc.Funcs[j]), append(extras, nc.Extra)
+		}
+	}
+
+	// defaults
+	dt := stg.Control.Dt / 10.0
+	if dt <= 0 {
+		dt = stg.Control.Tf / 10000.0
+	}
+	zb, zt := Global.Sim.MinElev, Global.Sim.MaxElev
+	nrigid, ninc := 0, 0
+	Global.Motion = nil
+	var o *InputMotion
+
+	// find components
+	for k, key := range keys {
+		idx, rigid := motion_key(key)
+		if idx < 0 {
+			continue
+		}
+		if LogErrCond(idx >= Global.Ndim, "input motion: key %q is not available in %dD", key, Global.Ndim) {
+			return
+		}
+		if o == nil {
+			o = &InputMotion{Comps: make([]*MotionComp, 3)}
+		}
+		if rigid {
+			nrigid++
+		} else {
+			ninc++
+		}
+		if c := o.Comps[idx]; c != nil {
+			if LogErrCond(c.Fname != funcs[k], "input motion: component %d is defined with two functions: %q and %q", idx, c.Fname, funcs[k]) {
+				return
+			}
+			continue
+		}
+		fcn := Global.Sim.Functions.Get(funcs[k])
+		if LogErrCond(fcn == nil, "input motion: cannot find function named %q", funcs[k]) {
+			return
+		}
+		if val, found := io.Keycode(extras[k], "dta"); found {
+			dt = io.Atof(val)
+		}
+		if val, found := io.Keycode(extras[k], "zb"); found {
+			zb = io.Atof(val)
+		}
+		if val, found := io.Keycode(extras[k], "zt"); found {
+			zt = io.Atof(val)
+		}
+		o.Comps[idx] = &MotionComp{Fname: funcs[k], Acc: fcn}
+	}
+	if o == nil {
+		return true
+	}
+
+	// check
+	if LogErrCond(nrigid > 0 && ninc > 0, "input motion: rigid base (\"ax\", \"ay\", \"az\") and incident wave (\"aix\", \"aiy\", \"aiz\") keys cannot be mixed") {
+		return
+	}
+	if LogErrCond(dt <= 0 || zt <= zb, "input motion: time step (%g) must be positive and zt (%g) must be greater than zb (%g)", dt, zt, zb) {
+		return
+	}
+
+	// integrate accelerations
+	o.Rigid = nrigid > 0
+	o.Zb, o.Zt = zb, zt
+	for _, c := range o.Comps {
+		if c != nil {
+			c.integrate(dt, stg.Control.Tf)
+		}
+	}
+	Global.Motion = o
+	return true
+}
+
+// check_freefield checks that the columns of soil below "freefield" faces, between zb and zt, are
+// made of a single material; since the analytical free field of InputMotion corresponds to a
+// homogeneous column, layered sites cannot be analysed with "freefield" faces. The columns are
+// given by the cells with vertices on the vertical lines through the vertices of "freefield" faces
+func (o *Domain) check_freefield(stg *inp.Stage) (ok bool) {
+
+	// skip if there is no input motion
+	if Global.Motion == nil {
+		return true
+	}
+
+	// horizontal coordinates of vertices of "freefield" faces
+	ndim := Global.Ndim
+	tol := 1e-10 * (Global.Motion.Zt - Global.Motion.Zb)
+	var lines [][]float64
+	add_line := func(x []float64) {
+		for _, l := range lines {
+			if same_line(l, x, tol) {
+				return
+			}
+		}
+		lines = append(lines, x[:ndim-1])
+	}
+	for _, c := range o.Msh.Cells {
+		edat := o.Reg.Etag2data(c.Tag)
+		if edat == nil || edat.Inact {
+			continue
+		}
+		for faceId, faceTag := range c.FTags {
+			if faceTag >= 0 || !has_freefield(stg.GetFaceBc(faceTag)) {
+				continue
+			}
+			for _, l := range shp.GetFaceLocalVerts(c.Type, faceId) {
+				add_line(o.Msh.Verts[c.Verts[l]].C)
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return true
+	}
+
+	// materials of cells in columns
+	var mat string
+	zb, zt := Global.Motion.Zb, Global.Motion.Zt
+	for _, c := range o.Msh.Cells {
+		edat := o.Reg.Etag2data(c.Tag)
+		if edat == nil || edat.Inact {
+			continue
+		}
+		for _, v := range c.Verts {
+			x := o.Msh.Verts[v].C
+			if x[ndim-1] < zb-tol || x[ndim-1] > zt+tol {
+				continue
+			}
+			incol := false
+			for _, l := range lines {
+				if same_line(l, x, tol) {
+					incol = true
+					break
+				}
+			}
+			if !incol {
+				continue
+			}
+			if mat == "" {
+				mat = edat.Mat
+			}
+			if LogErrCond(edat.Mat != mat, "input motion: the column between zb=%g and zt=%g below \"freefield\" faces crosses materials %q and %q (@ cell %d). The free field is computed for a homogeneous column; thus, layered sites require \"dashpot\" faces instead", zb, zt, mat, edat.Mat, c.Id) {
+				return
+			}
+			break
+		}
+	}
+	return true
+}
+
+// has_freefield tells whether face condition fbc has the "freefield" key
+func has_freefield(fbc *inp.FaceBc) bool {
+	if fbc == nil {
+		return false
+	}
+	for _, key := range fbc.Keys {
+		if key == "freefield" {
+			return true
+		}
+	}
+	return false
+}
+
+// same_line tells whether point x is on the vertical line with horizontal coordinates l
+func same_line(l, x []float64, tol float64) bool {
+	for i, li := range l {
+		if math.Abs(x[i]-li) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// FreeField computes the velocity v and the gradient dudz = ∂u/∂z of component idx of the
+// free-field motion at elevation z and time t; c is the wave velocity
+func (o *InputMotion) FreeField(idx int, z, t, c float64) (v, dudz float64) {
+	comp := o.Comps[idx]
+	if comp == nil {
+		return
+	}
+	ζ := z - o.Zb
+	H := o.Zt - o.Zb
+	if !o.Rigid {
+		vup := comp.G(t-ζ/c, nil)
+		vdn := comp.G(t-(2.0*H-ζ)/c, nil)
+		return vup + vdn, (vdn - vup) / c
+	}
+	sgn := 1.0
+	for k := 0; ; k++ {
+		t1 := t - (2.0*float64(k)*H+ζ)/c
+		t2 := t - (2.0*float64(k+1)*H-ζ)/c
+		if t1 < 0 {
+			break
+		}
+		vup := comp.G(t1, nil)
+		vdn := comp.G(t2, nil)
+		v += sgn * (vup + vdn)
+		dudz += sgn * (vdn - vup) / c
+		sgn = -sgn
+	}
+	return
+}
+
+// motion_key returns the index of component corresponding to an input motion key and whether the
+// key corresponds to a rigid base. idx == -1 means that key is not an input motion key
+func motion_key(key string) (idx int, rigid bool) {
+	switch key {
+	case "ax", "ay", "az":
+		return int(key[1] - 'x'), true
+	case "aix", "aiy", "aiz":
+		return int(key[2] - 'x'), false
+	}
+	return -1, false
+}
+
+// MotionComp: integration and interpolation ////////////////////////////////////////////////////////
+
+// integrate samples the accelerations in [0, tf] and integrates velocities and displacements
+func (o *MotionComp) integrate(dt, tf float64) {
+	n := int(math.Ceil(tf/dt)) + 2
+	o.Dt = dt
+	o.A = make([]float64, n)
+	o.V = make([]float64, n)
+	o.U = make([]float64, n)
+	for k := 0; k < n; k++ {
+		o.A[k] = o.Acc.F(float64(k)*dt, nil)
+	}
+	for k := 0; k < n-1; k++ {
+		Δa := o.A[k+1] - o.A[k]
+		o.V[k+1] = o.V[k] + dt*(o.A[k]+o.A[k+1])/2.0
+		o.U[k+1] = o.U[k] + o.V[k]*dt + o.A[k]*dt*dt/2.0 + Δa*dt*dt/6.0
+	}
+}
+
+// interp returns the grid index k, the time τ from the grid point and the slope of accelerations
+func (o *MotionComp) interp(t float64) (k int, τ, s float64) {
+	k = int(t / o.Dt)
+	if k > len(o.A)-2 {
+		k = len(o.A) - 2
+	}
+	τ = t - float64(k)*o.Dt
+	s = (o.A[k+1] - o.A[k]) / o.Dt
+	return
+}
+
+// Init initialises the function (nothing to be done)
+func (o *MotionComp) Init(prms fun.Prms) (err error) {
+	return
+}
+
+// F returns the displacement at time t
+func (o *MotionComp) F(t float64, x []float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	k, τ, s := o.interp(t)
+	return o.U[k] + o.V[k]*τ + o.A[k]*τ*τ/2.0 + s*τ*τ*τ/6.0
+}
+
+// G returns the velocity at time t
+func (o *MotionComp) G(t float64, x []float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	k, τ, s := o.interp(t)
+	return o.V[k] + o.A[k]*τ + s*τ*τ/2.0
+}
+
+// H returns the acceleration at time t
+func (o *MotionComp) H(t float64, x []float64) float64 {
+	if t < 0 {
+		return 0
+	}
+	k, τ, s := o.interp(t)
+	return o.A[k] + s*τ
+}
+
+// Grad returns the gradient with respect to x (zero)
+func (o *MotionComp) Grad(v []float64, t float64, x []float64) {
+	for i := 0; i < len(v); i++ {
+		v[i] = 0
+	}
+}
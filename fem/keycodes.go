@@ -8,6 +8,7 @@ import (
 	"math"
 
 	"github.com/cpmech/gofem/shp"
+	"github.com/cpmech/gosl/fun"
 	"github.com/cpmech/gosl/io"
 )
 
@@ -63,6 +64,66 @@ func GetSolidFlags(extra string) (useB, debug bool, thickness float64) {
 	return
 }
 
+// GetDynFlags returns the coefficients of Rayleigh damping C = αM・M + βK・K and the kind of mass
+// matrix of solid elements. The coefficients are given by the parameters "aM" and "bK" of the
+// material model or, alternatively, by the target damping ratio "xi" at two frequencies "f1" and
+// "f2" (in Hz), which gives (with ω = 2・π・f)
+//
+//   αM = 2・ξ・ω1・ω2 / (ω1 + ω2)   and   βK = 2・ξ / (ω1 + ω2)
+//
+//  The same keys in the extra data of elements (e.g. "!aM:0.1 !bK:0.001") override the parameters
+//  of the material. The mass matrix is selected with "!mass:" as "consistent" (default), "rowsum"
+//  or "hrz" (Hinton-Rock-Zienkiewicz diagonal scaling). Row-sum lumping is rejected by elements
+//  whose lumped masses are not positive; e.g. tri6, qua8, tet10 and hex20 elements
+func GetDynFlags(prms fun.Prms, extra string) (αM, βK float64, mass string, ok bool) {
+
+	// parameters of material and extra data of element
+	keys := []string{"aM", "bK", "xi", "f1", "f2"}
+	vals := make(map[string]float64)
+	for _, p := range prms {
+		for _, key := range keys {
+			if p.N == key {
+				vals[key] = p.V
+			}
+		}
+	}
+	for _, key := range keys {
+		if val, found := io.Keycode(extra, key); found {
+			vals[key] = io.Atof(val)
+		}
+	}
+
+	// coefficients
+	αM, βK = vals["aM"], vals["bK"]
+	if ξ, found := vals["xi"]; found {
+		if LogErrCond(αM != 0 || βK != 0, "Rayleigh damping must be given by either {aM, bK} or {xi, f1, f2}") {
+			return
+		}
+		ω1, ω2 := 2.0*math.Pi*vals["f1"], 2.0*math.Pi*vals["f2"]
+		if LogErrCond(ω1 <= 0 || ω2 <= 0, "damping ratio xi=%g requires positive frequencies f1 and f2. f1=%g and f2=%g are invalid", ξ, vals["f1"], vals["f2"]) {
+			return
+		}
+		αM = 2.0 * ξ * ω1 * ω2 / (ω1 + ω2)
+		βK = 2.0 * ξ / (ω1 + ω2)
+	}
+	if LogErrCond(αM < 0 || βK < 0, "coefficients of Rayleigh damping must be non-negative. aM=%g and bK=%g are invalid", αM, βK) {
+		return
+	}
+
+	// flag: mass matrix
+	mass = "consistent"
+	if s_mass, found := io.Keycode(extra, "mass"); found {
+		mass = s_mass
+	}
+	switch mass {
+	case "consistent", "rowsum", "hrz":
+	default:
+		LogErrCond(true, "mass matrix %q is invalid; options are \"consistent\", \"rowsum\" and \"hrz\"", mass)
+		return
+	}
+	return αM, βK, mass, true
+}
+
 func GetSeepFaceFlags(extra string) (Macaulay bool, BetRamp, Kappa float64) {
 
 	// defaults
@@ -0,0 +1,611 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/mpi"
+)
+
+// Krylov implements preconditioned iterative linear solvers (Krylov subspace methods); namely:
+// conjugate gradients (cg), restarted GMRES (gmres) and BiCGStab (bicgstab). Krylov satisfies la.LinSol.
+//
+//  Note: the Jacobian matrix is assembled directly into Krylov (see Domain.StartKb) because the
+//        entries of la.Triplet cannot be accessed; thus the triplet given to InitR is ignored.
+//
+//  Preconditioners:
+//    none   -- identity
+//    jacobi -- diagonal of K
+//    ilu0   -- incomplete LU factorisation with zero fill-in
+//    block  -- block diagonal for saddle-point systems such as u-p or Lagrange multipliers:
+//              ILU(0) of Kuu and diagonal approximation of the Schur complement for the second block:
+//                  S_ii = |K_ii| + Σ_j |K_ij K_ji| / |K_jj|  (j in first block)
+//
+//  In distributed runs, each processor holds the contributions of its elements to K and full-length
+//  vectors. Matrix-vector products are joined with AllReduceSum and the ilu0 and block
+//  preconditioners are applied as additive Schwarz (each processor factorises its part of K).
+//  The cg method should only be used with symmetric positive-definite systems; e.g. with
+//  elimination or penalty enforcement of essential boundary conditions.
+type Krylov struct {
+
+	// input
+	Method  string  // "cg", "gmres" or "bicgstab"
+	Precond string  // "none", "jacobi", "ilu0" or "block"
+	Tol     float64 // tolerance for the relative residual |b - A*x| / |b|
+	MaxIt   int     // maximum number of iterations
+	Restart int     // number of iterations before restarting GMRES
+	Verbose bool    // show number of iterations
+
+	// output
+	Nit   int     // number of iterations in the last solution
+	Resid float64 // relative residual in the last solution
+
+	// assembly (triplet)
+	n  int       // number of rows and columns
+	ti []int     // row indices
+	tj []int     // column indices
+	tx []float64 // values
+
+	// compressed-row matrix
+	rp []int     // [n+1] pointers to rows
+	ci []int     // column indices (sorted within each row; diagonal always present)
+	ax []float64 // values
+
+	// preconditioner
+	pblk []bool    // [n] equations in second block; e.g. p-like equations and Lagrange multipliers
+	dinv []float64 // [n] inverse of (global) diagonal for Jacobi
+	ws   []float64 // [n] square root of weights for additive Schwarz (distributed)
+	dg   []int     // [n] indices of diagonal entries in ci
+	lu   []float64 // ILU(0) factors with the same pattern as ax
+
+	// workspace
+	wrk []float64   // [n] workspace for AllReduceSum and preconditioner
+	r   []float64   // [n] residual
+	vs  [][]float64 // auxiliary vectors
+}
+
+// NewKrylov returns a new iterative solver
+func NewKrylov(dat *inp.LinSolData) *Krylov {
+	return &Krylov{
+		Method:  dat.Name,
+		Precond: dat.Precond,
+		Tol:     dat.Tol,
+		MaxIt:   dat.MaxIt,
+		Restart: dat.Restart,
+		Verbose: dat.Verbose,
+	}
+}
+
+// Init allocates the matrix
+//  n   -- number of rows and columns
+//  nnz -- maximum number of non-zero entries (with repetitions)
+func (o *Krylov) Init(n, nnz int) {
+	o.n = n
+	o.ti = make([]int, 0, nnz)
+	o.tj = make([]int, 0, nnz)
+	o.tx = make([]float64, 0, nnz)
+	o.pblk = make([]bool, n)
+	o.wrk = make([]float64, n)
+	o.r = make([]float64, n)
+}
+
+// SetBlock sets the equations in the second block of the block preconditioner
+func (o *Krylov) SetBlock(pblk []bool) {
+	o.pblk = pblk
+}
+
+// Start (re)starts the assembly of the matrix
+func (o *Krylov) Start() {
+	o.ti = o.ti[:0]
+	o.tj = o.tj[:0]
+	o.tx = o.tx[:0]
+}
+
+// Put adds x to the (i,j) component of the matrix
+func (o *Krylov) Put(i, j int, x float64) {
+	o.ti = append(o.ti, i)
+	o.tj = append(o.tj, j)
+	o.tx = append(o.tx, x)
+}
+
+// InitR initialises the solver; the triplet is ignored
+func (o *Krylov) InitR(tR *la.Triplet, symmetric, verbose, timing bool) (err error) {
+	o.Verbose = o.Verbose || verbose
+	return
+}
+
+// InitC initialises the solver for complex systems; not available
+func (o *Krylov) InitC(tC *la.TripletC, symmetric, verbose, timing bool) (err error) {
+	return chk.Err("Krylov: complex systems are not available")
+}
+
+// SetOrdScal sets the ordering and scaling schemes; not used by Krylov
+func (o *Krylov) SetOrdScal(ordering, scaling string) (err error) {
+	return
+}
+
+// Clean deletes temporary data structures
+func (o *Krylov) Clean() {
+}
+
+// Fact compresses the matrix and computes the preconditioner
+func (o *Krylov) Fact() (err error) {
+
+	// compressed-row matrix
+	o.compress()
+
+	// weights for additive Schwarz
+	o.ws = nil
+	if Global.Distr && (o.Precond == "ilu0" || o.Precond == "block") {
+		o.ws = make([]float64, o.n)
+		cnt := make([]float64, o.n) // number of processors sharing each equation
+		for _, i := range o.ti {
+			o.ws[i] = 1
+			cnt[i] = 1
+		}
+		mpi.AllReduceSum(cnt, o.wrk)
+		for i := 0; i < o.n; i++ {
+			if o.ws[i] > 0 {
+				o.ws[i] = 1.0 / math.Sqrt(cnt[i])
+			}
+		}
+	}
+
+	// preconditioner
+	switch o.Precond {
+	case "jacobi":
+		o.dinv = make([]float64, o.n)
+		for i := 0; i < o.n; i++ {
+			o.dinv[i] = o.ax[o.dg[i]]
+		}
+		if Global.Distr {
+			mpi.AllReduceSum(o.dinv, o.wrk)
+		}
+		for i := 0; i < o.n; i++ {
+			if o.dinv[i] == 0 {
+				o.dinv[i] = 1
+			}
+			o.dinv[i] = 1.0 / o.dinv[i]
+		}
+	case "ilu0":
+		o.lu = make([]float64, len(o.ax))
+		copy(o.lu, o.ax)
+		o.ilu0()
+	case "block":
+		o.lu = make([]float64, len(o.ax))
+		for i := 0; i < o.n; i++ {
+			if o.pblk[i] {
+				o.lu[o.dg[i]] = o.schur(i)
+				continue
+			}
+			for k := o.rp[i]; k < o.rp[i+1]; k++ {
+				if !o.pblk[o.ci[k]] {
+					o.lu[k] = o.ax[k]
+				}
+			}
+		}
+		o.ilu0()
+	}
+	return
+}
+
+// SolveR solves the linear system A * x = b
+func (o *Krylov) SolveR(x, b []float64, sum_b_to_root bool) (err error) {
+	if sum_b_to_root && Global.Distr {
+		mpi.AllReduceSum(b, o.wrk)
+	}
+	la.VecFill(x, 0)
+	o.Nit, o.Resid = 0, 0
+	bnorm := la.VecNorm(b)
+	if bnorm == 0 {
+		return
+	}
+	switch o.Method {
+	case "cg":
+		o.cg(x, b, bnorm)
+	case "gmres":
+		o.gmres(x, b, bnorm)
+	case "bicgstab":
+		o.bicgstab(x, b, bnorm)
+	default:
+		return chk.Err("Krylov: method %q is not available", o.Method)
+	}
+	if o.Verbose && Global.Root {
+		io.Pf("%s(%s): nit = %d  |r|/|b| = %g\n", o.Method, o.Precond, o.Nit, o.Resid)
+	}
+	if o.Resid > o.Tol {
+		return chk.Err("Krylov: %s did not converge after %d iterations. |r|/|b| = %g > %g", o.Method, o.Nit, o.Resid, o.Tol)
+	}
+	return
+}
+
+// SolveC solves complex systems; not available
+func (o *Krylov) SolveC(xR, xC, bR, bC []float64, sum_b_to_root bool) (err error) {
+	return chk.Err("Krylov: complex systems are not available")
+}
+
+// methods //////////////////////////////////////////////////////////////////////////////////////////
+
+// cg implements the preconditioned conjugate gradients method
+func (o *Krylov) cg(x, b []float64, bnorm float64) {
+	z, p, q := o.work3()
+	copy(o.r, b)
+	o.psolve(z, o.r)
+	copy(p, z)
+	rz := la.VecDot(o.r, z)
+	for o.Nit < o.MaxIt {
+		o.Nit++
+		o.matvec(q, p)
+		α := rz / la.VecDot(p, q)
+		for i := 0; i < o.n; i++ {
+			x[i] += α * p[i]
+			o.r[i] -= α * q[i]
+		}
+		o.Resid = la.VecNorm(o.r) / bnorm
+		if o.Resid <= o.Tol {
+			return
+		}
+		o.psolve(z, o.r)
+		rznew := la.VecDot(o.r, z)
+		β := rznew / rz
+		rz = rznew
+		for i := 0; i < o.n; i++ {
+			p[i] = z[i] + β*p[i]
+		}
+	}
+}
+
+// bicgstab implements the right-preconditioned BiCGStab method
+func (o *Krylov) bicgstab(x, b []float64, bnorm float64) {
+	r0, p, v, ph, s, sh, t := o.work7()
+	copy(o.r, b)
+	copy(r0, b)
+	ρ, α, ω := 1.0, 1.0, 1.0
+	la.VecFill(p, 0)
+	la.VecFill(v, 0)
+	for o.Nit < o.MaxIt {
+		o.Nit++
+		ρnew := la.VecDot(r0, o.r)
+		if ρnew == 0 { // breakdown
+			o.Resid = la.VecNorm(o.r) / bnorm
+			return
+		}
+		β := (ρnew / ρ) * (α / ω)
+		ρ = ρnew
+		for i := 0; i < o.n; i++ {
+			p[i] = o.r[i] + β*(p[i]-ω*v[i])
+		}
+		o.psolve(ph, p)
+		o.matvec(v, ph)
+		α = ρ / la.VecDot(r0, v)
+		for i := 0; i < o.n; i++ {
+			s[i] = o.r[i] - α*v[i]
+		}
+		o.Resid = la.VecNorm(s) / bnorm
+		if o.Resid <= o.Tol {
+			for i := 0; i < o.n; i++ {
+				x[i] += α * ph[i]
+			}
+			return
+		}
+		o.psolve(sh, s)
+		o.matvec(t, sh)
+		tt := la.VecDot(t, t)
+		ω = 0
+		if tt > 0 {
+			ω = la.VecDot(t, s) / tt
+		}
+		for i := 0; i < o.n; i++ {
+			x[i] += α*ph[i] + ω*sh[i]
+			o.r[i] = s[i] - ω*t[i]
+		}
+		o.Resid = la.VecNorm(o.r) / bnorm
+		if o.Resid <= o.Tol || ω == 0 {
+			return
+		}
+	}
+}
+
+// gmres implements the right-preconditioned and restarted GMRES method
+func (o *Krylov) gmres(x, b []float64, bnorm float64) {
+
+	// workspace
+	m := o.Restart
+	if len(o.vs) != 2*m+1 || len(o.vs[0]) != o.n {
+		o.vs = make([][]float64, 2*m+1)
+		for i := 0; i < len(o.vs); i++ {
+			o.vs[i] = make([]float64, o.n)
+		}
+	}
+	V, Z := o.vs[:m+1], o.vs[m+1:]
+	H := la.MatAlloc(m+1, m)
+	cs := make([]float64, m)
+	sn := make([]float64, m)
+	g := make([]float64, m+1)
+	y := make([]float64, m)
+
+	// outer iterations
+	for {
+
+		// residual
+		o.matvec(o.r, x)
+		for i := 0; i < o.n; i++ {
+			o.r[i] = b[i] - o.r[i]
+		}
+		β := la.VecNorm(o.r)
+		o.Resid = β / bnorm
+		if o.Resid <= o.Tol || o.Nit >= o.MaxIt {
+			return
+		}
+		for i := 0; i < o.n; i++ {
+			V[0][i] = o.r[i] / β
+		}
+		la.VecFill(g, 0)
+		g[0] = β
+
+		// Arnoldi process
+		k := 0
+		for j := 0; j < m && o.Nit < o.MaxIt; j++ {
+			o.Nit++
+			k = j + 1
+			o.psolve(Z[j], V[j])
+			w := V[j+1]
+			o.matvec(w, Z[j])
+			for i := 0; i <= j; i++ {
+				H[i][j] = la.VecDot(w, V[i])
+				for l := 0; l < o.n; l++ {
+					w[l] -= H[i][j] * V[i][l]
+				}
+			}
+			H[j+1][j] = la.VecNorm(w)
+			if H[j+1][j] > 0 {
+				for l := 0; l < o.n; l++ {
+					w[l] /= H[j+1][j]
+				}
+			}
+
+			// Givens rotations
+			for i := 0; i < j; i++ {
+				h := cs[i]*H[i][j] + sn[i]*H[i+1][j]
+				H[i+1][j] = -sn[i]*H[i][j] + cs[i]*H[i+1][j]
+				H[i][j] = h
+			}
+			den := math.Hypot(H[j][j], H[j+1][j])
+			cs[j], sn[j] = 1, 0
+			if den > 0 {
+				cs[j], sn[j] = H[j][j]/den, H[j+1][j]/den
+			}
+			H[j][j] = cs[j]*H[j][j] + sn[j]*H[j+1][j]
+			H[j+1][j] = 0
+			g[j+1] = -sn[j] * g[j]
+			g[j] = cs[j] * g[j]
+			if math.Abs(g[j+1])/bnorm <= o.Tol {
+				break
+			}
+		}
+
+		// update x
+		for i := k - 1; i >= 0; i-- {
+			y[i] = g[i]
+			for l := i + 1; l < k; l++ {
+				y[i] -= H[i][l] * y[l]
+			}
+			if H[i][i] != 0 {
+				y[i] /= H[i][i]
+			}
+		}
+		for i := 0; i < k; i++ {
+			for l := 0; l < o.n; l++ {
+				x[l] += y[i] * Z[i][l]
+			}
+		}
+	}
+}
+
+// auxiliary ///////////////////////////////////////////////////////////////////////////////////////
+
+// matvec computes y = A * x
+func (o *Krylov) matvec(y, x []float64) {
+	for i := 0; i < o.n; i++ {
+		y[i] = 0
+		for k := o.rp[i]; k < o.rp[i+1]; k++ {
+			y[i] += o.ax[k] * x[o.ci[k]]
+		}
+	}
+	if Global.Distr {
+		mpi.AllReduceSum(y, o.wrk)
+	}
+}
+
+// psolve applies the preconditioner; i.e. z = M⁻¹ * r
+func (o *Krylov) psolve(z, r []float64) {
+	switch o.Precond {
+	case "jacobi":
+		for i := 0; i < o.n; i++ {
+			z[i] = o.dinv[i] * r[i]
+		}
+	case "ilu0", "block":
+		if o.ws == nil {
+			o.lusolve(z, r)
+			return
+		}
+		for i := 0; i < o.n; i++ {
+			o.wrk[i] = o.ws[i] * r[i]
+		}
+		o.lusolve(z, o.wrk)
+		for i := 0; i < o.n; i++ {
+			z[i] *= o.ws[i]
+		}
+		mpi.AllReduceSum(z, o.wrk)
+	default:
+		copy(z, r)
+	}
+}
+
+// compress converts the triplet into the compressed-row format, summing duplicates
+func (o *Krylov) compress() {
+
+	// count entries per row, including diagonal
+	n := o.n
+	o.rp = make([]int, n+1)
+	for i := 0; i < n; i++ {
+		o.rp[i+1] = 1
+	}
+	for _, i := range o.ti {
+		o.rp[i+1]++
+	}
+	for i := 0; i < n; i++ {
+		o.rp[i+1] += o.rp[i]
+	}
+
+	// scatter
+	ci := make([]int, o.rp[n])
+	ax := make([]float64, o.rp[n])
+	pos := make([]int, n)
+	for i := 0; i < n; i++ {
+		pos[i] = o.rp[i] + 1
+		ci[o.rp[i]] = i
+	}
+	for k, i := range o.ti {
+		ci[pos[i]] = o.tj[k]
+		ax[pos[i]] = o.tx[k]
+		pos[i]++
+	}
+
+	// sort columns and sum duplicates
+	o.ci = make([]int, 0, len(ci))
+	o.ax = make([]float64, 0, len(ax))
+	o.dg = make([]int, n)
+	for i := 0; i < n; i++ {
+		a, b := o.rp[i], o.rp[i+1]
+		sort.Sort(csrow{ci[a:b], ax[a:b]})
+		o.rp[i] = len(o.ci)
+		for k := a; k < b; k++ {
+			if k > a && ci[k] == ci[k-1] {
+				o.ax[len(o.ax)-1] += ax[k]
+				continue
+			}
+			if ci[k] == i {
+				o.dg[i] = len(o.ci)
+			}
+			o.ci = append(o.ci, ci[k])
+			o.ax = append(o.ax, ax[k])
+		}
+	}
+	o.rp[n] = len(o.ci)
+}
+
+// schur returns the diagonal approximation of the Schur complement for equation i in the second block
+func (o *Krylov) schur(i int) (res float64) {
+	res = math.Abs(o.ax[o.dg[i]])
+	for k := o.rp[i]; k < o.rp[i+1]; k++ {
+		j := o.ci[k]
+		if o.pblk[j] {
+			continue
+		}
+		kjj := math.Abs(o.ax[o.dg[j]])
+		if kjj > 0 {
+			res += math.Abs(o.ax[k]*o.get(j, i)) / kjj
+		}
+	}
+	if res == 0 {
+		res = 1
+	}
+	return
+}
+
+// get returns the (i,j) component of the compressed matrix
+func (o *Krylov) get(i, j int) float64 {
+	a, b := o.rp[i], o.rp[i+1]
+	k := a + sort.SearchInts(o.ci[a:b], j)
+	if k < b && o.ci[k] == j {
+		return o.ax[k]
+	}
+	return 0
+}
+
+// ilu0 computes the incomplete LU factorisation with zero fill-in of the matrix in lu
+//  Note: zero pivots are replaced by one
+func (o *Krylov) ilu0() {
+	pos := make([]int, o.n)
+	for i := 0; i < o.n; i++ {
+		pos[i] = -1
+	}
+	for i := 0; i < o.n; i++ {
+		for k := o.rp[i]; k < o.rp[i+1]; k++ {
+			pos[o.ci[k]] = k
+		}
+		for k := o.rp[i]; k < o.dg[i]; k++ {
+			c := o.ci[k]
+			o.lu[k] /= o.lu[o.dg[c]]
+			for l := o.dg[c] + 1; l < o.rp[c+1]; l++ {
+				if p := pos[o.ci[l]]; p >= 0 {
+					o.lu[p] -= o.lu[k] * o.lu[l]
+				}
+			}
+		}
+		if o.lu[o.dg[i]] == 0 {
+			o.lu[o.dg[i]] = 1
+		}
+		for k := o.rp[i]; k < o.rp[i+1]; k++ {
+			pos[o.ci[k]] = -1
+		}
+	}
+}
+
+// lusolve solves (L*U) * z = r with the ILU(0) factors
+func (o *Krylov) lusolve(z, r []float64) {
+	for i := 0; i < o.n; i++ {
+		z[i] = r[i]
+		for k := o.rp[i]; k < o.dg[i]; k++ {
+			z[i] -= o.lu[k] * z[o.ci[k]]
+		}
+	}
+	for i := o.n - 1; i >= 0; i-- {
+		for k := o.dg[i] + 1; k < o.rp[i+1]; k++ {
+			z[i] -= o.lu[k] * z[o.ci[k]]
+		}
+		z[i] /= o.lu[o.dg[i]]
+	}
+}
+
+// work3 returns three auxiliary vectors
+func (o *Krylov) work3() (a, b, c []float64) {
+	o.alloc(3)
+	return o.vs[0], o.vs[1], o.vs[2]
+}
+
+// work7 returns seven auxiliary vectors
+func (o *Krylov) work7() (a, b, c, d, e, f, g []float64) {
+	o.alloc(7)
+	return o.vs[0], o.vs[1], o.vs[2], o.vs[3], o.vs[4], o.vs[5], o.vs[6]
+}
+
+// alloc allocates at least nv auxiliary vectors
+func (o *Krylov) alloc(nv int) {
+	if len(o.vs) >= nv && len(o.vs[0]) == o.n {
+		return
+	}
+	o.vs = make([][]float64, nv)
+	for i := 0; i < nv; i++ {
+		o.vs[i] = make([]float64, o.n)
+	}
+}
+
+// csrow sorts the columns of a compressed row
+type csrow struct {
+	c []int
+	x []float64
+}
+
+func (o csrow) Len() int           { return len(o.c) }
+func (o csrow) Swap(i, j int)      { o.c[i], o.c[j] = o.c[j], o.c[i]; o.x[i], o.x[j] = o.x[j], o.x[i] }
+func (o csrow) Less(i, j int) bool { return o.c[i] < o.c[j] }
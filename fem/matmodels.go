@@ -5,6 +5,7 @@
 package fem
 
 import (
+	"github.com/cpmech/gofem/inp"
 	"github.com/cpmech/gofem/mconduct"
 	"github.com/cpmech/gofem/mporous"
 	"github.com/cpmech/gofem/mreten"
@@ -17,6 +18,11 @@ import (
 // GetAndInitPorousModel get porous model from material name
 // It returns nil on errors, after logging
 func GetAndInitPorousModel(matname string) *mporous.Model {
+	return get_and_init_porous_model(matname, false)
+}
+
+// get_and_init_porous_model gets porous model; getnew indicates that new (not shared) models must be allocated
+func get_and_init_porous_model(matname string, getnew bool) *mporous.Model {
 
 	// materials
 	cndmat, lrmmat, pormat, err := Global.Sim.Mdb.GroupGet3(matname, "c", "l", "p")
@@ -26,7 +32,6 @@ func GetAndInitPorousModel(matname string) *mporous.Model {
 
 	// conductivity models
 	simfnk := Global.Sim.Data.FnameKey
-	getnew := false
 	cnd := mconduct.GetModel(simfnk, cndmat.Name, cndmat.Model, getnew)
 	if LogErrCond(cnd == nil, "cannot allocate conductivity models with name=%q", cndmat.Model) {
 		return nil
@@ -60,32 +65,24 @@ func GetAndInitPorousModel(matname string) *mporous.Model {
 	return mdl
 }
 
+// GetAndInitSolidModel gets solid model from material name
+// It returns nil on errors, after logging
 func GetAndInitSolidModel(matname string, ndim int) (msolid.Model, fun.Prms) {
+	return get_and_init_solid_model(matname, ndim, false)
+}
 
-	// material name
-	matdata := Global.Sim.Mdb.Get(matname)
-	if LogErrCond(matdata == nil, "materials database failed on getting %q (solid) material\n", matname) {
-		return nil, nil
-	}
-	mdlname := matdata.Model
+// get_and_init_solid_model gets solid model; getnew indicates that a new (not shared) model must be allocated
+func get_and_init_solid_model(matname string, ndim int, getnew bool) (msolid.Model, fun.Prms) {
 
-	// handle groups
-	if mdlname == "group" {
-		if s_matname, found := io.Keycode(matdata.Extra, "s"); found {
-			matname = s_matname
-			matdata = Global.Sim.Mdb.Get(matname)
-			if LogErrCond(matdata == nil, "materials database failed on getting %q (solid/sub) material\n", matname) {
-				return nil, nil
-			}
-			mdlname = matdata.Model
-		} else {
-			LogErrCond(true, "cannot find solid model in grouped material data. 's' subkey needed in Extra field")
-			return nil, nil
-		}
+	// material data
+	matdata := solid_material(matname)
+	if matdata == nil {
+		return nil, nil
 	}
+	matname, mdlname := matdata.Name, matdata.Model
 
 	// initialise model
-	mdl, existent := msolid.GetModel(Global.Sim.Data.FnameKey, matname, mdlname, false)
+	mdl, existent := msolid.GetModel(Global.Sim.Data.FnameKey, matname, mdlname, getnew)
 	if LogErrCond(mdl == nil, "cannot find solid model named %q", mdlname) {
 		return nil, nil
 	}
@@ -98,3 +95,28 @@ func GetAndInitSolidModel(matname string, ndim int) (msolid.Model, fun.Prms) {
 	// results
 	return mdl, matdata.Prms
 }
+
+// solid_material returns the material data of solid; handling groups with an "s" subkey
+//  Note: returns nil on errors
+func solid_material(matname string) *inp.Material {
+
+	// material data
+	matdata := Global.Sim.Mdb.Get(matname)
+	if LogErrCond(matdata == nil, "materials database failed on getting %q (solid) material\n", matname) {
+		return nil
+	}
+
+	// handle groups
+	if matdata.Model == "group" {
+		if s_matname, found := io.Keycode(matdata.Extra, "s"); found {
+			matdata = Global.Sim.Mdb.Get(s_matname)
+			if LogErrCond(matdata == nil, "materials database failed on getting %q (solid/sub) material\n", s_matname) {
+				return nil
+			}
+		} else {
+			LogErrCond(true, "cannot find solid model in grouped material data. 's' subkey needed in Extra field")
+			return nil
+		}
+	}
+	return matdata
+}
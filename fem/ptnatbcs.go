@@ -32,9 +32,10 @@ func (o *PtNaturalBcs) Reset() {
 }
 
 // AddToRhs adds the boundary conditions terms to the augmented fb vector
-func (o PtNaturalBcs) AddToRhs(fb []float64, t float64) {
+//  Note: the prescribed values are multiplied by the load factor in sol
+func (o PtNaturalBcs) AddToRhs(fb []float64, sol *Solution) {
 	for _, p := range o.Bcs {
-		fb[p.Eq] += p.Fcn.F(t, p.X)
+		fb[p.Eq] += p.Fcn.F(sol.T, p.X) * sol.LoadFac
 	}
 }
 
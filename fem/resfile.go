@@ -0,0 +1,265 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"math"
+	"os"
+	"path"
+
+	"github.com/cpmech/gosl/io"
+)
+
+// constants
+const (
+	RES_MAGIC = "GOFEMRES" // magic string at the beginning and at the end of results files
+	RES_NHDR  = 8          // size of header: magic string
+	RES_NFTR  = 16         // size of footer: index offset (int64) + magic string
+)
+
+// ResIndex holds the index of a results file
+type ResIndex struct {
+	Tidx   []int                 // [nout] output indices
+	Times  []float64             // [nout] output times
+	Chunks []map[string][2]int64 // [nout] field => {offset, size} in bytes
+}
+
+// ResFile implements a single-file container of results with random access by output index
+// (tidx) and field. Each processor writes its own file with the following layout:
+//   [magic] [chunk] [chunk] ... [index] [index offset] [magic]
+// where the (gob) index holds, for each tidx, the output time and the offset and size of each
+// chunk. The root processor saves the "Y", "Dydt" and "D2ydt2" fields (float64 arrays) and all
+// processors save the "ivs" field (encoded internal values). The index is rewritten after each
+// output; thus the file is consistent even if the simulation is interrupted.
+type ResFile struct {
+	Fnpath string   // file path
+	Index  ResIndex // index
+}
+
+// OpenResFile opens a results file and reads its index
+//  create -- an empty index is returned if the file does not exist (for writing)
+//  Note: returns nil on errors
+func OpenResFile(fnpath string, create bool) (o *ResFile) {
+
+	// new results file
+	o = &ResFile{Fnpath: fnpath}
+	fil, err := os.Open(fnpath)
+	if os.IsNotExist(err) && create {
+		return
+	}
+	if LogErr(err, "OpenResFile") {
+		return nil
+	}
+	defer func() {
+		LogErr(fil.Close(), "OpenResFile: cannot close file")
+	}()
+
+	// footer
+	stat, err := fil.Stat()
+	if LogErr(err, "OpenResFile") {
+		return nil
+	}
+	if LogErrCond(stat.Size() < RES_NHDR+RES_NFTR, "OpenResFile: file %q is too small", fnpath) {
+		return nil
+	}
+	ftr := make([]byte, RES_NFTR)
+	_, err = fil.ReadAt(ftr, stat.Size()-RES_NFTR)
+	if LogErr(err, "OpenResFile: cannot read footer") {
+		return nil
+	}
+	if LogErrCond(string(ftr[8:]) != RES_MAGIC, "OpenResFile: file %q is not a results file", fnpath) {
+		return nil
+	}
+
+	// index
+	idxpos := int64(binary.LittleEndian.Uint64(ftr[:8]))
+	if LogErrCond(idxpos < RES_NHDR || idxpos > stat.Size()-RES_NFTR, "OpenResFile: index of file %q is corrupted", fnpath) {
+		return nil
+	}
+	b := make([]byte, stat.Size()-RES_NFTR-idxpos)
+	_, err = fil.ReadAt(b, idxpos)
+	if LogErr(err, "OpenResFile: cannot read index") {
+		return nil
+	}
+	if LogErr(gob.NewDecoder(bytes.NewReader(b)).Decode(&o.Index), "OpenResFile: cannot decode index") {
+		return nil
+	}
+	return
+}
+
+// Append writes chunks corresponding to an output index and updates the index
+//  Note: existent entries with output indices greater than or equal to tidx are discarded;
+//        e.g. when restarting a simulation
+func (o *ResFile) Append(tidx int, t float64, fields []string, chunks [][]byte) (ok bool) {
+
+	// discard entries and find end of data
+	end := int64(RES_NHDR)
+	n := 0
+	for n < len(o.Index.Tidx) && o.Index.Tidx[n] < tidx {
+		for _, c := range o.Index.Chunks[n] {
+			if c[0]+c[1] > end {
+				end = c[0] + c[1]
+			}
+		}
+		n++
+	}
+	o.Index.Tidx = o.Index.Tidx[:n]
+	o.Index.Times = o.Index.Times[:n]
+	o.Index.Chunks = o.Index.Chunks[:n]
+
+	// open file
+	fil, err := os.OpenFile(o.Fnpath, os.O_RDWR|os.O_CREATE, 0644)
+	if LogErr(err, "ResFile.Append") {
+		return
+	}
+	defer func() {
+		LogErr(fil.Close(), "ResFile.Append: cannot close file")
+	}()
+
+	// header
+	if n == 0 {
+		_, err = fil.WriteAt([]byte(RES_MAGIC), 0)
+		if LogErr(err, "ResFile.Append: cannot write header") {
+			return
+		}
+	}
+
+	// chunks
+	entry := make(map[string][2]int64)
+	for i, field := range fields {
+		_, err = fil.WriteAt(chunks[i], end)
+		if LogErr(err, "ResFile.Append: cannot write chunk") {
+			return
+		}
+		entry[field] = [2]int64{end, int64(len(chunks[i]))}
+		end += int64(len(chunks[i]))
+	}
+	o.Index.Tidx = append(o.Index.Tidx, tidx)
+	o.Index.Times = append(o.Index.Times, t)
+	o.Index.Chunks = append(o.Index.Chunks, entry)
+
+	// index and footer
+	var buf bytes.Buffer
+	if LogErr(gob.NewEncoder(&buf).Encode(o.Index), "ResFile.Append: cannot encode index") {
+		return
+	}
+	ftr := make([]byte, RES_NFTR)
+	binary.LittleEndian.PutUint64(ftr[:8], uint64(end))
+	copy(ftr[8:], RES_MAGIC)
+	buf.Write(ftr)
+	_, err = fil.WriteAt(buf.Bytes(), end)
+	if LogErr(err, "ResFile.Append: cannot write index") {
+		return
+	}
+	return !LogErr(fil.Truncate(end+int64(buf.Len())), "ResFile.Append: cannot truncate file")
+}
+
+// Pos returns the position of tidx in index or -1 if not found
+func (o *ResFile) Pos(tidx int) int {
+	for i, k := range o.Index.Tidx {
+		if k == tidx {
+			return i
+		}
+	}
+	return -1
+}
+
+// Read reads a chunk
+func (o *ResFile) Read(tidx int, field string) (b []byte, ok bool) {
+	c, ok := o.chunk(tidx, field)
+	if !ok {
+		return
+	}
+	fil, err := os.Open(o.Fnpath)
+	if LogErr(err, "ResFile.Read") {
+		return nil, false
+	}
+	defer func() {
+		LogErr(fil.Close(), "ResFile.Read: cannot close file")
+	}()
+	b = make([]byte, c[1])
+	_, err = fil.ReadAt(b, c[0])
+	if LogErr(err, "ResFile.Read: cannot read chunk") {
+		return nil, false
+	}
+	return b, true
+}
+
+// ReadFloats reads selected components of a float64 field without reading the whole chunk
+//  idx -- indices of components; e.g. equation numbers
+func (o *ResFile) ReadFloats(tidx int, field string, idx []int) (vals []float64, ok bool) {
+	c, ok := o.chunk(tidx, field)
+	if !ok {
+		return
+	}
+	fil, err := os.Open(o.Fnpath)
+	if LogErr(err, "ResFile.ReadFloats") {
+		return nil, false
+	}
+	defer func() {
+		LogErr(fil.Close(), "ResFile.ReadFloats: cannot close file")
+	}()
+	vals = make([]float64, len(idx))
+	b := make([]byte, 8)
+	for i, k := range idx {
+		if LogErrCond(k < 0 || int64(8*k+8) > c[1], "ResFile.ReadFloats: index %d of field %q is out of range", k, field) {
+			return nil, false
+		}
+		_, err = fil.ReadAt(b, c[0]+int64(8*k))
+		if LogErr(err, "ResFile.ReadFloats: cannot read value") {
+			return nil, false
+		}
+		vals[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	return vals, true
+}
+
+// Nfloats returns the number of float64 values in a field
+func (o *ResFile) Nfloats(tidx int, field string) int {
+	c, ok := o.chunk(tidx, field)
+	if !ok {
+		return -1
+	}
+	return int(c[1] / 8)
+}
+
+// chunk returns the offset and size of a chunk
+func (o *ResFile) chunk(tidx int, field string) (c [2]int64, ok bool) {
+	pos := o.Pos(tidx)
+	if LogErrCond(pos < 0, "ResFile: cannot find output index %d in file %q", tidx, o.Fnpath) {
+		return
+	}
+	c, ok = o.Index.Chunks[pos][field]
+	LogErrCond(!ok, "ResFile: cannot find field %q of output index %d in file %q", field, tidx, o.Fnpath)
+	return
+}
+
+// ResFilePath returns the path of the results file of a processor
+func ResFilePath(dir, fnkey string, proc int) string {
+	return path.Join(dir, io.Sf("%s_p%d.res", fnkey, proc))
+}
+
+// auxiliary ///////////////////////////////////////////////////////////////////////////////////////
+
+// floats2bytes converts float64 values to little-endian bytes
+func floats2bytes(v []float64) []byte {
+	b := make([]byte, 8*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(x))
+	}
+	return b
+}
+
+// bytes2floats converts little-endian bytes to float64 values
+func bytes2floats(b []byte) []float64 {
+	v := make([]float64, len(b)/8)
+	for i := range v {
+		v[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return v
+}
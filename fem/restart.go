@@ -0,0 +1,49 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+// restart_summary reads the summary of a previous run of this simulation and truncates it at the
+// output index tidx in order to restart the simulation from this index
+//  Output:
+//   sum    -- summary to continue recording outputs
+//   stgidx -- index of stage corresponding to tidx
+func restart_summary(sum *Summary, tidx int) (stgidx int, ok bool) {
+
+	// read summary of previous run
+	prev := ReadSum(Global.Dirout, Global.Fnkey)
+	if LogErrCond(prev == nil, "restart: cannot read summary of previous run of %s", Global.Fnkey) {
+		return
+	}
+
+	// check
+	if LogErrCond(len(prev.StgTidx) == 0, "restart: summary of previous run does not contain stage indices") {
+		return
+	}
+	if LogErrCond(tidx >= len(prev.OutTimes), "restart: output index %d is not available. last index is %d", tidx, len(prev.OutTimes)-1) {
+		return
+	}
+	if LogErrCond(len(prev.OutSizes) != len(prev.OutTimes), "restart: summary of previous run does not contain the number of steps at each output") {
+		return
+	}
+	if LogErrCond(prev.Nproc != Global.Nproc, "restart: number of processors must be equal to the one in previous run. %d != %d", Global.Nproc, prev.Nproc) {
+		return
+	}
+
+	// find stage
+	for i, idx := range prev.StgTidx {
+		if idx <= tidx {
+			stgidx = i
+		}
+	}
+	if LogErrCond(stgidx >= len(Global.Sim.Stages), "restart: stage %d of previous run does not exist in simulation file", stgidx) {
+		return
+	}
+
+	// truncate summary
+	*sum = *prev
+	sum.Truncate(tidx)
+	sum.StgTidx = sum.StgTidx[:stgidx+1]
+	return stgidx, true
+}
@@ -110,7 +110,7 @@ func (o *RichardsonExtrap) Run(d *Domain, s *Summary, DtOut fun.Func, time *floa
 
 		// single step with Δt
 		d.Sol.T = t + o.Δt
-		o.diverging, ok = run_iterations(t+o.Δt, o.Δt, d, s)
+		o.diverging, _, ok = run_iterations(t+o.Δt, o.Δt, d, s)
 		if !ok {
 			return
 		}
@@ -130,7 +130,7 @@ func (o *RichardsonExtrap) Run(d *Domain, s *Summary, DtOut fun.Func, time *floa
 
 		// 1st halved step
 		d.Sol.T = t + o.Δt/2.0
-		o.diverging, ok = run_iterations(t+o.Δt/2.0, o.Δt/2.0, d, s)
+		o.diverging, _, ok = run_iterations(t+o.Δt/2.0, o.Δt/2.0, d, s)
 		if !ok {
 			break
 		}
@@ -142,7 +142,7 @@ func (o *RichardsonExtrap) Run(d *Domain, s *Summary, DtOut fun.Func, time *floa
 
 		// 2nd halved step
 		d.Sol.T = t + o.Δt
-		o.diverging, ok = run_iterations(t+o.Δt, o.Δt/2.0, d, s)
+		o.diverging, _, ok = run_iterations(t+o.Δt, o.Δt/2.0, d, s)
 		if !ok {
 			break
 		}
@@ -175,10 +175,13 @@ func (o *RichardsonExtrap) Run(d *Domain, s *Summary, DtOut fun.Func, time *floa
 			}
 			//if true {
 			if t >= tout || o.laststep {
-				s.OutTimes = append(s.OutTimes, t)
+				s.AddOutTime(t)
 				if !d.Out(*tidx) {
 					return
 				}
+				if !s.Save() {
+					return
+				}
 				tout += DtOut.F(t, nil)
 				*tidx += 1
 			}
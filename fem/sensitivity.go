@@ -0,0 +1,310 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"bytes"
+	"math"
+	"strings"
+
+	"github.com/cpmech/gofem/mporous"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/mpi"
+)
+
+// SensKey returns the key of the sensitivity of a y-variable w.r.t the idx-th parameter in Data.Sens
+//  Example: "ux" and {"prm":"E"} => "dux/dE"
+func SensKey(ykey string, idx int) string {
+	return "d" + ykey + "/d" + Global.Sim.Data.Sens[idx].Key
+}
+
+// SensField returns the name of the field holding the idx-th sensitivity vector in results files
+func SensField(idx int) string {
+	return io.Sf("dY/d%s", Global.Sim.Data.Sens[idx].Key)
+}
+
+// Sensitivities computes the derivatives of the converged solution w.r.t the material parameters
+// listed in Data.Sens by means of the direct differentiation method. The results go to Sol.Sens.
+// This function must be called after each converged time step.
+//
+//  Since fb(y(p), p) = 0 at equilibrium and ∂fb/∂y = -Kb:
+//
+//      Kb * dy/dp = ∂fb/∂p
+//
+//  where Kb is assembled and factorised at the converged state (the factorisation of the last
+//  iteration corresponds to a previous y or to a constant tangent) and ∂fb/∂p is computed with y
+//  fixed by re-running the update of internal variables from the beginning of the time step with
+//  p + h.
+//  The history is taken into account by carrying a perturbed state for each parameter: the
+//  update starts from the perturbed internal variables at the beginning of the time step and
+//  y + h・dy/dp (and the corresponding starred variables) of the previous step. After solving for
+//  dy/dp, the perturbed internal variables are updated to y + h・dy/dp and stored for the next step.
+func (o *Domain) Sensitivities() (ok bool) {
+
+	// residuals at converged state
+	fb0 := make([]float64, o.Nyb)
+	if !o.elems_add_to_rhs(fb0) {
+		return
+	}
+
+	// Jacobian at converged state
+	if !o.sens_factorise() {
+		return
+	}
+
+	// backup converged solution and internal variables (including states of contact pairs)
+	var conv bytes.Buffer
+	if !o.encode_ivs(&conv) {
+		return
+	}
+	o.backup()
+	defer func() {
+		o.restore()
+		if !o.decode_ivs(&conv) {
+			ok = false
+		}
+		if !Global.Sim.Data.Steady {
+			for _, e := range o.Elems {
+				e.InterpStarVars(o.Sol)
+			}
+		}
+	}()
+
+	// perturbed states at the beginning of the first time step
+	nsens := len(Global.Sim.Data.Sens)
+	if o.sensIvs == nil {
+		for _, e := range o.ElemIntvars {
+			e.RestoreIvs(false)
+		}
+		var buf bytes.Buffer
+		if !o.encode_ivs(&buf) {
+			return
+		}
+		o.sensIvs = make([][]byte, nsens)
+		for k := 0; k < nsens; k++ {
+			o.sensIvs[k] = buf.Bytes()
+		}
+		if !Global.Sim.Data.Steady {
+			o.sensDydt = la.MatAlloc(nsens, o.Ny)
+			o.sensD2ydt2 = la.MatAlloc(nsens, o.Ny)
+		}
+	}
+
+	// for each parameter
+	dydp0 := make([]float64, o.Ny)
+	for k, sen := range Global.Sim.Data.Sens {
+
+		// perturb parameter
+		prm := Global.Sim.Mdb.Get(sen.Mat).Prms.Find(sen.Prm)
+		p := prm.V
+		h := sen.Pert * math.Abs(p)
+		if h == 0 {
+			h = sen.Pert
+		}
+		prm.V = p + h
+		copy(dydp0, o.Sol.Sens[k])
+		ok = o.sens_reinit(sen.Mat) && o.sens_step(k, h, dydp0, fb0)
+
+		// restore parameter and models
+		prm.V = p
+		if !o.sens_reinit(sen.Mat) || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sens_factorise assembles and factorises Kb at the converged state
+func (o *Domain) sens_factorise() (ok bool) {
+
+	// discard corrections of constrained equations (elimination) computed with the last residual
+	if o.EssenBcs.Elim != nil {
+		o.EssenBcs.Elim.discard()
+	}
+
+	// assemble element matrices and constraints
+	kb := o.EssenBcs.Assembler(o.StartKb())
+	if !o.elems_add_to_kb(kb, false) {
+		return
+	}
+	if Global.Root {
+		o.EssenBcs.AddToKb(kb)
+	}
+
+	// initialise linear solver and perform factorisation
+	if o.InitLSol {
+		if LogErr(o.LinSol.InitR(o.Kb, Global.Sim.LinSol.Symmetric, Global.Sim.LinSol.Verbose, Global.Sim.LinSol.Timing), "cannot initialise linear solver") {
+			return
+		}
+		o.InitLSol = false
+	}
+	return !LogErr(o.LinSol.Fact(), "Sensitivities: factorisation")
+}
+
+// sens_step computes dy/dp of the k-th parameter perturbed by h and updates the perturbed state
+//  dydp0 -- sensitivities at the beginning of the time step
+//  fb0   -- residuals at converged state
+func (o *Domain) sens_step(k int, h float64, dydp0, fb0 []float64) (ok bool) {
+
+	// perturbed residuals with y fixed
+	fb := make([]float64, o.Nyb)
+	if !o.sens_perturb(k, h, dydp0, make([]float64, o.Ny)) {
+		return
+	}
+	if !o.elems_add_to_rhs(fb) {
+		return
+	}
+
+	// ∂fb/∂p
+	for i := 0; i < o.Ny; i++ {
+		fb[i] = (fb[i] - fb0[i]) / h
+	}
+	for i := o.Ny; i < o.Nyb; i++ {
+		fb[i] = 0
+	}
+	if Global.Distr {
+		mpi.AllReduceSum(fb, o.Wb)
+	}
+
+	// solve for dy/dp
+	if LogErr(o.EssenBcs.SolveR(o.LinSol, o.Wb, fb), "Sensitivities: solve") {
+		return
+	}
+	dydp := o.Sol.Sens[k]
+	copy(dydp, o.Wb[:o.Ny])
+
+	// perturbed internal variables at the end of the time step
+	if !o.sens_perturb(k, h, dydp0, dydp) {
+		return
+	}
+	var buf bytes.Buffer
+	if !o.encode_ivs(&buf) {
+		return
+	}
+	o.sensIvs[k] = buf.Bytes()
+
+	// sensitivities of time derivatives
+	if !Global.Sim.Data.Steady {
+		dc := Global.DynCoefs
+		for _, I := range o.T1eqs {
+			o.sensDydt[k][I] = dc.β1*(dydp[I]-dydp0[I]) - dc.β2*o.sensDydt[k][I]
+		}
+		for _, I := range o.T2eqs {
+			v, a := o.sensDydt[k][I], o.sensD2ydt2[k][I]
+			o.sensDydt[k][I] = dc.α4*(dydp[I]-dydp0[I]) - dc.α5*v - dc.α6*a
+			o.sensD2ydt2[k][I] = dc.α1*(dydp[I]-dydp0[I]) - dc.α2*v - dc.α3*a
+		}
+	}
+	return true
+}
+
+// sens_perturb sets the k-th perturbed state with y + h・dydp and updates the internal variables
+// starting from the perturbed ones at the beginning of the time step
+//  Note: the converged solution must be in bkpSol
+func (o *Domain) sens_perturb(k int, h float64, dydp0, dydp []float64) (ok bool) {
+	if !o.decode_ivs(bytes.NewReader(o.sensIvs[k])) {
+		return
+	}
+	for i := 0; i < o.Ny; i++ {
+		o.Sol.Y[i] = o.bkpSol.Y[i] + h*dydp[i]
+		o.Sol.ΔY[i] = o.bkpSol.ΔY[i] + h*(dydp[i]-dydp0[i])
+	}
+	if !Global.Sim.Data.Steady {
+		dc := Global.DynCoefs
+		v, a := o.sensDydt[k], o.sensD2ydt2[k]
+		for _, I := range o.T1eqs {
+			o.Sol.Psi[I] = o.bkpSol.Psi[I] + h*(dc.β1*dydp0[I]+dc.β2*v[I])
+		}
+		for _, I := range o.T2eqs {
+			o.Sol.Zet[I] = o.bkpSol.Zet[I] + h*(dc.α1*dydp0[I]+dc.α2*v[I]+dc.α3*a[I])
+			o.Sol.Chi[I] = o.bkpSol.Chi[I] + h*(dc.α4*dydp0[I]+dc.α5*v[I]+dc.α6*a[I])
+		}
+		for _, e := range o.Elems {
+			e.InterpStarVars(o.Sol)
+		}
+	}
+	return o.elems_update()
+}
+
+// sens_reinit re-initialises the material models (of elements) depending on material matname; e.g.
+// after one of its parameters has been changed in the materials database
+func (o *Domain) sens_reinit(matname string) (ok bool) {
+	done := make(map[interface{}]bool)
+	solid := func(e *ElemU) bool {
+		if done[e.Model] {
+			return true
+		}
+		done[e.Model] = true
+		matdata := solid_material(e.Mat)
+		if matdata == nil {
+			return false
+		}
+		if matdata.Name != matname {
+			return true
+		}
+		return !LogErr(e.Model.Init(Global.Ndim, Global.Sim.Data.Pstress, matdata.Prms), "cannot re-initialise solid model")
+	}
+	porous := func(e *ElemP) bool {
+		if done[e.Mdl] {
+			return true
+		}
+		done[e.Mdl] = true
+		return reinit_porous_model(e.Mdl, e.Mat, matname)
+	}
+	for _, ele := range o.Elems {
+		switch e := ele.(type) {
+		case *ElemU:
+			ok = solid(e)
+		case *ElemP:
+			ok = porous(e)
+		case *ElemUP:
+			ok = solid(e.U) && porous(e.P)
+		default:
+			edat := o.Reg.Etag2data(o.Msh.Cells[ele.Id()].Tag)
+			ok = !LogErrCond(uses_material(edat.Mat, matname), "Sensitivities: elements of type %q with material %q are not available for sensitivity analysis of parameters of %q", edat.Type, edat.Mat, matname)
+		}
+		if !ok {
+			return
+		}
+	}
+	return true
+}
+
+// uses_material tells whether material elmat is matname or is a group containing matname
+func uses_material(elmat, matname string) bool {
+	if elmat == matname {
+		return true
+	}
+	mat := Global.Sim.Mdb.Get(elmat)
+	if mat == nil {
+		return false
+	}
+	for _, code := range strings.Fields(mat.Extra) {
+		if i := strings.Index(code, ":"); i > 0 && code[i+1:] == matname {
+			return true
+		}
+	}
+	return false
+}
+
+// reinit_porous_model re-initialises porous model (and its conductivity and retention models)
+// if any of the materials in group elmat is equal to matname
+func reinit_porous_model(mdl *mporous.Model, elmat, matname string) (ok bool) {
+	cndmat, lrmmat, pormat, err := Global.Sim.Mdb.GroupGet3(elmat, "c", "l", "p")
+	if LogErr(err, io.Sf("materials database failed on getting %q (porous) group\n", elmat)) {
+		return
+	}
+	if cndmat.Name != matname && lrmmat.Name != matname && pormat.Name != matname {
+		return true
+	}
+	if LogErr(mdl.Cnd.Init(cndmat.Prms), "cannot re-initialise conductivity model") {
+		return
+	}
+	if LogErr(mdl.Lrm.Init(lrmmat.Prms), "cannot re-initialise liquid retention model") {
+		return
+	}
+	return !LogErr(mdl.Init(pormat.Prms, mdl.Cnd, mdl.Lrm), "cannot re-initialise porous model")
+}
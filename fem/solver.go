@@ -36,21 +36,26 @@ var Global struct {
 	WspcInum []int // workspace of integer numbers [nprocs]
 
 	// simulation, materials, meshes and convenience variables
-	Sim    *inp.Simulation // simulation data
-	Ndim   int             // space dimension
-	Dirout string          // directory for output of results
-	Fnkey  string          // filename key; e.g. mysim.sim => mysim
-	Enc    string          // encoder; e.g. "gob" or "json"
-	Stat   bool            // save residuals in summary
-	LogBcs bool            // log essential and ptnatural boundary conditions
-	Debug  bool            // debug flag
+	Sim     *inp.Simulation // simulation data
+	Ndim    int             // space dimension
+	Dirout  string          // directory for output of results
+	Fnkey   string          // filename key; e.g. mysim.sim => mysim
+	Enc     string          // encoder; e.g. "gob" or "json"
+	Stat    bool            // save residuals in summary
+	LogBcs  bool            // log essential and ptnatural boundary conditions
+	Debug   bool            // debug flag
+	Restart int             // output index (tidx) to restart from; 0 => no restart
 
 	// auxiliar structures
 	DynCoefs *DynCoefs    // dynamic coefficients
 	HydroSt  *HydroStatic // computes hydrostatic states
+	Motion   *InputMotion // input (earthquake) motion of current stage; nil if none
 
 	// for debugging
 	DebugKb func(d *Domain, it int) // debug Kb callback function
+
+	// callbacks
+	OutHook func(d *Domain, tidx int) (ok bool) // called by Domain.Out after saving results; e.g. to write VTU files on-the-fly
 }
 
 // End must be called and the end to flush log file
@@ -94,6 +99,7 @@ func Start(simfilepath string, erasefiles, verbose bool) (startisok bool) {
 	Global.Stat = Global.Sim.Data.Stat
 	Global.LogBcs = Global.Sim.Data.LogBcs
 	Global.Debug = Global.Sim.Data.Debug
+	Global.Restart = Global.Sim.Data.Restart
 
 	// fix show residual flag
 	if !Global.Root {
@@ -150,7 +156,24 @@ func Run() (runisok bool) {
 	// summary of outputs; e.g. with output times
 	cputime := time.Now()
 	var sum Summary
-	sum.OutTimes = []float64{t}
+
+	// restart: read summary of previous run and find stage to restart from
+	stgrst := -1 // index of stage to restart from
+	if Global.Restart > 0 {
+		if LogErrCond(Global.Sim.Solver.ArcLen, "restart of simulations with arc-length method is not available") {
+			return
+		}
+		if LogErrCond(len(Global.Sim.Data.Sens) > 0, "restart of simulations with sensitivity analysis is not available because the sensitivities of internal variables are not saved") {
+			return
+		}
+		var ok bool
+		stgrst, ok = restart_summary(&sum, Global.Restart)
+		if !ok {
+			return
+		}
+	}
+
+	// save summary upon exit
 	defer func() {
 		sum.Save()
 		if Global.Verbose && !Global.Debug {
@@ -174,6 +197,15 @@ func Run() (runisok bool) {
 				break
 			}
 			d.Sol.T = t
+			if stgidx < stgrst { // restart: stage computed already
+				continue
+			}
+			if stgidx == stgrst { // restart: recover state
+				if !d.In(&sum, Global.Restart, false) {
+					break
+				}
+				continue
+			}
 			if !d.Out(tidx) {
 				break
 			}
@@ -181,7 +213,26 @@ func Run() (runisok bool) {
 		if Stop() {
 			return
 		}
-		tidx += 1
+
+		// output indices and restart
+		switch {
+		case stgidx < stgrst:
+			continue
+		case stgidx == stgrst:
+			t = domains[0].Sol.T
+			tout = t + DtOut.F(t, nil)
+			tidx = Global.Restart + 1
+			if Global.Verbose {
+				io.Pfgreen("restarting stage %d from output %d at t = %g\n", stgidx, Global.Restart, t)
+			}
+		default:
+			sum.AddOutTime(t)
+			sum.StgTidx = append(sum.StgTidx, tidx)
+			if !sum.Save() {
+				return
+			}
+			tidx += 1
+		}
 
 		// log models
 		mconduct.LogModels()
@@ -205,6 +256,28 @@ func Run() (runisok bool) {
 			continue
 		}
 
+		// pseudo-time loop using arc-length method (one domain only; see ReadSim)
+		if Global.Sim.Solver.ArcLen {
+			var arc ArcLength
+			if !arc.Init(domains[0], Dt) {
+				return
+			}
+			if !arc.Run(domains[0], &sum, DtOut, &t, tf, tout, &tidx) {
+				return
+			}
+			continue
+		}
+
+		// time loop with adaptive time steps
+		if Global.Sim.Solver.Adapt {
+			var ad AdaptiveDt
+			ad.Init(domains, Dt, t)
+			if !ad.Run(domains, &sum, DtOut, &t, tf, tout, &tidx) {
+				return
+			}
+			continue
+		}
+
 		// time loop
 		ndiverg := 0 // number of steps diverging
 		md := 1.0    // time step multiplier if divergence control is on
@@ -260,7 +333,7 @@ func Run() (runisok bool) {
 				}
 
 				// run iterations
-				diverging, ok := run_iterations(t, Δt, d, &sum)
+				diverging, _, ok := run_iterations(t, Δt, d, &sum)
 				if !ok {
 					return
 				}
@@ -287,9 +360,18 @@ func Run() (runisok bool) {
 				continue
 			}
 
+			// sensitivities
+			if len(Global.Sim.Data.Sens) > 0 {
+				for _, d := range domains {
+					if !d.Sensitivities() {
+						return
+					}
+				}
+			}
+
 			// perform output
 			if t >= tout || lasttimestep {
-				sum.OutTimes = append(sum.OutTimes, t)
+				sum.AddOutTime(t)
 				for _, d := range domains {
 					//if true {
 					if false {
@@ -305,6 +387,9 @@ func Run() (runisok bool) {
 				if Stop() {
 					return
 				}
+				if !sum.Save() {
+					return
+				}
 				tout += Δtout
 				tidx += 1
 			}
@@ -314,7 +399,8 @@ func Run() (runisok bool) {
 }
 
 // run_iterations solves the nonlinear problem
-func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool) {
+//  Note: nit is the number of iterations (linear solutions) performed
+func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging bool, nit int, ok bool) {
 
 	// zero accumulated increments
 	la.VecFill(d.Sol.ΔY, 0)
@@ -329,6 +415,23 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 	var largFb, largFb0, Lδu float64
 	var prevFb, prevLδu float64
 
+	// augmentation of contact constraints after convergence
+	//  Note: the contact forces are modified; thus at least one more iteration is performed and the
+	//        divergence checks are restarted. The iterations are counted from the last augmentation
+	//        (it0); thus NmaxIt limits the iterations between augmentations and MaxAug the number
+	//        of augmentations
+	var naug, it0 int
+	var augmented bool
+	augment := func() (done bool) {
+		done = d.contacts_augment(&naug)
+		augmented = !done
+		if augmented {
+			it0 = it + 1
+		}
+		prevFb, prevLδu = math.MaxFloat64, math.MaxFloat64
+		return
+	}
+
 	// message
 	if Global.Sim.Data.ShowR {
 		io.Pf("\n%13s%4s%23s%23s\n", "t", "it", "largFb", "Lδu")
@@ -338,15 +441,11 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 	}
 
 	// iterations
-	for it = 0; it < Global.Sim.Solver.NmaxIt; it++ {
+	for it = 0; it-it0 < Global.Sim.Solver.NmaxIt; it++ {
 
 		// assemble right-hand side vector (fb) with negative of residuals
 		la.VecFill(d.Fb, 0)
-		for _, e := range d.Elems {
-			if !e.AddToRhs(d.Fb, d.Sol) {
-				break
-			}
-		}
+		d.elems_add_to_rhs(d.Fb)
 		if Stop() {
 			return
 		}
@@ -357,7 +456,7 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 		}
 
 		// point natural boundary conditions; e.g. concentrated loads
-		d.PtNatBcs.AddToRhs(d.Fb, t)
+		d.PtNatBcs.AddToRhs(d.Fb, d.Sol)
 
 		// essential boundary conditioins; e.g. constraints
 		d.EssenBcs.AddToRhs(d.Fb, d.Sol)
@@ -380,19 +479,26 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 		if it == 0 {
 			// store largest absolute component of fb
 			largFb0 = largFb
-		} else {
+		} else if !augmented {
 			// check convergence on Lf0
 			if largFb < Global.Sim.Solver.FbTol*largFb0 { // converged on fb
-				break
+				if augment() {
+					break
+				}
+				continue
 			}
 			// check convergence on fb_min
 			if largFb < Global.Sim.Solver.FbMin { // converged with smallest value of fb
-				break
+				if augment() {
+					break
+				}
+				continue
 			}
 		}
+		augmented = false // solve at least once after augmentation
 
 		// check divergence on fb
-		if it > 1 && Global.Sim.Solver.DvgCtrl {
+		if it-it0 > 1 && Global.Sim.Solver.DvgCtrl {
 			if largFb > prevFb {
 				diverging = true
 				break
@@ -405,12 +511,8 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 		if do_asm_fact {
 
 			// assemble element matrices
-			d.Kb.Start()
-			for _, e := range d.Elems {
-				if !e.AddToKb(d.Kb, d.Sol, it == 0) {
-					break
-				}
-			}
+			kb := d.EssenBcs.Assembler(d.StartKb())
+			d.elems_add_to_kb(kb, it == 0)
 			if Stop() {
 				return
 			}
@@ -420,9 +522,9 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 				Global.DebugKb(d, it)
 			}
 
-			// join A and tr(A) matrices into Kb
+			// join A and tr(A) matrices into Kb (or penalty terms)
 			if Global.Root {
-				d.Kb.PutMatAndMatT(&d.EssenBcs.A)
+				d.EssenBcs.AddToKb(kb)
 			}
 
 			// initialise linear solver
@@ -446,7 +548,7 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 		//panic("stop")
 
 		// solve for wb := δyb
-		LogErr(d.LinSol.SolveR(d.Wb, d.Fb, false), "solve")
+		LogErr(d.EssenBcs.SolveR(d.LinSol, d.Wb, d.Fb), "solve")
 		if Stop() {
 			return
 		}
@@ -471,9 +573,11 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 			}
 		}
 
-		// update Lagrange multipliers (λ)
-		for i := 0; i < d.Nlam; i++ {
-			d.Sol.L[i] += d.Wb[d.Ny+i] // λ += δλ
+		// update Lagrange multipliers (λ); otherwise computed in EssenBcs.AddToRhs
+		if d.Nyb > d.Ny {
+			for i := 0; i < d.Nlam; i++ {
+				d.Sol.L[i] += d.Wb[d.Ny+i] // λ += δλ
+			}
 		}
 
 		// backup / restore
@@ -490,11 +594,7 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 		}
 
 		// update secondary variables
-		for _, e := range d.Elems {
-			if !e.Update(d.Sol) {
-				break
-			}
-		}
+		d.elems_update()
 		if Stop() {
 			return
 		}
@@ -509,11 +609,14 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 
 		// stop if converged on δu
 		if Lδu < Global.Sim.Solver.Itol {
-			break
+			if augment() {
+				break
+			}
+			continue
 		}
 
 		// check divergence on Lδu
-		if it > 1 && Global.Sim.Solver.DvgCtrl {
+		if it-it0 > 1 && Global.Sim.Solver.DvgCtrl {
 			if Lδu > prevLδu {
 				diverging = true
 				break
@@ -523,8 +626,12 @@ func run_iterations(t, Δt float64, d *Domain, sum *Summary) (diverging, ok bool
 	}
 
 	// check if iterations diverged
-	if it == Global.Sim.Solver.NmaxIt {
+	nit = it + 1
+	if it-it0 == Global.Sim.Solver.NmaxIt {
 		io.PfMag("max number of iterations reached: it = %d\n", it)
+		if Global.Sim.Solver.Adapt { // time step will be reduced
+			diverging, ok = true, true
+		}
 		return
 	}
 
@@ -17,11 +17,42 @@ import (
 type Summary struct {
 	Nproc    int          // number of processors used in last last run; equal to 1 if not distributed
 	OutTimes []float64    // [nOutTimes] output times
+	StgTidx  []int        // [nstages] output index (tidx) at the beginning of each stage
 	Resids   utl.DblSlist // residuals (if Stat is on; includes all stages)
+	LoadFacs []float64    // load factors at converged steps (arc-length method)
+	CtrlDisp []float64    // control displacements at converged steps (arc-length method)
+	StepDts  []float64    // time step sizes of accepted steps (adaptive time stepping)
+	OutSizes [][]int      // [nOutTimes][3] number of steps in Resids, LoadFacs (CtrlDisp) and StepDts at each output
 	Dirout   string       // directory where results are stored
 	Fnkey    string       // filename key of simulation
 }
 
+// AddOutTime records output time t and the number of steps recorded so far
+func (o *Summary) AddOutTime(t float64) {
+	nres := 0
+	if len(o.Resids.Ptrs) > 0 {
+		nres = len(o.Resids.Ptrs) - 1
+	}
+	o.OutTimes = append(o.OutTimes, t)
+	o.OutSizes = append(o.OutSizes, []int{nres, len(o.LoadFacs), len(o.StepDts)})
+}
+
+// Truncate removes all records after output index tidx
+func (o *Summary) Truncate(tidx int) {
+	o.OutTimes = o.OutTimes[:tidx+1]
+	o.OutSizes = o.OutSizes[:tidx+1]
+	nres, nlf, ndt := o.OutSizes[tidx][0], o.OutSizes[tidx][1], o.OutSizes[tidx][2]
+	if nres == 0 {
+		o.Resids.Vals, o.Resids.Ptrs = nil, nil
+	} else {
+		o.Resids.Ptrs = o.Resids.Ptrs[:nres+1]
+		o.Resids.Vals = o.Resids.Vals[:o.Resids.Ptrs[nres]]
+	}
+	o.LoadFacs = o.LoadFacs[:nlf]
+	o.CtrlDisp = o.CtrlDisp[:nlf]
+	o.StepDts = o.StepDts[:ndt]
+}
+
 // SaveSums saves summary to disc
 func (o Summary) Save() (ok bool) {
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
 )
 
 func Test_beam01(tst *testing.T) {
@@ -88,3 +89,136 @@ func Test_beam01(tst *testing.T) {
 	chk.Ints(tst, "constrained ux equations", ct_ux_eqs, []int{0})
 	chk.Ints(tst, "constrained uy equations", ct_uy_eqs, []int{1, 4})
 }
+
+// run_beam runs a cantilever simulation and returns the displacements and rotations at the tip
+// (vertex 4) and the section forces of the first element at the clamped end
+func run_beam(tst *testing.T, simfn string) (tip []float64, sf map[string]float64) {
+	defer End()
+	if !Start(simfn, true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		nod := d.Vid2node[4]
+		tip = make([]float64, 6)
+		for i, key := range []string{"ux", "uy", "uz", "rx", "ry", "rz"} {
+			tip[i] = d.Sol.Y[nod.GetEq(key)]
+		}
+		sf = d.Elems[0].OutIpsData()[0].Calc(d.Sol)
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+	if !Run() {
+		tst.Errorf("Run failed\n")
+	}
+	return
+}
+
+func Test_beam02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("beam02. 3D cantilever. Euler-Bernoulli with orientation vector")
+
+	tip, sf := run_beam(tst, "data/beam02.sim")
+	if tst.Failed() {
+		return
+	}
+	io.Pforan("tip = %v\n", tip)
+	io.Pforan("sf  = %v\n", sf)
+
+	// local y axis is along global z and local z axis is along -y
+	L, E, G, Izz, Iyy, J := 2.0, 10000.0, 4000.0, 1e-5, 2e-5, 3e-5
+	fy, fz, mx := -1.0, -2.0, 0.5
+	chk.Vector(tst, "tip", 1e-8, tip, []float64{
+		0,
+		fy * L * L * L / (3.0 * E * Iyy),
+		fz * L * L * L / (3.0 * E * Izz),
+		mx * L / (G * J),
+		-fz * L * L / (2.0 * E * Izz),
+		fy * L * L / (2.0 * E * Iyy),
+	})
+
+	// section forces at clamped end (local axes)
+	chk.Scalar(tst, "N ", 1e-10, sf["N"], 0)
+	chk.Scalar(tst, "Vy", 1e-10, sf["Vy"], fz)
+	chk.Scalar(tst, "Vz", 1e-10, sf["Vz"], -fy)
+	chk.Scalar(tst, "T ", 1e-10, sf["T"], mx)
+	chk.Scalar(tst, "My", 1e-10, sf["My"], L*fy)
+	chk.Scalar(tst, "Mz", 1e-10, sf["Mz"], L*fz)
+}
+
+func Test_beam03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("beam03. 3D cantilever. Timoshenko")
+
+	tip, sf := run_beam(tst, "data/beam03.sim")
+	if tst.Failed() {
+		return
+	}
+	io.Pforan("tip = %v\n", tip)
+	io.Pforan("sf  = %v\n", sf)
+
+	// local axes coincide with global axes
+	L, E, G, Izz, Iyy, J, Ay, Az := 2.0, 10000.0, 4000.0, 1e-5, 2e-5, 3e-5, 0.002, 0.004
+	fy, fz, mx := -1.0, -2.0, 0.5
+	chk.Vector(tst, "tip", 1e-8, tip, []float64{
+		0,
+		fy*L*L*L/(3.0*E*Izz) + fy*L/(G*Ay),
+		fz*L*L*L/(3.0*E*Iyy) + fz*L/(G*Az),
+		mx * L / (G * J),
+		-fz * L * L / (2.0 * E * Iyy),
+		fy * L * L / (2.0 * E * Izz),
+	})
+
+	// section forces at clamped end
+	chk.Scalar(tst, "N ", 1e-10, sf["N"], 0)
+	chk.Scalar(tst, "Vy", 1e-10, sf["Vy"], fy)
+	chk.Scalar(tst, "Vz", 1e-10, sf["Vz"], fz)
+	chk.Scalar(tst, "T ", 1e-10, sf["T"], mx)
+	chk.Scalar(tst, "My", 1e-10, sf["My"], -L*fz)
+	chk.Scalar(tst, "Mz", 1e-10, sf["Mz"], L*fy)
+}
+
+func Test_beam04(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("beam04. distributed loads are multiplied by the load factor")
+
+	// domain
+	defer End()
+	if !Start("data/beam01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	dom := NewDomain(Global.Sim.Regions[0], false)
+	if dom == nil {
+		tst.Errorf("NewDomain failed\n")
+		return
+	}
+	if !dom.SetStage(0, Global.Sim.Stages[0], false) {
+		tst.Errorf("SetStage failed\n")
+		return
+	}
+
+	// external forces with zero displacements
+	e := dom.Elems[0].(*Beam)
+	fb := func(λ float64) []float64 {
+		dom.Sol.LoadFac = λ
+		res := make([]float64, dom.Ny)
+		if !e.AddToRhs(res, dom.Sol) {
+			tst.Errorf("AddToRhs failed\n")
+		}
+		return res
+	}
+	f1, f04 := fb(1), fb(0.4)
+	io.Pforan("fb(λ=1)   = %v\n", f1)
+	io.Pforan("fb(λ=0.4) = %v\n", f04)
+	if la.VecLargest(f1, 1) < 1 {
+		tst.Errorf("forces due to distributed load must not be zero\n")
+		return
+	}
+	la.VecScale(f1, 0, 0.4, f1)
+	chk.Vector(tst, "fb(λ=0.4)", 1e-15, f04, f1)
+	chk.Vector(tst, "fb(λ=0)", 1e-15, fb(0), make([]float64, dom.Ny))
+}
@@ -0,0 +1,189 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_contact01(tst *testing.T) {
+
+	/*  block resting on another block (frictionless)
+	 *
+	 *         qn = -10
+	 *      ↓↓↓↓↓↓↓↓↓↓↓↓↓↓
+	 *     9------8------7
+	 *     |      |      |
+	 *     4------5------6   slave  (-12)
+	 *     3-------------2   master (-11)
+	 *     |             |
+	 *     0-------------1
+	 *     ^      ^      ^
+	 */
+
+	//verbose()
+	chk.PrintTitle("contact01. block resting on another block")
+
+	// initialisation
+	defer End()
+	if !Start("data/contact01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators
+	if true {
+		defer contact_DebugKb(&testKb{
+			tst: tst, eid: 1, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: -1, tmax: -1,
+		})()
+	}
+
+	// get domain at the end of simulation
+	var dom *Domain
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+
+	// uniform contact pressure and negligible penetration
+	c := dom.Contacts[0]
+	for i, dat := range c.OutIpsData() {
+		res := dat.Calc(dom.Sol)
+		io.Pforan("vertex %d: pn = %v  gap = %v\n", c.Slaves[i].Vert.Id, res["pn"], res["gap"])
+		chk.Scalar(tst, "pn", 1e-8, res["pn"], 10)
+		chk.Scalar(tst, "tn", 1e-15, res["tn"], 0)
+		if -res["gap"] > c.Tol {
+			tst.Errorf("penetration %g is greater than tolerance %g\n", -res["gap"], c.Tol)
+			return
+		}
+	}
+
+	// vertical displacement at top: plane-strain with σx = 0 and σy = -10
+	E, ν := 10000.0, 0.25
+	uy := dom.Sol.Y[dom.Vid2node[7].GetEq("uy")]
+	chk.Scalar(tst, "uy @ top", 1e-9, uy, 0.5*(1-ν*ν)*(-10)/E)
+}
+
+func Test_contact02(tst *testing.T) {
+
+	/*  block sliding on another block (Coulomb friction)
+	 *
+	 *            qn = -10   ux = 0.01 t
+	 *           ↓↓↓↓↓↓↓↓↓↓ →
+	 *          7-----------6
+	 *          |           |
+	 *          4-----------5        slave  (-12)
+	 *    3-----------------------2  master (-11)
+	 *    |                       |
+	 *    0-----------------------1
+	 *    ^  ^  ^  ^  ^  ^  ^  ^  ^
+	 */
+
+	//verbose()
+	chk.PrintTitle("contact02. block sliding on another block")
+
+	// initialisation
+	defer End()
+	if !Start("data/contact02.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// get domain at the end of simulation
+	var dom *Domain
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+
+	// all slave vertices must be slipping
+	μ := 0.3
+	c := dom.Contacts[0]
+	var N, T float64
+	for i, s := range c.States {
+		io.Pforan("vertex %d: fn = %v  ft = %v  gap = %v\n", c.Slaves[i].Vert.Id, s.Fn, s.Ft, s.Gap)
+		if !s.Active || !s.Slip {
+			tst.Errorf("slave vertex %d must be slipping\n", c.Slaves[i].Vert.Id)
+			return
+		}
+		if -s.Gap > c.Tol {
+			tst.Errorf("penetration %g is greater than tolerance %g\n", -s.Gap, c.Tol)
+			return
+		}
+		chk.Scalar(tst, "ft/fn", 1e-12, -s.Ft[0]/s.Fn, μ)
+		N += s.Fn
+		T += s.Ft[0]
+	}
+
+	// resultant forces on slave face
+	chk.Scalar(tst, "N", 1e-8, N, 10)
+	chk.Scalar(tst, "T", 1e-8, T, -μ*10)
+}
+
+func Test_contact03(tst *testing.T) {
+
+	/*  block resting on another block (frictionless) with a small penalty coefficient
+	 *
+	 *  many augmentations are required; thus the maximum number of iterations is applied to the
+	 *  iterations between augmentations and not to the total number of iterations
+	 */
+
+	//verbose()
+	chk.PrintTitle("contact03. augmentations do not consume the iterations budget")
+
+	// initialisation
+	defer End()
+	if !Start("data/contact01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	dat := Global.Sim.Stages[0].Contact[0]
+	dat.Kn, dat.MaxAug = 1e5, 50
+	Global.Sim.Solver.NmaxIt = 5
+
+	// get domain at the end of simulation
+	var dom *Domain
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+
+	// uniform contact pressure and negligible penetration
+	c := dom.Contacts[0]
+	for i, dat := range c.OutIpsData() {
+		res := dat.Calc(dom.Sol)
+		io.Pforan("vertex %d: pn = %v  gap = %v\n", c.Slaves[i].Vert.Id, res["pn"], res["gap"])
+		chk.Scalar(tst, "pn", 1e-8, res["pn"], 10)
+		if -res["gap"] > c.Tol {
+			tst.Errorf("penetration %g is greater than tolerance %g\n", -res["gap"], c.Tol)
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+// run_enfmode runs enfmode01.sim with given enforcement mode and returns the last y and λ
+func run_enfmode(tst *testing.T, mode string) (y, λ []float64) {
+	defer End()
+	if !Start("data/enfmode01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	Global.Sim.Solver.EnfMode = mode
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		y = make([]float64, d.Ny)
+		λ = make([]float64, d.Nlam)
+		copy(y, d.Sol.Y)
+		copy(λ, d.Sol.L)
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+	if !Run() {
+		tst.Errorf("Run failed\n")
+	}
+	return
+}
+
+func Test_enfmode01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("enfmode01. Lagrange multipliers, elimination and penalty")
+
+	yL, λL := run_enfmode(tst, "lagrange")
+	yE, λE := run_enfmode(tst, "elimination")
+	yP, λP := run_enfmode(tst, "penalty")
+	if tst.Failed() {
+		return
+	}
+	io.Pforan("y(lagrange) = %v\n", yL)
+	io.Pforan("λ(lagrange) = %v\n", λL)
+
+	chk.Vector(tst, "y(elimination)", 1e-12, yE, yL)
+	chk.Vector(tst, "λ(elimination)", 1e-8, λE, λL)
+	chk.Vector(tst, "y(penalty)", 1e-7, yP, yL)
+	chk.Vector(tst, "λ(penalty)", 1e-3, λP, λL)
+}
+
+// run_enfmode_arc runs spo751arc.sim with given enforcement mode and returns the equilibrium path
+func run_enfmode_arc(tst *testing.T, mode string) (λ, u []float64) {
+	defer End()
+	if !Start("data/spo751arc.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	Global.Sim.Solver.EnfMode = mode
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+	sum := ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		tst.Errorf("cannot read summary\n")
+		return
+	}
+	return sum.LoadFacs, sum.CtrlDisp
+}
+
+func Test_enfmode02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("enfmode02. arc-length method with Lagrange multipliers, elimination and penalty")
+
+	λL, uL := run_enfmode_arc(tst, "lagrange")
+	λE, uE := run_enfmode_arc(tst, "elimination")
+	λP, _ := run_enfmode_arc(tst, "penalty")
+	if tst.Failed() {
+		return
+	}
+	io.Pforan("λ(lagrange) = %v\n", λL)
+	io.Pforan("u(lagrange) = %v\n", uL)
+
+	// elimination: same path
+	chk.Vector(tst, "λ(elimination)", 1e-8, λE, λL)
+	chk.Vector(tst, "u(elimination)", 1e-8, uE, uL)
+
+	// penalty: same limit load
+	λmax := func(λ []float64) (res float64) {
+		for _, v := range λ {
+			res = max(res, v)
+		}
+		return
+	}
+	chk.Scalar(tst, "λmax(penalty)", 1e-4*λmax(λL), λmax(λP), λmax(λL))
+}
@@ -0,0 +1,63 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_footing01(tst *testing.T) {
+
+	/*  strip footing on weightless soil (half of domain)
+	 *
+	 *      q (width B/2 = 1)
+	 *    ↓↓↓↓↓
+	 *    o-----o---------------o
+	 *   >|                     |<
+	 *   >|     Tresca soil     |<   5 x 5
+	 *   >|                     |<
+	 *    o---------------------o
+	 *    ^  ^  ^  ^  ^  ^  ^  ^
+	 */
+
+	//verbose()
+	chk.PrintTitle("footing01. strip footing on Tresca soil: Prandtl's solution")
+
+	// run simulation
+	if !Start("data/footing01.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// read summary
+	sum := ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		tst.Errorf("cannot read summary\n")
+		return
+	}
+
+	// collapse load: qlim = (2 + π) c with qref = c = 10 kPa
+	λlim := 2.0 + math.Pi
+	λmax := sum.LoadFacs[0]
+	for _, λ := range sum.LoadFacs {
+		λmax = max(λmax, λ)
+	}
+	io.Pforan("ub   = %v\n", sum.CtrlDisp)
+	io.Pforan("λmax = %v  λlim = %v\n", λmax, λlim)
+	chk.Scalar(tst, "λmax/λlim", 0.1, λmax/λlim, 1)
+}
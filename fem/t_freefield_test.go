@@ -0,0 +1,197 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_inmotion01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("inmotion01. integration of accelerations")
+
+	// constant acceleration
+	c := MotionComp{Acc: &fun.Cte{C: 2}}
+	c.integrate(0.01, 1.0)
+	for _, t := range []float64{0, 0.005, 0.333, 0.5, 1.0} {
+		chk.Scalar(tst, io.Sf("a(%g)", t), 1e-15, c.H(t, nil), 2)
+		chk.Scalar(tst, io.Sf("v(%g)", t), 1e-14, c.G(t, nil), 2*t)
+		chk.Scalar(tst, io.Sf("u(%g)", t), 1e-14, c.F(t, nil), t*t)
+	}
+
+	// free-field of compliant base: incident plus reflected waves
+	m := InputMotion{Zb: 0, Zt: 10, Comps: []*MotionComp{&c, nil, nil}}
+	v, dudz := m.FreeField(0, 4, 0.5, 100)
+	chk.Scalar(tst, "v", 1e-14, v, 2*(0.5-0.04)+2*(0.5-0.16))
+	chk.Scalar(tst, "dudz", 1e-14, dudz, (2*(0.5-0.16)-2*(0.5-0.04))/100)
+
+	// free-field of rigid base: motion at base is recovered
+	m.Rigid = true
+	v, _ = m.FreeField(0, 0, 0.7, 100)
+	chk.Scalar(tst, "v(base)", 1e-14, v, 1.4)
+}
+
+func Test_freefield01(tst *testing.T) {
+
+	/*  soil column subjected to vertically propagating shear wave
+	 *
+	 *         40---41  free surface
+	 *          |    |
+	 *          :    :  freefield (-13 and -11)
+	 *          |    |
+	 *          0----1  dashpot + incident wave (aix)
+	 */
+
+	//verbose()
+	chk.PrintTitle("freefield01. soil column with compliant base and free-field boundaries")
+
+	// initialisation
+	defer End()
+	if !Start("data/freefield01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators
+	if true {
+		defer u_DebugKb(&testKb{
+			tst: tst, eid: 0, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: -1, itmax: -1, tmin: 0.02, tmax: 0.03,
+		})()
+	}
+
+	// displacements at the top must match the free-field solution: u = 2・uinc(t - H/cs)
+	E, ν, ρ, H := 50000.0, 0.25, 2.0, 10.0
+	cs := math.Sqrt(E / (2.0 * (1.0 + ν)) / ρ)
+	var dom *Domain
+	var errmax, umax float64
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		ux := d.Sol.Y[d.Vid2node[40].GetEq("ux")]
+		ucor := 2.0 * Global.Motion.Comps[0].F(d.Sol.T-H/cs, nil)
+		errmax = math.Max(errmax, math.Abs(ux-ucor))
+		umax = math.Max(umax, math.Abs(ucor))
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+	io.Pforan("errmax = %v  umax = %v\n", errmax, umax)
+	if errmax > 0.01*umax {
+		tst.Errorf("displacements at top do not match free-field solution: error = %g\n", errmax)
+		return
+	}
+
+	// the wave must have left the column through the base: permanent offset and no motion
+	ufinal := 2.0 * Global.Motion.Comps[0].F(1.0, nil)
+	for _, nod := range dom.Nodes {
+		eq := nod.GetEq("ux")
+		chk.Scalar(tst, io.Sf("ux @ %d", nod.Vert.Id), 5e-4, dom.Sol.Y[eq], ufinal)
+		chk.Scalar(tst, io.Sf("vx @ %d", nod.Vert.Id), 5e-3, dom.Sol.Dydt[eq], 0)
+	}
+}
+
+func Test_freefield02(tst *testing.T) {
+
+	/*  saturated soil column (u-p elements) subjected to vertically propagating shear wave. the
+	 *  density of dashpots is the density of the mixture and the static tractions at free-field
+	 *  boundaries are computed from total stresses. mesh and boundaries as in freefield01
+	 */
+
+	//verbose()
+	chk.PrintTitle("freefield02. saturated soil column with compliant base and free-field boundaries")
+
+	// initialisation
+	defer End()
+	if !Start("data/freefield02.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators
+	if true {
+		defer up_DebugKb(&testKb{
+			tst: tst, eid: 0, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: -1, itmax: -1, tmin: 0.02, tmax: 0.03,
+		})()
+	}
+
+	// displacements at the top must match the free-field solution with the density of the mixture
+	E, ν, nf, ρL, ρS, H := 50000.0, 0.25, 0.3, 1.0, 2.5, 10.0
+	ρ := (1.0-nf)*ρS + nf*ρL
+	cs := math.Sqrt(E / (2.0 * (1.0 + ν)) / ρ)
+	var dom *Domain
+	var errmax, umax float64
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		ux := d.Sol.Y[d.Vid2node[40].GetEq("ux")]
+		ucor := 2.0 * Global.Motion.Comps[0].F(d.Sol.T-H/cs, nil)
+		errmax = math.Max(errmax, math.Abs(ux-ucor))
+		umax = math.Max(umax, math.Abs(ucor))
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+	io.Pforan("errmax = %v  umax = %v\n", errmax, umax)
+	if errmax > 0.02*umax {
+		tst.Errorf("displacements at top do not match free-field solution: error = %g\n", errmax)
+		return
+	}
+
+	// the wave must have left the column through the base; the column must not have been squeezed
+	// by the lateral boundaries and the liquid pressure must remain hydrostatic
+	ufinal := 2.0 * Global.Motion.Comps[0].F(1.0, nil)
+	for _, nod := range dom.Nodes {
+		eq := nod.GetEq("ux")
+		chk.Scalar(tst, io.Sf("ux @ %d", nod.Vert.Id), 1e-3, dom.Sol.Y[eq], ufinal)
+		chk.Scalar(tst, io.Sf("vx @ %d", nod.Vert.Id), 1e-2, dom.Sol.Dydt[eq], 0)
+		if eq = nod.GetEq("pl"); eq >= 0 {
+			chk.Scalar(tst, io.Sf("pl @ %d", nod.Vert.Id), 1.0, dom.Sol.Y[eq], ρL*10*(H-nod.Vert.C[1]))
+		}
+	}
+}
+
+func Test_freefield03(tst *testing.T) {
+
+	/*  soil column with two layers and free-field boundaries. the analytical free field corresponds
+	 *  to a homogeneous column; thus, the simulation must be rejected
+	 *
+	 *         40---41  free surface
+	 *          |soil2|
+	 *         20---21
+	 *          |soil |
+	 *          0----1  dashpot + incident wave (aix)
+	 */
+
+	//verbose()
+	chk.PrintTitle("freefield03. layered soil column with free-field boundaries is rejected")
+
+	// initialisation
+	defer End()
+	if !Start("data/freefield03.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// run simulation
+	if Run() {
+		tst.Errorf("free-field boundaries of layered column must be rejected\n")
+	}
+}
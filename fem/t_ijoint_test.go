@@ -0,0 +1,233 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_ijoint01(tst *testing.T) {
+
+	/*  block sliding on frictional interface
+	 *
+	 *       qn = -10   ux = 0.01 t
+	 *      ↓↓↓↓↓↓↓↓↓↓ →
+	 *     7-----------6
+	 *     |  block    |
+	 *     4-----------5   interface (c=1, φ=20°)
+	 *     3-----------2
+	 *     |  block    |
+	 *     0-----------1
+	 *     ^  ^  ^  ^  ^
+	 */
+
+	//verbose()
+	chk.PrintTitle("ijoint01. block sliding on frictional interface")
+
+	// initialisation
+	defer End()
+	if !Start("data/ijoint01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators
+	eid := 2 // ijoint element
+	if true {
+		defer ijoint_DebugKb(&testKb{
+			tst: tst, eid: eid, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: 0.75, tmax: -1,
+		})()
+	}
+
+	// get interface element at the end of simulation
+	var ele *Ijoint
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		ele = d.Elems[eid].(*Ijoint)
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+
+	// all integration points must be slipping
+	c, tφ := 1.0, math.Tan(20.0*math.Pi/180.0)
+	var N, T float64
+	for idx, ip := range ele.IpsElem {
+		σ := ele.States[idx].Sig
+		io.Pforan("σn = %v  τ = %v  slip = %v\n", σ[0], σ[1], ele.States[idx].Alp[0])
+		chk.Scalar(tst, "f", 1e-10, σ[1]+σ[0]*tφ-c, 0)
+		if ele.States[idx].Alp[0] <= 0 {
+			tst.Errorf("ip %d must be slipping\n", idx)
+			return
+		}
+		N += ip.W * ele.Jf[idx] * σ[0]
+		T += ip.W * ele.Jf[idx] * σ[1]
+	}
+
+	// resultant forces on interface
+	chk.Scalar(tst, "N", 1e-8, N, -10)
+	chk.Scalar(tst, "T", 1e-8, T, c+10*tφ)
+}
+
+func Test_ijoint02(tst *testing.T) {
+
+	/*  opening of interface
+	 *
+	 *        ux = 0, uy = dtop(t)
+	 *     7-----------6
+	 *     |  block    |
+	 *     4-----------5   interface (σt=0)
+	 *     3-----------2
+	 *     |  block    |
+	 *     0-----------1
+	 *     ^  ^  ^  ^  ^
+	 *
+	 *  dtop: 0 → -0.001 (t=0.5) → 0.002 (t=1)
+	 */
+
+	//verbose()
+	chk.PrintTitle("ijoint02. opening of interface (gap)")
+
+	// initialisation
+	defer End()
+	if !Start("data/ijoint02.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators of open interface
+	eid := 2 // ijoint element
+	if true {
+		defer ijoint_DebugKb(&testKb{
+			tst: tst, eid: eid, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: 0.75, tmax: -1,
+		})()
+	}
+
+	// check interface at the end of compression and collect domain
+	var dom *Domain
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		if math.Abs(d.Sol.T-0.5) > 1e-10 {
+			return true
+		}
+		ele := d.Elems[eid].(*Ijoint)
+		for idx, s := range ele.States {
+			io.Pforan("t=0.5: σn = %v  broken = %v\n", s.Sig[0], s.Alp[1])
+			if s.Sig[0] >= 0 || s.Alp[1] > 0 {
+				tst.Errorf("ip %d must be closed and bonded during compression\n", idx)
+			}
+		}
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+
+	// interface must be open with zero tractions and gap equal to the displacement of top block
+	ele := dom.Elems[eid].(*Ijoint)
+	for idx, s := range ele.States {
+		io.Pforan("σ = %v  gap = %v  broken = %v\n", s.Sig, s.EpsE[0], s.Alp[1])
+		chk.Vector(tst, io.Sf("σ @ %d", idx), 1e-15, s.Sig, []float64{0, 0})
+		chk.Scalar(tst, io.Sf("gap @ %d", idx), 1e-12, s.EpsE[0], 0.002)
+		if s.Alp[1] < 1 {
+			tst.Errorf("bond of ip %d must be broken\n", idx)
+			return
+		}
+	}
+
+	// bottom block must be unloaded and top block must be translated
+	for vid, uy := range []float64{0, 0, 0, 0, 0.002, 0.002, 0.002, 0.002} {
+		chk.Scalar(tst, io.Sf("uy @ %d", vid), 1e-12, dom.Sol.Y[dom.Vid2node[vid].GetEq("uy")], uy)
+	}
+}
+
+func Test_ijoint03(tst *testing.T) {
+
+	/*  steady seepage across leaky interface between porous blocks
+	 *
+	 *       pl = 0, qn = -10
+	 *     7-----------6
+	 *     |  block    |   klsat = kl ÷ gref = 0.001 → kl/H = 0.004
+	 *     4-----------5   interface: kl = 0.004
+	 *     3-----------2
+	 *     |  block    |   klsat = 0.001 → kl/H = 0.004
+	 *     0-----------1
+	 *       pl = 10
+	 *
+	 *  three equal resistances in series: plA = 20/3 and plB = 10/3
+	 */
+
+	//verbose()
+	chk.PrintTitle("ijoint03. u-p interface: leakage and effective normal traction")
+
+	// initialisation
+	defer End()
+	if !Start("data/ijointp01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators, including Kup and Kpp
+	eid := 2 // ijointp element
+	if true {
+		defer ijoint_DebugKb(&testKb{
+			tst: tst, eid: eid, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: 0, tmax: -1,
+		})()
+	}
+
+	// collect domain
+	var dom *Domain
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+
+	// liquid pressures
+	ele := dom.Elems[eid].(*Ijoint)
+	if !ele.HasP {
+		tst.Errorf("interface element must have liquid pressure\n")
+		return
+	}
+	plA, plB := 20.0/3.0, 10.0/3.0
+	for vid, pl := range []float64{10, 10, plA, plA, plB, plB, 0, 0} {
+		chk.Scalar(tst, io.Sf("pl @ %d", vid), 1e-7, dom.Sol.Y[dom.Vid2node[vid].GetEq("pl")], pl)
+	}
+
+	// leakage and resultant forces on interface
+	var N, Ne float64
+	for idx, ip := range ele.IpsElem {
+		pl, q := ele.plvars(idx, dom.Sol)
+		σn := ele.States[idx].Sig[0]
+		io.Pforan("pl = %v  q = %v  σn = %v\n", pl, q, σn)
+		chk.Scalar(tst, "pl", 1e-7, pl, (plA+plB)/2.0)
+		chk.Scalar(tst, "q", 1e-9, q, ele.Kl*(plA-plB))
+		N += ip.W * ele.Jf[idx] * (σn - pl)
+		Ne += ip.W * ele.Jf[idx] * σn
+	}
+	chk.Scalar(tst, "N (total)", 1e-7, N, -10)
+	chk.Scalar(tst, "N (effective)", 1e-7, Ne, -10+(plA+plB)/2.0)
+}
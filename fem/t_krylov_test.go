@@ -0,0 +1,166 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_krylov01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("krylov01. iterative solvers with SPD matrix")
+
+	// 1D Laplacian with repeated (element-like) entries
+	n := 50
+	kry := &Krylov{Tol: 1e-12, MaxIt: 1000, Restart: 50}
+	kry.Init(n, 4*n)
+	kry.Start()
+	for i := 0; i < n-1; i++ {
+		kry.Put(i, i, 1)
+		kry.Put(i, i+1, -1)
+		kry.Put(i+1, i, -1)
+		kry.Put(i+1, i+1, 1)
+	}
+	kry.Put(0, 0, 1)
+	kry.Put(n-1, n-1, 1)
+
+	// exact solution and right-hand side
+	xcor := make([]float64, n)
+	for i := 0; i < n; i++ {
+		xcor[i] = float64(i%7) - 3
+	}
+	b := make([]float64, n)
+	x := make([]float64, n)
+	kry.compress()
+	kry.matvec(b, xcor)
+
+	// check compressed matrix
+	chk.IntAssert(len(kry.ci), 3*n-2)
+	chk.Scalar(tst, "K[0][0]", 1e-17, kry.get(0, 0), 2)
+	chk.Scalar(tst, "K[1][1]", 1e-17, kry.get(1, 1), 2)
+	chk.Scalar(tst, "K[1][2]", 1e-17, kry.get(1, 2), -1)
+	chk.Scalar(tst, "K[1][3]", 1e-17, kry.get(1, 3), 0)
+
+	// solve
+	for _, method := range []string{"cg", "gmres", "bicgstab"} {
+		for _, precond := range []string{"none", "jacobi", "ilu0"} {
+			kry.Method, kry.Precond = method, precond
+			if err := kry.Fact(); err != nil {
+				tst.Errorf("Fact failed: %v\n", err)
+				return
+			}
+			if err := kry.SolveR(x, b, false); err != nil {
+				tst.Errorf("%s(%s) failed: %v\n", method, precond, err)
+				continue
+			}
+			io.Pforan("%8s(%6s): nit = %3d\n", method, precond, kry.Nit)
+			chk.Vector(tst, io.Sf("%s(%s): x", method, precond), 1e-9, x, xcor)
+		}
+	}
+
+	// ILU(0) of a tridiagonal matrix is exact
+	kry.Method, kry.Precond = "gmres", "ilu0"
+	kry.Fact()
+	kry.SolveR(x, b, false)
+	chk.IntAssert(kry.Nit, 1)
+}
+
+func Test_krylov02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("krylov02. iterative solvers with saddle-point matrix")
+
+	//  _       _
+	// |  K  Bt  |  with K = 1D Laplacian and B = constraints on first and last equations
+	// |_ B   0 _|
+	n := 20
+	kry := &Krylov{Tol: 1e-12, MaxIt: 200, Restart: 30}
+	kry.Init(n+2, 3*n+4)
+	kry.Start()
+	for i := 0; i < n; i++ {
+		kry.Put(i, i, 2)
+		if i > 0 {
+			kry.Put(i, i-1, -1)
+			kry.Put(i-1, i, -1)
+		}
+	}
+	for k, eq := range []int{0, n - 1} {
+		kry.Put(n+k, eq, 1)
+		kry.Put(eq, n+k, 1)
+	}
+	pblk := make([]bool, n+2)
+	pblk[n], pblk[n+1] = true, true
+	kry.SetBlock(pblk)
+
+	// exact solution and right-hand side
+	xcor := make([]float64, n+2)
+	for i := 0; i < n+2; i++ {
+		xcor[i] = float64(i%5) - 2
+	}
+	b := make([]float64, n+2)
+	x := make([]float64, n+2)
+	kry.compress()
+	kry.matvec(b, xcor)
+
+	// solve
+	for _, method := range []string{"gmres", "bicgstab"} {
+		for _, precond := range []string{"ilu0", "block"} {
+			kry.Method, kry.Precond = method, precond
+			if err := kry.Fact(); err != nil {
+				tst.Errorf("Fact failed: %v\n", err)
+				return
+			}
+			if err := kry.SolveR(x, b, false); err != nil {
+				tst.Errorf("%s(%s) failed: %v\n", method, precond, err)
+				continue
+			}
+			io.Pforan("%8s(%6s): nit = %3d\n", method, precond, kry.Nit)
+			chk.Vector(tst, io.Sf("%s(%s): x", method, precond), 1e-9, x, xcor)
+		}
+	}
+}
+
+func Test_krylov03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("krylov03. iterative solvers in FE simulation")
+
+	// run with given linear solver and enforcement mode
+	run := func(linsol, precond, mode string) (y []float64) {
+		defer End()
+		if !Start("data/enfmode01.sim", true, chk.Verbose) {
+			tst.Errorf("Start failed\n")
+			return
+		}
+		Global.Sim.LinSol.Name = linsol
+		Global.Sim.LinSol.Precond = precond
+		Global.Sim.Solver.EnfMode = mode
+		Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+			y = make([]float64, d.Ny)
+			copy(y, d.Sol.Y)
+			return true
+		}
+		defer func() { Global.OutHook = nil }()
+		if !Run() {
+			tst.Errorf("Run failed\n")
+		}
+		return
+	}
+
+	// direct solver
+	ycor := run("umfpack", "", "lagrange")
+	if tst.Failed() {
+		return
+	}
+
+	// iterative solvers
+	chk.Vector(tst, "cg(ilu0) with elimination", 1e-8, run("cg", "ilu0", "elimination"), ycor)
+	chk.Vector(tst, "gmres(block) with multipliers", 1e-8, run("gmres", "block", "lagrange"), ycor)
+	chk.Vector(tst, "bicgstab(jacobi) with elimination", 1e-8, run("bicgstab", "jacobi", "elimination"), ycor)
+}
@@ -0,0 +1,145 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_mpc01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mpc01. inclined supports, multi-point and periodic constraints in 3D")
+
+	// domain
+	defer End()
+	if !Start("data/mpc01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	dom := NewDomain(Global.Sim.Regions[0], false)
+	if !dom.SetStage(0, Global.Sim.Stages[0], false) {
+		tst.Errorf("SetStage failed\n")
+		return
+	}
+
+	// periodic pairs
+	masters, slaves := dom.Msh.PeriodicPairs(-10, -11)
+	chk.IntAssert(len(masters), 4)
+	for i, m := range masters {
+		a, b := dom.Msh.Verts[m].C, dom.Msh.Verts[slaves[i]].C
+		chk.Vector(tst, io.Sf("x[%d] - x[%d]", slaves[i], m), 1e-15, []float64{b[0] - a[0], b[1] - a[1], b[2] - a[2]}, []float64{2, 0, 0})
+	}
+
+	// equations => keys and coordinates
+	eq2key := make(map[int]string)
+	eq2x := make(map[int][]float64)
+	for _, nod := range dom.Nodes {
+		for _, dof := range nod.Dofs {
+			eq2key[dof.Eq] = dof.Key
+			eq2x[dof.Eq] = nod.Vert.C
+		}
+	}
+
+	// constraints
+	counts := make(map[string]int)
+	s := 1.0 / math.Sqrt2
+	for _, c := range dom.EssenBcs.Bcs {
+		io.Pforan("c.Key=%s c.Eqs=%v c.ValsA=%v\n", c.Key, c.Eqs, c.ValsA)
+		counts[c.Key] += 1
+		switch c.Key {
+		case "incsup":
+			if len(c.Eqs) == 1 { // face normal
+				chk.StrAssert(eq2key[c.Eqs[0]], "uz")
+				chk.Scalar(tst, "z", 1e-15, eq2x[c.Eqs[0]][2], 0)
+				chk.Scalar(tst, "|nz|", 1e-15, math.Abs(c.ValsA[0]), 1)
+				continue
+			}
+			chk.StrAssert(eq2key[c.Eqs[0]]+eq2key[c.Eqs[1]], "uxuy")
+			chk.Scalar(tst, "y", 1e-15, eq2x[c.Eqs[0]][1], 1)
+			chk.Vector(tst, "n", 1e-15, c.ValsA, []float64{s, s})
+		case "periodic":
+			chk.StrAssert(eq2key[c.Eqs[0]], eq2key[c.Eqs[1]])
+			chk.Scalar(tst, "x(slave)", 1e-15, eq2x[c.Eqs[0]][0], 2)
+			chk.Scalar(tst, "x(master)", 1e-15, eq2x[c.Eqs[1]][0], 0)
+			chk.Vector(tst, "vals", 1e-15, c.ValsA, []float64{1, -1})
+			if eq2key[c.Eqs[0]] == "uz" && eq2x[c.Eqs[0]][2] == 0 {
+				tst.Errorf("redundant periodic constraint between equations %v\n", c.Eqs)
+			}
+		case "mpc":
+			chk.StrAssert(eq2key[c.Eqs[0]]+eq2key[c.Eqs[1]], "uxuy")
+			chk.Vector(tst, "x(tag=-1)", 1e-15, eq2x[c.Eqs[0]], []float64{0, 0, 0})
+			chk.Vector(tst, "x(tag=-2)", 1e-15, eq2x[c.Eqs[1]], []float64{2, 1, 1})
+			chk.Vector(tst, "coefs", 1e-15, c.ValsA, []float64{1, -2})
+		default:
+			tst.Errorf("key %s is incorrect", c.Key)
+		}
+	}
+	chk.IntAssert(counts["incsup"], 12)
+	chk.IntAssert(counts["periodic"], 10)
+	chk.IntAssert(counts["mpc"], 1)
+}
+
+func Test_mpc02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mpc02. inclined supports with averaged face normals and default direction")
+
+	// domain
+	defer End()
+	if !Start("data/incsup01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	dom := NewDomain(Global.Sim.Regions[0], false)
+	if !dom.SetStage(0, Global.Sim.Stages[0], false) {
+		tst.Errorf("SetStage failed\n")
+		return
+	}
+
+	// equations => vertices and keys
+	eq2vid := make(map[int]int)
+	eq2key := make(map[int]string)
+	for _, nod := range dom.Nodes {
+		for _, dof := range nod.Dofs {
+			eq2vid[dof.Eq] = nod.Vert.Id
+			eq2key[dof.Eq] = dof.Key
+		}
+	}
+
+	// normals of inclined supports at vertices
+	normals := make(map[int][]float64)
+	for _, c := range dom.EssenBcs.Bcs {
+		if c.Key != "incsup" {
+			continue
+		}
+		vid := eq2vid[c.Eqs[0]]
+		if _, found := normals[vid]; found {
+			tst.Errorf("vertex %d must have one inclined support only\n", vid)
+			return
+		}
+		n := make([]float64, 2)
+		for i, eq := range c.Eqs {
+			if eq2key[eq] == "ux" {
+				n[0] = c.ValsA[i]
+			} else {
+				n[1] = c.ValsA[i]
+			}
+		}
+		normals[vid] = n
+	}
+
+	// check: default direction @ 0; normals of each face @ 3 and 5; average @ 4
+	s, c := 1.0/math.Sqrt(1.25), 0.5/math.Sqrt(1.25)
+	chk.IntAssert(len(normals), 4)
+	chk.Vector(tst, "n @ 0", 1e-15, normals[0], []float64{1, 0})
+	chk.Vector(tst, "n @ 3", 1e-15, normals[3], []float64{-c, s})
+	chk.Vector(tst, "n @ 4", 1e-15, normals[4], []float64{0, 1})
+	chk.Vector(tst, "n @ 5", 1e-15, normals[5], []float64{c, s})
+}
@@ -197,3 +197,92 @@ func Test_p02_(tst *testing.T) {
 		return
 	}
 }
+
+func Test_p02adapt(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("p02adapt. adaptive time stepping")
+
+	// reference solution with small constant time steps
+	plref, tref, _, ok := p02_final_pl("data/p02fine.sim")
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// solution with adaptive time steps
+	pl, t, sum, ok := p02_final_pl("data/p02adapt.sim")
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// check solution
+	chk.Scalar(tst, "tf", 1e-10, t, tref)
+	chk.Vector(tst, "pl", 1e-2, pl, plref)
+
+	// check accepted time steps: Δt must start with the given value and grow up to Δtmax at most
+	dts := sum.StepDts
+	io.Pforan("number of accepted steps = %v\n", len(dts))
+	io.Pforan("Δt = %v\n", dts)
+	if len(dts) < 2 {
+		tst.Errorf("there must be at least two accepted steps\n")
+		return
+	}
+	chk.Scalar(tst, "Δt0", 1e-15, dts[0], 1)
+	dtmax, tsum := 0.0, 0.0
+	for _, dt := range dts {
+		dtmax = max(dtmax, dt)
+		tsum += dt
+	}
+	chk.Scalar(tst, "ΣΔt", 1e-8, tsum, tref)
+	if dts[1] <= dts[0] {
+		tst.Errorf("Δt must grow after the first step: Δt1 = %g <= Δt0 = %g\n", dts[1], dts[0])
+	}
+	if dtmax < 10 || dtmax > 250 {
+		tst.Errorf("max(Δt) must be in [10, Δtmax=250]. %g is incorrect\n", dtmax)
+	}
+	if len(dts) >= 5000 {
+		tst.Errorf("adaptive time stepping must use fewer steps than the reference simulation. nsteps = %d\n", len(dts))
+	}
+}
+
+// p02_final_pl runs p02 simulations and returns the liquid pressures at nodes @ final time
+// together with the summary of the simulation
+func p02_final_pl(simfilepath string) (pl []float64, t float64, sum *Summary, ok bool) {
+
+	// start simulation
+	if !Start(simfilepath, true, chk.Verbose) {
+		return
+	}
+	defer End()
+
+	// run simulation
+	if !Run() {
+		return
+	}
+
+	// allocate domain
+	distr := false
+	d := NewDomain(Global.Sim.Regions[0], distr)
+	if !d.SetStage(0, Global.Sim.Stages[0], distr) {
+		return
+	}
+
+	// read results
+	sum = ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		return
+	}
+	ntout := len(sum.OutTimes)
+	io.Pforan("%s: output times = %v\n", Global.Fnkey, sum.OutTimes)
+	if !d.In(sum, ntout-1, true) {
+		return
+	}
+
+	// liquid pressures
+	for _, nod := range d.Nodes {
+		pl = append(pl, d.Sol.Y[nod.GetEq("pl")])
+	}
+	return pl, d.Sol.T, sum, true
+}
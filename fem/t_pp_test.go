@@ -0,0 +1,145 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_pp01a(tst *testing.T) {
+
+	/* this tests simulates liquid and gas flow along a column
+	 * by reducing the initial hydrostatic liquid pressure at
+	 * the bottom of the column (same mesh as in p01)
+	 */
+
+	//verbose()
+	chk.PrintTitle("pp01a")
+
+	// start simulation
+	if !Start("data/pp01.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// domain
+	distr := false
+	dom := NewDomain(Global.Sim.Regions[0], distr)
+	if dom == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// set stage
+	if !dom.SetStage(0, Global.Sim.Stages[0], distr) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// nodes and elements
+	chk.IntAssert(len(dom.Nodes), 27)
+	chk.IntAssert(len(dom.Elems), 4)
+
+	// check dofs
+	for _, nod := range dom.Nodes {
+		chk.IntAssert(len(nod.Dofs), 2)
+		chk.StrAssert(nod.Dofs[0].Key, "pl")
+		chk.StrAssert(nod.Dofs[1].Key, "pg")
+	}
+
+	// check maps
+	for _, ele := range dom.Elems {
+		e := ele.(*ElemPP)
+		io.Pforan("e%d.plmap = %v\n", e.Id(), e.PLmap)
+		io.Pforan("e%d.pgmap = %v\n", e.Id(), e.PGmap)
+		chk.IntAssert(len(e.PLmap), 9)
+		chk.IntAssert(len(e.PGmap), 9)
+		for m := 0; m < 9; m++ {
+			chk.IntAssert(e.PGmap[m], e.PLmap[m]+1)
+		}
+	}
+
+	// constraints
+	chk.IntAssert(len(dom.EssenBcs.Bcs), 6)
+	var nct_pl, nct_pg int
+	var ct_pl_eqs []int // equations with pl prescribed [sorted]
+	for _, c := range dom.EssenBcs.Bcs {
+		chk.IntAssert(len(c.Eqs), 1)
+		switch c.Key {
+		case "pl":
+			ct_pl_eqs = append(ct_pl_eqs, c.Eqs[0])
+			nct_pl++
+		case "pg":
+			nct_pg++
+		default:
+			tst.Errorf("key %s is incorrect", c.Key)
+		}
+	}
+	sort.Ints(ct_pl_eqs)
+	chk.IntAssert(nct_pl, 3)
+	chk.IntAssert(nct_pg, 3)
+	chk.Ints(tst, "equations with pl prescribed", ct_pl_eqs, []int{0, 2, 8})
+
+	// initial values @ nodes
+	io.Pforan("initial values @ nodes\n")
+	for _, nod := range dom.Nodes {
+		z := nod.Vert.C[1]
+		pl := dom.Sol.Y[nod.Dofs[0].Eq]
+		pg := dom.Sol.Y[nod.Dofs[1].Eq]
+		plC, _, _ := Global.HydroSt.Calc(z)
+		chk.Scalar(tst, io.Sf("nod %3d : pl(@ %4g)= %6g", nod.Vert.Id, z, pl), 1e-17, pl, plC)
+		chk.Scalar(tst, io.Sf("nod %3d : pg(@ %4g)= %6g", nod.Vert.Id, z, pg), 1e-17, pg, 0)
+	}
+
+	// intial values @ integration points
+	io.Pforan("initial values @ integration points\n")
+	for _, ele := range dom.Elems {
+		e := ele.(*ElemPP)
+		for idx, ip := range e.IpsElem {
+			s := e.States[idx]
+			z := e.Shp.IpRealCoords(e.X, ip)[1]
+			_, ρLC, _ := Global.HydroSt.Calc(z)
+			chk.Scalar(tst, io.Sf("sl(@ %18g)= %18g", z, s.A_sl), 1e-17, s.A_sl, 1)
+			chk.Scalar(tst, io.Sf("ρL(@ %18g)= %18g", z, s.A_ρL), 1e-13, s.A_ρL, ρLC)
+			chk.Scalar(tst, io.Sf("ρG(@ %18g)= %18g", z, s.A_ρG), 1e-17, s.A_ρG, e.Mdl.RhoG0)
+		}
+	}
+}
+
+func Test_pp01b(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("pp01b")
+
+	// start simulation
+	if !Start("data/pp01.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// for debugging Kb
+	if true {
+		defer pp_DebugKb(&testKb{
+			tst: tst, eid: 3, tol: 1e-6, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: 800, tmax: 1000,
+		})()
+	}
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gofem/shp"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_rayleigh01(tst *testing.T) {
+
+	/*  one element under oedometric conditions subjected to suddenly applied load
+	 *  => single degree of freedom system: m・ü + c・u̇ + k・u = F with c = αM・m + βK・k
+	 *
+	 *        qn = -10
+	 *      ↓↓↓↓↓↓↓↓↓↓↓
+	 *      3---------2
+	 *     >|         |<
+	 *     >|         |<
+	 *      0---------1
+	 *      ^         ^
+	 *
+	 *  mass of top nodes: lumped => m = ρ・A/2; consistent => m = ρ・A/3
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh01. Rayleigh damping and lumped mass matrices")
+
+	// constants
+	E, ν, ρ, F := 1000.0, 0.25, 1.0, -10.0
+	k := E * (1.0 - ν) / ((1.0 + ν) * (1.0 - 2.0*ν))
+	ξ, ω1, ω2 := 0.05, 2.0*math.Pi*5.0, 2.0*math.Pi*10.0
+
+	// simulations
+	for _, tc := range []struct {
+		fn     string
+		m      float64
+		αM, βK float64
+	}{
+		{"data/rayleigh01.sim", ρ / 2.0, 2.0 * ξ * ω1 * ω2 / (ω1 + ω2), 2.0 * ξ / (ω1 + ω2)}, // row-sum
+		{"data/rayleigh02.sim", ρ / 2.0, 2.0, 0.002},                                         // HRZ
+		{"data/rayleigh03.sim", ρ / 3.0, 2.0, 0.002},                                         // consistent
+	} {
+		if !run_sdof(tst, tc.fn, tc.m, tc.αM*tc.m+tc.βK*k, k, F) {
+			return
+		}
+	}
+}
+
+func Test_lumped01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("lumped01. row-sum and HRZ lumped mass matrices")
+
+	// initialisation
+	defer End()
+	if !Start("data/rayleigh01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// elements in natural coordinates. row-sum lumping must be rejected if any row sum is not
+	// positive; e.g. at corners of tri6, qua8, tet10 and hex20 cells
+	nonpositive := map[string]bool{"tri6": true, "qua8": true, "tet10": true, "hex20": true}
+	for _, ctype := range []string{"tri3", "tri6", "tri10", "tri15", "qua4", "qua8", "qua9", "qua12", "qua16", "tet4", "tet10", "hex8", "hex20"} {
+		o := ElemU{Shp: shp.Get(ctype)}
+		o.X = o.Shp.NatCoords
+		o.IpsElem, _ = GetIntegrationPoints(0, 0, ctype)
+		var vol float64
+		switch ctype[:3] {
+		case "tri":
+			vol = 0.5
+		case "qua":
+			vol = 4.0
+		case "tet":
+			vol = 1.0 / 6.0
+		case "hex":
+			vol = 8.0
+		}
+		for _, mass := range []string{"rowsum", "hrz"} {
+			o.Mass = mass
+			ok := o.init_damping(nil)
+			M := lumped_masses(&o)
+			io.Pforan("%6s: %6s: M = %v\n", ctype, mass, M)
+			sum, positive := 0.0, true
+			for _, Mm := range M {
+				sum += Mm
+				positive = positive && Mm > 1e-12*vol
+			}
+			chk.Scalar(tst, io.Sf("%s: %s: total mass", ctype, mass), 1e-13, sum, vol)
+			if mass == "hrz" && !(ok && positive) {
+				tst.Errorf("%s: HRZ masses must be positive. M = %v is incorrect\n", ctype, M)
+				return
+			}
+			if mass == "rowsum" && (ok != positive || ok && nonpositive[ctype]) {
+				tst.Errorf("%s: row-sum lumping must be rejected if and only if a mass is not positive. M = %v\n", ctype, M)
+				return
+			}
+		}
+	}
+
+	// qua8: row sums are negative at corners and thus row-sum lumping is rejected; HRZ yields 3/76
+	// and 16/76 of total mass
+	o := ElemU{Shp: shp.Get("qua8"), Mass: "rowsum"}
+	o.X = o.Shp.NatCoords
+	o.IpsElem, _ = GetIntegrationPoints(9, 0, "qua8")
+	chk.Vector(tst, "qua8: row sums", 1e-15, lumped_masses(&o), []float64{-1.0 / 3.0, -1.0 / 3.0, -1.0 / 3.0, -1.0 / 3.0, 4.0 / 3.0, 4.0 / 3.0, 4.0 / 3.0, 4.0 / 3.0})
+	if o.init_damping(nil) {
+		tst.Errorf("qua8: row-sum lumping must be rejected\n")
+		return
+	}
+	o.Mass = "hrz"
+	o.init_damping(nil)
+	c, e := 4.0*3.0/76.0, 4.0*16.0/76.0
+	chk.Vector(tst, "qua8: HRZ", 1e-15, lumped_masses(&o), []float64{c, c, c, c, e, e, e, e})
+}
+
+// run_sdof runs simulation of the single degree of freedom problem and compares the vertical
+// displacements at top with the solution computed with Newmark's method (θ1 = θ2 = 0.5)
+func run_sdof(tst *testing.T, simfn string, m, c, k, F float64) (ok bool) {
+
+	// initialisation
+	defer End()
+	if !Start(simfn, true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators
+	if true {
+		defer u_DebugKb(&testKb{
+			tst: tst, eid: 0, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: -1, itmax: -1, tmin: -1, tmax: 0.01,
+		})()
+	}
+
+	// solution of single degree of freedom system
+	dt, tf := 0.001, 0.3
+	nsteps := int(tf/dt + 0.5)
+	h, H := dt, dt*dt/2.0
+	α1, α2, α3 := 1.0/(0.5*H), h/(0.5*H), 1.0
+	α4, α5, α6 := 0.5*h/(0.5*H), 1.0, 0.0
+	ucor := make([]float64, nsteps+1)
+	var u, v, a float64
+	for n := 1; n <= nsteps; n++ {
+		ζ := α1*u + α2*v + α3*a
+		χ := α4*u + α5*v + α6*a
+		u = (F + m*ζ + c*χ) / (m*α1 + c*α4 + k)
+		a, v = α1*u-ζ, α4*u-χ
+		ucor[n] = u
+	}
+
+	// check displacements at top
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		n := int(d.Sol.T/dt + 0.5)
+		for _, vid := range []int{2, 3} {
+			uy := d.Sol.Y[d.Vid2node[vid].GetEq("uy")]
+			chk.Scalar(tst, io.Sf("%s: uy @ %d (t=%g)", simfn, vid, d.Sol.T), 1e-10, uy, ucor[n])
+		}
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+	io.Pforan("%s: u(tf) = %v  ust = %v\n", simfn, ucor[nsteps], F/k)
+	return true
+}
+
+// lumped_masses computes the lumped masses of nodes per unit density
+func lumped_masses(o *ElemU) (M []float64) {
+	M = make([]float64, o.Shp.Nverts)
+	for _, ip := range o.IpsElem {
+		o.Shp.CalcAtIp(o.X, ip, true)
+		for m := 0; m < o.Shp.Nverts; m++ {
+			M[m] += o.Shp.J * ip.W * o.lumpw(m)
+		}
+	}
+	return
+}
+
+func Test_rayleigh02(tst *testing.T) {
+
+	/*  coupled column (up elements) subjected to suddenly applied load with Rayleigh damping and
+	 *  lumped masses: HRZ with {aM, bK} in upper layer and row-sum with {xi, f1, f2} and B matrix
+	 *  in lower layer. the consistency of the mass and damping terms of Kb is checked
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh02. Rayleigh damping and lumped mass matrices in up elements")
+
+	for _, eid := range []int{0, 3} {
+		if !run_rayleighup(tst, eid) {
+			return
+		}
+	}
+}
+
+// run_rayleighup runs the simulation of the coupled column and checks Kb of element eid
+func run_rayleighup(tst *testing.T, eid int) (ok bool) {
+
+	// initialisation
+	defer End()
+	if !Start("data/rayleighup01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// callback to check consistent tangent operators
+	defer up_DebugKb(&testKb{
+		tst: tst, eid: eid, tol: 1e-6, verb: chk.Verbose,
+		ni: -1, nj: -1, itmin: -1, itmax: -1, tmin: -1, tmax: 0.02,
+	})()
+
+	// domain
+	var dom *Domain
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		dom = d
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+	for i, e := range dom.Elems {
+		o := e.(*ElemUP).U
+		mass := "hrz"
+		if i < 2 {
+			mass = "rowsum"
+		}
+		if o.Mass != mass || o.AlpM <= 0 || o.BetK <= 0 {
+			tst.Errorf("element %d: mass = %q, aM = %g and bK = %g are incorrect\n", i, o.Mass, o.AlpM, o.BetK)
+			return
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_resfile01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("resfile01")
+
+	// new file
+	fn := "/tmp/gofem/resfile01.res"
+	os.MkdirAll("/tmp/gofem", 0777)
+	os.Remove(fn)
+	res := OpenResFile(fn, true)
+	if res == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// write three output times
+	for tidx := 0; tidx < 3; tidx++ {
+		Y := []float64{float64(tidx), 10 + float64(tidx), 20 + float64(tidx)}
+		if !res.Append(tidx, 0.5*float64(tidx), []string{"Y", "ivs"}, [][]byte{floats2bytes(Y), []byte("abc")}) {
+			tst.Errorf("test failed\n")
+			return
+		}
+	}
+
+	// read back
+	res = OpenResFile(fn, false)
+	if res == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	io.Pforan("index = %v\n", res.Index)
+	chk.Ints(tst, "tidx", res.Index.Tidx, []int{0, 1, 2})
+	chk.Vector(tst, "times", 1e-17, res.Index.Times, []float64{0, 0.5, 1})
+	chk.IntAssert(res.Nfloats(1, "Y"), 3)
+	vals, ok := res.ReadFloats(1, "Y", []int{2, 0})
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+	chk.Vector(tst, "Y @ tidx=1", 1e-17, vals, []float64{21, 1})
+	b, ok := res.Read(2, "ivs")
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+	if string(b) != "abc" {
+		tst.Errorf("ivs chunk is incorrect: %q\n", b)
+		return
+	}
+
+	// restart from tidx=1
+	if !res.Append(1, 0.25, []string{"Y"}, [][]byte{floats2bytes([]float64{-1, -2})}) {
+		tst.Errorf("test failed\n")
+		return
+	}
+	res = OpenResFile(fn, false)
+	if res == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	chk.Ints(tst, "tidx (restart)", res.Index.Tidx, []int{0, 1})
+	vals, ok = res.ReadFloats(1, "Y", []int{0, 1})
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+	chk.Vector(tst, "Y @ tidx=1 (restart)", 1e-17, vals, []float64{-1, -2})
+	vals, ok = res.ReadFloats(0, "Y", []int{0, 1, 2})
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+	chk.Vector(tst, "Y @ tidx=0 (restart)", 1e-17, vals, []float64{0, 10, 20})
+}
+
+func Test_resfile02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("resfile02")
+
+	// start
+	if !Start("data/bh16.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+	defer End()
+	Global.Sim.Data.Indexed = true
+
+	// domain A
+	distr := false
+	domA := NewDomain(Global.Sim.Regions[0], distr)
+	if domA == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	if !domA.SetStage(0, Global.Sim.Stages[0], distr) {
+		tst.Errorf("test failed\n")
+		return
+	}
+	for i, _ := range domA.Sol.Y {
+		domA.Sol.Y[i] = float64(i)
+	}
+	domA.Sol.T = 1.5
+
+	// write file
+	tidx := 0
+	if !domA.Out(tidx) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// domain B
+	domB := NewDomain(Global.Sim.Regions[0], distr)
+	if domB == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	if !domB.SetStage(0, Global.Sim.Stages[0], distr) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// read file
+	sum := Summary{Nproc: 1, Dirout: Global.Dirout, Fnkey: Global.Fnkey}
+	if !domB.In(&sum, tidx, true) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// check
+	chk.Scalar(tst, "t", 1e-17, domB.Sol.T, 1.5)
+	chk.Vector(tst, "Y", 1e-17, domA.Sol.Y, domB.Sol.Y)
+	chk.Vector(tst, "dy/dt", 1e-17, domA.Sol.Dydt, domB.Sol.Dydt)
+	chk.Vector(tst, "d²y/dt²", 1e-17, domA.Sol.D2ydt2, domB.Sol.D2ydt2)
+}
@@ -0,0 +1,109 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_restart01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("restart01")
+
+	// complete run
+	dref, sumref, ok := restart_run("data/p01.sim", true, 0)
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// restart from the middle of the time loop
+	d, sum, ok := restart_run("data/p01.sim", false, 50)
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// check
+	chk.IntAssert(len(sum.OutTimes), len(sumref.OutTimes))
+	chk.Vector(tst, "Y", 1e-10, d.Sol.Y, dref.Sol.Y)
+}
+
+func Test_restart02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("restart02. plasticity, Lagrange multipliers and residuals")
+
+	// complete run
+	dref, sumref, ok := restart_run("data/spo751.sim", true, 0)
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+	if len(dref.Sol.L) == 0 {
+		tst.Errorf("simulation must have Lagrange multipliers\n")
+		return
+	}
+	if len(sumref.OutTimes) < 4 {
+		tst.Errorf("simulation must have at least 4 outputs\n")
+		return
+	}
+
+	// restart from the middle of the time loop
+	d, sum, ok := restart_run("data/spo751.sim", false, 2)
+	if !ok {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// check solution
+	chk.IntAssert(len(sum.OutTimes), len(sumref.OutTimes))
+	chk.Vector(tst, "t", 1e-15, sum.OutTimes, sumref.OutTimes)
+	chk.Vector(tst, "Y", 1e-10, d.Sol.Y, dref.Sol.Y)
+	chk.Vector(tst, "L", 1e-8, d.Sol.L, dref.Sol.L)
+
+	// check summary: residuals of steps after the restart index must have been discarded
+	chk.IntAssert(len(sum.OutSizes), len(sumref.OutSizes))
+	chk.Ints(tst, "Resids.Ptrs", sum.Resids.Ptrs, sumref.Resids.Ptrs)
+	chk.Vector(tst, "Resids.Vals", 1e-8, sum.Resids.Vals, sumref.Resids.Vals)
+}
+
+// restart_run runs simulation and returns the domain with the solution at the last output index
+func restart_run(simfn string, erasefiles bool, restart int) (d *Domain, sum *Summary, ok bool) {
+
+	// start simulation
+	if !Start(simfn, erasefiles, chk.Verbose) {
+		return
+	}
+	defer End()
+
+	// run simulation
+	Global.Restart = restart
+	defer func() { Global.Restart = 0 }()
+	if !Run() {
+		return
+	}
+
+	// read results
+	sum = ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		return
+	}
+	nout := len(sum.OutTimes)
+	io.Pforan("restart = %d: nout = %d, stage indices = %v\n", restart, nout, sum.StgTidx)
+	distr := false
+	d = NewDomain(Global.Sim.Regions[0], distr)
+	if !d.SetStage(0, Global.Sim.Stages[0], distr) {
+		return
+	}
+	if !d.In(sum, nout-1, true) {
+		return
+	}
+	return d, sum, true
+}
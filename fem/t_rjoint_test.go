@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
 	"github.com/cpmech/gosl/plt"
 )
 
@@ -74,3 +76,65 @@ func Test_rjoint01(tst *testing.T) {
 		plt.Show()
 	}
 }
+
+func Test_rjoint02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("rjoint02. rods crossing tri6, tet4 and tet10 cells")
+
+	for _, simfn := range []string{"rjoint02.sim", "rjoint03.sim", "rjoint04.sim"} {
+
+		// initialisation
+		if !Start("data/"+simfn, true, chk.Verbose) {
+			tst.Errorf("Start failed\n")
+			End()
+			return
+		}
+
+		// allocate domain and connect elements
+		dom := NewDomain(Global.Sim.Regions[0], false)
+		if !dom.SetStage(0, Global.Sim.Stages[0], false) {
+			tst.Errorf("SetStage failed\n")
+			End()
+			return
+		}
+
+		// check shape functions of solids @ nodes and ips of rods
+		njoints := 0
+		for _, elem := range dom.Elems {
+			e, ok := elem.(*Rjoint)
+			if !ok {
+				continue
+			}
+			njoints++
+			ndim := len(e.Sld.X)
+			x := make([]float64, ndim)
+			check := func(msg string, N [][]float64, col int, y []float64) {
+				sum := 0.0
+				la.VecFill(x, 0)
+				for n := 0; n < e.Sld.Shp.Nverts; n++ {
+					sum += N[n][col]
+					for i := 0; i < ndim; i++ {
+						x[i] += e.Sld.X[i][n] * N[n][col]
+					}
+				}
+				chk.Scalar(tst, io.Sf("%s: eid=%d %s %d: ΣN", simfn, e.Cid, msg, col), 1e-13, sum, 1)
+				chk.Vector(tst, io.Sf("%s: eid=%d %s %d: x", simfn, e.Cid, msg, col), 1e-13, x, y[:ndim])
+			}
+			for m := 0; m < e.Rod.Shp.Nverts; m++ {
+				y := make([]float64, ndim)
+				for i := 0; i < ndim; i++ {
+					y[i] = e.Rod.X[i][m]
+				}
+				check("node", e.Nmat, m, y)
+			}
+			if e.Coulomb {
+				for idx, ip := range e.Rod.IpsElem {
+					check("ip", e.Pmat, idx, e.Rod.Shp.IpRealCoords(e.Rod.X, ip))
+				}
+			}
+		}
+		chk.IntAssert(njoints, len(dom.Msh.CellTag2cells[-3]))
+		End()
+	}
+}
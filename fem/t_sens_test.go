@@ -0,0 +1,131 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// run_sens runs simulation and returns y and dy/dp at all output times. If prm is not empty, the
+// sensitivity analysis is switched off and the parameter prm of material mat is set to val in an
+// isolated copy of the material; i.e. with a new name such that the models shared by other runs
+// are not affected
+func run_sens(tst *testing.T, simfn, mat, prm string, val float64) (y [][]float64, dydp [][][]float64) {
+	defer End()
+	if !Start(simfn, true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+
+	// isolated material
+	if prm != "" {
+		Global.Sim.Data.Sens = nil
+		matdata := Global.Sim.Mdb.Get(mat)
+		matdata.Name = io.Sf("%s_%s_%g", mat, prm, val)
+		matdata.Prms.Find(prm).V = val
+		for _, reg := range Global.Sim.Regions {
+			for _, edat := range reg.ElemsData {
+				if edat.Mat == mat {
+					edat.Mat = matdata.Name
+				}
+			}
+		}
+	}
+
+	// run
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		y = append(y, la.VecClone(d.Sol.Y))
+		dydp = append(dydp, la.MatClone(d.Sol.Sens))
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+	if !Run() {
+		tst.Errorf("Run failed\n")
+	}
+	return
+}
+
+// check_sens compares the sensitivities w.r.t the idx-th parameter with central finite differences
+// of full runs at all output times
+func check_sens(tst *testing.T, simfn string, idx int, δ, tol float64) {
+
+	// sensitivities
+	y, dydp := run_sens(tst, simfn, "", "", 0)
+	if tst.Failed() {
+		return
+	}
+	sen := Global.Sim.Data.Sens[idx]
+	p := Global.Sim.Mdb.Get(sen.Mat).Prms.Find(sen.Prm).V
+
+	// central finite differences
+	yp, _ := run_sens(tst, simfn, sen.Mat, sen.Prm, p+δ)
+	ym, _ := run_sens(tst, simfn, sen.Mat, sen.Prm, p-δ)
+	if tst.Failed() {
+		return
+	}
+	if len(yp) != len(y) || len(ym) != len(y) {
+		tst.Errorf("number of output times is incorrect: %d, %d != %d\n", len(yp), len(ym), len(y))
+		return
+	}
+	for tidx := range y {
+		num := make([]float64, len(y[tidx]))
+		for i := range num {
+			num[i] = (yp[tidx][i] - ym[tidx][i]) / (2 * δ)
+		}
+		io.Pforan("tidx=%2d: max|dy/d%s| = %g\n", tidx, sen.Prm, la.VecLargest(num, 1))
+		chk.Vector(tst, io.Sf("dy/d%s @ tidx=%d", sen.Prm, tidx), tol, dydp[tidx][idx], num)
+	}
+}
+
+func Test_sens01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("sens01. direct differentiation sensitivity: elastic cubes")
+
+	// dy/dnu
+	check_sens(tst, "data/sens01.sim", 0, 1e-4, 1e-6)
+
+	// only displacements are prescribed => solution independent of E
+	_, dydp := run_sens(tst, "data/sens01.sim", "", "", 0)
+	if tst.Failed() {
+		return
+	}
+	last := dydp[len(dydp)-1]
+	chk.Vector(tst, "dy/dE", 1e-9, last[1], make([]float64, len(last[1])))
+}
+
+func Test_sens02(tst *testing.T) {
+
+	/* loading and unloading of elastoplastic thick cylinder with 12 time steps.
+	 * the sensitivities depend on the history of plastic strains; thus the results at each
+	 * output time are compared with finite differences of the whole simulation
+	 */
+
+	//verbose()
+	chk.PrintTitle("sens02. direct differentiation sensitivity: elastoplastic cylinder")
+
+	// dy/dqy0: qy0 = 0.24 GPa and u ~ 0.1 mm
+	check_sens(tst, "data/sens02.sim", 0, 1e-6, 1e-4)
+
+	// dy/dE: E = 210 GPa
+	check_sens(tst, "data/sens02.sim", 1, 1e-3, 1e-7)
+}
+
+func Test_sens03(tst *testing.T) {
+
+	/* as sens02 but with constant tangent during iterations: the sensitivities must be computed
+	 * with the tangent at the converged state and not with the factorisation of the first iteration
+	 */
+
+	//verbose()
+	chk.PrintTitle("sens03. direct differentiation sensitivity: constant tangent")
+
+	// dy/dqy0
+	check_sens(tst, "data/sens03.sim", 0, 1e-6, 1e-4)
+}
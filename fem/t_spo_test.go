@@ -216,6 +216,153 @@ func Test_spo751re(tst *testing.T) {
 	}
 }
 
+func Test_spo751arc(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo751arc. arc-length method")
+
+	// run simulation
+	if !Start("data/spo751arc.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// read summary
+	sum := ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		tst.Errorf("cannot read summary\n")
+		return
+	}
+
+	// equilibrium path: initial point + 20 steps
+	io.Pforan("λ  = %v\n", sum.LoadFacs)
+	io.Pforan("ub = %v\n", sum.CtrlDisp)
+	chk.IntAssert(len(sum.LoadFacs), 21)
+	chk.IntAssert(len(sum.CtrlDisp), 21)
+	chk.Scalar(tst, "λ0", 1e-17, sum.LoadFacs[0], 0)
+	chk.Scalar(tst, "ub0", 1e-17, sum.CtrlDisp[0], 0)
+	for i := 1; i < len(sum.CtrlDisp); i++ {
+		if sum.CtrlDisp[i] <= sum.CtrlDisp[i-1] {
+			tst.Errorf("control displacement must increase along the path. ub[%d]=%g <= ub[%d]=%g\n", i, sum.CtrlDisp[i], i-1, sum.CtrlDisp[i-1])
+			return
+		}
+	}
+
+	// limit load: Plim = 2 σy ln(b/a) / sqrt(3) with Pref = 0.2 GPa
+	σy, a, b, Pref := 0.24, 100.0, 200.0, 0.2
+	λlim := 2.0 * σy * math.Log(b/a) / math.Sqrt(3.0) / Pref
+	λmax := sum.LoadFacs[0]
+	for _, λ := range sum.LoadFacs {
+		λmax = max(λmax, λ)
+	}
+	io.Pforan("λmax = %v  λlim = %v\n", λmax, λlim)
+	chk.Scalar(tst, "λmax", 0.03, λmax, λlim)
+}
+
+func Test_spo754arc(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo754arc. strip footing collapse with arc-length method")
+
+	// run simulation
+	if !Start("data/spo754arc.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// read summary
+	sum := ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		tst.Errorf("cannot read summary\n")
+		return
+	}
+
+	// equilibrium path: initial point + 40 steps
+	io.Pforan("λ  = %v\n", sum.LoadFacs)
+	io.Pforan("uy = %v\n", sum.CtrlDisp)
+	chk.IntAssert(len(sum.LoadFacs), 41)
+	chk.IntAssert(len(sum.CtrlDisp), 41)
+	for i := 1; i < len(sum.CtrlDisp); i++ {
+		if sum.CtrlDisp[i] >= sum.CtrlDisp[i-1] {
+			tst.Errorf("settlement of footing must increase along the path. uy[%d]=%g >= uy[%d]=%g\n", i, sum.CtrlDisp[i], i-1, sum.CtrlDisp[i-1])
+			return
+		}
+	}
+
+	// limit load (Prandtl): qlim = (2 + π) c with c = σy / sqrt(3) and qref = 1000 kPa
+	σy, qref := 848.7, 1000.0
+	λlim := (2.0 + math.Pi) * σy / math.Sqrt(3.0) / qref
+	λmax := sum.LoadFacs[0]
+	for _, λ := range sum.LoadFacs {
+		λmax = max(λmax, λ)
+	}
+	io.Pforan("λmax = %v  λlim = %v\n", λmax, λlim)
+	chk.Scalar(tst, "λmax", 0.15, λmax, λlim)
+}
+
+func Test_spo751mc(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo751mc. Mohr-Coulomb model with φ=0 (Tresca)")
+
+	// run simulation
+	if !Start("data/spo751mc.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// read summary
+	sum := ReadSum(Global.Dirout, Global.Fnkey)
+	if sum == nil {
+		tst.Errorf("cannot read summary\n")
+		return
+	}
+
+	// analytical solution: Tresca with c = σy/sqrt(3) yields the same limit pressure as von Mises
+	var sol ana.PressCylin
+	sol.Init([]*fun.Prm{
+		&fun.Prm{N: "a", V: 100}, &fun.Prm{N: "b", V: 200},
+		&fun.Prm{N: "E", V: 210}, &fun.Prm{N: "ν", V: 0.3},
+		&fun.Prm{N: "σy", V: 0.24},
+	})
+
+	// limit load with Pref = 0.2 GPa
+	λlim := sol.Plim / 0.2
+	λmax := sum.LoadFacs[0]
+	for _, λ := range sum.LoadFacs {
+		λmax = max(λmax, λ)
+	}
+	io.Pforan("λmax = %v  λlim = %v\n", λmax, λlim)
+	chk.Scalar(tst, "λmax", 0.03, λmax, λlim)
+}
+
 func plot_spo751(fnkey string) {
 
 	// constants
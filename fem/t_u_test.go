@@ -126,3 +126,61 @@ func Test_sigini02(tst *testing.T) {
 		sol.CheckStress(tst, t, σ, x, tols)
 	}
 }
+
+func Test_ogden01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ogden01")
+
+	// start simulation
+	if !Start("data/ogden01.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+	defer End()
+
+	// for debugging Kb
+	if true {
+		defer u_DebugKb(&testKb{
+			tst: tst, eid: 0, tol: 1e-5, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: -1, tmax: -1,
+		})()
+	}
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// allocate domain
+	distr := false
+	d := NewDomain(Global.Sim.Regions[0], distr)
+	if !d.SetStage(0, Global.Sim.Stages[0], distr) {
+		tst.Errorf("SetStage failed\n")
+		return
+	}
+
+	// read results
+	sum := ReadSum(Global.Dirout, Global.Fnkey)
+	ntout := len(sum.OutTimes)
+	d.In(sum, ntout-1, true)
+
+	// homogeneous deformation: λx from lateral free surface and λy = 0.7
+	e := d.Elems[0].(*ElemU)
+	λy := 0.7
+	for _, n := range d.Nodes {
+		y := n.Vert.C[1]
+		uy := d.Sol.Y[n.GetEq("uy")]
+		chk.Scalar(tst, io.Sf("uy @ y=%g", y), 1e-12, uy, (λy-1.0)*y)
+	}
+	for idx, _ := range e.IpsElem {
+		σ := e.States[idx].Sig
+		io.Pforan("σ = %v\n", σ)
+		chk.Scalar(tst, "σx", 1e-7, σ[0], 0)
+		chk.Scalar(tst, "σxy", 1e-7, σ[3], 0)
+		if σ[1] >= 0 {
+			tst.Errorf("vertical stress must be compressive. σy = %g\n", σ[1])
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_upp01a(tst *testing.T) {
+
+	/* this tests simulates the coupled deformation and liquid-gas flow along
+	 * a column by reducing the liquid pressure at the bottom (same as pp01)
+	 */
+
+	//verbose()
+	chk.PrintTitle("upp01a")
+
+	// start simulation
+	if !Start("data/upp01.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+
+	// make sure to flush log
+	defer End()
+
+	// for debugging Kb
+	if true {
+		defer upp_DebugKb(&testKb{
+			tst: tst, eid: 3, tol: 1e-6, verb: chk.Verbose,
+			ni: -1, nj: -1, itmin: 1, itmax: -1, tmin: 800, tmax: 1000,
+		})()
+	}
+
+	// run simulation
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+}
+
+func Test_upp01b(tst *testing.T) {
+
+	/* with an (almost) rigid solid skeleton, the liquid and gas pressures
+	 * computed with upp elements must be equal to those computed with pp elements
+	 */
+
+	//verbose()
+	chk.PrintTitle("upp01b")
+
+	// run pp simulation and save pressures @ nodes
+	ppres := make(map[int][][]float64) // tidx => [nnod][2]{pl, pg}
+	if !run_upp_cmp(tst, "data/pp02.sim", func(d *Domain, tidx int) {
+		res := make([][]float64, len(d.Nodes))
+		for i, nod := range d.Nodes {
+			res[i] = []float64{d.Sol.Y[nod.GetEq("pl")], d.Sol.Y[nod.GetEq("pg")]}
+		}
+		ppres[tidx] = res
+	}) {
+		return
+	}
+
+	// run upp simulation and compare
+	if !run_upp_cmp(tst, "data/upp02.sim", func(d *Domain, tidx int) {
+		res, found := ppres[tidx]
+		if !found {
+			tst.Errorf("cannot find pp results for tidx = %d\n", tidx)
+			return
+		}
+		for i, nod := range d.Nodes {
+			if chk.Verbose && i == 0 {
+				io.Pfyel("t = %v\n", d.Sol.T)
+			}
+			chk.Scalar(tst, io.Sf("pl @ %2d", nod.Vert.Id), 1e-3, d.Sol.Y[nod.GetEq("pl")], res[i][0])
+			chk.Scalar(tst, io.Sf("pg @ %2d", nod.Vert.Id), 1e-3, d.Sol.Y[nod.GetEq("pg")], res[i][1])
+		}
+	}) {
+		return
+	}
+}
+
+// run_upp_cmp runs simulation and calls hook at each output time
+func run_upp_cmp(tst *testing.T, simfn string, hook func(d *Domain, tidx int)) (ok bool) {
+	defer End()
+	if !Start(simfn, true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		hook(d, tidx)
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+	if !Run() {
+		tst.Errorf("Run failed\n")
+		return
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+)
+
+// run_with_workers runs simulation with given number of workers and returns the last y
+func run_with_workers(tst *testing.T, simfile string, nworkers int) (y []float64, nw int) {
+	defer End()
+	if !Start(simfile, true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	Global.Sim.Data.Nworkers = nworkers
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		y = make([]float64, d.Ny)
+		copy(y, d.Sol.Y)
+		nw = len(d.Workers)
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+	if !Run() {
+		tst.Errorf("Run failed\n")
+	}
+	return
+}
+
+func Test_workers01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("workers01. concurrent computation of elements: spo751")
+
+	// start simulation
+	if !Start("data/spo751.sim", true, chk.Verbose) {
+		tst.Errorf("test failed\n")
+		return
+	}
+	defer End()
+
+	// run simulation with workers
+	Global.Sim.Data.Nworkers = 3
+	nw := 0
+	Global.OutHook = func(d *Domain, tidx int) (ok bool) {
+		nw = len(d.Workers)
+		return true
+	}
+	defer func() { Global.OutHook = nil }()
+	if !Run() {
+		tst.Errorf("test failed\n")
+		return
+	}
+	if nw != 3 {
+		tst.Errorf("number of workers is incorrect: %d != 3\n", nw)
+		return
+	}
+
+	// check
+	verb := false
+	skipK := true
+	tolK := 1e-17
+	tolu := 1e-12
+	tols := 1e-14
+	TestingCompareResultsU(tst, "data/spo751.sim", "cmp/spo751.cmp", tolK, tolu, tols, skipK, verb)
+}
+
+func Test_workers02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("workers02. concurrent computation of elements: up01")
+
+	yS, nwS := run_with_workers(tst, "data/up01.sim", 0)
+	yC, nwC := run_with_workers(tst, "data/up01.sim", 2)
+	if tst.Failed() {
+		return
+	}
+	if nwS != 0 || nwC != 2 {
+		tst.Errorf("numbers of workers are incorrect: %d != 0 or %d != 2\n", nwS, nwC)
+		return
+	}
+	chk.Vector(tst, "y(workers)", 1e-13, yC, yS)
+}
+
+func Test_workers03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("workers03. errors logged by concurrent workers")
+
+	// domain with workers
+	defer End()
+	if !Start("data/up01.sim", true, chk.Verbose) {
+		tst.Errorf("Start failed\n")
+		return
+	}
+	Global.Sim.Data.Nworkers = 2
+	dom := NewDomain(Global.Sim.Regions[0], false)
+	if !dom.SetStage(0, Global.Sim.Stages[0], false) {
+		tst.Errorf("SetStage failed\n")
+		return
+	}
+	if len(dom.Workers) != 2 {
+		tst.Errorf("number of workers is incorrect: %d != 2\n", len(dom.Workers))
+		return
+	}
+
+	// all workers log errors at the same time (run with -race)
+	ok := dom.run_workers(func(w *Worker, e Elem) bool {
+		return !LogErrCond(true, "workers03: error in element %d", e.Id())
+	}, func(e Elem) bool {
+		return true
+	})
+	if ok {
+		tst.Errorf("run_workers must fail\n")
+	}
+	if !Stop() {
+		tst.Errorf("Stop must return true after errors\n")
+	}
+	Global.WspcStop[Global.Rank] = 0
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/cpmech/gofem/msolid"
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
 	"github.com/cpmech/gosl/num"
 )
 
@@ -164,6 +165,12 @@ func TestingCompareResultsU(tst *testing.T, simfname, cmpfname string, tolK, tol
 	}
 }
 
+// testElem defines the methods of elements (or contact pairs) used to compute Kb numerically
+type testElem interface {
+	AddToRhs(fb []float64, sol *Solution) (ok bool)
+	Update(sol *Solution) (ok bool)
+}
+
 // testKb helps on checking Kb matrices
 type testKb struct {
 
@@ -384,6 +391,147 @@ func up_DebugKb(o *testKb) (resetDebugKb func()) {
 	return
 }
 
+// pp_DebugKb defines a global function to debug Kb for pp-elements
+//  Note: it returns a function to reset the global function
+func pp_DebugKb(o *testKb) (resetDebugKb func()) {
+
+	// define reset function
+	resetDebugKb = func() {
+		Global.DebugKb = nil
+	}
+
+	// define debug function
+	Global.DebugKb = func(d *Domain, it int) {
+
+		elem := d.Elems[o.eid]
+		if e, ok := elem.(*ElemPP); ok {
+
+			// skip?
+			o.it = it
+			o.t = d.Sol.T
+			if o.skip() {
+				return
+			}
+
+			// copy states and solution
+			nip := len(e.IpsElem)
+			states := make([]*mporous.State, nip)
+			statesBkp := make([]*mporous.State, nip)
+			for i := 0; i < nip; i++ {
+				states[i] = e.States[i].GetCopy()
+				statesBkp[i] = e.StatesBkp[i].GetCopy()
+			}
+			o.aux_arrays(d)
+
+			// make sure to restore states and solution
+			defer func() {
+				for i := 0; i < nip; i++ {
+					e.States[i].Set(states[i])
+					e.StatesBkp[i].Set(statesBkp[i])
+				}
+				copy(d.Sol.ΔY, o.ΔYbkp)
+			}()
+
+			// define restore function
+			restore := func() {
+				if it == 0 {
+					for k := 0; k < nip; k++ {
+						e.States[k].Set(states[k])
+					}
+					return
+				}
+				for k := 0; k < nip; k++ {
+					e.States[k].Set(statesBkp[k])
+				}
+			}
+
+			// check
+			o.check("Kll", d, e, e.PLmap, e.PLmap, e.Kll, restore)
+			o.check("Klg", d, e, e.PLmap, e.PGmap, e.Klg, restore)
+			o.check("Kgl", d, e, e.PGmap, e.PLmap, e.Kgl, restore)
+			o.check("Kgg", d, e, e.PGmap, e.PGmap, e.Kgg, restore)
+		}
+	}
+	return
+}
+
+// upp_DebugKb defines a global function to debug Kb for upp-elements
+//  Note: it returns a function to reset the global function
+func upp_DebugKb(o *testKb) (resetDebugKb func()) {
+
+	// define reset function
+	resetDebugKb = func() {
+		Global.DebugKb = nil
+	}
+
+	// define debug function
+	Global.DebugKb = func(d *Domain, it int) {
+
+		elem := d.Elems[o.eid]
+		if e, ok := elem.(*ElemUPP); ok {
+
+			// skip?
+			o.it = it
+			o.t = d.Sol.T
+			if o.skip() {
+				return
+			}
+
+			// copy states and solution
+			nip := len(e.U.IpsElem)
+			u_states := make([]*msolid.State, nip)
+			p_states := make([]*mporous.State, nip)
+			u_statesBkp := make([]*msolid.State, nip)
+			p_statesBkp := make([]*mporous.State, nip)
+			for i := 0; i < nip; i++ {
+				u_states[i] = e.U.States[i].GetCopy()
+				p_states[i] = e.P.States[i].GetCopy()
+				u_statesBkp[i] = e.U.StatesBkp[i].GetCopy()
+				p_statesBkp[i] = e.P.StatesBkp[i].GetCopy()
+			}
+			o.aux_arrays(d)
+
+			// make sure to restore states and solution
+			defer func() {
+				for i := 0; i < nip; i++ {
+					e.U.States[i].Set(u_states[i])
+					e.P.States[i].Set(p_states[i])
+					e.U.StatesBkp[i].Set(u_statesBkp[i])
+					e.P.StatesBkp[i].Set(p_statesBkp[i])
+				}
+				copy(d.Sol.ΔY, o.ΔYbkp)
+			}()
+
+			// define restore function
+			restore := func() {
+				if it == 0 {
+					for k := 0; k < nip; k++ {
+						e.U.States[k].Set(u_states[k])
+						e.P.States[k].Set(p_states[k])
+					}
+					return
+				}
+				for k := 0; k < nip; k++ {
+					e.U.States[k].Set(u_statesBkp[k])
+					e.P.States[k].Set(p_statesBkp[k])
+				}
+			}
+
+			// check
+			o.check("Kuu", d, e, e.U.Umap, e.U.Umap, e.U.K, restore)
+			o.check("Kul", d, e, e.U.Umap, e.P.PLmap, e.Kul, restore)
+			o.check("Kug", d, e, e.U.Umap, e.P.PGmap, e.Kug, restore)
+			o.check("Klu", d, e, e.P.PLmap, e.U.Umap, e.Klu, restore)
+			o.check("Kgu", d, e, e.P.PGmap, e.U.Umap, e.Kgu, restore)
+			o.check("Kll", d, e, e.P.PLmap, e.P.PLmap, e.P.Kll, restore)
+			o.check("Klg", d, e, e.P.PLmap, e.P.PGmap, e.P.Klg, restore)
+			o.check("Kgl", d, e, e.P.PGmap, e.P.PLmap, e.P.Kgl, restore)
+			o.check("Kgg", d, e, e.P.PGmap, e.P.PGmap, e.P.Kgg, restore)
+		}
+	}
+	return
+}
+
 // rjoint_DebugKb defines a global function to debug Kb for rjoint-elements
 //  Note: it returns a function to reset the global function
 func rjoint_DebugKb(o *testKb) (resetDebugKb func()) {
@@ -450,6 +598,148 @@ func rjoint_DebugKb(o *testKb) (resetDebugKb func()) {
 	return
 }
 
+// ijoint_DebugKb defines a global function to debug Kb for ijoint-elements
+//  Note: it returns a function to reset the global function
+func ijoint_DebugKb(o *testKb) (resetDebugKb func()) {
+
+	// define reset function
+	resetDebugKb = func() {
+		Global.DebugKb = nil
+	}
+
+	// define debug function
+	Global.DebugKb = func(d *Domain, it int) {
+
+		elem := d.Elems[o.eid]
+		if e, ok := elem.(*Ijoint); ok {
+
+			// skip?
+			o.it = it
+			o.t = d.Sol.T
+			if o.skip() {
+				return
+			}
+
+			// copy states and solution
+			nip := len(e.IpsElem)
+			states := make([]*msolid.State, nip)
+			statesBkp := make([]*msolid.State, nip)
+			for i := 0; i < nip; i++ {
+				states[i] = e.States[i].GetCopy()
+				statesBkp[i] = e.StatesBkp[i].GetCopy()
+			}
+			o.aux_arrays(d)
+
+			// make sure to restore states and solution
+			defer func() {
+				for i := 0; i < nip; i++ {
+					e.States[i].Set(states[i])
+					e.StatesBkp[i].Set(statesBkp[i])
+				}
+				copy(d.Sol.ΔY, o.ΔYbkp)
+			}()
+
+			// define restore function
+			restore := func() {
+				if it == 0 {
+					for k := 0; k < nip; k++ {
+						e.States[k].Set(states[k])
+					}
+					return
+				}
+				for k := 0; k < nip; k++ {
+					e.States[k].Set(statesBkp[k])
+				}
+			}
+
+			// check
+			o.check("K", d, e, e.Umap, e.Umap, e.K, restore)
+			if e.HasP {
+				o.check("Kup", d, e, e.Umap, e.Pmap, e.Kup, restore)
+				o.check("Kpp", d, e, e.Pmap, e.Pmap, e.Kpp, restore)
+			}
+		} else {
+			io.Pfred("warning: eid=%d does not correspond to Ijoint element\n", o.eid)
+		}
+	}
+	return
+}
+
+// contact_DebugKb defines a global function to debug Kb for contact pairs
+//  Note: eid is the index of the slave vertex of the first contact pair
+//        it returns a function to reset the global function
+func contact_DebugKb(o *testKb) (resetDebugKb func()) {
+
+	// define reset function
+	resetDebugKb = func() {
+		Global.DebugKb = nil
+	}
+
+	// define debug function
+	Global.DebugKb = func(d *Domain, it int) {
+
+		if len(d.Contacts) < 1 {
+			io.Pfred("warning: there are no contact pairs\n")
+			return
+		}
+		c := d.Contacts[0]
+
+		// skip?
+		o.it = it
+		o.t = d.Sol.T
+		if o.skip() || !c.States[o.eid].Active {
+			return
+		}
+
+		// copy states and solution
+		nslaves := len(c.States)
+		states := make([]*ContactState, nslaves)
+		statesBkp := make([]*ContactState, nslaves)
+		for i := 0; i < nslaves; i++ {
+			states[i] = NewContactState(Global.Ndim)
+			statesBkp[i] = NewContactState(Global.Ndim)
+			states[i].Set(c.States[i])
+			statesBkp[i].Set(c.StatesBkp[i])
+		}
+		o.aux_arrays(d)
+
+		// make sure to restore states and solution
+		defer func() {
+			for i := 0; i < nslaves; i++ {
+				c.States[i].Set(states[i])
+				c.StatesBkp[i].Set(statesBkp[i])
+			}
+			copy(d.Sol.ΔY, o.ΔYbkp)
+		}()
+
+		// define restore function
+		restore := func() {
+			if it == 0 {
+				for k := 0; k < nslaves; k++ {
+					c.States[k].Set(states[k])
+				}
+				return
+			}
+			for k := 0; k < nslaves; k++ {
+				c.States[k].Set(statesBkp[k])
+			}
+		}
+
+		// tangent matrix of slave vertex
+		c.calc_K(d.Sol, o.eid)
+		umap := make([]int, len(c.Umap))
+		copy(umap, c.Umap)
+		K := la.MatAlloc(len(umap), len(umap))
+		for i := range umap {
+			copy(K[i], c.K[i][:len(umap)])
+		}
+
+		// check
+		o.check("K", d, c, umap, umap, K, restore)
+	}
+	return
+}
+
 // skip skips test based on it and/or t
 func (o testKb) skip() bool {
 	if o.itmin >= 0 {
@@ -492,7 +782,7 @@ func (o *testKb) aux_arrays(d *Domain) {
 }
 
 // check performs the checking of Kb using numerical derivatives
-func (o *testKb) check(label string, d *Domain, e Elem, Imap, Jmap []int, Kana [][]float64, restore func()) {
+func (o *testKb) check(label string, d *Domain, e testElem, Imap, Jmap []int, Kana [][]float64, restore func()) {
 	var imap, jmap []int
 	if o.ni < 0 {
 		imap = Imap
@@ -0,0 +1,238 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/cpmech/gofem/mporous"
+	"github.com/cpmech/gofem/msolid"
+	"github.com/cpmech/gofem/shp"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// Worker computes a subset of elements concurrently with other workers (goroutines).
+// Because shapes and material models hold scratchpad data and are shared among elements,
+// each worker owns private copies of these structures (see ElemConcurrent). Contributions
+// to fb and Kb are first added to the worker's own arrays and then joined by the Domain.
+type Worker struct {
+	Elems []Elem // elements computed by this worker
+
+	// private copies of shared structures
+	shapes map[string]*shp.Shape     // cell type => shape
+	solids map[string]msolid.Model   // material name => solid model
+	prms   map[string]fun.Prms       // material name => parameters of solid model
+	porous map[string]*mporous.Model // material name => porous model
+
+	// contributions
+	fb []float64 // [nyb] right-hand side vector
+	kb workerKb  // entries of Jacobian matrix
+	ok bool      // last computation was successful
+}
+
+// NewWorker returns a new worker
+func NewWorker(nyb int) *Worker {
+	return &Worker{
+		shapes: make(map[string]*shp.Shape),
+		solids: make(map[string]msolid.Model),
+		prms:   make(map[string]fun.Prms),
+		porous: make(map[string]*mporous.Model),
+		fb:     make([]float64, nyb),
+	}
+}
+
+// Shape returns the private copy of shape of given cell type
+func (o *Worker) Shape(cellType string) *shp.Shape {
+	s, ok := o.shapes[cellType]
+	if !ok {
+		s = shp.GetCopy(cellType)
+		o.shapes[cellType] = s
+	}
+	return s
+}
+
+// SolidModel returns the private copy of solid model
+//  Note: returns nil on errors
+func (o *Worker) SolidModel(matname string, ndim int) (msolid.Model, fun.Prms) {
+	m, ok := o.solids[matname]
+	if !ok {
+		m, o.prms[matname] = get_and_init_solid_model(matname, ndim, true)
+		if m == nil {
+			return nil, nil
+		}
+		o.solids[matname] = m
+	}
+	return m, o.prms[matname]
+}
+
+// PorousModel returns the private copy of porous model
+//  Note: returns nil on errors
+func (o *Worker) PorousModel(matname string) *mporous.Model {
+	m, ok := o.porous[matname]
+	if !ok {
+		m = get_and_init_porous_model(matname, true)
+		if m == nil {
+			return nil
+		}
+		o.porous[matname] = m
+	}
+	return m
+}
+
+// workerKb holds the entries of Kb computed by a worker
+type workerKb struct {
+	i, j []int
+	x    []float64
+}
+
+// Put adds x to the (i,j) component
+func (o *workerKb) Put(i, j int, x float64) {
+	o.i = append(o.i, i)
+	o.j = append(o.j, j)
+	o.x = append(o.x, x)
+}
+
+// domain: concurrent computation of elements ///////////////////////////////////////////////////////
+
+// set_workers distributes elements among workers
+func (o *Domain) set_workers() (ok bool) {
+
+	// serial
+	o.Workers = nil
+	o.ElemSerial = o.Elems
+	nw := Global.Sim.Data.Nworkers
+	if nw < 2 {
+		return true
+	}
+	if runtime.GOMAXPROCS(0) < nw {
+		runtime.GOMAXPROCS(nw)
+	}
+
+	// elements that can be computed concurrently
+	var conc []Elem
+	o.ElemSerial = make([]Elem, 0)
+	for _, e := range o.Elems {
+		if _, ok := e.(ElemConcurrent); ok {
+			conc = append(conc, e)
+		} else {
+			o.ElemSerial = append(o.ElemSerial, e)
+		}
+	}
+	if len(conc) < nw {
+		o.ElemSerial = o.Elems
+		return true
+	}
+
+	// distribute contiguous chunks of elements
+	o.Workers = make([]*Worker, nw)
+	for i := 0; i < nw; i++ {
+		w := NewWorker(o.Nyb)
+		start, end := (i*len(conc))/nw, ((i+1)*len(conc))/nw
+		w.Elems = conc[start:end]
+		for _, e := range w.Elems {
+			if !e.(ElemConcurrent).SetWorker(w) {
+				return
+			}
+		}
+		o.Workers[i] = w
+	}
+	return true
+}
+
+// run_workers runs fcn for all elements of each worker concurrently and then for all serial elements
+func (o *Domain) run_workers(fcn func(w *Worker, e Elem) bool, serial func(e Elem) bool) (ok bool) {
+	var wg sync.WaitGroup
+	for _, w := range o.Workers {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			w.ok = false
+			for _, e := range w.Elems {
+				if !fcn(w, e) {
+					return
+				}
+			}
+			w.ok = true
+		}(w)
+	}
+	wg.Wait()
+	for _, w := range o.Workers {
+		if !w.ok {
+			return
+		}
+	}
+	for _, e := range o.ElemSerial {
+		if !serial(e) {
+			return
+		}
+	}
+	return true
+}
+
+// elems_add_to_rhs adds the contributions of all elements and contact pairs to fb
+func (o *Domain) elems_add_to_rhs(fb []float64) (ok bool) {
+	for _, w := range o.Workers {
+		la.VecFill(w.fb, 0)
+	}
+	ok = o.run_workers(func(w *Worker, e Elem) bool {
+		return e.AddToRhs(w.fb, o.Sol)
+	}, func(e Elem) bool {
+		return e.AddToRhs(fb, o.Sol)
+	})
+	for _, w := range o.Workers {
+		for i, v := range w.fb {
+			fb[i] += v
+		}
+	}
+	for _, c := range o.Contacts {
+		if !c.AddToRhs(fb, o.Sol) {
+			return false
+		}
+	}
+	return
+}
+
+// elems_add_to_kb adds the contributions of all elements and contact pairs to Kb
+func (o *Domain) elems_add_to_kb(kb Assembler, firstIt bool) (ok bool) {
+	for _, w := range o.Workers {
+		w.kb.i, w.kb.j, w.kb.x = w.kb.i[:0], w.kb.j[:0], w.kb.x[:0]
+	}
+	ok = o.run_workers(func(w *Worker, e Elem) bool {
+		return e.AddToKb(&w.kb, o.Sol, firstIt)
+	}, func(e Elem) bool {
+		return e.AddToKb(kb, o.Sol, firstIt)
+	})
+	for _, w := range o.Workers {
+		for k, v := range w.kb.x {
+			kb.Put(w.kb.i[k], w.kb.j[k], v)
+		}
+	}
+	for _, c := range o.Contacts {
+		if !c.AddToKb(kb, o.Sol, firstIt) {
+			return false
+		}
+	}
+	return
+}
+
+// elems_update updates all elements and contact pairs
+func (o *Domain) elems_update() (ok bool) {
+	update := func(e Elem) bool {
+		return e.Update(o.Sol)
+	}
+	if !o.run_workers(func(w *Worker, e Elem) bool {
+		return update(e)
+	}, update) {
+		return
+	}
+	for _, c := range o.Contacts {
+		if !c.Update(o.Sol) {
+			return
+		}
+	}
+	return true
+}
@@ -0,0 +1,417 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package inp
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cpmech/gofem/shp"
+)
+
+// abqElem holds data of an Abaqus/CalculiX element type
+//  Note: the local node numbering of all supported types coincides with gofem's
+type abqElem struct {
+	ctype string // gofem geometry type
+	ndim  int    // space dimension
+	faces []int  // Abaqus face S(k+1) => gofem face faces[k]; nil => no faces
+}
+
+// abqElems maps Abaqus/CalculiX element types to element data
+var abqElems = map[string]*abqElem{
+	"T2D2":  &abqElem{"lin2", 2, nil},
+	"B21":   &abqElem{"lin2", 2, nil},
+	"CPE3":  &abqElem{"tri3", 2, []int{0, 1, 2}},
+	"CPE6":  &abqElem{"tri6", 2, []int{0, 1, 2}},
+	"CPE4":  &abqElem{"qua4", 2, []int{0, 1, 2, 3}},
+	"CPE4R": &abqElem{"qua4", 2, []int{0, 1, 2, 3}},
+	"CPE8":  &abqElem{"qua8", 2, []int{0, 1, 2, 3}},
+	"CPE8R": &abqElem{"qua8", 2, []int{0, 1, 2, 3}},
+	"C3D4":  &abqElem{"tet4", 3, []int{2, 1, 3, 0}},
+	"C3D10": &abqElem{"tet10", 3, []int{2, 1, 3, 0}},
+	"C3D8":  &abqElem{"hex8", 3, []int{4, 5, 2, 1, 3, 0}},
+	"C3D8R": &abqElem{"hex8", 3, []int{4, 5, 2, 1, 3, 0}},
+	"C3D20": &abqElem{"hex20", 3, []int{4, 5, 2, 1, 3, 0}},
+}
+
+// abqSet holds a node or element set
+type abqSet struct {
+	name string       // set name (uppercase)
+	tag  int          // tag (negative); 0 => internal set (name starting with "_")
+	ids  map[int]bool // Abaqus ids of nodes or elements
+}
+
+// abqLine holds a line of an Abaqus file
+type abqLine struct {
+	keyword string            // keyword (uppercase, without "*"); empty for data lines
+	params  map[string]string // keyword parameters (uppercase keys)
+	data    []string          // data values
+}
+
+// is_abaqus checks whether the mesh file is an Abaqus/CalculiX input file or not
+//  Note: ".inp" or ".abq" files whose first non-comment line starts with "*"
+func is_abaqus(fn string, b []byte) bool {
+	ext := strings.ToLower(filepath.Ext(fn))
+	if ext != ".inp" && ext != ".abq" {
+		return false
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(b), []byte("*"))
+}
+
+// read_abaqus reads an Abaqus/CalculiX input file into Verts and Cells
+//  Notes:
+//   1) only flat input files are supported; i.e. no *INCLUDE and no part instances
+//   2) tags are given by the order of definition of sets, i.e. the first element set (ELSET) has
+//      tag -1, the second -2, and so on; the same applies to surfaces (*SURFACE) which are
+//      converted to face tags and to node sets (NSET) which are converted to vertex tags
+//   3) the tag of a cell (or vertex) is given by the first set containing it; all elements must
+//      belong to at least one element set. Internal sets (names starting with "_") are ignored
+//   4) the mapping between set names and tags is written to the log file
+func (o *Mesh) read_abaqus(b []byte) (ok bool) {
+
+	// auxiliary
+	var nids []int                         // Abaqus ids of nodes
+	var coords [][]float64                 // coordinates of nodes
+	var eids []int                         // Abaqus ids of elements
+	var etypes []*abqElem                  // types of elements
+	var enodes [][]int                     // Abaqus ids of nodes of elements
+	var elsets, nsets []*abqSet            // element and node sets
+	name2elset := make(map[string]*abqSet) // element sets
+	surfaces := make(map[string][][2]int)  // surface name => set of (cell index, gofem face id)
+	var surfnames []string                 // surface names in order of definition
+	eid2cid := make(map[int]int)           // Abaqus element id => cell index
+	lines := abq_lines(b)
+
+	// new set
+	newset := func(sets *[]*abqSet, name string) (s *abqSet) {
+		s = &abqSet{name: name, ids: make(map[int]bool)}
+		if !strings.HasPrefix(name, "_") {
+			ntags := 0
+			for _, t := range *sets {
+				if t.tag < 0 {
+					ntags++
+				}
+			}
+			s.tag = -(ntags + 1)
+		}
+		*sets = append(*sets, s)
+		return
+	}
+
+	// parse lines
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		switch l.keyword {
+
+		// nodes
+		case "NODE":
+			var nset *abqSet
+			if name, found := l.params["NSET"]; found {
+				nset = newset(&nsets, name)
+			}
+			for ; i+1 < len(lines) && lines[i+1].keyword == ""; i++ {
+				vals, err := abq_floats(lines[i+1].data)
+				if LogErrCond(err != nil || len(vals) < 3, "msh: abaqus: cannot read node %v\n", lines[i+1].data) {
+					return
+				}
+				id := int(vals[0])
+				nids = append(nids, id)
+				coords = append(coords, []float64{vals[1], vals[2], 0})
+				if len(vals) > 3 {
+					coords[len(coords)-1][2] = vals[3]
+				}
+				if nset != nil {
+					nset.ids[id] = true
+				}
+			}
+
+		// elements
+		case "ELEMENT":
+			etype, found := abqElems[l.params["TYPE"]]
+			if LogErrCond(!found, "msh: abaqus: element type %q is not supported\n", l.params["TYPE"]) {
+				return
+			}
+			var elset *abqSet
+			if name, found := l.params["ELSET"]; found {
+				elset = name2elset[name]
+				if elset == nil {
+					elset = newset(&elsets, name)
+					name2elset[name] = elset
+				}
+			}
+			nverts := shp.Get(etype.ctype).Nverts
+			var vals []int
+			for ; i+1 < len(lines) && lines[i+1].keyword == ""; i++ {
+				v, err := abq_ints(lines[i+1].data)
+				if LogErr(err, "msh: abaqus: cannot read element") {
+					return
+				}
+				vals = append(vals, v...)
+				if len(vals) < nverts+1 {
+					continue // continuation line
+				}
+				if LogErrCond(len(vals) > nverts+1, "msh: abaqus: element %d has too many nodes\n", vals[0]) {
+					return
+				}
+				eids = append(eids, vals[0])
+				etypes = append(etypes, etype)
+				enodes = append(enodes, vals[1:])
+				if elset != nil {
+					elset.ids[vals[0]] = true
+				}
+				vals = nil
+			}
+			if len(vals) > 0 {
+				LogErrCond(true, "msh: abaqus: incomplete element %d\n", vals[0])
+				return
+			}
+
+		// element and node sets
+		case "ELSET", "NSET":
+			var s *abqSet
+			if l.keyword == "ELSET" {
+				s = name2elset[l.params["ELSET"]]
+				if s == nil {
+					s = newset(&elsets, l.params["ELSET"])
+					name2elset[s.name] = s
+				}
+			} else {
+				s = newset(&nsets, l.params["NSET"])
+			}
+			_, generate := l.params["GENERATE"]
+			for ; i+1 < len(lines) && lines[i+1].keyword == ""; i++ {
+				data := lines[i+1].data
+				if generate {
+					v, err := abq_ints(data)
+					if LogErrCond(err != nil || len(v) < 2, "msh: abaqus: cannot read generated set %q\n", s.name) {
+						return
+					}
+					step := 1
+					if len(v) > 2 {
+						step = v[2]
+					}
+					if LogErrCond(step < 1, "msh: abaqus: increment of set %q must be positive\n", s.name) {
+						return
+					}
+					for id := v[0]; id <= v[1]; id += step {
+						s.ids[id] = true
+					}
+					continue
+				}
+				for _, val := range data {
+					id, err := strconv.Atoi(val)
+					if err == nil {
+						s.ids[id] = true
+						continue
+					}
+					other := abq_findset(elsets, nsets, l.keyword, strings.ToUpper(val))
+					if LogErrCond(other == nil, "msh: abaqus: cannot find set %q\n", val) {
+						return
+					}
+					for id := range other.ids {
+						s.ids[id] = true
+					}
+				}
+			}
+
+		// surfaces
+		case "SURFACE":
+			name := l.params["NAME"]
+			if LogErrCond(name == "", "msh: abaqus: surface must have a name\n") {
+				return
+			}
+			if typ, found := l.params["TYPE"]; found && typ != "ELEMENT" {
+				log.Printf("msh: abaqus: surface %q with TYPE=%s is ignored\n", name, typ)
+				continue
+			}
+			if _, found := surfaces[name]; !found {
+				surfnames = append(surfnames, name)
+			}
+			for ; i+1 < len(lines) && lines[i+1].keyword == ""; i++ {
+				data := lines[i+1].data
+				if LogErrCond(len(data) < 2, "msh: abaqus: cannot read surface %q\n", name) {
+					return
+				}
+				face, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(data[1]), "S"))
+				if LogErrCond(err != nil || face < 1, "msh: abaqus: invalid face %q in surface %q\n", data[1], name) {
+					return
+				}
+				var ids []int
+				if id, err := strconv.Atoi(data[0]); err == nil {
+					ids = []int{id}
+				} else {
+					s := name2elset[strings.ToUpper(data[0])]
+					if LogErrCond(s == nil, "msh: abaqus: cannot find element set %q\n", data[0]) {
+						return
+					}
+					for id := range s.ids {
+						ids = append(ids, id)
+					}
+				}
+				for _, id := range ids {
+					surfaces[name] = append(surfaces[name], [2]int{id, face - 1})
+				}
+			}
+
+		// not supported
+		case "INCLUDE", "INSTANCE":
+			LogErrCond(true, "msh: abaqus: keyword *%s is not supported\n", l.keyword)
+			return
+		}
+	}
+
+	// space dimension
+	ndim := 2
+	for _, e := range etypes {
+		ndim = imax(ndim, e.ndim)
+	}
+
+	// vertices
+	nid2vid := make(map[int]int)
+	o.Verts = make([]*Vert, len(nids))
+	for i, id := range nids {
+		nid2vid[id] = i
+		o.Verts[i] = &Vert{Id: i, C: coords[i][:ndim]}
+	}
+	for _, s := range nsets {
+		if s.tag == 0 {
+			continue
+		}
+		log.Printf("msh: abaqus: nset %q => vertex tag %d\n", s.name, s.tag)
+		for id := range s.ids {
+			vid, found := nid2vid[id]
+			if LogErrCond(!found, "msh: abaqus: cannot find node %d of set %q\n", id, s.name) {
+				return
+			}
+			if o.Verts[vid].Tag == 0 {
+				o.Verts[vid].Tag = s.tag
+			}
+		}
+	}
+
+	// cells
+	o.Cells = make([]*Cell, len(eids))
+	for i, id := range eids {
+		c := &Cell{Id: i, Type: etypes[i].ctype, Verts: make([]int, len(enodes[i]))}
+		for j, nid := range enodes[i] {
+			vid, found := nid2vid[nid]
+			if LogErrCond(!found, "msh: abaqus: cannot find node %d of element %d\n", nid, id) {
+				return
+			}
+			c.Verts[j] = vid
+		}
+		for _, s := range elsets {
+			if s.tag < 0 && s.ids[id] {
+				c.Tag = s.tag
+				break
+			}
+		}
+		if LogErrCond(c.Tag == 0, "msh: abaqus: element %d does not belong to any element set\n", id) {
+			return
+		}
+		eid2cid[id] = i
+		o.Cells[i] = c
+	}
+	for _, s := range elsets {
+		if s.tag < 0 {
+			log.Printf("msh: abaqus: elset %q => cell tag %d\n", s.name, s.tag)
+		}
+	}
+
+	// face tags
+	for k, name := range surfnames {
+		ftag := -(k + 1)
+		log.Printf("msh: abaqus: surface %q => face tag %d\n", name, ftag)
+		for _, pair := range surfaces[name] {
+			cid, found := eid2cid[pair[0]]
+			if LogErrCond(!found, "msh: abaqus: cannot find element %d of surface %q\n", pair[0], name) {
+				return
+			}
+			faces := etypes[cid].faces
+			if LogErrCond(pair[1] >= len(faces), "msh: abaqus: element %d does not have face S%d\n", pair[0], pair[1]+1) {
+				return
+			}
+			c := o.Cells[cid]
+			if len(c.FTags) == 0 {
+				c.FTags = make([]int, len(faces))
+			}
+			c.FTags[faces[pair[1]]] = ftag
+		}
+	}
+	return true
+}
+
+// abq_lines splits an Abaqus file into keyword and data lines; comments and empty lines are skipped
+func abq_lines(b []byte) (lines []*abqLine) {
+	for _, str := range strings.Split(string(b), "\n") {
+		str = strings.TrimSpace(str)
+		if str == "" || strings.HasPrefix(str, "**") {
+			continue
+		}
+		var vals []string
+		for _, v := range strings.Split(str, ",") {
+			v = strings.TrimSpace(v)
+			if v != "" {
+				vals = append(vals, v)
+			}
+		}
+		if !strings.HasPrefix(str, "*") {
+			lines = append(lines, &abqLine{data: vals})
+			continue
+		}
+		l := &abqLine{keyword: strings.ToUpper(strings.TrimSpace(vals[0][1:])), params: make(map[string]string)}
+		for _, p := range vals[1:] {
+			kv := strings.SplitN(p, "=", 2)
+			key := strings.ToUpper(strings.TrimSpace(kv[0]))
+			l.params[key] = ""
+			if len(kv) > 1 {
+				l.params[key] = strings.ToUpper(strings.TrimSpace(kv[1]))
+			}
+		}
+		lines = append(lines, l)
+	}
+	return
+}
+
+// abq_findset finds an element or node set by name
+func abq_findset(elsets, nsets []*abqSet, keyword, name string) *abqSet {
+	sets := elsets
+	if keyword == "NSET" {
+		sets = nsets
+	}
+	for _, s := range sets {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// abq_ints converts data values to integers
+func abq_ints(data []string) (v []int, err error) {
+	v = make([]int, len(data))
+	for i, s := range data {
+		v[i], err = strconv.Atoi(s)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// abq_floats converts data values to floats
+func abq_floats(data []string) (v []float64, err error) {
+	v = make([]float64, len(data))
+	for i, s := range data {
+		v[i], err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
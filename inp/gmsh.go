@@ -0,0 +1,443 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package inp
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cpmech/gofem/shp"
+)
+
+// gmshElem holds data of a Gmsh element type
+type gmshElem struct {
+	ctype  string // gofem geometry type
+	gndim  int    // geometry dimension; e.g. "qua4" => 2
+	nverts int    // number of nodes
+	perm   []int  // gofem local vertex i => Gmsh local node perm[i]; nil => same ordering
+}
+
+// gmshElems maps Gmsh element type codes to element data
+var gmshElems = map[int]*gmshElem{
+	1:  &gmshElem{"lin2", 1, 2, nil},
+	2:  &gmshElem{"tri3", 2, 3, nil},
+	3:  &gmshElem{"qua4", 2, 4, nil},
+	4:  &gmshElem{"tet4", 3, 4, nil},
+	5:  &gmshElem{"hex8", 3, 8, nil},
+	8:  &gmshElem{"lin3", 1, 3, nil},
+	9:  &gmshElem{"tri6", 2, 6, nil},
+	10: &gmshElem{"qua9", 2, 9, nil},
+	11: &gmshElem{"tet10", 3, 10, []int{0, 1, 2, 3, 4, 5, 6, 7, 9, 8}},
+	15: &gmshElem{"pnt", 0, 1, nil},
+	16: &gmshElem{"qua8", 2, 8, nil},
+	17: &gmshElem{"hex20", 3, 20, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 11, 13, 9, 16, 18, 19, 17, 10, 12, 14, 15}},
+}
+
+// gmshItem holds an element read from a Gmsh file
+type gmshItem struct {
+	elem  *gmshElem // element type
+	phys  int       // physical group; 0 => none
+	nodes []int     // Gmsh node tags
+}
+
+// gmshTokens holds the tokens of a section in a Gmsh file
+type gmshTokens struct {
+	name string   // section name
+	toks []string // tokens
+	pos  int      // current position
+	fail bool     // an error occurred
+}
+
+// is_gmsh checks whether the mesh file is a Gmsh file or not
+//  Note: ".gmsh" files or any file starting with "$MeshFormat" (e.g. ".msh" from Gmsh)
+func is_gmsh(fn string, b []byte) bool {
+	if strings.ToLower(filepath.Ext(fn)) == ".gmsh" {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(b), []byte("$MeshFormat"))
+}
+
+// read_gmsh reads a Gmsh ASCII mesh file (format version 2 or 4) into Verts and Cells
+//  Notes:
+//   1) the highest dimension elements become cells; tags are given by -(physical group) or -1 if
+//      the element does not belong to any physical group
+//   2) elements with dimension equal to (ndim-1) and belonging to physical groups set the face
+//      tags (FTags) of the cells sharing their corner vertices
+//   3) point elements belonging to physical groups set the vertex tags
+//   4) physical lines in 3D meshes are ignored
+func (o *Mesh) read_gmsh(b []byte) (ok bool) {
+
+	// sections
+	sections := gmsh_sections(b)
+	mf, found := sections["MeshFormat"]
+	if LogErrCond(!found, "msh: gmsh: cannot find $MeshFormat section\n") {
+		return
+	}
+	version, ftype := mf.float(), mf.int()
+	if LogErrCond(mf.fail, "msh: gmsh: cannot read $MeshFormat section\n") {
+		return
+	}
+	if LogErrCond(ftype != 0, "msh: gmsh: binary files are not supported\n") {
+		return
+	}
+	nodes, found := sections["Nodes"]
+	if LogErrCond(!found, "msh: gmsh: cannot find $Nodes section\n") {
+		return
+	}
+	elems, found := sections["Elements"]
+	if LogErrCond(!found, "msh: gmsh: cannot find $Elements section\n") {
+		return
+	}
+
+	// read nodes and elements
+	var ntags []int
+	var coords [][]float64
+	var items []*gmshItem
+	switch {
+	case version >= 2 && version < 3:
+		ntags, coords = gmsh_nodes_v2(nodes)
+		items = gmsh_elems_v2(elems)
+	case version >= 4 && version < 5:
+		ents, found := sections["Entities"]
+		if LogErrCond(!found, "msh: gmsh: cannot find $Entities section\n") {
+			return
+		}
+		v41 := version > 4.05
+		phys := gmsh_entities_v4(ents, v41)
+		if LogErrCond(ents.fail, "msh: gmsh: cannot read $Entities section\n") {
+			return
+		}
+		ntags, coords = gmsh_nodes_v4(nodes, v41)
+		items = gmsh_elems_v4(elems, phys, v41)
+	default:
+		LogErrCond(true, "msh: gmsh: file format version %g is not supported\n", version)
+		return
+	}
+	if LogErrCond(nodes.fail, "msh: gmsh: cannot read $Nodes section\n") {
+		return
+	}
+	if LogErrCond(elems.fail, "msh: gmsh: cannot read $Elements section\n") {
+		return
+	}
+
+	// space dimension
+	ndim := 0
+	for _, e := range items {
+		ndim = imax(ndim, e.elem.gndim)
+	}
+	if LogErrCond(ndim < 2, "msh: gmsh: mesh must have 2D or 3D elements\n") {
+		return
+	}
+
+	// vertices
+	tag2vid := make(map[int]int)
+	o.Verts = make([]*Vert, len(ntags))
+	for i, tag := range ntags {
+		tag2vid[tag] = i
+		o.Verts[i] = &Vert{Id: i, C: coords[i][:ndim]}
+	}
+
+	// convert Gmsh node tags to vertex ids
+	for _, e := range items {
+		for i, tag := range e.nodes {
+			vid, found := tag2vid[tag]
+			if LogErrCond(!found, "msh: gmsh: cannot find node %d\n", tag) {
+				return
+			}
+			e.nodes[i] = vid
+		}
+	}
+
+	// cells
+	for _, e := range items {
+		if e.elem.gndim != ndim {
+			continue
+		}
+		c := &Cell{Id: len(o.Cells), Tag: -1, Type: e.elem.ctype, Verts: make([]int, e.elem.nverts)}
+		if e.phys > 0 {
+			c.Tag = -e.phys
+		}
+		for i := 0; i < e.elem.nverts; i++ {
+			if e.elem.perm == nil {
+				c.Verts[i] = e.nodes[i]
+			} else {
+				c.Verts[i] = e.nodes[e.elem.perm[i]]
+			}
+		}
+		o.Cells = append(o.Cells, c)
+	}
+
+	// faces
+	var key2faces map[[4]int][]CellFaceId
+	for _, e := range items {
+		if e.elem.gndim != ndim-1 || e.phys == 0 {
+			continue
+		}
+		if key2faces == nil {
+			key2faces = make(map[[4]int][]CellFaceId)
+			for _, c := range o.Cells {
+				s := shp.Get(c.Type)
+				nc := shp.Get(s.BasicType).FaceNverts
+				for fid, lverts := range s.FaceLocalV {
+					verts := make([]int, nc)
+					for i := 0; i < nc; i++ {
+						verts[i] = c.Verts[lverts[i]]
+					}
+					key := gmsh_key(verts)
+					key2faces[key] = append(key2faces[key], CellFaceId{c, fid})
+				}
+			}
+		}
+		nc := 2
+		if ndim == 3 {
+			nc = shp.Get(shp.GetBasicType(e.elem.ctype)).Nverts
+		}
+		pairs, found := key2faces[gmsh_key(e.nodes[:nc])]
+		if LogErrCond(!found, "msh: gmsh: cannot find cell face with vertices %v\n", e.nodes) {
+			return
+		}
+		for _, p := range pairs {
+			if len(p.C.FTags) == 0 {
+				p.C.FTags = make([]int, len(shp.Get(p.C.Type).FaceLocalV))
+			}
+			p.C.FTags[p.Fid] = -e.phys
+		}
+	}
+
+	// vertex tags
+	for _, e := range items {
+		if e.elem.gndim == 0 && e.phys > 0 {
+			o.Verts[e.nodes[0]].Tag = -e.phys
+		}
+	}
+	return true
+}
+
+// gmsh_nodes_v2 reads nodes in format version 2
+func gmsh_nodes_v2(s *gmshTokens) (tags []int, coords [][]float64) {
+	n := s.int()
+	if n < 0 {
+		s.fail = true
+		return
+	}
+	tags = make([]int, n)
+	coords = make([][]float64, n)
+	for i := 0; i < n && !s.fail; i++ {
+		tags[i] = s.int()
+		coords[i] = []float64{s.float(), s.float(), s.float()}
+	}
+	return
+}
+
+// gmsh_elems_v2 reads elements in format version 2
+func gmsh_elems_v2(s *gmshTokens) (items []*gmshItem) {
+	n := s.int()
+	for i := 0; i < n && !s.fail; i++ {
+		s.int() // element tag
+		elem := s.elem()
+		ntags := s.int()
+		phys := 0
+		for j := 0; j < ntags; j++ {
+			tag := s.int()
+			if j == 0 {
+				phys = tag
+			}
+		}
+		if s.fail {
+			return
+		}
+		items = append(items, &gmshItem{elem, phys, s.ints(elem.nverts)})
+	}
+	return
+}
+
+// gmsh_entities_v4 reads entities in format version 4 and returns the first physical group of
+// each entity: phys[dim][entityTag]
+func gmsh_entities_v4(s *gmshTokens, v41 bool) (phys []map[int]int) {
+	phys = make([]map[int]int, 4)
+	nents := s.ints(4)
+	for dim := 0; dim < 4 && !s.fail; dim++ {
+		phys[dim] = make(map[int]int)
+		for i := 0; i < nents[dim] && !s.fail; i++ {
+			tag := s.int()
+			if dim == 0 && v41 {
+				s.skip(3) // x y z
+			} else {
+				s.skip(6) // bounding box
+			}
+			nphys := s.int()
+			for j, p := range s.ints(nphys) {
+				if j == 0 {
+					phys[dim][tag] = imax(p, -p)
+				}
+			}
+			if dim > 0 {
+				s.skip(s.int()) // bounding entities
+			}
+		}
+	}
+	return
+}
+
+// gmsh_nodes_v4 reads nodes in format version 4
+func gmsh_nodes_v4(s *gmshTokens, v41 bool) (tags []int, coords [][]float64) {
+	nblocks := s.int()
+	s.int() // total number of nodes
+	if v41 {
+		s.skip(2) // min and max tags
+	}
+	for b := 0; b < nblocks && !s.fail; b++ {
+		s.skip(2) // entity dim and tag
+		parametric := s.int()
+		n := s.int()
+		if parametric != 0 {
+			LogErrCond(true, "msh: gmsh: parametric nodes are not supported\n")
+			s.fail = true
+			return
+		}
+		if v41 {
+			tags = append(tags, s.ints(n)...)
+			for i := 0; i < n; i++ {
+				coords = append(coords, []float64{s.float(), s.float(), s.float()})
+			}
+			continue
+		}
+		for i := 0; i < n; i++ {
+			tags = append(tags, s.int())
+			coords = append(coords, []float64{s.float(), s.float(), s.float()})
+		}
+	}
+	return
+}
+
+// gmsh_elems_v4 reads elements in format version 4
+func gmsh_elems_v4(s *gmshTokens, phys []map[int]int, v41 bool) (items []*gmshItem) {
+	nblocks := s.int()
+	s.int() // total number of elements
+	if v41 {
+		s.skip(2) // min and max tags
+	}
+	var dim, tag int
+	for b := 0; b < nblocks && !s.fail; b++ {
+		if v41 {
+			dim, tag = s.int(), s.int()
+		} else {
+			tag, dim = s.int(), s.int()
+		}
+		elem := s.elem()
+		n := s.int()
+		if s.fail || dim < 0 || dim > 3 {
+			s.fail = true
+			return
+		}
+		for i := 0; i < n && !s.fail; i++ {
+			s.int() // element tag
+			items = append(items, &gmshItem{elem, phys[dim][tag], s.ints(elem.nverts)})
+		}
+	}
+	return
+}
+
+// gmsh_sections splits a Gmsh file into sections of tokens
+func gmsh_sections(b []byte) (sections map[string]*gmshTokens) {
+	sections = make(map[string]*gmshTokens)
+	var cur *gmshTokens
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "$") {
+			if strings.HasPrefix(line, "$End") {
+				cur = nil
+				continue
+			}
+			cur = &gmshTokens{name: line[1:]}
+			sections[cur.name] = cur
+			continue
+		}
+		if cur != nil {
+			cur.toks = append(cur.toks, strings.Fields(line)...)
+		}
+	}
+	return
+}
+
+// gmsh_key returns a key to identify a face by means of its corner vertices
+func gmsh_key(verts []int) (key [4]int) {
+	key = [4]int{-1, -1, -1, -1}
+	copy(key[:], verts)
+	sort.Ints(key[:len(verts)])
+	return
+}
+
+// next returns the next token
+func (o *gmshTokens) next() string {
+	if o.fail || o.pos >= len(o.toks) {
+		o.fail = true
+		return ""
+	}
+	o.pos++
+	return o.toks[o.pos-1]
+}
+
+// skip skips n tokens
+func (o *gmshTokens) skip(n int) {
+	for i := 0; i < n; i++ {
+		o.next()
+	}
+}
+
+// int returns the next token as an integer
+func (o *gmshTokens) int() int {
+	tok := o.next()
+	if o.fail {
+		return 0
+	}
+	v, err := strconv.Atoi(tok)
+	if err != nil {
+		o.fail = true
+	}
+	return v
+}
+
+// ints returns the next n tokens as integers
+func (o *gmshTokens) ints(n int) (v []int) {
+	if n < 0 {
+		o.fail = true
+		return
+	}
+	v = make([]int, n)
+	for i := 0; i < n; i++ {
+		v[i] = o.int()
+	}
+	return
+}
+
+// float returns the next token as a float
+func (o *gmshTokens) float() float64 {
+	tok := o.next()
+	if o.fail {
+		return 0
+	}
+	v, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		o.fail = true
+	}
+	return v
+}
+
+// elem returns the element type corresponding to the next token
+func (o *gmshTokens) elem() *gmshElem {
+	code := o.int()
+	if o.fail {
+		return nil
+	}
+	elem, found := gmshElems[code]
+	if LogErrCond(!found, "msh: gmsh: element type %d is not supported\n", code) {
+		o.fail = true
+	}
+	return elem
+}
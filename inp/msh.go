@@ -9,6 +9,7 @@ import (
 	"log"
 	"math"
 	"path/filepath"
+	"sort"
 
 	"github.com/cpmech/gofem/shp"
 
@@ -90,6 +91,10 @@ type Mesh struct {
 
 // ReadMsh reads a mesh for FE analyses
 //  Note: returns nil on errors
+//  Gmsh ASCII files (format version 2 or 4) are recognised by the ".gmsh" extension or by the
+//  "$MeshFormat" header; e.g. ".msh" files generated by Gmsh. Abaqus/CalculiX files are recognised
+//  by the ".inp" or ".abq" extensions if the first line starts with "*". Otherwise, the JSON
+//  format is used.
 func ReadMsh(dir, fn string) *Mesh {
 
 	// new mesh
@@ -103,8 +108,21 @@ func ReadMsh(dir, fn string) *Mesh {
 	}
 
 	// decode
-	if LogErr(json.Unmarshal(b, &o), "msh: cannot unmarshal mesh file "+fn+"\n") {
-		return nil
+	switch {
+	case is_gmsh(fn, b):
+		if !o.read_gmsh(b) {
+			LogErrCond(true, "msh: cannot read Gmsh file "+fn+"\n")
+			return nil
+		}
+	case is_abaqus(fn, b):
+		if !o.read_abaqus(b) {
+			LogErrCond(true, "msh: cannot read Abaqus file "+fn+"\n")
+			return nil
+		}
+	default:
+		if LogErr(json.Unmarshal(b, &o), "msh: cannot unmarshal mesh file "+fn+"\n") {
+			return nil
+		}
 	}
 
 	// check
@@ -210,9 +228,18 @@ func ReadMsh(dir, fn string) *Mesh {
 		o.Part2cells[c.Part] = append(cells, c)
 
 		// get shape structure
-		switch c.Type {
-		case "joint":
+		ftype := IjointFaceType(c.Type)
+		switch {
+		case c.Type == "joint":
 			c.IsJoint = true
+		case ftype != "":
+			c.Shp = shp.Get(ftype)
+			if LogErrCond(len(c.Verts) != 2*c.Shp.Nverts, "msh: interface cell %d of type %q must have %d vertices\n", c.Id, c.Type, 2*c.Shp.Nverts) {
+				return nil
+			}
+			if LogErrCond(c.Shp.Gndim != o.Ndim-1, "msh: interface cell %d of type %q cannot be used in %dD meshes\n", c.Id, c.Type, o.Ndim) {
+				return nil
+			}
 		default:
 			c.Shp = shp.Get(c.Type)
 			if LogErrCond(c.Shp == nil, "msh: cannot find shape type == %q\n", c.Type) {
@@ -231,6 +258,89 @@ func ReadMsh(dir, fn string) *Mesh {
 	return &o
 }
 
+// IjointFaceType returns the face type of zero-thickness interface cells; e.g. "jlin3" => "lin3".
+// An empty string is returned if ctype does not correspond to an interface cell.
+//  Note: the vertices of interface cells are ordered as follows: first the vertices of the face
+//        on side A, then the corresponding vertices of the face on side B
+func IjointFaceType(ctype string) string {
+	if len(ctype) < 2 || ctype[0] != 'j' {
+		return ""
+	}
+	if shp.Get(ctype[1:]) == nil {
+		return ""
+	}
+	return ctype[1:]
+}
+
+// PeriodicPairs pairs the vertices on a slave face with the vertices on a master face by means of
+// the translation between the centroids of both faces
+//  Output:
+//   masters, slaves -- vertex ids such that slaves[i] corresponds to masters[i]
+//  Note: returns nil on errors
+func (o *Mesh) PeriodicPairs(master, slave int) (masters, slaves []int) {
+
+	// vertices on faces
+	mverts, okm := o.FaceTag2verts[master]
+	sverts, oks := o.FaceTag2verts[slave]
+	if LogErrCond(!okm || !oks, "msh: cannot find vertices on faces with tags %d (master) and %d (slave)\n", master, slave) {
+		return nil, nil
+	}
+	if LogErrCond(len(mverts) != len(sverts), "msh: periodic faces %d and %d must have the same number of vertices. %d != %d\n", master, slave, len(mverts), len(sverts)) {
+		return nil, nil
+	}
+
+	// translation between centroids
+	ndim := o.Ndim
+	d := make([]float64, ndim)
+	for i := 0; i < ndim; i++ {
+		for k := range mverts {
+			d[i] += (o.Verts[sverts[k]].C[i] - o.Verts[mverts[k]].C[i]) / float64(len(mverts))
+		}
+	}
+
+	// tolerance
+	tol := Ztol * max(o.Xmax-o.Xmin, max(o.Ymax-o.Ymin, o.Zmax-o.Zmin))
+
+	// sort master vertices by x-coordinate
+	sorted := vertsByX{make([]int, len(mverts)), o.Verts}
+	copy(sorted.ids, mverts)
+	sort.Sort(sorted)
+
+	// find pairs
+	for _, s := range sverts {
+		x := o.Verts[s].C
+		k := sort.Search(len(sorted.ids), func(i int) bool { return o.Verts[sorted.ids[i]].C[0] >= x[0]-d[0]-tol })
+		found := -1
+		for ; k < len(sorted.ids) && o.Verts[sorted.ids[k]].C[0] <= x[0]-d[0]+tol; k++ {
+			m := sorted.ids[k]
+			dist := 0.0
+			for i := 0; i < ndim; i++ {
+				dist = max(dist, math.Abs(x[i]-d[i]-o.Verts[m].C[i]))
+			}
+			if dist <= tol {
+				found = m
+				break
+			}
+		}
+		if LogErrCond(found < 0, "msh: cannot find vertex on master face %d corresponding to vertex %d on slave face %d\n", master, s, slave) {
+			return nil, nil
+		}
+		masters = append(masters, found)
+		slaves = append(slaves, s)
+	}
+	return
+}
+
+// vertsByX sorts vertices ids by x-coordinate
+type vertsByX struct {
+	ids   []int
+	verts []*Vert
+}
+
+func (o vertsByX) Len() int           { return len(o.ids) }
+func (o vertsByX) Swap(i, j int)      { o.ids[i], o.ids[j] = o.ids[j], o.ids[i] }
+func (o vertsByX) Less(i, j int) bool { return o.verts[o.ids[i]].C[0] < o.verts[o.ids[j]].C[0] }
+
 // String returns a JSON representation of *Vert
 func (o *Vert) String() string {
 	l := io.Sf("{\"id\":%4d, \"tag\":%6d, \"c\":[", o.Id, o.Tag)
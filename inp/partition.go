@@ -0,0 +1,231 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package inp
+
+import (
+	"log"
+	"math"
+	"sort"
+)
+
+// HasPartitions returns whether the cells are distributed among exactly npart partitions
+// numbered from 0 to npart-1
+func (o *Mesh) HasPartitions(npart int) bool {
+	if len(o.Part2cells) != npart {
+		return false
+	}
+	for p := 0; p < npart; p++ {
+		if len(o.Part2cells[p]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Partition distributes the cells among npart partitions by means of the recursive inertial
+// bisection method; i.e. the set of centroids of cells is recursively split by planes (lines in 2D)
+// perpendicular to the principal axis of inertia of the set. Each cell is weighted by its number of
+// vertices. Joint cells follow the partition of their line (rod) cells. The field Part of cells
+// and the map Part2cells are replaced and load-balance statistics are logged.
+func (o *Mesh) Partition(npart int) (ok bool) {
+
+	// check
+	if LogErrCond(npart < 1, "msh: number of partitions must be greater than zero. %d is invalid\n", npart) {
+		return
+	}
+	nc := 0
+	for _, c := range o.Cells {
+		if !c.IsJoint {
+			nc++
+		}
+	}
+	if LogErrCond(nc < npart, "msh: number of cells (%d) must not be smaller than the number of partitions (%d)\n", nc, npart) {
+		return
+	}
+
+	// centroids and weights of cells
+	var p partitioner
+	p.ndim = o.Ndim
+	p.xc = make([][]float64, len(o.Cells))
+	p.w = make([]float64, len(o.Cells))
+	cids := make([]int, 0, nc)
+	for _, c := range o.Cells {
+		p.xc[c.Id] = make([]float64, o.Ndim)
+		for _, v := range c.Verts {
+			for i := 0; i < o.Ndim; i++ {
+				p.xc[c.Id][i] += o.Verts[v].C[i] / float64(len(c.Verts))
+			}
+		}
+		p.w[c.Id] = float64(len(c.Verts))
+		if !c.IsJoint {
+			cids = append(cids, c.Id)
+		}
+	}
+
+	// bisect
+	p.part = make([]int, len(o.Cells))
+	p.bisect(cids, 0, npart)
+
+	// set partitions
+	o.Part2cells = make(map[int][]*Cell)
+	for _, c := range o.Cells {
+		c.Part = p.part[c.Id]
+		if c.IsJoint {
+			c.Part = p.part[c.JlinId]
+		}
+		o.Part2cells[c.Part] = append(o.Part2cells[c.Part], c)
+	}
+
+	// statistics
+	load := make([]float64, npart)
+	vpart := make([]int, len(o.Verts)) // vertex => partition + 1; -1 means vertex on interface
+	nint := 0
+	for _, c := range o.Cells {
+		load[c.Part] += p.w[c.Id]
+		for _, v := range c.Verts {
+			switch vpart[v] {
+			case 0:
+				vpart[v] = c.Part + 1
+			case -1, c.Part + 1:
+			default:
+				vpart[v] = -1
+				nint++
+			}
+		}
+	}
+	lmin, lmax, lsum := load[0], load[0], 0.0
+	for _, l := range load {
+		lmin, lmax, lsum = min(lmin, l), max(lmax, l), lsum+l
+	}
+	nmin, nmax := len(o.Cells), 0
+	for i := 0; i < npart; i++ {
+		nmin, nmax = imin(nmin, len(o.Part2cells[i])), imax(nmax, len(o.Part2cells[i]))
+	}
+	log.Printf("msh: partition: npart=%d ncells(min,max)=(%d,%d) load(min,max)=(%g,%g) imbalance=%g ninterfaceverts=%d\n", npart, nmin, nmax, lmin, lmax, lmax*float64(npart)/lsum, nint)
+	return true
+}
+
+// partitioner implements the recursive inertial bisection
+type partitioner struct {
+	ndim int         // space dimension
+	xc   [][]float64 // [ncells][ndim] centroids of cells
+	w    []float64   // [ncells] weights of cells
+	part []int       // [ncells] partitions of cells
+}
+
+// bisect splits the set of cells cids into n partitions numbered from first to first+n-1
+func (o *partitioner) bisect(cids []int, first, n int) {
+
+	// single partition
+	if n == 1 {
+		for _, cid := range cids {
+			o.part[cid] = first
+		}
+		return
+	}
+
+	// centre of the set
+	ndim := o.ndim
+	xm := make([]float64, ndim)
+	wsum := 0.0
+	for _, cid := range cids {
+		for i := 0; i < ndim; i++ {
+			xm[i] += o.w[cid] * o.xc[cid][i]
+		}
+		wsum += o.w[cid]
+	}
+	for i := 0; i < ndim; i++ {
+		xm[i] /= wsum
+	}
+
+	// inertia (covariance) matrix
+	J := make([][]float64, ndim)
+	for i := 0; i < ndim; i++ {
+		J[i] = make([]float64, ndim)
+	}
+	for _, cid := range cids {
+		for i := 0; i < ndim; i++ {
+			for j := 0; j < ndim; j++ {
+				J[i][j] += o.w[cid] * (o.xc[cid][i] - xm[i]) * (o.xc[cid][j] - xm[j])
+			}
+		}
+	}
+
+	// principal axis: power iterations starting from the direction with the largest spread
+	a := make([]float64, ndim)
+	imx := 0
+	for i := 1; i < ndim; i++ {
+		if J[i][i] > J[imx][imx] {
+			imx = i
+		}
+	}
+	a[imx] = 1
+	b := make([]float64, ndim)
+	for it := 0; it < 100; it++ {
+		nrm := 0.0
+		for i := 0; i < ndim; i++ {
+			b[i] = 0
+			for j := 0; j < ndim; j++ {
+				b[i] += J[i][j] * a[j]
+			}
+			nrm += b[i] * b[i]
+		}
+		nrm = math.Sqrt(nrm)
+		if nrm == 0 {
+			break
+		}
+		dif := 0.0
+		for i := 0; i < ndim; i++ {
+			b[i] /= nrm
+			dif = max(dif, math.Abs(b[i]-a[i]))
+			a[i] = b[i]
+		}
+		if dif < 1e-12 {
+			break
+		}
+	}
+
+	// sort cells by their projections onto the principal axis
+	s := cellsByProj{cids, make([]float64, len(cids))}
+	for k, cid := range cids {
+		for i := 0; i < ndim; i++ {
+			s.proj[k] += a[i] * (o.xc[cid][i] - xm[i])
+		}
+	}
+	sort.Sort(s)
+
+	// split such that the weights of both sets are proportional to their numbers of partitions
+	nl := n / 2
+	target := wsum * float64(nl) / float64(n)
+	k, wl := 0, 0.0
+	for k < len(cids)-(n-nl) {
+		wnew := wl + o.w[cids[k]]
+		if k >= nl && math.Abs(wnew-target) > math.Abs(wl-target) {
+			break
+		}
+		wl = wnew
+		k++
+	}
+	o.bisect(cids[:k], first, nl)
+	o.bisect(cids[k:], first+nl, n-nl)
+}
+
+// cellsByProj sorts cells by projections of centroids (ties are sorted by ids)
+type cellsByProj struct {
+	cids []int
+	proj []float64
+}
+
+func (o cellsByProj) Len() int { return len(o.cids) }
+func (o cellsByProj) Swap(i, j int) {
+	o.cids[i], o.cids[j] = o.cids[j], o.cids[i]
+	o.proj[i], o.proj[j] = o.proj[j], o.proj[i]
+}
+func (o cellsByProj) Less(i, j int) bool {
+	if math.Abs(o.proj[i]-o.proj[j]) > 1e-10 {
+		return o.proj[i] < o.proj[j]
+	}
+	return o.cids[i] < o.cids[j]
+}
@@ -12,6 +12,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
@@ -45,6 +46,18 @@ type Data struct {
 	NoDiv bool `json:"nodiv"` // disregard divergence control in both fb or Lδu
 	CteTg bool `json:"ctetg"` // use constant tangent (modified Newton) during iterations
 
+	// shared-memory parallelism
+	Nworkers int `json:"nworkers"` // number of goroutines computing elements concurrently; 0 or 1 => serial; -1 => number of CPUs
+
+	// sensitivity analysis
+	Sens []*SensData `json:"sens"` // material parameters for sensitivity analysis (direct differentiation)
+
+	// restart
+	Restart int `json:"restart"` // output index (tidx) of a previous run to restart from; 0 => no restart. files are not erased
+
+	// results files
+	Indexed bool `json:"indexed"` // save results into a single indexed binary file per processor (<fnkey>_p<rank>.res) instead of one file per output time
+
 	// derived
 	FnameDir string // directory where .sim filename is locatd
 	FnameKey string // simulation filename key; e.g. mysim01.sim => mysim01
@@ -59,6 +72,9 @@ func (o *Data) SetDefault() {
 func (o *Data) PostProcess(dir, fn string, erasefiles bool) {
 	o.FnameDir = os.ExpandEnv(dir)
 	o.FnameKey = io.FnKey(fn)
+	if o.Nworkers < 0 {
+		o.Nworkers = runtime.NumCPU()
+	}
 	if o.DirOut == "" {
 		o.DirOut = "/tmp/gofem/" + o.FnameKey
 	}
@@ -69,22 +85,37 @@ func (o *Data) PostProcess(dir, fn string, erasefiles bool) {
 	if err != nil {
 		chk.Panic("cannot create directory for output results (%s): %v", o.DirOut, err)
 	}
-	if erasefiles {
+	if erasefiles && o.Restart < 1 {
 		io.RemoveAll(io.Sf("%s/%s_*.vtu", o.DirOut, o.FnameKey))
 		io.RemoveAll(io.Sf("%s/%s_*.log", o.DirOut, o.FnameKey))
 		io.RemoveAll(io.Sf("%s/%s_*.gob", o.DirOut, o.FnameKey))
 		io.RemoveAll(io.Sf("%s/%s_*.json", o.DirOut, o.FnameKey))
+		io.RemoveAll(io.Sf("%s/%s_*.res", o.DirOut, o.FnameKey))
 	}
 }
 
+// SensData holds data of a material parameter p for sensitivity analyses; i.e. for computing dy/dp
+type SensData struct {
+	Mat  string  `json:"mat"`  // name of material holding the parameter; e.g. "soil1"
+	Prm  string  `json:"prm"`  // name of parameter; e.g. "E"
+	Key  string  `json:"key"`  // key of parameter in results; e.g. "E" => "dux/dE". default = Prm
+	Pert float64 `json:"pert"` // relative perturbation of parameter for computing ∂fb/∂p; default = 1e-6
+}
+
 // LinSolData holds data for linear solvers
 type LinSolData struct {
-	Name      string `json:"name"`      // "mumps" or "umfpack"
+	Name      string `json:"name"`      // "mumps" or "umfpack" (direct); "cg", "gmres" or "bicgstab" (iterative)
 	Symmetric bool   `json:"symmetric"` // use symmetric solver
 	Verbose   bool   `json:"verbose"`   // verbose?
 	Timing    bool   `json:"timing"`    // show timing statistics
 	Ordering  string `json:"ordering"`  // ordering scheme
 	Scaling   string `json:"scaling"`   // scaling scheme
+
+	// iterative solvers
+	Precond string  `json:"precond"` // preconditioner: "none", "jacobi", "ilu0" or "block" (u-p and constraints)
+	Tol     float64 `json:"tol"`     // tolerance for the relative residual
+	MaxIt   int     `json:"maxit"`   // maximum number of iterations
+	Restart int     `json:"restart"` // number of iterations before restarting GMRES
 }
 
 // SetDefault sets defaults values
@@ -92,10 +123,27 @@ func (o *LinSolData) SetDefault() {
 	o.Name = "umfpack"
 	o.Ordering = "amf"
 	o.Scaling = "rcit"
+	o.Precond = "ilu0"
+	o.Tol = 1e-10
+	o.MaxIt = 10000
+	o.Restart = 50
+}
+
+// Iterative returns whether an iterative (Krylov) solver is selected or not
+func (o *LinSolData) Iterative() bool {
+	switch o.Name {
+	case "cg", "gmres", "bicgstab":
+		return true
+	}
+	return false
 }
 
 // PostProcess performs a post-processing of the just read json file
+//  Note: direct solvers are selected according to the number of processors
 func (o *LinSolData) PostProcess() {
+	if o.Iterative() {
+		return
+	}
 	if mpi.IsOn() {
 		if mpi.Size() > 1 {
 			o.Name = "mumps"
@@ -130,6 +178,32 @@ type SolverData struct {
 	REmmin   float64 // Richardson extrapolation: min multiplier
 	REmmax   float64 // Richardson extrapolation: max multiplier
 
+	// adaptive time stepping
+	Adapt   bool    `json:"adapt"`   // use adaptive time stepping driven by number of iterations and local error
+	AdNopt  int     `json:"adnopt"`  // adaptive Δt: target number of iterations
+	AdMmin  float64 `json:"admmin"`  // adaptive Δt: min multiplier (shrink limit)
+	AdMmax  float64 `json:"admmax"`  // adaptive Δt: max multiplier (growth limit)
+	AdMfac  float64 `json:"admfac"`  // adaptive Δt: safety factor for multiplier computed with local error
+	AdDtMax float64 `json:"addtmax"` // adaptive Δt: max Δt; 0 => no limit
+	AdAtol  float64 `json:"adatol"`  // adaptive Δt: absolute tolerance for local error
+	AdRtol  float64 `json:"adrtol"`  // adaptive Δt: relative tolerance for local error
+
+	// arc-length (continuation) method
+	ArcLen    bool    `json:"arclen"`    // use arc-length method to scale natural boundary conditions @ tf by a load factor
+	ArcRiks   bool    `json:"arcriks"`   // use Riks' normal plane constraint instead of Crisfield's spherical constraint
+	ArcPsi    float64 `json:"arcpsi"`    // ψ: scaling of load term in constraint; 0 => cylindrical arc-length
+	ArcDlam0  float64 `json:"arcdlam0"`  // initial increment of load factor (first step)
+	ArcNopt   int     `json:"arcnopt"`   // optimal number of iterations for scaling the arc-length
+	ArcMmin   float64 `json:"arcmmin"`   // min multiplier of arc-length
+	ArcMmax   float64 `json:"arcmmax"`   // max multiplier of arc-length
+	ArcLamMax float64 `json:"arclammax"` // max load factor to stop stage; 0 => run until tf
+	ArcVert   int     `json:"arcvert"`   // id of vertex with control displacement to be recorded in summary
+	ArcKey    string  `json:"arckey"`    // key of control displacement; e.g. "uy". "" => largest absolute value of y
+
+	// enforcement of essential boundary conditions / constraints
+	EnfMode string  `json:"enfmode"` // "lagrange" (multipliers; default), "elimination" (reduced system) or "penalty"
+	Penalty float64 `json:"penalty"` // penalty coefficient α; should be large compared with the coefficients of K
+
 	// transient analyses
 	DtMin      float64 `json:"dtmin"`      // minium value of Dt for transient (θ and Newmark / Dyn coefficients)
 	Theta      float64 `json:"theta"`      // θ-method
@@ -171,6 +245,25 @@ func (o *SolverData) SetDefault() {
 	o.REmmin = 0.1
 	o.REmmax = 2.0
 
+	// adaptive time stepping
+	o.AdNopt = 6
+	o.AdMmin = 0.2
+	o.AdMmax = 2.0
+	o.AdMfac = 0.9
+	o.AdAtol = 1e-3
+	o.AdRtol = 1e-3
+
+	// arc-length (continuation) method
+	o.ArcDlam0 = 0.1
+	o.ArcNopt = 5
+	o.ArcMmin = 0.25
+	o.ArcMmax = 2.0
+	o.ArcLamMax = 1.0
+
+	// enforcement of essential boundary conditions / constraints
+	o.EnfMode = "lagrange"
+	o.Penalty = 1e10
+
 	// transient analyses
 	o.DtMin = 1e-8
 	o.Theta = 0.5
@@ -217,7 +310,7 @@ type Region struct {
 
 	// input data
 	Desc      string      `json:"desc"`      // description of region. ex: ground, indenter, etc.
-	Mshfile   string      `json:"mshfile"`   // file path of file with mesh data
+	Mshfile   string      `json:"mshfile"`   // file path of file with mesh data (JSON or Gmsh; see ReadMsh)
 	ElemsData []*ElemData `json:"elemsdata"` // list of elements data
 
 	// derived
@@ -259,6 +352,43 @@ type EleCond struct {
 	Extra string   `json:"extra"` // extra information. ex: '!λl:10'
 }
 
+// Mpc holds data for a linear multi-point constraint:
+//   Σ coefs[i] * y[keys[i]] @ vertex(tags[i]) = func(t)
+type Mpc struct {
+	Tags  []int     `json:"tags"`  // vertex tags; each tag must correspond to one vertex only
+	Keys  []string  `json:"keys"`  // dof keys. ex: ux, uy, uz, pl
+	Coefs []float64 `json:"coefs"` // coefficients
+	Func  string    `json:"func"`  // name of function giving the right-hand side. ex: zero
+}
+
+// PeriodicBc holds data for periodic constraints between paired faces:
+//   y[key] @ slave vertex - y[key] @ master vertex = func(t)
+//  Note: the vertices on the slave face are paired with the vertices on the master face by means of
+//        the translation between the centroids of both faces
+type PeriodicBc struct {
+	Master int      `json:"master"` // master face tag
+	Slave  int      `json:"slave"`  // slave face tag
+	Keys   []string `json:"keys"`   // dof keys. ex: ux, uy, uz
+	Funcs  []string `json:"funcs"`  // name of functions giving the jumps. ex: zero
+}
+
+// ContactData holds data for frictional contact between faces of deformable bodies:
+//   the vertices on the slave face cannot penetrate the master face. The constraints are
+//   enforced by the augmented Lagrangian method with penalty coefficients kn and kt
+//  Note: only contact between faces of the same region (domain) is available. Contact between
+//        faces of different regions is not implemented because regions are solved separately and
+//        such pairs are rejected; thus, separate bodies must be defined in the same mesh file
+//        with separate vertices on the contact surfaces (e.g. an indenter resting on the ground)
+type ContactData struct {
+	Master int     `json:"master"` // master face tag
+	Slave  int     `json:"slave"`  // slave face tag
+	Kn     float64 `json:"kn"`     // normal penalty coefficient [force/length³]
+	Kt     float64 `json:"kt"`     // tangential penalty coefficient [force/length³]. 0 => kn
+	Mu     float64 `json:"mu"`     // Coulomb friction coefficient
+	Tol    float64 `json:"tol"`    // tolerance on penetration and stick slip. 0 => 1e-8
+	MaxAug int     `json:"maxaug"` // maximum number of augmentations per time step. 0 => 20
+}
+
 // TimeControl holds data for defining the simulation time stepping
 type TimeControl struct {
 	Tf     float64 `json:"tf"`     // final time
@@ -273,6 +403,9 @@ type TimeControl struct {
 }
 
 // GeoStData holds data for setting initial geostatic state (hydrostatic as well)
+//  Note: cells of contact bodies without liquid pressure (e.g. an indenter resting on the ground)
+//        may be left out of layers; their initial stresses are zero. A contact body is given by
+//        the tags of cells owning the master or slave faces of contact pairs
 type GeoStData struct {
 	Nu     []float64 `json:"nu"`     // [nlayers] Poisson's coefficient to compute effective horizontal state for each layer
 	K0     []float64 `json:"K0"`     // [nlayers] Earth pressure coefficient at rest to compute effective horizontal stresses
@@ -329,6 +462,11 @@ type Stage struct {
 	SeamBcs  []*SeamBc  `json:"seambcs"`  // seam (3D) boundary conditions
 	NodeBcs  []*NodeBc  `json:"nodebcs"`  // node boundary conditions
 
+	// constraints
+	Mpcs     []*Mpc         `json:"mpcs"`     // linear multi-point constraints
+	Periodic []*PeriodicBc  `json:"periodic"` // periodic constraints between paired faces
+	Contact  []*ContactData `json:"contact"`  // frictional contact between faces
+
 	// timecontrol
 	Control TimeControl `json:"control"` // time control
 }
@@ -359,6 +497,7 @@ type Simulation struct {
 	// derived
 	Mdb        *MatDb   // materials database
 	Ndim       int      // space dimension
+	MinElev    float64  // minimum elevation
 	MaxElev    float64  // maximum elevation
 	Gfcn       fun.Func // first stage: gravity constant function
 	WaterRho0  float64  // first stage: intrinsic density of water corresponding to pressure pl=0
@@ -405,12 +544,69 @@ func ReadSim(dir, fn, logPrefix string, erasefiles bool) *Simulation {
 		return nil
 	}
 
+	// check iterative solvers
+	if o.LinSol.Iterative() {
+		switch o.LinSol.Precond {
+		case "none", "jacobi", "ilu0", "block":
+		default:
+			LogErrCond(true, "sim: preconditioner %q is invalid; options: none, jacobi, ilu0, block", o.LinSol.Precond)
+			return nil
+		}
+		if LogErrCond(o.LinSol.Tol <= 0 || o.LinSol.MaxIt < 1 || o.LinSol.Restart < 1, "sim: tol, maxit and restart of iterative linear solver must be positive") {
+			return nil
+		}
+	}
+
+	// check enforcement of constraints
+	switch o.Solver.EnfMode {
+	case "lagrange", "elimination", "penalty":
+	default:
+		LogErrCond(true, "sim: enforcement mode of constraints %q is invalid; options: lagrange, elimination, penalty", o.Solver.EnfMode)
+		return nil
+	}
+
+	// check arc-length method
+	if o.Solver.ArcLen {
+		if LogErrCond(len(o.Regions) != 1, "sim: arc-length method works with one region only; %d regions are given", len(o.Regions)) {
+			return nil
+		}
+		if LogErrCond(o.Solver.Adapt, "sim: arc-length method cannot be combined with adaptive time stepping (adapt)") {
+			return nil
+		}
+	}
+
 	// read materials database
 	o.Mdb = ReadMat(o.Data.FnameDir, o.Data.Matfile)
 	if LogErrCond(o.Mdb == nil, "sim: cannot read materials file") {
 		return nil
 	}
 
+	// check sensitivity analysis data
+	for _, sen := range o.Data.Sens {
+		mat := o.Mdb.Get(sen.Mat)
+		if LogErrCond(mat == nil, "sim: cannot find material %q for sensitivity analysis", sen.Mat) {
+			return nil
+		}
+		if LogErrCond(mat.Prms.Find(sen.Prm) == nil, "sim: cannot find parameter %q of material %q for sensitivity analysis", sen.Prm, sen.Mat) {
+			return nil
+		}
+		if sen.Key == "" {
+			sen.Key = sen.Prm
+		}
+		if sen.Pert <= 0 {
+			sen.Pert = 1e-6
+		}
+	}
+	if LogErrCond(len(o.Data.Sens) > 0 && o.Solver.ArcLen, "sim: sensitivity analysis is not available with the arc-length method") {
+		return nil
+	}
+	if LogErrCond(len(o.Data.Sens) > 0 && o.Solver.RE, "sim: sensitivity analysis is not available with Richardson's extrapolation") {
+		return nil
+	}
+	if LogErrCond(len(o.Data.Sens) > 0 && o.Data.Restart > 0, "sim: sensitivity analysis is not available with restarts because the sensitivities of internal variables are not saved") {
+		return nil
+	}
+
 	// for all regions
 	for i, reg := range o.Regions {
 
@@ -426,20 +622,22 @@ func ReadSim(dir, fn, logPrefix string, erasefiles bool) *Simulation {
 			reg.etag2idx[ed.Tag] = j
 		}
 
-		// get ndim and max elevation
+		// get ndim and min/max elevations
 		if i == 0 {
 			o.Ndim = reg.Msh.Ndim
-			o.MaxElev = reg.Msh.Ymax
+			o.MinElev, o.MaxElev = reg.Msh.Ymin, reg.Msh.Ymax
 			if o.Ndim == 3 {
-				o.MaxElev = reg.Msh.Zmax
+				o.MinElev, o.MaxElev = reg.Msh.Zmin, reg.Msh.Zmax
 			}
 		} else {
 			if LogErrCond(reg.Msh.Ndim != o.Ndim, "all meshes must have the same ndim. %d != %d") {
 				return nil
 			}
 			if o.Ndim == 2 {
+				o.MinElev = min(o.MinElev, reg.Msh.Ymin)
 				o.MaxElev = max(o.MaxElev, reg.Msh.Ymax)
 			} else {
+				o.MinElev = min(o.MinElev, reg.Msh.Zmin)
 				o.MaxElev = max(o.MaxElev, reg.Msh.Zmax)
 			}
 		}
@@ -502,6 +700,22 @@ func ReadSim(dir, fn, logPrefix string, erasefiles bool) *Simulation {
 			stg.Control.DtOut = stg.Control.DtoFunc.F(t, nil)
 		}
 
+		// contact pairs: regions (domains) are solved separately; thus both faces must be in the same mesh
+		for _, c := range stg.Contact {
+			found := false
+			for _, reg := range o.Regions {
+				_, okm := reg.Msh.FaceTag2cells[c.Master]
+				_, oks := reg.Msh.FaceTag2cells[c.Slave]
+				if okm && oks {
+					found = true
+					break
+				}
+			}
+			if LogErrCond(!found, "sim: master face %d and slave face %d of contact pair must belong to the same region. contact between regions is not available", c.Master, c.Slave) {
+				return nil
+			}
+		}
+
 		// first stage
 		if i == 0 {
 
@@ -10,6 +10,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/cpmech/gofem/shp"
+
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
 )
@@ -35,6 +37,187 @@ func Test_msh01(tst *testing.T) {
 	chk.Scalar(tst, "ymax", 1e-17, msh.Ymax, 1)
 }
 
+func Test_msh02(tst *testing.T) {
+
+	chk.PrintTitle("msh02. Gmsh v2")
+
+	msh := ReadMsh("data", "gmsh2d.msh")
+	if msh == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	io.Pforan("%v\n", msh)
+	chk.IntAssert(msh.Ndim, 2)
+	chk.IntAssert(len(msh.Verts), 6)
+	chk.IntAssert(len(msh.Cells), 2)
+	chk.Scalar(tst, "xmax", 1e-17, msh.Xmax, 2)
+	chk.Scalar(tst, "ymax", 1e-17, msh.Ymax, 1)
+	chk.Ints(tst, "verts0", msh.Cells[0].Verts, []int{0, 1, 4, 3})
+	chk.Ints(tst, "verts1", msh.Cells[1].Verts, []int{1, 2, 5, 4})
+	chk.Ints(tst, "ftags0", msh.Cells[0].FTags, []int{-10, 0, 0, -11})
+	chk.Ints(tst, "ftags1", msh.Cells[1].FTags, []int{-10, 0, 0, 0})
+	chk.IntAssert(msh.Cells[0].Tag, -1)
+	chk.IntAssert(msh.Verts[0].Tag, -20)
+	chk.Ints(tst, "bottom verts", msh.FaceTag2verts[-10], []int{0, 1, 2})
+}
+
+func Test_msh03(tst *testing.T) {
+
+	chk.PrintTitle("msh03. Gmsh v4")
+
+	msh := ReadMsh("data", "gmsh3d.gmsh")
+	if msh == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	io.Pforan("%v\n", msh)
+	chk.IntAssert(msh.Ndim, 3)
+	chk.IntAssert(len(msh.Verts), 8)
+	chk.IntAssert(len(msh.Cells), 1)
+	chk.Scalar(tst, "zmax", 1e-17, msh.Zmax, 1)
+	chk.IntAssert(msh.Cells[0].Tag, -5)
+	chk.Ints(tst, "verts", msh.Cells[0].Verts, []int{0, 1, 2, 3, 4, 5, 6, 7})
+	chk.Ints(tst, "ftags", msh.Cells[0].FTags, []int{0, 0, 0, 0, -7, 0})
+	chk.IntAssert(msh.Verts[0].Tag, -3)
+	chk.IntAssert(len(msh.FaceTag2cells[-7]), 1)
+}
+
+func Test_msh03a(tst *testing.T) {
+
+	chk.PrintTitle("msh03a. Gmsh v2 with tet10")
+
+	msh := ReadMsh("data", "gmsh3d_tet10.msh")
+	if msh == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	io.Pforan("%v\n", msh)
+	chk.IntAssert(msh.Ndim, 3)
+	chk.IntAssert(len(msh.Verts), 10)
+	chk.IntAssert(len(msh.Cells), 1)
+	if msh.Cells[0].Type != "tet10" {
+		tst.Errorf("cell type is incorrect: %q\n", msh.Cells[0].Type)
+		return
+	}
+	chk.Ints(tst, "verts", msh.Cells[0].Verts, []int{0, 1, 2, 3, 4, 5, 6, 7, 9, 8})
+	chk.Ints(tst, "ftags", msh.Cells[0].FTags, []int{0, 0, -7, 0})
+	chk.IntAssert(msh.Cells[0].Tag, -1)
+	chk.IntAssert(msh.Verts[3].Tag, -3)
+	check_gmsh_quadratic(tst, msh, func(r float64) float64 { return r })
+}
+
+func Test_msh03b(tst *testing.T) {
+
+	chk.PrintTitle("msh03b. Gmsh v4 with hex20")
+
+	msh := ReadMsh("data", "gmsh3d_hex20.gmsh")
+	if msh == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	io.Pforan("%v\n", msh)
+	chk.IntAssert(msh.Ndim, 3)
+	chk.IntAssert(len(msh.Verts), 20)
+	chk.IntAssert(len(msh.Cells), 1)
+	if msh.Cells[0].Type != "hex20" {
+		tst.Errorf("cell type is incorrect: %q\n", msh.Cells[0].Type)
+		return
+	}
+	chk.Ints(tst, "verts", msh.Cells[0].Verts, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 11, 13, 9, 16, 18, 19, 17, 10, 12, 14, 15})
+	chk.Ints(tst, "ftags", msh.Cells[0].FTags, []int{0, 0, 0, 0, 0, -7})
+	chk.IntAssert(msh.Cells[0].Tag, -5)
+	chk.IntAssert(msh.Verts[4].Tag, -3)
+	chk.IntAssert(len(msh.FaceTag2cells[-7]), 1)
+	check_gmsh_quadratic(tst, msh, func(r float64) float64 { return (r + 1.0) / 2.0 })
+}
+
+// check_gmsh_quadratic checks that the vertices of the first cell of a mesh with the reference
+// geometry (unit tetrahedron or cube) are ordered as the natural coordinates of the gofem shape
+// r2x maps the natural coordinates to the real coordinates
+func check_gmsh_quadratic(tst *testing.T, msh *Mesh, r2x func(r float64) float64) {
+	c := msh.Cells[0]
+	s := shp.Get(c.Type)
+	for m, vid := range c.Verts {
+		for i := 0; i < msh.Ndim; i++ {
+			chk.Scalar(tst, io.Sf("x%d of local vertex %d", i, m), 1e-15, msh.Verts[vid].C[i], r2x(s.NatCoords[i][m]))
+		}
+	}
+}
+
+func Test_msh04(tst *testing.T) {
+
+	chk.PrintTitle("msh04. Abaqus")
+
+	msh := ReadMsh("data", "abaqus2d.inp")
+	if msh == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	io.Pforan("%v\n", msh)
+	chk.IntAssert(msh.Ndim, 2)
+	chk.IntAssert(len(msh.Verts), 6)
+	chk.IntAssert(len(msh.Cells), 3)
+	chk.Ints(tst, "verts0", msh.Cells[0].Verts, []int{0, 1, 4, 3})
+	chk.Ints(tst, "verts2", msh.Cells[2].Verts, []int{3, 4})
+	chk.IntAssert(msh.Cells[0].Tag, -1)
+	chk.IntAssert(msh.Cells[1].Tag, -1)
+	chk.IntAssert(msh.Cells[2].Tag, -2)
+	if msh.Cells[2].Type != "lin2" {
+		tst.Errorf("cell type is incorrect: %q\n", msh.Cells[2].Type)
+		return
+	}
+	chk.Ints(tst, "ftags0", msh.Cells[0].FTags, []int{-1, 0, 0, 0})
+	chk.Ints(tst, "ftags1", msh.Cells[1].FTags, []int{-1, -2, 0, 0})
+	chk.IntAssert(msh.Verts[0].Tag, -1)
+	chk.IntAssert(msh.Verts[1].Tag, 0)
+	chk.Ints(tst, "right verts", msh.FaceTag2verts[-2], []int{2, 5})
+}
+
+func Test_msh05(tst *testing.T) {
+
+	chk.PrintTitle("msh05. partitioning")
+
+	msh := ReadMsh("data", "frees01.msh")
+	if msh == nil {
+		tst.Errorf("test failed\n")
+		return
+	}
+	if !msh.HasPartitions(4) || msh.HasPartitions(5) {
+		tst.Errorf("HasPartitions failed\n")
+		return
+	}
+
+	// 5 partitions => one row of cells each
+	if !msh.Partition(5) {
+		tst.Errorf("Partition failed\n")
+		return
+	}
+	chk.IntAssert(len(msh.Part2cells), 5)
+	for _, c := range msh.Cells {
+		chk.IntAssert(c.Part, c.Id/3)
+	}
+	if !msh.HasPartitions(5) {
+		tst.Errorf("HasPartitions failed\n")
+		return
+	}
+
+	// 2 partitions
+	if !msh.Partition(2) {
+		tst.Errorf("Partition failed\n")
+		return
+	}
+	chk.IntAssert(len(msh.Part2cells[0]), 8)
+	chk.IntAssert(len(msh.Part2cells[1]), 7)
+	for _, c := range msh.Cells {
+		chk.IntAssert(c.Part, c.Id/8)
+	}
+
+	// too many partitions
+	if msh.Partition(16) {
+		tst.Errorf("Partition should have failed\n")
+	}
+}
+
 func Test_sim01(tst *testing.T) {
 
 	//verbose()
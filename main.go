@@ -8,6 +8,7 @@ import (
 	"flag"
 
 	"github.com/cpmech/gofem/fem"
+	"github.com/cpmech/gofem/out"
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/mpi"
@@ -40,6 +41,8 @@ func main() {
 	}
 
 	// simulation filenamepath
+	restart := flag.Int("restart", 0, "output index (tidx) of a previous run to restart from; 0 => no restart")
+	vtu := flag.Bool("vtu", false, "write VTU and PVD files on-the-fly")
 	flag.Parse()
 	var fnamepath string
 	if len(flag.Args()) > 0 {
@@ -62,6 +65,9 @@ func main() {
 	if len(flag.Args()) > 2 {
 		verbose = io.Atob(flag.Arg(2))
 	}
+	if *restart > 0 { // keep files of previous run
+		erasefiles = false
+	}
 
 	// profiling?
 	defer utl.DoProf(false)()
@@ -75,6 +81,16 @@ func main() {
 	// make sure to flush log
 	defer fem.End()
 
+	// restart from previous run
+	if *restart > 0 {
+		fem.Global.Restart = *restart
+	}
+
+	// write VTU files on-the-fly
+	if *vtu {
+		out.VtuOnTheFly(nil, false)
+	}
+
 	// run simulation
 	if !fem.Run() {
 		io.PfRed("ERROR: cannot run simulation\n")
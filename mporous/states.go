@@ -105,3 +105,103 @@ func (o Model) CalcLs(res *LsVars, sta *State, pl, divus float64, derivs bool) (
 	}
 	return
 }
+
+// LgsVars hold data for liquid-gas-solid computations
+type LgsVars struct {
+	A_ρl, A_ρg, A_ρ, A_p                    float64
+	Cpl, Cpg, Cvs, Dpl, Dpg, Dvs            float64
+	Dρdpl, Dpdpl, DCpldpl, DCpgdpl, DCvsdpl float64
+	DDpldpl, DDpgdpl, DDvsdpl               float64
+	Dρdpg, Dpdpg, DCpldpg, DCpgdpg, DCvsdpg float64
+	DDpldpg, DDpgdpg, DDvsdpg               float64
+	Dklrdpl, Dklrdpg, Dkgrdpl, Dkgrdpg      float64
+	DρdusM, DCpldusM, DCpgdusM              float64
+	DDpldusM, DDpgdusM                      float64
+}
+
+// CalcLgs calculates variables for liquid-gas-solid simulations
+//  Note: the liquid and gas balance equations are written as
+//    Cpl・dpl/dt + Cpg・dpg/dt + Cvs・div(vs) - div(ρl・wl) = 0
+//    Dpl・dpl/dt + Dpg・dpg/dt + Dvs・div(vs) - div(ρg・wg) = 0
+func (o Model) CalcLgs(res *LgsVars, sta *State, pl, pg, divus float64, derivs bool) (err error) {
+
+	// auxiliary
+	ns0 := sta.A_ns0
+	sl := sta.A_sl
+	sg := 1.0 - sl
+	ρL := sta.A_ρL
+	ρG := sta.A_ρG
+	Cl := o.Cl
+	Cg := o.Cg
+	ρS := o.RhoS0
+
+	// n variables
+	ns := (1.0 - divus) * ns0
+	nf := 1.0 - ns
+	nl := nf * sl
+	ng := nf * sg
+
+	// ρ variables
+	ρs := ns * ρS
+	res.A_ρl = nl * ρL
+	res.A_ρg = ng * ρG
+	res.A_ρ = res.A_ρl + res.A_ρg + ρs
+
+	// capillary pressure and pore-fluid pressure
+	pc := pg - pl
+	res.A_p = sl*pl + sg*pg
+
+	// moduli
+	Ccb, e := o.Ccb(sta, pc)
+	if e != nil {
+		return e
+	}
+	res.Cpl = nf * (sl*Cl - ρL*Ccb)
+	res.Cpg = nf * ρL * Ccb
+	res.Cvs = sl * ρL
+	res.Dpl = nf * ρG * Ccb
+	res.Dpg = nf * (sg*Cg - ρG*Ccb)
+	res.Dvs = sg * ρG
+
+	// derivatives
+	if derivs {
+
+		// Ccd
+		Ccd, e := o.Ccd(sta, pc)
+		if e != nil {
+			return e
+		}
+
+		// derivatives w.r.t pl
+		res.Dρdpl = nf * (sl*Cl - ρL*Ccb + ρG*Ccb)
+		res.Dpdpl = sl + pc*Ccb
+		res.DCpldpl = nf * (ρL*Ccd - 2.0*Ccb*Cl)
+		res.DCpgdpl = nf * (Cl*Ccb - ρL*Ccd)
+		res.DCvsdpl = sl*Cl - Ccb*ρL
+		res.DDpldpl = -nf * ρG * Ccd
+		res.DDpgdpl = nf * (Ccb*Cg + ρG*Ccd)
+		res.DDvsdpl = Ccb * ρG
+		res.Dklrdpl = -o.Cnd.DklrDsl(sl) * Ccb
+		res.Dkgrdpl = o.Cnd.DkgrDsg(sg) * Ccb
+
+		// derivatives w.r.t pg
+		res.Dρdpg = nf * (sg*Cg + ρL*Ccb - ρG*Ccb)
+		res.Dpdpg = sg - pc*Ccb
+		res.DCpldpg = nf * (Ccb*Cl - ρL*Ccd)
+		res.DCpgdpg = nf * ρL * Ccd
+		res.DCvsdpg = Ccb * ρL
+		res.DDpldpg = nf * (Cg*Ccb + ρG*Ccd)
+		res.DDpgdpg = -nf * (2.0*Ccb*Cg + ρG*Ccd)
+		res.DDvsdpg = sg*Cg - Ccb*ρG
+		res.Dklrdpg = o.Cnd.DklrDsl(sl) * Ccb
+		res.Dkgrdpg = -o.Cnd.DkgrDsg(sg) * Ccb
+
+		// derivatives w.r.t us (multipliers only)
+		res.DρdusM = (sl*ρL + sg*ρG - ρS) * ns0
+		res.DCpldusM = (sl*Cl - ρL*Ccb) * ns0
+		res.DCpgdusM = ρL * Ccb * ns0
+		res.DDpldusM = ρG * Ccb * ns0
+		res.DDpgdusM = (sg*Cg - ρG*Ccb) * ns0
+	}
+	return
+}
@@ -0,0 +1,213 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mreten
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cpmech/gofem/calib"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+// CalibPath holds the measurements (pc, sl) along a drying or wetting path
+type CalibPath struct {
+	Desc string    `json:"desc"` // description; e.g. "main drying"
+	File string    `json:"file"` // file with table of measurements (columns: pc sl); read if Pc is empty
+	Pc   []float64 `json:"pc"`   // capillary pressures
+	Sl   []float64 `json:"sl"`   // liquid saturations
+}
+
+// Calibrator fits the parameters of a liquid retention model to laboratory data [3].
+// Consecutive paths form a single history of capillary pressures starting at the first
+// measurement; thus hysteretic models are followed with Update along drying and wetting paths.
+type Calibrator struct {
+
+	// input
+	Name  string       `json:"name"`  // name of material to be written; e.g. "lrm1"
+	Desc  string       `json:"desc"`  // description of material
+	Model string       `json:"model"` // name of model; e.g. "ref-m1"
+	Prms  fun.Prms     `json:"prms"`  // all parameters: initial values of calibrated ones and fixed values of others
+	Free  []*calib.Prm `json:"free"`  // parameters to be calibrated
+	Paths []*CalibPath `json:"paths"` // measurements
+
+	// options of minimisation; e.g. "method"
+	calib.Options
+
+	// results
+	Cost float64 `json:"-"` // minimum cost = ½ Σ (sl_model - sl_measured)²
+
+	// derived
+	mdl  Model      // liquid retention model
+	free []*fun.Prm // parameters to be calibrated
+}
+
+// ReadCalibrator reads the calibration data from a JSON file. Tables of measurements referenced
+// by paths are read from dir as well.
+func ReadCalibrator(dir, fn string) (o *Calibrator, err error) {
+	b, err := io.ReadFile(filepath.Join(dir, fn))
+	if err != nil {
+		return
+	}
+	o = new(Calibrator)
+	err = json.Unmarshal(b, o)
+	if err != nil {
+		return nil, chk.Err("mreten: cannot unmarshal calibration file %q:\n%v", fn, err)
+	}
+	for _, p := range o.Paths {
+		if len(p.Pc) == 0 && p.File != "" {
+			p.Pc, p.Sl, err = ReadCalibTable(filepath.Join(dir, p.File))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = o.Init()
+	return
+}
+
+// ReadCalibTable reads a table with two columns (pc sl). Empty lines, lines starting with '#'
+// and a header line are ignored
+func ReadCalibTable(fn string) (pc, sl []float64, err error) {
+	b, err := io.ReadFile(fn)
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for ln := 1; scanner.Scan(); ln++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		vals := strings.Fields(strings.Replace(line, ",", " ", -1))
+		if len(vals) < 2 {
+			return nil, nil, chk.Err("mreten: line %d of table %q must have 2 columns (pc sl)", ln, fn)
+		}
+		x, e1 := strconv.ParseFloat(vals[0], 64)
+		y, e2 := strconv.ParseFloat(vals[1], 64)
+		if e1 != nil || e2 != nil {
+			if len(pc) == 0 {
+				continue // header
+			}
+			return nil, nil, chk.Err("mreten: cannot parse line %d of table %q", ln, fn)
+		}
+		pc = append(pc, x)
+		sl = append(sl, y)
+	}
+	return
+}
+
+// Init checks data, allocates model and sets defaults
+func (o *Calibrator) Init() (err error) {
+
+	// check paths
+	if len(o.Paths) < 1 {
+		return chk.Err("mreten: at least one path of measurements is required")
+	}
+	for i, p := range o.Paths {
+		if len(p.Pc) != len(p.Sl) || len(p.Pc) < 1 {
+			return chk.Err("mreten: path %d must have the same (non-zero) number of pc and sl values. %d != %d", i, len(p.Pc), len(p.Sl))
+		}
+	}
+
+	// model
+	o.mdl = GetModel("calib", o.Name, o.Model, true)
+	if o.mdl == nil {
+		return chk.Err("mreten: cannot allocate model %q", o.Model)
+	}
+	if len(o.Prms) == 0 {
+		o.Prms = o.mdl.GetPrms(true)
+	}
+
+	// parameters to be calibrated
+	o.free, err = calib.GetPrms(o.Prms, o.Free)
+	if err != nil {
+		return
+	}
+	err = o.Options.Init(len(o.Free))
+	if err != nil {
+		return
+	}
+	return o.mdl.Init(o.Prms)
+}
+
+// Predict computes the saturations predicted by the model at all measured capillary pressures
+func (o *Calibrator) Predict() (sl [][]float64, err error) {
+	nonrate, isnonrate := o.mdl.(Nonrate)
+	sl = make([][]float64, len(o.Paths))
+	pc0, sl0 := o.Paths[0].Pc[0], o.Paths[0].Sl[0]
+	for i, p := range o.Paths {
+		sl[i] = make([]float64, len(p.Pc))
+		for j, pc := range p.Pc {
+			switch {
+			case i == 0 && j == 0:
+			case isnonrate:
+				sl0 = nonrate.Sl(pc)
+			case pc != pc0:
+				sl0, err = Update(o.mdl, pc0, sl0, pc-pc0)
+				if err != nil {
+					return
+				}
+			}
+			pc0 = pc
+			sl[i][j] = sl0
+		}
+	}
+	return
+}
+
+// Run runs calibration. The optimal values are set in Prms and the model is re-initialised
+func (o *Calibrator) Run() (err error) {
+
+	// number of residuals: all measurements but the initial one
+	nr := -1
+	for _, p := range o.Paths {
+		nr += len(p.Pc)
+	}
+
+	// problem
+	prob := calib.Problem{
+		Nr: nr,
+		Resid: func(r, x []float64) (e error) {
+			for i, prm := range o.free {
+				prm.V = x[i]
+			}
+			if e = o.mdl.Init(o.Prms); e != nil {
+				return
+			}
+			sl, e := o.Predict()
+			if e != nil {
+				return
+			}
+			k := 0
+			for i, p := range o.Paths {
+				for j := range p.Sl {
+					if i == 0 && j == 0 {
+						continue
+					}
+					r[k] = sl[i][j] - p.Sl[j]
+					k++
+				}
+			}
+			return
+		},
+	}
+	if _, ok := o.mdl.(Nonrate); !ok {
+		prob.Hrel = 1e-5 // predictions are computed with an ODE solver and are thus noisier
+	}
+
+	// run and re-initialise model with optimal values
+	o.Cost, err = o.Solve(&prob, o.Free, o.free)
+	if e := o.mdl.Init(o.Prms); e != nil && err == nil {
+		err = e
+	}
+	return
+}
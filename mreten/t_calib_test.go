@@ -0,0 +1,92 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mreten
+
+import (
+	"testing"
+
+	"github.com/cpmech/gofem/calib"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/utl"
+)
+
+func Test_calib01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("calib01. van Genuchten: drying path from table")
+
+	cal, err := ReadCalibrator("data", "vg01.cal")
+	if err != nil {
+		tst.Errorf("ReadCalibrator failed: %v\n", err)
+		return
+	}
+	chk.IntAssert(len(cal.Paths[0].Pc), 16)
+
+	for _, method := range []string{"lsq", "evol"} {
+		cal.Method = method
+		for i, v := range []float64{0.1, 3, 3} {
+			cal.Prms.Find(cal.Free[i].N).V = v
+		}
+		err = cal.Run()
+		if err != nil {
+			tst.Errorf("Run failed: %v\n", err)
+			return
+		}
+		io.Pforan("%s: cost = %g\n%v\n", method, cal.Cost, cal.Prms)
+		chk.Scalar(tst, "alp", 1e-8, cal.Prms.Find("alp").V, 0.08)
+		chk.Scalar(tst, "m", 1e-6, cal.Prms.Find("m").V, 4)
+		chk.Scalar(tst, "n", 1e-6, cal.Prms.Find("n").V, 4)
+	}
+}
+
+func Test_calib02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("calib02. ref-m1: drying and wetting paths (hysteresis)")
+
+	// synthetic data
+	cal := &Calibrator{
+		Name:  "lrm1",
+		Model: "ref-m1",
+		Free: []*calib.Prm{
+			{N: "lamd", Min: 1, Max: 6},
+			{N: "lamw", Min: 1, Max: 6},
+		},
+		Paths: []*CalibPath{
+			{Desc: "drying", Pc: utl.LinSpace(0, 20, 11), Sl: make([]float64, 11)},
+			{Desc: "wetting", Pc: utl.LinSpace(20, 0, 11), Sl: make([]float64, 11)},
+		},
+	}
+	cal.Paths[0].Sl[0] = 1
+	err := cal.Init()
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+	sl, err := cal.Predict()
+	if err != nil {
+		tst.Errorf("Predict failed: %v\n", err)
+		return
+	}
+	for i, p := range cal.Paths {
+		copy(p.Sl, sl[i])
+	}
+	io.Pforan("drying:  sl = %v\n", sl[0])
+	io.Pforan("wetting: sl = %v\n", sl[1])
+
+	// calibrate
+	cal.Prms.Find("lamd").V = 2.4
+	cal.Prms.Find("lamw").V = 3.5
+	err = cal.Run()
+	if err != nil {
+		tst.Errorf("Run failed: %v\n", err)
+		return
+	}
+	io.Pforan("cost = %g\n%v\n", cal.Cost, cal.Prms)
+	chk.Scalar(tst, "lamd", 1e-3, cal.Prms.Find("lamd").V, 3)
+	chk.Scalar(tst, "lamw", 1e-3, cal.Prms.Find("lamw").V, 3)
+}
@@ -4,7 +4,11 @@
 
 package msolid
 
-import "github.com/cpmech/gosl/tsr"
+import (
+	"math"
+
+	"github.com/cpmech/gosl/tsr"
+)
 
 // max returns the max between two floats
 func max(a, b float64) float64 {
@@ -109,3 +113,33 @@ func Eigenprojectors(P0, P1, P2 []float64, n [][]float64) {
 	}
 }
 */
+
+// man2ten2 returns the i-j component of a 2nd order tensor given in Mandel basis
+//  Note: m may have 4 (2D) or 6 (3D) components; missing components are zero
+func man2ten2(m []float64, i, j int) float64 {
+	a := tsr.T2MI[i][j]
+	if a >= len(m) {
+		return 0
+	}
+	if i == j {
+		return m[a]
+	}
+	return m[a] / math.Sqrt2
+}
+
+// man2ten4 returns the i-j-k-l component of a 4th order tensor given in Mandel basis
+//  Note: M may be [4][4] (2D) or [6][6] (3D); missing components are zero
+func man2ten4(M [][]float64, i, j, k, l int) float64 {
+	a, b := tsr.T2MI[i][j], tsr.T2MI[k][l]
+	if a >= len(M) || b >= len(M) {
+		return 0
+	}
+	c := M[a][b]
+	if i != j {
+		c /= math.Sqrt2
+	}
+	if k != l {
+		c /= math.Sqrt2
+	}
+	return c
+}
@@ -0,0 +1,360 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+
+	"github.com/cpmech/gofem/calib"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/plt"
+	"github.com/cpmech/gosl/tsr"
+)
+
+// CalibTest holds the results of a laboratory test. Compression is positive
+type CalibTest struct {
+	Desc  string    `json:"desc"`  // description; e.g. "CD triaxial; σc = 100 kPa"
+	Type  string    `json:"type"`  // "triax": drained triaxial compression with constant cell pressure (default); "undrained": constant volume; "oedo": oedometer
+	File  string    `json:"file"`  // file with table of measurements (columns: ea and some of q, ev, p); read if Ea is empty
+	Sr0   float64   `json:"sr0"`   // initial radial (cell) stress
+	Sa0   float64   `json:"sa0"`   // initial axial stress; default = Sr0
+	Nincs int       `json:"nincs"` // number of increments between measurements; default = 1
+	Ea    []float64 `json:"ea"`    // axial strains
+	Q     []float64 `json:"q"`     // deviatoric stresses q = σa - σr; may be empty
+	Ev    []float64 `json:"ev"`    // volumetric strains; may be empty
+	P     []float64 `json:"p"`     // mean stresses; may be empty
+}
+
+// Calibrator fits the parameters of a solid model to results of triaxial and oedometer tests.
+// The tests are simulated by means of Driver.RunTriax with the measured axial strains and the
+// misfit in the εd-q, εd-εv and εd-p planes (whichever are given) is minimised; i.e. the model
+// results are interpolated at the deviatoric strains of the measurements (see CalibTest.Ed).
+// Each quantity is normalised by its maximum measured absolute value.
+type Calibrator struct {
+
+	// input
+	Name  string       `json:"name"`  // name of material to be written; e.g. "clay"
+	Desc  string       `json:"desc"`  // description of material
+	Model string       `json:"model"` // name of model; e.g. "dp", "ccm", "smp" or "vm"
+	Prms  fun.Prms     `json:"prms"`  // all parameters: initial values of calibrated ones and fixed values of others
+	Free  []*calib.Prm `json:"free"`  // parameters to be calibrated
+	Tests []*CalibTest `json:"tests"` // measurements
+
+	// options of minimisation; e.g. "method"
+	calib.Options
+
+	// results
+	Cost float64 `json:"-"` // minimum cost = ½ Σ r²
+
+	// derived
+	mdl  Model      // solid model
+	drv  Driver     // driver to simulate tests
+	free []*fun.Prm // parameters to be calibrated
+}
+
+// ReadCalibrator reads the calibration data from a JSON file. Tables of measurements referenced
+// by tests are read from dir as well.
+func ReadCalibrator(dir, fn string) (o *Calibrator, err error) {
+	b, err := io.ReadFile(filepath.Join(dir, fn))
+	if err != nil {
+		return
+	}
+	o = new(Calibrator)
+	err = json.Unmarshal(b, o)
+	if err != nil {
+		return nil, chk.Err("msolid: cannot unmarshal calibration file %q:\n%v", fn, err)
+	}
+	for _, t := range o.Tests {
+		if len(t.Ea) == 0 && t.File != "" {
+			err = t.ReadTable(filepath.Join(dir, t.File))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = o.Init()
+	return
+}
+
+// ReadTable reads measurements from a table with header. Column "ea" is required and the others
+// ("q", "ev" and "p") are optional
+func (o *CalibTest) ReadTable(fn string) (err error) {
+	_, d, err := io.ReadTable(fn)
+	if err != nil {
+		return
+	}
+	var ok bool
+	if o.Ea, ok = d["ea"]; !ok {
+		return chk.Err("msolid: table %q must have column \"ea\"", fn)
+	}
+	o.Q, o.Ev, o.P = d["q"], d["ev"], d["p"]
+	return
+}
+
+// Ed returns the deviatoric strains εd = 2/3 |εa - εr| of the measurements with εr = (εv - εa)/2;
+// where εv are the measured volumetric strains in drained triaxial tests, zero in undrained tests
+// and equal to εa in oedometer tests. Strains are relative to the first measurement.
+//  Note: nil is returned for drained triaxial tests without εv
+func (o *CalibTest) Ed() (ed []float64) {
+	if o.Type == "triax" && len(o.Ev) == 0 {
+		return nil
+	}
+	ed = make([]float64, len(o.Ea))
+	for j := range o.Ea {
+		εa, εv := o.Ea[j]-o.Ea[0], 0.0
+		switch o.Type {
+		case "triax":
+			εv = o.Ev[j] - o.Ev[0]
+		case "oedo":
+			εv = εa
+		}
+		εr := (εv - εa) / 2.0
+		ed[j] = 2.0 * math.Abs(εa-εr) / 3.0
+	}
+	return
+}
+
+// Init checks data, allocates model and sets defaults
+func (o *Calibrator) Init() (err error) {
+
+	// check tests
+	if len(o.Tests) < 1 {
+		return chk.Err("msolid: at least one test is required")
+	}
+	for i, t := range o.Tests {
+		n := len(t.Ea)
+		if n < 2 {
+			return chk.Err("msolid: test %d must have at least 2 measurements", i)
+		}
+		if len(t.Q)+len(t.Ev)+len(t.P) == 0 {
+			return chk.Err("msolid: test %d must have at least one of q, ev or p", i)
+		}
+		if (len(t.Q) > 0 && len(t.Q) != n) || (len(t.Ev) > 0 && len(t.Ev) != n) || (len(t.P) > 0 && len(t.P) != n) {
+			return chk.Err("msolid: q, ev and p of test %d must have the same size as ea (%d)", i, n)
+		}
+		if t.Type == "" {
+			t.Type = "triax"
+		}
+		if t.Type != "triax" && t.Type != "undrained" && t.Type != "oedo" {
+			return chk.Err("msolid: type of test %q is invalid; options: triax, undrained, oedo", t.Type)
+		}
+		if t.Sa0 == 0 {
+			t.Sa0 = t.Sr0
+		}
+	}
+
+	// model
+	o.mdl, _ = GetModel("calib", o.Name, o.Model, true)
+	if o.mdl == nil {
+		return chk.Err("msolid: cannot allocate model %q", o.Model)
+	}
+	if len(o.Prms) == 0 {
+		o.Prms = o.mdl.GetPrms()
+	}
+
+	// parameters to be calibrated
+	o.free, err = calib.GetPrms(o.Prms, o.Free)
+	if err != nil {
+		return
+	}
+	err = o.Options.Init(len(o.Free))
+	if err != nil {
+		return
+	}
+
+	// driver
+	o.drv.Silent = true
+	err = o.drv.InitWithModel(calib_ndim, o.mdl)
+	if err != nil {
+		return
+	}
+	return o.mdl.Init(calib_ndim, false, o.Prms)
+}
+
+// Simulate runs the test with index idx. Results are returned by Driver; see Driver.Res and Driver.Eps
+func (o *Calibrator) Simulate(idx int) (drv *Driver, err error) {
+	t := o.Tests[idx]
+	err = o.drv.RunTriax(t.Sr0, t.Sa0, t.Ea, t.Nincs, t.Type)
+	return &o.drv, err
+}
+
+// Predict computes q, εv and p predicted by the model at the deviatoric strains ed of the
+// measurements (see CalibTest.Ed). If ed cannot be computed; i.e. in drained triaxial tests
+// without εv, ed are the deviatoric strains computed by the model at the measured axial strains
+func (o *Calibrator) Predict() (ed, q, ev, p [][]float64, err error) {
+	nt := len(o.Tests)
+	ed, q, ev, p = make([][]float64, nt), make([][]float64, nt), make([][]float64, nt), make([][]float64, nt)
+	for i, t := range o.Tests {
+		_, err = o.Simulate(i)
+		if err != nil {
+			return
+		}
+
+		// model results
+		nr := len(o.drv.Res)
+		edm, qm, evm, pm := make([]float64, nr), make([]float64, nr), make([]float64, nr), make([]float64, nr)
+		devε := make([]float64, len(o.drv.Eps[0]))
+		ev0 := 0.0
+		if len(t.Ev) > 0 {
+			ev0 = t.Ev[0]
+		}
+		for k := 0; k < nr; k++ {
+			pm[k], qm[k], _ = tsr.M_pqw(o.drv.Res[k].Sig)
+			_, evm[k], edm[k] = tsr.M_devε(devε, o.drv.Eps[k])
+			evm[k] = ev0 - evm[k]
+		}
+
+		// predictions at measurements
+		n := len(t.Ea)
+		q[i], ev[i], p[i] = make([]float64, n), make([]float64, n), make([]float64, n)
+		ed[i] = t.Ed()
+		if ed[i] == nil {
+			ed[i] = make([]float64, n)
+			nincs := imax(t.Nincs, 1)
+			for j := 0; j < n; j++ {
+				k := j * nincs
+				ed[i][j], q[i][j], ev[i][j], p[i][j] = edm[k], qm[k], evm[k], pm[k]
+			}
+			continue
+		}
+		for j := 0; j < n; j++ {
+			q[i][j] = calib_interp(edm, qm, ed[i][j])
+			ev[i][j] = calib_interp(edm, evm, ed[i][j])
+			p[i][j] = calib_interp(edm, pm, ed[i][j])
+		}
+	}
+	return
+}
+
+// Run runs calibration. The optimal values are set in Prms and the model is re-initialised
+func (o *Calibrator) Run() (err error) {
+
+	// scaling factors and number of residuals
+	nr := 0
+	scales := make([][]float64, len(o.Tests))
+	for i, t := range o.Tests {
+		scales[i] = []float64{calib_scale(t.Q), calib_scale(t.Ev), calib_scale(t.P)}
+		for _, vals := range [][]float64{t.Q, t.Ev, t.P} {
+			if len(vals) > 0 {
+				nr += len(vals) - 1
+			}
+		}
+	}
+
+	// problem
+	prob := calib.Problem{
+		Nr:   nr,
+		Hrel: 1e-6,
+		Resid: func(r, x []float64) (e error) {
+			for i, prm := range o.free {
+				prm.V = x[i]
+			}
+			if e = o.mdl.Init(calib_ndim, false, o.Prms); e != nil {
+				return
+			}
+			_, q, ev, p, e := o.Predict()
+			if e != nil {
+				return
+			}
+			k := 0
+			for i, t := range o.Tests {
+				mdl, dat := [][]float64{q[i], ev[i], p[i]}, [][]float64{t.Q, t.Ev, t.P}
+				for m := range dat {
+					for j := 1; j < len(dat[m]); j++ {
+						r[k] = (mdl[m][j] - dat[m][j]) / scales[i][m]
+						k++
+					}
+				}
+			}
+			return
+		},
+	}
+
+	// run and re-initialise model with optimal values
+	o.Cost, err = o.Solve(&prob, o.Free, o.free)
+	if e := o.mdl.Init(calib_ndim, false, o.Prms); e != nil && err == nil {
+		err = e
+	}
+	return
+}
+
+// Plot plots model predictions (with Plotter) and measurements in the εd-q and εd-εv planes
+func (o *Calibrator) Plot(dirout, fnkey string) (err error) {
+	var plr Plotter
+	plr.SetFig(false, false, 1.5, 400, dirout, fnkey)
+	if m, ok := o.mdl.(EPmodel); ok {
+		plr.SetModel(m)
+	}
+	clrs := []string{"red", "blue", "green", "magenta", "cyan", "black"}
+	keys := []string{"ed,q", "ed,ev"}
+	ed, _, _, _, err := o.Predict()
+	if err != nil {
+		return
+	}
+	plr.PlotFcn = func() {
+		for i, t := range o.Tests {
+			n := len(t.Ea)
+			x, ev := make([]float64, n), make([]float64, n)
+			for j := 0; j < n; j++ {
+				x[j] = ed[i][j] * 100.0
+				if len(t.Ev) > 0 {
+					ev[j] = -(t.Ev[j] - t.Ev[0]) * 100.0 // Plotter: tension is positive
+				}
+			}
+			args := io.Sf("'o', color='%s', ms=4, clip_on=0", clrs[i%len(clrs)])
+			if len(t.Q) > 0 {
+				plt.Subplot(2, 1, 1)
+				plt.Plot(x, t.Q, args)
+			}
+			if len(t.Ev) > 0 {
+				plt.Subplot(2, 1, 2)
+				plt.Plot(x, ev, args)
+			}
+		}
+	}
+	for i, t := range o.Tests {
+		_, err = o.Simulate(i)
+		if err != nil {
+			return
+		}
+		plr.Clr, plr.SpClr, plr.EpClr = clrs[i%len(clrs)], clrs[i%len(clrs)], clrs[i%len(clrs)]
+		plr.Lbl = t.Desc
+		plr.Plot(keys, o.drv.Res, o.drv.Eps, i == 0, i == len(o.Tests)-1)
+	}
+	return
+}
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// calib_ndim is the space dimension used to simulate tests
+const calib_ndim = 2
+
+// calib_scale returns the scaling factor of measurements: max(|vals|) or 1 if all vals are zero
+func calib_scale(vals []float64) (s float64) {
+	for _, v := range vals {
+		s = math.Max(s, math.Abs(v))
+	}
+	if s == 0 {
+		return 1
+	}
+	return
+}
+
+// calib_interp linearly interpolates y(x) given increasing X and corresponding Y. The first or
+// last segments are extrapolated if x is outside the range of X
+func calib_interp(X, Y []float64, x float64) float64 {
+	k := 0
+	for k < len(X)-2 && X[k+1] < x {
+		k++
+	}
+	if X[k+1] == X[k] {
+		return Y[k+1]
+	}
+	return Y[k] + (x-X[k])*(Y[k+1]-Y[k])/(X[k+1]-X[k])
+}
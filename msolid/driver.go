@@ -5,6 +5,8 @@
 package msolid
 
 import (
+	"math"
+
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
 	"github.com/cpmech/gosl/io"
@@ -26,6 +28,7 @@ type Driver struct {
 	TolD    float64 // tolerance to check consistent matrix
 	VerD    bool    // verbose check of D
 	WithPC  bool    // with predictor-corrector data
+	TolR    float64 // tolerance on the radial stress in triaxial tests (RunTriax)
 
 	// results
 	Res []*State    // stress/ivs results
@@ -52,6 +55,7 @@ func (o *Driver) Init(simfnk, modelname string, ndim int, pstress bool, prms fun
 	}
 	o.D = la.MatAlloc(o.nsig, o.nsig)
 	o.TolD = 1e-8
+	o.TolR = 1e-10
 	o.VerD = chk.Verbose
 	return
 }
@@ -62,6 +66,7 @@ func (o *Driver) InitWithModel(ndim int, model Model) (err error) {
 	o.model = model
 	o.D = la.MatAlloc(o.nsig, o.nsig)
 	o.TolD = 1e-8
+	o.TolR = 1e-10
 	o.VerD = chk.Verbose
 	return
 }
@@ -232,10 +237,124 @@ func (o *Driver) Run(pth *Path) (err error) {
 	return
 }
 
+// RunTriax runs a triaxial compression or oedometer test. The axial strains εz are prescribed and
+// the radial strains εx = εy are
+//  "triax"     -- drained triaxial test: found by Newton's method such that σx = σy = -sr0
+//  "undrained" -- undrained triaxial test: -εz/2; i.e. the volume is constant
+//  "oedo"      -- oedometer test: zero
+//  Input:
+//   sr0   -- initial radial (cell) stress; compression positive
+//   sa0   -- initial axial stress; compression positive
+//   Ea    -- axial strains (compression positive); only increments are considered
+//   nincs -- number of increments between consecutive axial strains
+//   typ   -- type of test: "triax", "undrained" or "oedo"
+func (o *Driver) RunTriax(sr0, sa0 float64, Ea []float64, nincs int, typ string) (err error) {
+
+	// type of test
+	drained := typ == "triax"
+	if !drained && typ != "undrained" && typ != "oedo" {
+		return chk.Err(_driver_err06, typ)
+	}
+
+	// small-strain model
+	sml, ok := o.model.(Small)
+	if !ok {
+		return chk.Err("triaxial tests can only be run with small-strain models\n")
+	}
+
+	// initial stresses
+	σ0 := make([]float64, o.nsig)
+	σ0[0], σ0[1], σ0[2] = -sr0, -sr0, -sa0
+
+	// allocate results arrays
+	if nincs < 1 {
+		nincs = 1
+	}
+	nr := 1 + (len(Ea)-1)*nincs
+	if nr < 2 {
+		return chk.Err(_driver_err04, len(Ea), nincs)
+	}
+	o.Res = make([]*State, nr)
+	o.Eps = la.MatAlloc(nr, o.nsig)
+	for i := 0; i < nr; i++ {
+		o.Res[i], err = o.model.InitIntVars(σ0)
+		if err != nil {
+			return
+		}
+	}
+	o.PreCor = nil
+
+	// update states
+	Δε := make([]float64, o.nsig)
+	tol := o.TolR * (1.0 + math.Abs(sr0))
+	k := 1
+	for i := 1; i < len(Ea); i++ {
+		Δεa := -(Ea[i] - Ea[i-1]) / float64(nincs)
+		for inc := 0; inc < nincs; inc++ {
+
+			// initial radial strain increment from tangent at beginning of increment
+			o.Res[k].Set(o.Res[k-1])
+			Δεr := 0.0
+			switch typ {
+			case "triax":
+				err = sml.CalcD(o.D, o.Res[k], true)
+				if err != nil {
+					return chk.Err(_driver_err03, err)
+				}
+				Δεr = -o.D[0][2] * Δεa / (o.D[0][0] + o.D[0][1])
+			case "undrained":
+				Δεr = -Δεa / 2.0
+			}
+
+			// iterations: find Δεr such that σx = -sr0
+			for it := 0; ; it++ {
+
+				// update strains and stresses
+				Δε[0], Δε[1], Δε[2] = Δεr, Δεr, Δεa
+				la.VecAdd2(o.Eps[k], 1, o.Eps[k-1], 1, Δε) // εnew = εold + Δε
+				o.Res[k].Set(o.Res[k-1])
+				err = sml.Update(o.Res[k], o.Eps[k], Δε, 0, 0)
+				if err != nil {
+					if !o.Silent {
+						io.Pfred(_driver_err02, err)
+					}
+					return
+				}
+
+				// check convergence
+				if !drained {
+					break
+				}
+				r := o.Res[k].Sig[0] + sr0
+				if math.Abs(r) < tol {
+					break
+				}
+				if it == _driver_maxitR {
+					return chk.Err(_driver_err05, it, r)
+				}
+
+				// Newton update
+				err = sml.CalcD(o.D, o.Res[k], false)
+				if err != nil {
+					return chk.Err(_driver_err03, err)
+				}
+				Δεr -= r / (o.D[0][0] + o.D[0][1])
+			}
+			k += 1
+		}
+	}
+	return
+}
+
+// constants
+const _driver_maxitR = 20 // max number of iterations to find radial strains in RunTriax
+
 // error messages
 var (
 	_driver_err01 = "strain update failed\n%v\n"
 	_driver_err02 = "stress update failed\n%v\n"
 	_driver_err03 = "check of consistent matrix failed:\n %v\n\n"
 	_driver_err04 = "size of path is incorrect. Size=%d, Nincs=%d\n"
+	_driver_err05 = "radial stress did not converge after %d iterations. residual = %g\n"
+	_driver_err06 = "type of test %q is invalid; options: triax, undrained, oedo\n"
 )
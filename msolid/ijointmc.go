@@ -0,0 +1,228 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+)
+
+// IjointMC implements a Mohr-Coulomb slip/gap model for zero-thickness interfaces (Goodman joints).
+// Tractions and relative displacements are written in the local system of the interface:
+//  σ = {σn, τ1, τ2} and w = {wn, w1, w2}; with τ2 and w2 in 3D only
+//  Notes:
+//   1) normal tractions and openings are positive in tension
+//   2) the interface opens (gap) when σn reaches the tensile strength σt; then the bond is broken
+//      and the cohesion and tensile strength are set to zero for the remainder of the analysis
+//   3) the yield function is f = |τ| + σn tanφ - c and the plastic potential g = |τ| + σn tanψ
+//  Internal variables:
+//   EpsE   -- elastic relative displacements (including the gap while open)
+//   Alp[0] -- accumulated slip
+//   Alp[1] -- bond broken flag (1 == broken)
+type IjointMC struct {
+	Nsig int     // number of traction components == ndim
+	Kn   float64 // normal stiffness
+	Ks   float64 // shear stiffness
+	C    float64 // cohesion
+	Φ    float64 // friction angle [deg]
+	Ψ    float64 // dilatancy angle [deg]
+	Σt   float64 // tensile strength
+
+	// derived
+	tφ float64 // tan(φ)
+	tψ float64 // tan(ψ)
+}
+
+// Init initialises model
+func (o *IjointMC) Init(ndim int, prms fun.Prms) (err error) {
+
+	// parameters
+	o.Nsig = ndim
+	for _, p := range prms {
+		switch p.N {
+		case "kn":
+			o.Kn = p.V
+		case "ks":
+			o.Ks = p.V
+		case "c":
+			o.C = p.V
+		case "phi":
+			o.Φ = p.V
+		case "psi":
+			o.Ψ = p.V
+		case "sigt":
+			o.Σt = p.V
+		}
+	}
+
+	// check parameters
+	if o.Kn <= 0 || o.Ks <= 0 {
+		return chk.Err("ijointmc: stiffnesses kn=%g and ks=%g must be positive\n", o.Kn, o.Ks)
+	}
+	if o.C < 0 || o.Φ < 0 || o.Φ >= 90 {
+		return chk.Err("ijointmc: c=%g and φ=%g must satisfy c ≥ 0 and 0 ≤ φ < 90\n", o.C, o.Φ)
+	}
+	if o.Ψ < 0 || o.Ψ > o.Φ {
+		return chk.Err("ijointmc: dilatancy angle ψ=%g must be in [0, φ=%g]\n", o.Ψ, o.Φ)
+	}
+	o.tφ = math.Tan(o.Φ * math.Pi / 180.0)
+	o.tψ = math.Tan(o.Ψ * math.Pi / 180.0)
+	if o.Σt < 0 || o.Σt*o.tφ > o.C {
+		return chk.Err("ijointmc: tensile strength σt=%g must be in [0, c/tanφ]\n", o.Σt)
+	}
+	return
+}
+
+// GetPrms gets (an example) of parameters
+func (o IjointMC) GetPrms() fun.Prms {
+	return []*fun.Prm{
+		&fun.Prm{N: "kn", V: 1e6},
+		&fun.Prm{N: "ks", V: 1e5},
+		&fun.Prm{N: "c", V: 10},
+		&fun.Prm{N: "phi", V: 30},
+		&fun.Prm{N: "psi", V: 0},
+		&fun.Prm{N: "sigt", V: 0},
+	}
+}
+
+// InitIntVars initialises internal (secondary) variables
+//  σ -- initial tractions in the local system {σn, τ1, τ2}
+func (o IjointMC) InitIntVars(σ []float64) (s *State, err error) {
+	s = NewState(o.Nsig, 2, false, false)
+	copy(s.Sig, σ)
+	if s.Sig[0] > o.Σt {
+		return nil, chk.Err("ijointmc: initial normal traction σn=%g exceeds tensile strength σt=%g\n", s.Sig[0], o.Σt)
+	}
+	s.EpsE[0] = s.Sig[0] / o.Kn
+	for i := 1; i < o.Nsig; i++ {
+		s.EpsE[i] = s.Sig[i] / o.Ks
+	}
+	return
+}
+
+// Update updates tractions for given increment of relative displacements
+func (o *IjointMC) Update(s *State, Δw []float64) (err error) {
+
+	// trial elastic relative displacements
+	for i := 0; i < o.Nsig; i++ {
+		s.EpsE[i] += Δw[i]
+	}
+	s.Loading = false
+	s.Dgam = 0
+
+	// strength
+	c, σt := o.C, o.Σt
+	if s.Alp[1] > 0 {
+		c, σt = 0, 0
+	}
+
+	// open interface (gap)
+	σn := o.Kn * s.EpsE[0]
+	if σn > σt {
+		for i := 0; i < o.Nsig; i++ {
+			s.Sig[i] = 0
+		}
+		for i := 1; i < o.Nsig; i++ {
+			s.EpsE[i] = 0
+		}
+		s.Alp[1] = 1
+		return
+	}
+
+	// trial shear traction
+	τ := 0.0
+	for i := 1; i < o.Nsig; i++ {
+		s.Sig[i] = o.Ks * s.EpsE[i]
+		τ += s.Sig[i] * s.Sig[i]
+	}
+	τ = math.Sqrt(τ)
+
+	// elastic update
+	s.Sig[0] = σn
+	f := τ + σn*o.tφ - c
+	if f <= 0 {
+		return
+	}
+
+	// plastic update: slip
+	Δγ := f / (o.Ks + o.Kn*o.tφ*o.tψ)
+	for i := 1; i < o.Nsig; i++ {
+		s.EpsE[i] -= Δγ * s.Sig[i] / τ
+		s.Sig[i] = o.Ks * s.EpsE[i]
+	}
+	s.EpsE[0] -= Δγ * o.tψ
+	s.Sig[0] = o.Kn * s.EpsE[0]
+	s.Alp[0] += Δγ
+	s.Dgam = Δγ
+	s.Loading = true
+	return
+}
+
+// CalcD computes D = dσ_new/dw_new consistent with Update
+func (o *IjointMC) CalcD(D [][]float64, s *State, firstIt bool) (err error) {
+
+	// open interface
+	for i := 0; i < o.Nsig; i++ {
+		for j := 0; j < o.Nsig; j++ {
+			D[i][j] = 0
+		}
+	}
+	if s.Alp[1] > 0 && s.EpsE[0] > 0 {
+		return
+	}
+
+	// elastic
+	D[0][0] = o.Kn
+	for i := 1; i < o.Nsig; i++ {
+		D[i][i] = o.Ks
+	}
+	if !s.Loading {
+		return
+	}
+
+	// unit slip direction and norm of trial shear traction
+	m := make([]float64, o.Nsig)
+	τ := 0.0
+	for i := 1; i < o.Nsig; i++ {
+		τ += s.Sig[i] * s.Sig[i]
+	}
+	τ = math.Sqrt(τ)
+	if τ > 0 {
+		for i := 1; i < o.Nsig; i++ {
+			m[i] = s.Sig[i] / τ
+		}
+	}
+	τtr := τ + o.Ks*s.Dgam
+
+	// De·b and aᵀ·De with a = {tanφ, m} and b = {tanψ, m}
+	den := o.Ks + o.Kn*o.tφ*o.tψ
+	Db := make([]float64, o.Nsig)
+	aD := make([]float64, o.Nsig)
+	Db[0], aD[0] = o.Kn*o.tψ, o.Kn*o.tφ
+	for i := 1; i < o.Nsig; i++ {
+		Db[i], aD[i] = o.Ks*m[i], o.Ks*m[i]
+	}
+	for i := 0; i < o.Nsig; i++ {
+		for j := 0; j < o.Nsig; j++ {
+			D[i][j] -= Db[i] * aD[j] / den
+		}
+	}
+
+	// change of slip direction
+	if τtr > 0 {
+		β := o.Ks * o.Ks * s.Dgam / τtr
+		for i := 1; i < o.Nsig; i++ {
+			for j := 1; j < o.Nsig; j++ {
+				if i == j {
+					D[i][j] -= β
+				}
+				D[i][j] += β * m[i] * m[j]
+			}
+		}
+	}
+	return
+}
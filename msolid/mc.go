@@ -0,0 +1,434 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/tsr"
+)
+
+// MohrCoulomb implements the Mohr-Coulomb plasticity model with a hyperbolic approximation near
+// the apex and rounded corners [1], non-associated flow (dilatancy angle ψ) and an optional
+// tension cut-off on the major principal stress (Rankine). The tension cut-off is joined to the
+// Mohr-Coulomb surface by means of a smooth maximum function; thus a single smooth yield function
+// is used and the stress update is carried out by PrincStrainsUp in principal values.
+//  Note: stresses are positive in tension
+//  References:
+//   [1] Abbo AJ and Sloan SW (1995) A smooth hyperbolic approximation to the Mohr-Coulomb yield
+//       criterion. Computers & Structures, 54(3), 427-441
+type MohrCoulomb struct {
+	SmallElasticity
+	PU PrincStrainsUp // stress updater
+
+	// parameters
+	c  float64 // cohesion
+	φ  float64 // friction angle [deg]
+	ψ  float64 // dilatancy angle [deg]
+	a  float64 // hyperbolic approximation parameter (apex rounding)
+	θT float64 // transition Lode angle for rounding corners [deg]
+	σt float64 // tension cut-off (max principal stress); zero => no cut-off
+	δ  float64 // smoothing parameter for joining tension cut-off
+
+	// surfaces
+	fmc mcSurf // Mohr-Coulomb yield function
+	gmc mcSurf // Mohr-Coulomb plastic potential
+	ft  mcSurf // tension cut-off: yield function and plastic potential
+	tcf bool   // has tension cut-off
+
+	// auxiliary
+	λ  []float64   // principal stresses
+	Nt []float64   // ∂ft/∂σ
+	Ng []float64   // ∂gmc/∂σ
+	Mt [][]float64 // ∂²ft/∂σ²
+	Mg [][]float64 // ∂²gmc/∂σ²
+}
+
+// add model to factory
+func init() {
+	allocators["mc"] = func() Model { return new(MohrCoulomb) }
+}
+
+// Init initialises model
+func (o *MohrCoulomb) Init(ndim int, pstress bool, prms fun.Prms) (err error) {
+
+	// elasticity
+	if pstress {
+		return chk.Err("mc: plane-stress analyses are not available\n")
+	}
+	err = o.SmallElasticity.Init(ndim, pstress, prms)
+	if err != nil {
+		return
+	}
+
+	// parameters
+	o.a, o.θT, o.δ = -1, 29, -1
+	has_ψ := false
+	for _, p := range prms {
+		switch p.N {
+		case "c":
+			o.c = p.V
+		case "phi":
+			o.φ = p.V
+		case "psi":
+			o.ψ, has_ψ = p.V, true
+		case "a":
+			o.a = p.V
+		case "thT":
+			o.θT = p.V
+		case "sigt":
+			o.σt = p.V
+		case "tsm":
+			o.δ = p.V
+		}
+	}
+
+	// check parameters
+	if o.c < 0 || o.φ < 0 || o.φ >= 90 {
+		return chk.Err("mc: c=%g and φ=%g must satisfy c ≥ 0 and 0 ≤ φ < 90\n", o.c, o.φ)
+	}
+	if !has_ψ {
+		o.ψ = o.φ
+	}
+	if o.ψ < 0 || o.ψ > o.φ {
+		return chk.Err("mc: dilatancy angle ψ=%g must be in [0, φ=%g]\n", o.ψ, o.φ)
+	}
+	if o.θT <= 0 || o.θT >= 30 {
+		return chk.Err("mc: transition Lode angle θT=%g must be in (0, 30)\n", o.θT)
+	}
+	if o.σt < 0 {
+		return chk.Err("mc: tension cut-off σt=%g must be non-negative\n", o.σt)
+	}
+
+	// defaults
+	φ, ψ, θT := o.φ*math.Pi/180.0, o.ψ*math.Pi/180.0, o.θT*math.Pi/180.0
+	if o.a < 0 {
+		o.a = 0
+		if o.φ > 0 {
+			o.a = 0.05 * o.c / math.Tan(φ)
+		}
+	}
+	if o.δ < 0 {
+		o.δ = 0.01 * max(o.c, o.σt)
+	}
+
+	// surfaces
+	k := o.c * math.Cos(φ)
+	o.fmc.init(math.Sin(φ), k, o.a, θT)
+	o.gmc.init(math.Sin(ψ), k, o.a, θT)
+	o.tcf = o.σt > 0
+	if o.tcf {
+		o.ft.init(1, o.σt, o.δ, θT)
+	}
+
+	// auxiliary
+	o.λ = make([]float64, 3)
+	o.Nt = make([]float64, 3)
+	o.Ng = make([]float64, 3)
+	o.Mt = [][]float64{make([]float64, 3), make([]float64, 3), make([]float64, 3)}
+	o.Mg = [][]float64{make([]float64, 3), make([]float64, 3), make([]float64, 3)}
+
+	// stress updater
+	return o.PU.Init(ndim, prms, o)
+}
+
+// GetPrms gets (an example) of parameters
+func (o MohrCoulomb) GetPrms() fun.Prms {
+	return []*fun.Prm{
+		&fun.Prm{N: "E", V: 10000},
+		&fun.Prm{N: "nu", V: 0.3},
+		&fun.Prm{N: "c", V: 10},
+		&fun.Prm{N: "phi", V: 30},
+		&fun.Prm{N: "psi", V: 10},
+		&fun.Prm{N: "sigt", V: 0},
+	}
+}
+
+// InitIntVars initialises internal (secondary) variables
+func (o MohrCoulomb) InitIntVars(σ []float64) (s *State, err error) {
+	nalp := 1 // alp[0] is not used (no hardening)
+	s = NewState(o.Nsig, nalp, false, false)
+	copy(s.Sig, σ)
+	trσ := σ[0] + σ[1] + σ[2]
+	for i := 0; i < o.Nsig; i++ {
+		s.EpsE[i] = trσ*tsr.Im[i]/(9.0*o.K) + (σ[i]-trσ*tsr.Im[i]/3.0)/(2.0*o.G)
+	}
+	return
+}
+
+// Update updates stresses for given strains
+func (o *MohrCoulomb) Update(s *State, ε, Δε []float64, eid, ipid int) (err error) {
+	return o.PU.Update(s, ε, Δε, eid, ipid)
+}
+
+// CalcD computes D = dσ_new/dε_new consistent with StressUpdate
+func (o *MohrCoulomb) CalcD(D [][]float64, s *State, firstIt bool) (err error) {
+	return o.PU.CalcD(D, s)
+}
+
+// ContD computes D = dσ_new/dε_new continuous
+func (o *MohrCoulomb) ContD(D [][]float64, s *State) (err error) {
+	return chk.Err("mc: ContD is not available\n")
+}
+
+// EPmodel ///////////////////////////////////////////////////////////////////////////////////////////
+
+// Info returns some information and data from this model
+func (o MohrCoulomb) Info() (nalp, nsurf int) {
+	return 1, 1
+}
+
+// Get_phi returns φ or zero
+func (o MohrCoulomb) Get_phi() float64 {
+	return o.φ
+}
+
+// Get_bsmp gets b coefficient if using SMP invariants
+func (o MohrCoulomb) Get_bsmp() float64 {
+	return 0
+}
+
+// Set_bsmp sets b coefficient if using SMP invariants
+func (o *MohrCoulomb) Set_bsmp(b float64) {
+}
+
+// L_YieldFunc computes the yield function value for given principal stresses (σ)
+func (o *MohrCoulomb) L_YieldFunc(σ, α []float64) float64 {
+	f := o.fmc.calc(σ, nil, nil)
+	if o.tcf {
+		f, _, _ = mcSmax(f, o.ft.calc(σ, nil, nil), o.δ)
+	}
+	return f
+}
+
+// YieldFuncs computes yield function values
+func (o *MohrCoulomb) YieldFuncs(s *State) []float64 {
+	err := tsr.M_EigenValsNum(o.λ, s.Sig)
+	if err != nil {
+		chk.Panic("mc: cannot compute principal stresses:\n%v", err)
+	}
+	return []float64{o.L_YieldFunc(o.λ, s.Alp)}
+}
+
+// ElastUpdate updates state with an elastic response
+func (o MohrCoulomb) ElastUpdate(s *State, ε []float64) {
+	trε := ε[0] + ε[1] + ε[2]
+	for i := 0; i < o.Nsig; i++ {
+		s.Sig[i] = o.K*trε*tsr.Im[i] + 2.0*o.G*(ε[i]-trε*tsr.Im[i]/3.0)
+	}
+}
+
+// ElastD returns continuum elastic D
+func (o MohrCoulomb) ElastD(D [][]float64, s *State) {
+	o.SmallElasticity.CalcD(D, s)
+}
+
+// E_CalcSig computes principal stresses for given principal elastic strains
+func (o MohrCoulomb) E_CalcSig(σ, εe []float64) {
+	trεe := εe[0] + εe[1] + εe[2]
+	for i := 0; i < 3; i++ {
+		σ[i] = o.K*trεe + 2.0*o.G*(εe[i]-trεe/3.0)
+	}
+}
+
+// E_CalcDe computes elastic modulus in principal components
+func (o MohrCoulomb) E_CalcDe(De [][]float64, εe []float64) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			De[i][j] = o.K*tsr.Im[i]*tsr.Im[j] + 2.0*o.G*tsr.Psd[i][j]
+		}
+	}
+}
+
+// L_FlowHard computes model variabes for given principal values
+func (o *MohrCoulomb) L_FlowHard(Nb, h, σ, α []float64) (f float64, err error) {
+	f = o.fmc.calc(σ, nil, nil)
+	g := o.gmc.calc(σ, Nb, nil)
+	if o.tcf {
+		ft := o.ft.calc(σ, o.Nt, nil)
+		f, _, _ = mcSmax(f, ft, o.δ)
+		_, w, _ := mcSmax(g, ft, o.δ)
+		for i := 0; i < 3; i++ {
+			Nb[i] = w*Nb[i] + (1.0-w)*o.Nt[i]
+		}
+	}
+	h[0] = 0 // no hardening
+	return
+}
+
+// L_SecondDerivs computes second order derivatives
+//  N    -- ∂f/∂σ     [nsig]
+//  Nb   -- ∂g/∂σ     [nsig]
+//  A    -- ∂f/∂α_i   [nalp]
+//  h    -- hardening [nalp]
+//  Mb   -- ∂Nb/∂εe   [nsig][nsig]
+//  a_i  -- ∂Nb/∂α_i  [nalp][nsig]
+//  b_i  -- ∂h_i/∂εe  [nalp][nsig]
+//  c_ij -- ∂h_i/∂α_j [nalp][nalp]
+func (o *MohrCoulomb) L_SecondDerivs(N, Nb, A, h []float64, Mb, a, b, c [][]float64, σ, α []float64) (err error) {
+
+	// Mohr-Coulomb surfaces
+	f := o.fmc.calc(σ, N, nil)
+	g := o.gmc.calc(σ, o.Ng, o.Mg)
+	for i := 0; i < 3; i++ {
+		Nb[i] = o.Ng[i]
+		for j := 0; j < 3; j++ {
+			Mb[i][j] = o.Mg[i][j]
+		}
+	}
+
+	// join tension cut-off
+	if o.tcf {
+		ft := o.ft.calc(σ, o.Nt, o.Mt)
+		_, wf, _ := mcSmax(f, ft, o.δ)
+		_, wg, cg := mcSmax(g, ft, o.δ)
+		for i := 0; i < 3; i++ {
+			N[i] = wf*N[i] + (1.0-wf)*o.Nt[i]
+			Nb[i] = wg*o.Ng[i] + (1.0-wg)*o.Nt[i]
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				Mb[i][j] = wg*o.Mg[i][j] + (1.0-wg)*o.Mt[i][j] + cg*(o.Ng[i]-o.Nt[i])*(o.Ng[j]-o.Nt[j])
+			}
+		}
+	}
+
+	// no hardening
+	A[0], h[0] = 0, 0
+	for i := 0; i < 3; i++ {
+		a[0][i], b[0][i] = 0, 0
+	}
+	c[0][0] = 0
+	return
+}
+
+// auxiliary ///////////////////////////////////////////////////////////////////////////////////////
+
+// mcSurf implements Mohr-Coulomb-like surfaces in principal stresses space [1]
+//
+//   F = σm sinφ + sqrt(J2 K(θ)² + a² sin²φ) - k
+//
+//  with σm = tr(σ)/3 and the Lode angle θ given by sin(3θ) = -(3√3/2) J3 / J2^(3/2); θ = 30° on
+//  the compression meridian. K(θ) = cos(θ) - sin(θ) sinφ / √3 if |θ| ≤ θT; otherwise, corners
+//  are rounded with K(θ) = A - B sin(3θ). With sinφ = 1 and k = σt, F is the Rankine criterion.
+type mcSurf struct {
+	sφ, k, a float64    // sin(φ), k and a
+	tT       float64    // sin(3 θT)
+	A, B     [2]float64 // coefficients for rounding corners: [0] θ > θT; [1] θ < -θT
+}
+
+// init initialises surface
+func (o *mcSurf) init(sφ, k, a, θT float64) {
+	o.sφ, o.k, o.a = sφ, k, a
+	o.tT = math.Sin(3.0 * θT)
+	for i, sg := range []float64{1, -1} {
+		o.A[i] = math.Cos(θT) * (3.0 + math.Tan(θT)*math.Tan(3.0*θT) + sg*(math.Tan(3.0*θT)-3.0*math.Tan(θT))*sφ/math.Sqrt(3.0)) / 3.0
+		o.B[i] = (sg*math.Sin(θT) + sφ*math.Cos(θT)/math.Sqrt(3.0)) / (3.0 * math.Cos(3.0*θT))
+	}
+}
+
+// lode computes K and its first and second derivatives with respect to t = sin(3θ)
+func (o mcSurf) lode(t float64) (K, Kt, Ktt float64) {
+	switch {
+	case t > o.tT:
+		return o.A[0] - o.B[0]*t, -o.B[0], 0
+	case t < -o.tT:
+		return o.A[1] - o.B[1]*t, -o.B[1], 0
+	}
+	θ := math.Asin(t) / 3.0
+	c3θ := math.Sqrt(1.0 - t*t)
+	θt := 1.0 / (3.0 * c3θ)
+	θtt := t / (3.0 * c3θ * c3θ * c3θ)
+	K = math.Cos(θ) - math.Sin(θ)*o.sφ/math.Sqrt(3.0)
+	Kθ := -math.Sin(θ) - math.Cos(θ)*o.sφ/math.Sqrt(3.0)
+	Kt = Kθ * θt
+	Ktt = -K*θt*θt + Kθ*θtt
+	return
+}
+
+// calc computes F and, if N != nil, N = ∂F/∂σ and, if M != nil, M = ∂²F/∂σ²
+func (o mcSurf) calc(σ, N []float64, M [][]float64) (F float64) {
+
+	// invariants
+	var s, v, dt [3]float64
+	σm := (σ[0] + σ[1] + σ[2]) / 3.0
+	for i := 0; i < 3; i++ {
+		s[i] = σ[i] - σm
+	}
+	J2 := (s[0]*s[0] + s[1]*s[1] + s[2]*s[2]) / 2.0
+	J3 := s[0] * s[1] * s[2]
+	lode := J2 > mcJ2min
+	t := 0.0
+	if lode {
+		t = max(-1, min(1, mcCoef*J3/math.Pow(J2, 1.5)))
+	}
+	K, Kt, Ktt := o.lode(t)
+
+	// function
+	R := J2*K*K + o.a*o.a*o.sφ*o.sφ
+	r := math.Sqrt(R)
+	F = σm*o.sφ + r - o.k
+	if N == nil {
+		return
+	}
+
+	// first derivatives
+	if r < mcJ2min {
+		for i := 0; i < 3; i++ {
+			N[i] = o.sφ / 3.0
+			if M != nil {
+				M[i][0], M[i][1], M[i][2] = 0, 0, 0
+			}
+		}
+		return
+	}
+	var dR [3]float64
+	for i := 0; i < 3; i++ {
+		v[i] = s[i]*s[i] - 2.0*J2/3.0 // ∂J3/∂σ
+		if lode {
+			dt[i] = mcCoef * (v[i]/math.Pow(J2, 1.5) - 1.5*J3*s[i]/math.Pow(J2, 2.5))
+		}
+		dR[i] = K*K*s[i] + 2.0*J2*K*Kt*dt[i]
+		N[i] = o.sφ/3.0 + dR[i]/(2.0*r)
+	}
+	if M == nil {
+		return
+	}
+
+	// second derivatives
+	var du, dv, d2t, d2R float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			du = tsr.Psd[i][j] // ∂²J2/∂σi∂σj
+			d2t = 0
+			if lode {
+				dv = 2.0*s[i]*du - 2.0*s[j]/3.0 // ∂²J3/∂σi∂σj
+				d2t = mcCoef * (dv/math.Pow(J2, 1.5) - 1.5*(s[j]*v[i]+v[j]*s[i]+J3*du)/math.Pow(J2, 2.5) + 3.75*J3*s[i]*s[j]/math.Pow(J2, 3.5))
+			}
+			d2R = 2.0*K*Kt*(s[i]*dt[j]+dt[i]*s[j]) + K*K*du + 2.0*J2*(Kt*Kt+K*Ktt)*dt[i]*dt[j] + 2.0*J2*K*Kt*d2t
+			M[i][j] = d2R/(2.0*r) - dR[i]*dR[j]/(4.0*R*r)
+		}
+	}
+	return
+}
+
+// mcSmax computes the smooth maximum S = (h1 + h2 + sqrt((h1-h2)² + δ²)) / 2, w = ∂S/∂h1 and
+// c = ∂w/∂h1 = -∂w/∂h2
+func mcSmax(h1, h2, δ float64) (S, w, c float64) {
+	d := h1 - h2
+	r := math.Sqrt(d*d + δ*δ)
+	if r == 0 {
+		return h1, 0.5, 0
+	}
+	return (h1 + h2 + r) / 2.0, (1.0 + d/r) / 2.0, δ * δ / (2.0 * r * r * r)
+}
+
+// constants
+const (
+	mcJ2min = 1e-20                    // minimum J2 to compute Lode angle in Mohr-Coulomb surfaces
+	mcCoef  = -1.5 * 1.732050807568877 // -3√3/2: coefficient to compute sin(3θ)
+)
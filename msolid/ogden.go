@@ -6,13 +6,19 @@ package msolid
 
 import (
 	"math"
+	"strings"
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
 	"github.com/cpmech/gosl/tsr"
+	"github.com/cpmech/gosl/utl"
 )
 
-// Ogden implements a linear elastic model
+// Ogden implements a compressible Ogden hyperelastic model for large deformations
+//  Note: the principal Kirchhoff stresses are given by
+//    τ_k = Σ_p μ_p J^(-α_p/3) (λ_k^α_p - (λ_0^α_p + λ_1^α_p + λ_2^α_p)/3) + K ln(J)
+//  where λ_k are the principal stretches, i.e. λ_k² are the eigenvalues of b = F・tr(F)
 type Ogden struct {
 
 	// basic data
@@ -23,15 +29,23 @@ type Ogden struct {
 	Mu  []float64 // μ parameters
 	K   float64   // Bulk modulus
 
+	// constants
+	Pert  float64 // perturbation value for computing the tangent
+	EvTol float64 // tolerance to detect repeated eigenvalues
+	Zero  float64 // minimum eigenvalue to be considered zero
+
 	// auxiliary
-	Fi   [][]float64 // inverse of F [3][3]
-	J    float64     // det(F)
-	b    [][]float64 // left Cauchy-Green deformation [3][3]
-	bm   []float64   // Mandel version of b
-	λ    []float64   // eigenvalues of b [3]
-	P    [][]float64 // eigenprojectors of b [3][nsig]
-	τ    []float64   // eigenvalues Kirchhoff stress [3]
-	dτdb [][]float64
+	Fi   [][]float64   // inverse of F [3][3]
+	J    float64       // det(F)
+	b    [][]float64   // left Cauchy-Green deformation [3][3]
+	bm   []float64     // Mandel version of b
+	x    []float64     // eigenvalues of b [3]
+	λ    []float64     // principal stretches: λ_k = sqrt(x_k) [3]
+	P    [][]float64   // eigenprojectors of b [3][nsig]
+	τ    []float64     // eigenvalues Kirchhoff stress [3]
+	dτdx [][]float64   // derivatives of principal Kirchhoff stresses w.r.t eigenvalues of b [3][3]
+	dPdb [][][]float64 // derivatives of eigenprojectors w.r.t b [3][nsig][nsig]
+	dτdb [][]float64   // Mandel version of ∂τ/∂b [nsig][nsig]
 }
 
 // add model to factory
@@ -44,133 +58,200 @@ func (o *Ogden) Init(ndim int, pstress bool, prms fun.Prms) (err error) {
 
 	// basic data
 	o.Nsig = 2 * ndim
+	if pstress {
+		return chk.Err("Ogden model does not work with plane-stress\n")
+	}
 
 	// parameters
 	for _, p := range prms {
-		if p.N == "K" {
+		switch {
+		case p.N == "K":
 			o.K = p.V
-		}
-		if p.N[:3] == "alp" {
+		case strings.HasPrefix(p.N, "alp"):
 			o.Alp = append(o.Alp, p.V)
-		}
-		if p.N[:3] == "mu" {
+		case strings.HasPrefix(p.N, "mu"):
 			o.Mu = append(o.Mu, p.V)
 		}
 	}
 	if len(o.Alp) != len(o.Mu) {
 		return chk.Err("number of alp must be equal to number of mu. %d != %d\n", len(o.Alp), len(o.Mu))
 	}
+	if len(o.Alp) == 0 {
+		return chk.Err("Ogden model needs at least one pair of alp and mu parameters\n")
+	}
+
+	// constants
+	o.Pert = 1e-5
+	o.EvTol = tsr.EV_EVTOL
+	o.Zero = tsr.EV_ZERO
 
 	// auxiliary
 	o.Fi = tsr.Alloc2()
 	o.b = tsr.Alloc2()
 	o.bm = make([]float64, o.Nsig)
+	o.x = make([]float64, 3)
 	o.λ = make([]float64, 3)
 	o.P = tsr.M_AllocEigenprojs(o.Nsig)
 	o.τ = make([]float64, 3)
+	o.dτdx = la.MatAlloc(3, 3)
+	o.dPdb = utl.Deep3alloc(3, o.Nsig, o.Nsig)
+	o.dτdb = la.MatAlloc(o.Nsig, o.Nsig)
 	return
 }
 
 // GetPrms gets (an example) of parameters
 func (o Ogden) GetPrms() fun.Prms {
-	return []*fun.Prm{}
+	return []*fun.Prm{
+		&fun.Prm{N: "alp1", V: 2},
+		&fun.Prm{N: "mu1", V: 300},
+		&fun.Prm{N: "K", V: 1000},
+	}
 }
 
 // InitIntVars initialises internal (secondary) variables
 func (o Ogden) InitIntVars(σ []float64) (s *State, err error) {
 	s = NewState(o.Nsig, 0, true, false)
 	copy(s.Sig, σ)
+	for i := 0; i < 3; i++ {
+		s.F[i][i] = 1
+	}
 	return
 }
 
-// Update updates stresses for given strains
-func (o *Ogden) Update(s *State, F [][]float64) (err error) {
-
-	// TODO
-	return chk.Err("Ogden model is not implemented yet")
+// Update updates stresses for given deformation gradient
+//  Note: F is copied into s.F; FΔ is not needed by this (path-independent) model
+func (o *Ogden) Update(s *State, F, FΔ [][]float64) (err error) {
 
 	// spectral decomposition
-	err = o.b_and_spectral_decomp(F)
+	err = o.b_and_spectral_decomp(F, tsr.EV_PERT)
 	if err != nil {
 		return
 	}
 
 	// updated principal Kirchhoff stress
-	lnJ := math.Log(o.J)
-	for i := 0; i < 3; i++ {
-		o.τ[i] = 0
-		for p, α := range o.Alp {
-			f := (math.Pow(o.λ[0], α) + math.Pow(o.λ[1], α) + math.Pow(o.λ[2], α)) / 3.0
-			o.τ[i] += o.Mu[p]*math.Pow(o.J, -α/3.0)*(math.Pow(o.λ[i], α)-f) + o.K*lnJ
-		}
-	}
+	o.calc_τ()
 
 	// assemble Cauchy stress
-	for i := 0; i < len(s.Sig); i++ {
+	for i := 0; i < o.Nsig; i++ {
 		s.Sig[i] = (o.τ[0]*o.P[0][i] + o.τ[1]*o.P[1][i] + o.τ[2]*o.P[2][i]) / o.J
 	}
+	la.MatCopy(s.F, 1, F)
 	return
 }
 
 // CalcA computes tangent modulus A = (2/J) * ∂τ/∂b . b - σ palm I
+//  Note: A[i][j][k][l] = (2/J) * Σ_q ∂τ_ij/∂b_kq * b_ql - σ_il * δ_jk
 func (o *Ogden) CalcA(A [][][][]float64, s *State, firstIt bool) (err error) {
 
-	// TODO
-	return chk.Err("Ogden model is not implemented yet")
+	// spectral decomposition with perturbed eigenvalues
+	err = o.b_and_spectral_decomp(s.F, o.Pert)
+	if err != nil {
+		return
+	}
 
-	// spectral decomposition
-	err = o.b_and_spectral_decomp(s.F)
+	// derivatives of eigenprojectors w.r.t b
+	err = tsr.M_EigenProjsDeriv(o.dPdb, o.bm, o.x, o.P, o.Zero)
 	if err != nil {
 		return
 	}
 
-	// recover principal Kirchhoff
-	σ := s.Sig
-	for i := 0; i < 3; i++ {
-		o.τ[i] = 0
+	// principal Kirchhoff stresses and derivatives w.r.t eigenvalues of b
+	o.calc_τ()
+	o.calc_dτdx()
+
+	// ∂τ/∂b (Mandel)
+	for i := 0; i < o.Nsig; i++ {
 		for j := 0; j < o.Nsig; j++ {
-			o.τ[i] += o.J * σ[j] * o.P[i][j]
+			o.dτdb[i][j] = 0
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					o.dτdb[i][j] += o.dτdx[k][l] * o.P[k][i] * o.P[l][j]
+				}
+				o.dτdb[i][j] += o.τ[k] * o.dPdb[k][i][j]
+			}
 		}
 	}
 
-	// derivatives
-	var cf float64
-	for _, α := range o.Alp {
-		f := (math.Pow(o.λ[0], α) + math.Pow(o.λ[1], α) + math.Pow(o.λ[2], α)) / 3.0
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				// TODO
-				o.dτdb[i][j] = (cf)*(f-math.Pow(o.λ[i], α)-math.Pow(o.λ[j], α)) + o.K/(2*o.λ[j]*o.λ[j])
+	// spatial tangent modulus
+	σ := s.Sig
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					A[i][j][k][l] = 0
+					for q := 0; q < 3; q++ {
+						A[i][j][k][l] += 2.0 * man2ten4(o.dτdb, i, j, k, q) * o.b[q][l] / o.J
+					}
+					if j == k {
+						A[i][j][k][l] -= man2ten2(σ, i, l)
+					}
+				}
 			}
 		}
 	}
-
-	// assemble
-
-	// compute spatial tangent modulus
 	return
 }
 
-// spectral_decomp computes the spectral decomposition of b := F*tr(F) tensor
-func (o *Ogden) b_and_spectral_decomp(F [][]float64) (err error) {
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// b_and_spectral_decomp computes the spectral decomposition of b := F*tr(F) tensor
+//  Note: the Mandel version of b is perturbed if eigenvalues are repeated
+func (o *Ogden) b_and_spectral_decomp(F [][]float64, pert float64) (err error) {
 
 	// determinant of F
 	o.J, err = tsr.Inv(o.Fi, F)
 	if err != nil {
 		return
 	}
+	if o.J <= 0 {
+		return chk.Err("Ogden model: det(F) must be positive. J = %g is invalid\n", o.J)
+	}
 
 	// left Cauchy-Green tensor
 	tsr.LeftCauchyGreenDef(o.b, F)
 
 	// eigenvalues and eigenprojectors
 	tsr.Ten2Man(o.bm, o.b)
-	err = tsr.M_EigenValsProjsNum(o.P, o.λ, o.bm)
+	_, err = tsr.M_FixZeroOrRepeated(o.x, o.bm, pert, o.EvTol, o.Zero)
 	if err != nil {
 		return
 	}
-	o.λ[0] = math.Sqrt(o.λ[0])
-	o.λ[1] = math.Sqrt(o.λ[1])
-	o.λ[2] = math.Sqrt(o.λ[2])
+	err = tsr.M_EigenValsProjsNum(o.P, o.x, o.bm)
+	if err != nil {
+		return
+	}
+	o.λ[0] = math.Sqrt(o.x[0])
+	o.λ[1] = math.Sqrt(o.x[1])
+	o.λ[2] = math.Sqrt(o.x[2])
 	return
 }
+
+// calc_τ computes the principal Kirchhoff stresses
+func (o *Ogden) calc_τ() {
+	lnJ := math.Log(o.J)
+	for k := 0; k < 3; k++ {
+		o.τ[k] = o.K * lnJ
+		for p, α := range o.Alp {
+			f := (math.Pow(o.λ[0], α) + math.Pow(o.λ[1], α) + math.Pow(o.λ[2], α)) / 3.0
+			o.τ[k] += o.Mu[p] * math.Pow(o.J, -α/3.0) * (math.Pow(o.λ[k], α) - f)
+		}
+	}
+}
+
+// calc_dτdx computes the derivatives of principal Kirchhoff stresses w.r.t eigenvalues of b
+//  Note: J² = x_0 x_1 x_2; thus ∂J/∂x_l = J / (2 x_l)
+func (o *Ogden) calc_dτdx() {
+	for k := 0; k < 3; k++ {
+		for l := 0; l < 3; l++ {
+			o.dτdx[k][l] = o.K / (2.0 * o.x[l])
+			for p, α := range o.Alp {
+				c := o.Mu[p] * math.Pow(o.J, -α/3.0) * α
+				f := (math.Pow(o.λ[0], α) + math.Pow(o.λ[1], α) + math.Pow(o.λ[2], α)) / 3.0
+				o.dτdx[k][l] -= c * (math.Pow(o.λ[k], α) - f + math.Pow(o.λ[l], α)) / (6.0 * o.x[l])
+				if k == l {
+					o.dτdx[k][l] += c * math.Pow(o.λ[k], α) / (2.0 * o.x[k])
+				}
+			}
+		}
+	}
+}
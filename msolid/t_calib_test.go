@@ -0,0 +1,187 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"testing"
+
+	"github.com/cpmech/gofem/calib"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/tsr"
+	"github.com/cpmech/gosl/utl"
+)
+
+func Test_calib01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("calib01. deviatoric strains of measurements and misfit in εd-q and εd-εv planes")
+
+	// deviatoric strains: εd = εa - εv/3 (drained), εa (undrained) and 2/3 εa (oedometer)
+	ea, ev := []float64{0.01, 0.02, 0.04}, []float64{0.002, 0.001, -0.002}
+	for _, tc := range []struct {
+		typ string
+		ed  []float64
+	}{
+		{"triax", []float64{0, 0.01 + 0.001/3.0, 0.03 + 0.004/3.0}},
+		{"undrained", []float64{0, 0.01, 0.03}},
+		{"oedo", []float64{0, 0.02 / 3.0, 0.06 / 3.0}},
+	} {
+		t := CalibTest{Type: tc.typ, Ea: ea, Ev: ev}
+		chk.Vector(tst, tc.typ+": ed", 1e-15, t.Ed(), tc.ed)
+	}
+	t := CalibTest{Type: "triax", Ea: ea}
+	if t.Ed() != nil {
+		tst.Errorf("ed of drained test without εv must be nil\n")
+		return
+	}
+
+	// drained triaxial test with q and εv from table
+	cal, err := ReadCalibrator("data", "vm01.cal")
+	if err != nil {
+		tst.Errorf("ReadCalibrator failed: %v\n", err)
+		return
+	}
+	chk.IntAssert(len(cal.Tests[0].Ea), 21)
+
+	// radial stress must be constant
+	drv, err := cal.Simulate(0)
+	if err != nil {
+		tst.Errorf("Simulate failed: %v\n", err)
+		return
+	}
+	for _, s := range drv.Res {
+		chk.Scalar(tst, "σx", 1e-8, s.Sig[0], -50)
+		chk.Scalar(tst, "σy", 1e-8, s.Sig[1], -50)
+	}
+
+	// with the parameters used to generate the table, the model must reproduce the measurements
+	// in the εd-q and εd-εv planes
+	qy0, H := cal.Prms.Find("qy0"), cal.Prms.Find("H")
+	qy0.V, H.V = 20, 200
+	err = cal.mdl.Init(calib_ndim, false, cal.Prms)
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+	t = *cal.Tests[0]
+	ed, q, evm, _, err := cal.Predict()
+	if err != nil {
+		tst.Errorf("Predict failed: %v\n", err)
+		return
+	}
+	chk.Vector(tst, "ed", 1e-15, ed[0], t.Ed())
+	chk.Vector(tst, "q(ed)", 1e-8, q[0], t.Q)
+	chk.Vector(tst, "ev(ed)", 1e-10, evm[0], t.Ev)
+
+	// calibrate
+	qy0.V, H.V = 15, 100
+	err = cal.Run()
+	if err != nil {
+		tst.Errorf("Run failed: %v\n", err)
+		return
+	}
+	io.Pforan("cost = %g\n%v\n", cal.Cost, cal.Prms)
+	chk.Scalar(tst, "qy0", 1e-6, qy0.V, 20)
+	chk.Scalar(tst, "H", 1e-4, H.V, 200)
+
+	// plot
+	if false {
+		cal.Plot("/tmp/gofem", "test_calib01")
+	}
+}
+
+func Test_calib02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("calib02. Drucker-Prager: drained and undrained triaxial tests")
+
+	// synthetic data
+	cal := &Calibrator{
+		Name:  "dp",
+		Model: "dp",
+		Prms: []*fun.Prm{
+			&fun.Prm{N: "K", V: 1500},
+			&fun.Prm{N: "G", V: 1000},
+			&fun.Prm{N: "M", V: 1},
+			&fun.Prm{N: "Mb", V: 0.5},
+			&fun.Prm{N: "qy0", V: 5},
+			&fun.Prm{N: "H", V: 0},
+		},
+		Free: []*calib.Prm{
+			{N: "M", Min: 0.2, Max: 2},
+			{N: "qy0", Min: 0, Max: 20},
+		},
+		Tests: []*CalibTest{
+			{Desc: "drained; sr0=50", Sr0: 50, Nincs: 4, Ea: utl.LinSpace(0, 0.04, 9)},
+			{Desc: "undrained; sr0=100", Type: "undrained", Sr0: 100, Nincs: 4, Ea: utl.LinSpace(0, 0.04, 9)},
+		},
+	}
+	for _, t := range cal.Tests {
+		n := len(t.Ea)
+		t.Q, t.P = make([]float64, n), make([]float64, n)
+	}
+	err := cal.Init()
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+
+	// drained test: constant radial stress and εd = εa - εv/3 (compression positive)
+	drv, err := cal.Simulate(0)
+	if err != nil {
+		tst.Errorf("Simulate failed: %v\n", err)
+		return
+	}
+	for i, s := range drv.Res {
+		ε := drv.Eps[i]
+		_, _, ed := tsr.M_devε(make([]float64, len(ε)), ε)
+		chk.Scalar(tst, io.Sf("drained: σr @ %d", i), 1e-8, s.Sig[0], -50)
+		chk.Scalar(tst, io.Sf("drained: εd @ %d", i), 1e-14, ed, -ε[2]+tsr.M_εv(ε)/3.0)
+	}
+
+	// undrained test: constant volume and εd = εa
+	drv, err = cal.Simulate(1)
+	if err != nil {
+		tst.Errorf("Simulate failed: %v\n", err)
+		return
+	}
+	for i, ε := range drv.Eps {
+		_, _, ed := tsr.M_devε(make([]float64, len(ε)), ε)
+		chk.Scalar(tst, io.Sf("undrained: εv @ %d", i), 1e-15, tsr.M_εv(ε), 0)
+		chk.Scalar(tst, io.Sf("undrained: εd @ %d", i), 1e-15, ed, -ε[2])
+	}
+
+	// measurements: q and εv (drained) and q and p (undrained). without εv, the predictions of
+	// the drained test are computed at the measured εa and thus at the εd given by the model
+	_, q, ev, p, err := cal.Predict()
+	if err != nil {
+		tst.Errorf("Predict failed: %v\n", err)
+		return
+	}
+	cal.Tests[0].Ev, cal.Tests[0].P = ev[0], nil
+	for i, t := range cal.Tests {
+		copy(t.Q, q[i])
+		copy(t.P, p[i])
+		io.Pforan("%s: q = %v\n", t.Desc, t.Q)
+	}
+	io.Pforan("%s: ev = %v\n", cal.Tests[0].Desc, cal.Tests[0].Ev)
+	io.Pforan("%s: p  = %v\n", cal.Tests[1].Desc, cal.Tests[1].P)
+
+	// calibrate
+	cal.Method = "evol"
+	cal.Prms.Find("M").V = 0.8
+	cal.Prms.Find("qy0").V = 2
+	err = cal.Run()
+	if err != nil {
+		tst.Errorf("Run failed: %v\n", err)
+		return
+	}
+	io.Pforan("cost = %g\n%v\n", cal.Cost, cal.Prms)
+	chk.Scalar(tst, "M", 1e-6, cal.Prms.Find("M").V, 1)
+	chk.Scalar(tst, "qy0", 1e-5, cal.Prms.Find("qy0").V, 5)
+}
@@ -0,0 +1,132 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/num"
+)
+
+func Test_ijointmc01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ijointmc01. slip with dilatancy: consistent matrix")
+
+	// model
+	var mdl IjointMC
+	err := mdl.Init(3, []*fun.Prm{
+		&fun.Prm{N: "kn", V: 1000},
+		&fun.Prm{N: "ks", V: 500},
+		&fun.Prm{N: "c", V: 2},
+		&fun.Prm{N: "phi", V: 30},
+		&fun.Prm{N: "psi", V: 10},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+
+	// initial state
+	s0, err := mdl.InitIntVars([]float64{-10, 0, 0})
+	if err != nil {
+		tst.Errorf("InitIntVars failed: %v\n", err)
+		return
+	}
+
+	// update
+	Δw := []float64{0, 0.02, 0.01}
+	s := s0.GetCopy()
+	err = mdl.Update(s, Δw)
+	if err != nil {
+		tst.Errorf("Update failed: %v\n", err)
+		return
+	}
+	io.Pforan("σ = %v  slip = %v\n", s.Sig, s.Alp[0])
+	if !s.Loading {
+		tst.Errorf("state must be plastic\n")
+		return
+	}
+
+	// tractions must be on the yield surface and slip parallel to trial shear traction
+	τ := math.Sqrt(s.Sig[1]*s.Sig[1] + s.Sig[2]*s.Sig[2])
+	chk.Scalar(tst, "f", 1e-12, τ+s.Sig[0]*math.Tan(math.Pi/6.0)-2, 0)
+	chk.Scalar(tst, "τ2/τ1", 1e-12, s.Sig[2]/s.Sig[1], 0.5)
+	chk.Scalar(tst, "σn", 1e-12, s.Sig[0], -10-1000*s.Alp[0]*math.Tan(math.Pi/18.0))
+
+	// consistent matrix
+	D := la.MatAlloc(3, 3)
+	err = mdl.CalcD(D, s, false)
+	if err != nil {
+		tst.Errorf("CalcD failed: %v\n", err)
+		return
+	}
+	verb := io.Verbose
+	var tmp float64
+	stmp := s0.GetCopy()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			dnum := num.DerivCen(func(x float64, args ...interface{}) (res float64) {
+				tmp, Δw[j] = Δw[j], x
+				stmp.Set(s0)
+				mdl.Update(stmp, Δw)
+				res = stmp.Sig[i]
+				Δw[j] = tmp
+				return
+			}, Δw[j])
+			chk.AnaNum(tst, io.Sf("D%d%d", i, j), 1e-6, D[i][j], dnum, verb)
+		}
+	}
+}
+
+func Test_ijointmc02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ijointmc02. opening, closing and frictional slip")
+
+	// model
+	var mdl IjointMC
+	err := mdl.Init(2, []*fun.Prm{
+		&fun.Prm{N: "kn", V: 1000},
+		&fun.Prm{N: "ks", V: 500},
+		&fun.Prm{N: "c", V: 2},
+		&fun.Prm{N: "phi", V: 30},
+		&fun.Prm{N: "sigt", V: 1},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+	s, err := mdl.InitIntVars([]float64{0, 0})
+	if err != nil {
+		tst.Errorf("InitIntVars failed: %v\n", err)
+		return
+	}
+	D := la.MatAlloc(2, 2)
+
+	// opening beyond tensile strength
+	mdl.Update(s, []float64{0.002, 0})
+	mdl.CalcD(D, s, false)
+	chk.Vector(tst, "σ (open)", 1e-15, s.Sig, []float64{0, 0})
+	chk.Matrix(tst, "D (open)", 1e-15, D, [][]float64{{0, 0}, {0, 0}})
+	chk.Scalar(tst, "broken", 1e-15, s.Alp[1], 1)
+
+	// closing
+	mdl.Update(s, []float64{-0.003, 0})
+	mdl.CalcD(D, s, false)
+	chk.Vector(tst, "σ (closed)", 1e-12, s.Sig, []float64{-1, 0})
+	chk.Matrix(tst, "D (closed)", 1e-12, D, [][]float64{{1000, 0}, {0, 500}})
+
+	// slip without cohesion: bond is broken
+	mdl.Update(s, []float64{0, 0.01})
+	tφ := math.Tan(math.Pi / 6.0)
+	chk.Vector(tst, "σ (slip)", 1e-12, s.Sig, []float64{-1, tφ})
+	chk.Scalar(tst, "slip", 1e-12, s.Alp[0], (5-tφ)/500)
+}
@@ -0,0 +1,189 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/num"
+	"github.com/cpmech/gosl/utl"
+)
+
+func Test_mc01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mc01. derivatives of yield function and plastic potential")
+
+	var mdl MohrCoulomb
+	err := mdl.Init(3, false, []*fun.Prm{
+		&fun.Prm{N: "E", V: 10000},
+		&fun.Prm{N: "nu", V: 0.3},
+		&fun.Prm{N: "c", V: 10},
+		&fun.Prm{N: "phi", V: 30},
+		&fun.Prm{N: "psi", V: 10},
+		&fun.Prm{N: "sigt", V: 5},
+		&fun.Prm{N: "tsm", V: 1},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+
+	// derivatives
+	N := make([]float64, 3)
+	Nb := make([]float64, 3)
+	Ntmp := make([]float64, 3)
+	A := make([]float64, 1)
+	h := make([]float64, 1)
+	α := make([]float64, 1)
+	Mb := la.MatAlloc(3, 3)
+	a := la.MatAlloc(1, 3)
+	b := la.MatAlloc(1, 3)
+	c := la.MatAlloc(1, 1)
+
+	// principal stresses: generic, near compression and extension meridians, near apex and
+	// near tension cut-off
+	tol := 1e-6
+	verb := io.Verbose
+	var tmp float64
+	for _, σ := range [][]float64{
+		{-10, -30, -50},
+		{-20, -40.5, -40},
+		{-20, -20.3, -40},
+		{17, 16.9, 17.2},
+		{4, -20, -40},
+	} {
+		io.Pforan("σ = %v\n", σ)
+		err = mdl.L_SecondDerivs(N, Nb, A, h, Mb, a, b, c, σ, α)
+		if err != nil {
+			tst.Errorf("L_SecondDerivs failed: %v\n", err)
+			return
+		}
+		for j := 0; j < 3; j++ {
+			dnum := num.DerivCen(func(x float64, args ...interface{}) (res float64) {
+				tmp, σ[j] = σ[j], x
+				res = mdl.L_YieldFunc(σ, α)
+				σ[j] = tmp
+				return
+			}, σ[j])
+			chk.AnaNum(tst, io.Sf("N%d", j), tol, N[j], dnum, verb)
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				dnum := num.DerivCen(func(x float64, args ...interface{}) (res float64) {
+					tmp, σ[j] = σ[j], x
+					mdl.L_FlowHard(Ntmp, h, σ, α)
+					res = Ntmp[i]
+					σ[j] = tmp
+					return
+				}, σ[j])
+				chk.AnaNum(tst, io.Sf("Mb%d%d", i, j), tol, Mb[i][j], dnum, verb)
+			}
+		}
+	}
+}
+
+func Test_mc02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mc02. stress-strain path and consistent matrix")
+
+	// allocate driver
+	ndim, pstress := 2, false
+	simfnk, modelname := "test", "mc"
+	var drv Driver
+	err := drv.Init(simfnk, modelname, ndim, pstress, []*fun.Prm{
+		&fun.Prm{N: "E", V: 1500},
+		&fun.Prm{N: "nu", V: 0.25},
+		&fun.Prm{N: "c", V: 1},
+		&fun.Prm{N: "phi", V: 25},
+		&fun.Prm{N: "psi", V: 5},
+		&fun.Prm{N: "sigt", V: 1},
+	})
+	drv.CheckD = true
+	drv.TolD = 1e-4
+	if err != nil {
+		tst.Errorf("test failed: %v\n", err)
+		return
+	}
+
+	// mc model
+	mc := drv.model.(*MohrCoulomb)
+
+	// path
+	p0 := 0.0
+	DP := []float64{2, -1, -3}
+	DQ := []float64{6, 0, 0}
+	nincs := 1
+	niout := 1
+	noise := 0.0
+	var pth Path
+	err = pth.SetPQstrain(ndim, nincs, niout, mc.K, mc.G, p0, DP, DQ, noise)
+	if err != nil {
+		tst.Errorf("test failed: %v\n", err)
+		return
+	}
+
+	// run
+	err = drv.Run(&pth)
+	if err != nil {
+		tst.Errorf("test failed: %v\n", err)
+		return
+	}
+
+	// stresses must be admissible
+	for i, s := range drv.Res {
+		f := mc.YieldFuncs(s)[0]
+		if f > 1e-8 {
+			tst.Errorf("stress state %d is not admissible: f = %g\n", i, f)
+			return
+		}
+	}
+}
+
+func Test_mc03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mc03. drained triaxial compression: strength")
+
+	// allocate driver
+	c, φ := 10.0, 30.0
+	ndim, pstress := 2, false
+	simfnk, modelname := "test", "mc"
+	var drv Driver
+	err := drv.Init(simfnk, modelname, ndim, pstress, []*fun.Prm{
+		&fun.Prm{N: "E", V: 10000},
+		&fun.Prm{N: "nu", V: 0.3},
+		&fun.Prm{N: "c", V: c},
+		&fun.Prm{N: "phi", V: φ},
+		&fun.Prm{N: "psi", V: 10},
+	})
+	if err != nil {
+		tst.Errorf("test failed: %v\n", err)
+		return
+	}
+
+	// run
+	sr := 100.0
+	err = drv.RunTriax(sr, sr, utl.LinSpace(0, 0.05, 11), 10, "triax")
+	if err != nil {
+		tst.Errorf("test failed: %v\n", err)
+		return
+	}
+
+	// final deviatoric stress: rounding of corners reduces the strength by about 1.5%
+	sφ := math.Sin(φ * math.Pi / 180.0)
+	qmc := 2.0 * (sr*sφ + c*math.Sqrt(1.0-sφ*sφ)) / (1.0 - sφ)
+	s := drv.Res[len(drv.Res)-1]
+	q := s.Sig[0] - s.Sig[2]
+	io.Pforan("q = %v  qmc = %v\n", q, qmc)
+	chk.Scalar(tst, "σx", 1e-8, s.Sig[0], -sr)
+	chk.Scalar(tst, "q/qmc", 0.03, q/qmc, 1)
+}
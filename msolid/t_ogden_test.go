@@ -0,0 +1,156 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/num"
+	"github.com/cpmech/gosl/tsr"
+)
+
+func Test_ogden01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ogden01")
+
+	// model
+	var mdl Ogden
+	ndim, pstress := 3, false
+	err := mdl.Init(ndim, pstress, []*fun.Prm{
+		&fun.Prm{N: "alp1", V: 2},
+		&fun.Prm{N: "mu1", V: 300},
+		&fun.Prm{N: "K", V: 1000},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+
+	// state
+	s, err := mdl.InitIntVars(make([]float64, 6))
+	if err != nil {
+		tst.Errorf("InitIntVars failed: %v\n", err)
+		return
+	}
+
+	// undeformed state => zero stresses
+	F := tsr.Alloc2()
+	for i := 0; i < 3; i++ {
+		F[i][i] = 1
+	}
+	err = mdl.Update(s, F, F)
+	if err != nil {
+		tst.Errorf("Update failed: %v\n", err)
+		return
+	}
+	chk.Vector(tst, "σ(F=I)", 1e-10, s.Sig, nil)
+
+	// uniaxial stretch (neo-Hookean-like case with α = 2)
+	λ := 1.2
+	F[0][0] = λ
+	err = mdl.Update(s, F, F)
+	if err != nil {
+		tst.Errorf("Update failed: %v\n", err)
+		return
+	}
+	J := λ
+	f := (λ*λ + 2.0) / 3.0
+	μ, K := 300.0, 1000.0
+	σx := (μ*math.Pow(J, -2.0/3.0)*(λ*λ-f) + K*math.Log(J)) / J
+	σy := (μ*math.Pow(J, -2.0/3.0)*(1.0-f) + K*math.Log(J)) / J
+	io.Pforan("σ = %v\n", s.Sig)
+	chk.Vector(tst, "σ(uniaxial)", 1e-10, s.Sig, []float64{σx, σy, σy, 0, 0, 0})
+}
+
+func Test_ogden02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ogden02")
+
+	// model with two terms
+	var mdl Ogden
+	ndim, pstress := 3, false
+	err := mdl.Init(ndim, pstress, []*fun.Prm{
+		&fun.Prm{N: "alp1", V: 1.3},
+		&fun.Prm{N: "mu1", V: 600},
+		&fun.Prm{N: "alp2", V: 5},
+		&fun.Prm{N: "mu2", V: 1.2},
+		&fun.Prm{N: "K", V: 2000},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+
+	// state
+	s, err := mdl.InitIntVars(make([]float64, 6))
+	if err != nil {
+		tst.Errorf("InitIntVars failed: %v\n", err)
+		return
+	}
+
+	// deformation gradient
+	F := [][]float64{
+		{1.10, 0.20, 0.05},
+		{0.10, 0.95, 0.15},
+		{0.02, 0.08, 1.05},
+	}
+	err = mdl.Update(s, F, F)
+	if err != nil {
+		tst.Errorf("Update failed: %v\n", err)
+		return
+	}
+
+	// tangent modulus
+	A := tsr.Alloc4()
+	err = mdl.CalcA(A, s, true)
+	if err != nil {
+		tst.Errorf("CalcA failed: %v\n", err)
+		return
+	}
+
+	// Kirchhoff stress as a function of F
+	stmp, _ := mdl.InitIntVars(make([]float64, 6))
+	Ftmp := tsr.Alloc2()
+	τ := func(i, j int) float64 {
+		mdl.Update(stmp, Ftmp, Ftmp)
+		return man2ten2(stmp.Sig, i, j) * mdl.J
+	}
+
+	// check A[i][j][k][l] = (1/J) * Σ_m ∂τ_ij/∂F_km * F_lm - σ_il * δ_jk
+	J := mdl.J
+	tol := 1e-4
+	verb := chk.Verbose
+	var tmp float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					anum := 0.0
+					for m := 0; m < 3; m++ {
+						la.MatCopy(Ftmp, 1, F)
+						dτdF := num.DerivCen(func(x float64, args ...interface{}) (res float64) {
+							tmp, Ftmp[k][m] = Ftmp[k][m], x
+							res = τ(i, j)
+							Ftmp[k][m] = tmp
+							return
+						}, F[k][m])
+						anum += dτdF * F[l][m] / J
+					}
+					if j == k {
+						anum -= man2ten2(s.Sig, i, l)
+					}
+					chk.AnaNum(tst, io.Sf("A%d%d%d%d", i, j, k, l), tol, A[i][j][k][l], anum, verb)
+				}
+			}
+		}
+	}
+}
@@ -39,3 +39,11 @@ func min(a, b float64) float64 {
 	}
 	return b
 }
+
+// iabs returns the absolute value of an integer
+func iabs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -11,23 +11,30 @@ import (
 	"github.com/cpmech/gosl/la"
 )
 
+// ComputeExtrapolatedValues extrapolates ip values of Dom to vertices; results go to ExVals
 func ComputeExtrapolatedValues(extrapKeys []string) {
+	ExVals = extrapolate(Dom, extrapKeys)
+}
+
+// extrapolate extrapolates ip values of a domain to vertices
+//  Output: exvals -- [nverts][nkeys] extrapolated values with keys prefixed by "ex_"
+func extrapolate(dom *fem.Domain, extrapKeys []string) (exvals []map[string]float64) {
 
 	// auxiliary
-	verts := Dom.Msh.Verts
-	cells := Dom.Msh.Cells
+	verts := dom.Msh.Verts
+	cells := dom.Msh.Cells
 
 	// allocate structures for extrapolation
 	nverts := len(verts)
-	ExVals = make([]map[string]float64, nverts)
+	exvals = make([]map[string]float64, nverts)
 	counts := make([]map[string]float64, nverts)
 	for i := 0; i < nverts; i++ {
-		ExVals[i] = make(map[string]float64)
+		exvals[i] = make(map[string]float64)
 		counts[i] = make(map[string]float64)
 	}
 
 	// loop over elements
-	for _, ele := range Dom.Elems {
+	for _, ele := range dom.Elems {
 
 		// get shape and integration points from known elements
 		var sha *shp.Shape
@@ -61,12 +68,12 @@ func ComputeExtrapolatedValues(extrapKeys []string) {
 		// perform extrapolation
 		cell := cells[ele.Id()]
 		for j := 0; j < len(ips); j++ {
-			vals := dat[j].Calc(Dom.Sol)
+			vals := dat[j].Calc(dom.Sol)
 			for _, key := range extrapKeys {
 				if val, ok := vals[key]; ok {
 					for i := 0; i < sha.Nverts; i++ {
 						v := cell.Verts[i]
-						ExVals[v]["ex_"+key] += Emat[i][j] * val
+						exvals[v]["ex_"+key] += Emat[i][j] * val
 					}
 				} else {
 					chk.Panic("ip does not have key = %s", key)
@@ -86,7 +93,8 @@ func ComputeExtrapolatedValues(extrapKeys []string) {
 	// compute average
 	for i := 0; i < nverts; i++ {
 		for key, cnt := range counts[i] {
-			ExVals[i]["ex_"+key] /= cnt
+			exvals[i]["ex_"+key] /= cnt
 		}
 	}
+	return
 }
@@ -27,6 +27,9 @@ type AtIp []float64
 // Ids or tags of vertices can be stored in Verts
 type N []int
 
+// F implements locator of nodes on faces with given tags
+type F []int
+
 // P implements [element][integrationPoint] locator
 // Pairs of ids or tags of cells and integration points indices can be stored in Cells
 //  Note: 1) negative element ids means element tags
@@ -119,6 +122,33 @@ func (o N) Locate() (res Points) {
 	return
 }
 
+// Locate finds nodes on tagged faces
+//  Note: vertices shared by faces with different tags (e.g. corners) are located only once
+func (o F) Locate() (res Points) {
+	var A []float64             // reference point
+	found := make(map[int]bool) // vertices already located
+	for _, tag := range o {
+		vids, ok := Dom.Msh.FaceTag2verts[tag]
+		if !ok {
+			chk.Panic("cannot find face with tag = %d", tag)
+		}
+		for _, vid := range vids {
+			if found[vid] {
+				continue
+			}
+			found[vid] = true
+			q := get_nod_point(vid, A)
+			if q != nil {
+				res = append(res, q)
+				if A == nil {
+					A = q.X
+				}
+			}
+		}
+	}
+	return
+}
+
 // Locate finds points
 func (o P) Locate() (res Points) {
 	var A []float64 // reference point
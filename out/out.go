@@ -130,13 +130,8 @@ func Start(simfnpath string, stageIdx, regionIdx int) {
 	first := true
 
 	// add integration points to slice of ips and to bins
-	for cid, ele := range Dom.Cid2elem {
-		if ele == nil {
-			continue
-		}
-		dat := ele.OutIpsData()
-		nip := len(dat)
-		ids := make([]int, nip)
+	add_ips := func(dat []*fem.OutIpData) (ids []int) {
+		ids = make([]int, len(dat))
 		for i, d := range dat {
 
 			// add to bins
@@ -169,6 +164,17 @@ func Start(simfnpath string, stageIdx, regionIdx int) {
 				}
 			}
 		}
-		Cid2ips[cid] = ids
+		return
+	}
+	for cid, ele := range Dom.Cid2elem {
+		if ele == nil {
+			continue
+		}
+		Cid2ips[cid] = add_ips(ele.OutIpsData())
+	}
+
+	// add slave vertices of contact pairs; e.g. with contact pressures "pn"
+	for _, c := range Dom.Contacts {
+		add_ips(c.OutIpsData())
 	}
 }
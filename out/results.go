@@ -7,6 +7,7 @@ package out
 import (
 	"strings"
 
+	"github.com/cpmech/gofem/fem"
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/num"
 	"github.com/cpmech/gosl/utl"
@@ -65,6 +66,12 @@ func LoadResults(times []float64) {
 	}
 	TimeInds, Times = utl.GetITout(Sum.OutTimes, times, TolT)
 
+	// indexed results file and nodal values only
+	if fem.Global.Sim.Data.Indexed && Extrap == nil && !has_ips() {
+		load_nodal_results()
+		return
+	}
+
 	// for each selected output time
 	for _, tidx := range TimeInds {
 
@@ -92,11 +99,17 @@ func LoadResults(times []float64) {
 				// handle node
 				if vid >= 0 {
 
-					// add dofs to results map
+					// add dofs (reactions and sensitivities) to results map
 					nod := Dom.Vid2node[vid]
 					for _, dof := range nod.Dofs {
 						if dof != nil {
 							utl.StrDblsMapAppend(&p.Vals, dof.Key, Dom.Sol.Y[dof.Eq])
+							if fem.Global.Sim.Data.React {
+								utl.StrDblsMapAppend(&p.Vals, Dom.Y2R[dof.Key], Dom.Sol.R[dof.Eq])
+							}
+							for k, dydp := range Dom.Sol.Sens {
+								utl.StrDblsMapAppend(&p.Vals, fem.SensKey(dof.Key, k), dydp[dof.Eq])
+							}
 						}
 					}
 
@@ -121,6 +134,80 @@ func LoadResults(times []float64) {
 	}
 }
 
+// load_nodal_results loads the nodal values of all points by reading only the required
+// components of Y from the indexed results file; i.e. without decoding the whole domain
+func load_nodal_results() {
+
+	// points, keys and equations
+	var pts []*Point
+	var keys []string
+	var eqs []int
+	for _, ps := range Results {
+		for _, p := range ps {
+			if p.Vid < 0 {
+				continue
+			}
+			for _, dof := range Dom.Vid2node[p.Vid].Dofs {
+				if dof != nil {
+					pts = append(pts, p)
+					keys = append(keys, dof.Key)
+					eqs = append(eqs, dof.Eq)
+				}
+			}
+		}
+	}
+
+	// results file of root processor
+	res := fem.OpenResFile(fem.ResFilePath(Sum.Dirout, Sum.Fnkey, 0), false)
+	if res == nil {
+		chk.Panic("cannot open results file; please check log file")
+	}
+
+	// for each selected output time
+	for _, tidx := range TimeInds {
+		if res.Nfloats(tidx, "Y") != Dom.Ny {
+			chk.Panic("inconsistency of results detected: summary and simulation file might be different")
+		}
+		vals, ok := res.ReadFloats(tidx, "Y", eqs)
+		if !ok {
+			chk.Panic("cannot load results from file; please check log file")
+		}
+		for i, p := range pts {
+			utl.StrDblsMapAppend(&p.Vals, keys[i], vals[i])
+		}
+		if fem.Global.Sim.Data.React {
+			reacts, ok := res.ReadFloats(tidx, "R", eqs)
+			if !ok {
+				chk.Panic("cannot load reactions from file; please check log file")
+			}
+			for i, p := range pts {
+				utl.StrDblsMapAppend(&p.Vals, Dom.Y2R[keys[i]], reacts[i])
+			}
+		}
+		for k := range fem.Global.Sim.Data.Sens {
+			sens, ok := res.ReadFloats(tidx, fem.SensField(k), eqs)
+			if !ok {
+				chk.Panic("cannot load sensitivities from file; please check log file")
+			}
+			for i, p := range pts {
+				utl.StrDblsMapAppend(&p.Vals, fem.SensKey(keys[i], k), sens[i])
+			}
+		}
+	}
+}
+
+// has_ips checks whether integration points are among the defined points or not
+func has_ips() bool {
+	for _, pts := range Results {
+		for _, p := range pts {
+			if p.IpId >= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // GetRes gets results as a time or space series corresponding to a given alias
 // for a single point or set of points.
 //  idxI -- index in TimeInds slice corresponding to selected output time; use -1 for the last item.
@@ -152,6 +239,24 @@ func GetRes(key, alias string, idxI int) []float64 {
 	return nil
 }
 
+// SumRes returns the time series of the sum of results over all points corresponding to a given alias
+//  Example: total vertical reaction on a footing: Define("footing", F{-31}) then SumRes("ry", "footing")
+func SumRes(key, alias string) (res []float64) {
+	pts, ok := Results[alias]
+	if !ok {
+		chk.Panic("cannot get sum of %q at %q", key, alias)
+	}
+	res = make([]float64, len(TimeInds))
+	for _, p := range pts {
+		if v, ok := p.Vals[key]; ok {
+			for i := 0; i < len(res); i++ {
+				res[i] += v[i]
+			}
+		}
+	}
+	return
+}
+
 // GetIds return the ids corresponding to alias
 func GetIds(alias string) (vids, ipids []int) {
 	if pts, ok := Results[alias]; ok {
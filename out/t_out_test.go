@@ -213,3 +213,57 @@ func Test_out02(tst *testing.T) {
 		sol.CheckDispl(tst, t, []float64{ux[j], uy[j]}, x, tolu)
 	}
 }
+
+func Test_out03(tst *testing.T) {
+
+	// finalise analysis process and catch errors
+	defer func() {
+		if err := recover(); err != nil {
+			tst.Fail()
+			io.PfRed("ERROR: %v\n", err)
+		} else {
+			fem.End()
+		}
+	}()
+
+	// test title
+	//verbose()
+	chk.PrintTitle("out03. reaction forces")
+
+	// run FE simulation
+	if !fem.Start("data/react01.sim", true, chk.Verbose) {
+		chk.Panic("cannot start FE simulation")
+	}
+	if !fem.Run() {
+		chk.Panic("cannot run FE simulation")
+	}
+
+	// start analysis process
+	Start("data/react01.sim", 0, 0)
+
+	// define points
+	Define("A B C D", N{0, 1, 2, 3})
+	Define("bottom", F{-10})
+	Define("left", F{-13})
+	Define("bottom-left", F{-10, -13}) // corner A is shared by both faces
+
+	// load results
+	LoadResults(nil)
+
+	// nodal reactions
+	tol := 1e-12
+	chk.Vector(tst, "ry @ A", tol, GetRes("ry", "A", 0), []float64{0, 50})
+	chk.Vector(tst, "rx @ A", tol, GetRes("rx", "A", 0), []float64{0, 25})
+	chk.Vector(tst, "ry @ B", tol, GetRes("ry", "B", 0), []float64{0, 50})
+	chk.Vector(tst, "rx @ B", tol, GetRes("rx", "B", 0), []float64{0, 0})
+	chk.Vector(tst, "ry @ C", tol, GetRes("ry", "C", 0), []float64{0, 0})
+
+	// sum over faces
+	chk.Vector(tst, "Σry @ bottom", tol, SumRes("ry", "bottom"), []float64{0, 100})
+	chk.Vector(tst, "Σrx @ left", tol, SumRes("rx", "left"), []float64{0, 50})
+
+	// sum over faces sharing a corner: A must be counted once
+	chk.IntAssert(len(Results["bottom-left"]), 3)
+	chk.Vector(tst, "Σry @ bottom-left", tol, SumRes("ry", "bottom-left"), []float64{0, 100})
+	chk.Vector(tst, "Σrx @ bottom-left", tol, SumRes("rx", "bottom-left"), []float64{0, 50})
+}
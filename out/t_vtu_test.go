@@ -0,0 +1,92 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package out
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cpmech/gofem/fem"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_vtu01(tst *testing.T) {
+
+	// finalise analysis process and catch errors
+	defer func() {
+		if err := recover(); err != nil {
+			tst.Fail()
+			io.PfRed("ERROR: %v\n", err)
+		} else {
+			fem.End()
+		}
+	}()
+
+	// test title
+	//verbose()
+	chk.PrintTitle("vtu01")
+
+	// run FE simulation
+	if !fem.Start("data/onequa4.sim", true, chk.Verbose) {
+		chk.Panic("cannot start FE simulation")
+	}
+	if !fem.Run() {
+		chk.Panic("cannot run FE simulation")
+	}
+
+	// write vtu files
+	Start("data/onequa4.sim", 0, 0)
+	WriteVTU(nil, true)
+
+	// check pvd file
+	dir, fnk := fem.Global.Dirout, fem.Global.Fnkey
+	b, err := ioutil.ReadFile(filepath.Join(dir, fnk+".pvd"))
+	if err != nil {
+		tst.Errorf("cannot read pvd file: %v\n", err)
+		return
+	}
+	chk.IntAssert(strings.Count(string(b), "<DataSet"), len(Sum.OutTimes))
+
+	// check vtu file
+	b, err = ioutil.ReadFile(filepath.Join(dir, io.Sf("%s_%06d.vtu", fnk, len(Sum.OutTimes)-1)))
+	if err != nil {
+		tst.Errorf("cannot read vtu file: %v\n", err)
+		return
+	}
+	vtu := string(b)
+	if !strings.Contains(vtu, "NumberOfPoints=\"4\" NumberOfCells=\"1\"") {
+		tst.Errorf("number of points or cells is incorrect\n")
+		return
+	}
+	for _, name := range []string{"\"u\"", "\"eid\"", "\"tag\""} {
+		if !strings.Contains(vtu, "Name="+name) {
+			tst.Errorf("array %s is missing\n", name)
+			return
+		}
+	}
+
+	// decode coordinates: first array in appended data; 8 chars (header) + 128 chars (96 bytes)
+	app := vtu[strings.Index(vtu, "\n_")+2:]
+	hdr, err := base64.StdEncoding.DecodeString(app[:8])
+	if err != nil {
+		tst.Errorf("cannot decode header: %v\n", err)
+		return
+	}
+	chk.IntAssert(int(binary.LittleEndian.Uint32(hdr)), 96)
+	raw, err := base64.StdEncoding.DecodeString(app[8 : 8+128])
+	if err != nil {
+		tst.Errorf("cannot decode coordinates: %v\n", err)
+		return
+	}
+	X := make([]float64, 12)
+	binary.Read(bytes.NewReader(raw), binary.LittleEndian, X)
+	chk.Vector(tst, "X", 1e-17, X, []float64{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0})
+}
@@ -0,0 +1,514 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package out
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cpmech/gofem/fem"
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/shp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+// VtuWriter writes VTU files with the results of a domain (one file per output time) and PVD
+// collections referencing these files. Two sets of files are generated:
+//   <fnkey>_<tidx>.vtu      -- nodal values, extrapolated values, element ids and tags
+//   <fnkey>_ips_<tidx>.vtu  -- values at integration points (all ip keys)
+//   <fnkey>.pvd and <fnkey>_ips.pvd -- collections
+//  Notes:
+//   1) components of vectors are grouped; e.g. "ux", "uy", "uz" => "u" and "nwlx", "nwly" => "nwl";
+//      stresses "sx", "sy", "sz", "sxy", "syz", "szx" are grouped into "sig"
+//   2) in parallel runs, each processor writes a piece (<fnkey>_p<rank>_...) with its elements and
+//      the root processor writes the corresponding .pvtu files
+type VtuWriter struct {
+
+	// input
+	Dom    *fem.Domain // domain
+	Dirout string      // directory for output
+	Fnkey  string      // filename key
+	Base64 bool        // use base64 encoding of appended data instead of raw binary
+	Extrap []string    // keys to be extrapolated to vertices; e.g. []string{"nwlx", "nwly"}
+
+	// auxiliary
+	rank  int       // my rank
+	nproc int       // number of processors writing pieces
+	times []float64 // output times written so far
+	tidxs []int     // output indices written so far
+}
+
+// vtuFile holds the data of a VTU file
+type vtuFile struct {
+	b64    bool         // base64 encoding of appended data
+	pdata  bytes.Buffer // XML of point data arrays
+	cdata  bytes.Buffer // XML of cell data arrays
+	geom   bytes.Buffer // XML of points and cells
+	app    bytes.Buffer // appended data
+	parrs  []string     // point arrays descriptions for pvtu files
+	carrs  []string     // cell arrays descriptions for pvtu files
+	npts   int          // number of points
+	ncells int          // number of cells
+}
+
+// VTK data types
+var vtuTypes = map[string]string{"float64": "Float64", "int32": "Int32", "uint8": "UInt8"}
+
+// stress keys
+var vtuSigKeys = []string{"sx", "sy", "sz", "sxy", "syz", "szx"}
+
+// NewVtuWriter returns a new VTU writer
+func NewVtuWriter(dom *fem.Domain, dirout, fnkey string) *VtuWriter {
+	o := &VtuWriter{Dom: dom, Dirout: dirout, Fnkey: fnkey, nproc: 1}
+	if fem.Global.Distr {
+		o.rank = fem.Global.Rank
+		o.nproc = fem.Global.Nproc
+	}
+	return o
+}
+
+// WriteVTU writes VTU and PVD files for all output times using the domain allocated by Start
+//  extrap -- keys to be extrapolated to vertices; e.g. []string{"nwlx", "nwly"}; may be nil
+//  b64    -- use base64 encoding instead of raw binary
+func WriteVTU(extrap []string, b64 bool) {
+	w := NewVtuWriter(Dom, fem.Global.Dirout, fem.Global.Fnkey)
+	w.Extrap = extrap
+	w.Base64 = b64
+	for tidx := range Sum.OutTimes {
+		if !Dom.In(Sum, tidx, true) {
+			chk.Panic("cannot load results into domain; please check log file")
+		}
+		err := w.Write(tidx)
+		if err != nil {
+			chk.Panic("cannot write VTU files: %v", err)
+		}
+	}
+}
+
+// VtuOnTheFly sets fem.Global.OutHook such that VTU and PVD files are written by Domain.Out
+//  Note: must be called after fem.Start; the filename key of each region other than the first one
+//        is suffixed by "_r<region index>"
+func VtuOnTheFly(extrap []string, b64 bool) {
+	writers := make(map[*fem.Domain]*VtuWriter)
+	fem.Global.OutHook = func(d *fem.Domain, tidx int) (ok bool) {
+		w, found := writers[d]
+		if !found {
+			fnkey := fem.Global.Fnkey
+			for i, reg := range fem.Global.Sim.Regions {
+				if reg == d.Reg && i > 0 {
+					fnkey += io.Sf("_r%d", i)
+				}
+			}
+			w = NewVtuWriter(d, fem.Global.Dirout, fnkey)
+			w.Extrap = extrap
+			w.Base64 = b64
+			writers[d] = w
+		}
+		return !fem.LogErr(w.Write(tidx), "cannot write VTU files")
+	}
+}
+
+// Write writes the VTU files corresponding to the current state of the domain and updates the
+// PVD collections
+func (o *VtuWriter) Write(tidx int) (err error) {
+
+	// ips and extrapolated values
+	var ips []*fem.OutIpData
+	for _, e := range o.Dom.Elems {
+		ips = append(ips, e.OutIpsData()...)
+	}
+	var exvals []map[string]float64
+	if len(o.Extrap) > 0 {
+		exvals = extrapolate(o.Dom, o.Extrap)
+	}
+
+	// nodes file
+	var f vtuFile
+	f.b64 = o.Base64
+	o.topology(&f)
+	o.nodal_data(&f, exvals)
+	o.cells_data(&f)
+	err = f.save(o.path(tidx, "", o.rank, o.nproc > 1))
+	if err != nil {
+		return
+	}
+	if o.rank == 0 && o.nproc > 1 {
+		err = f.save_pvtu(o, tidx, "")
+		if err != nil {
+			return
+		}
+	}
+
+	// ips file
+	var g vtuFile
+	g.b64 = o.Base64
+	o.ips_data(&g, ips)
+	err = g.save(o.path(tidx, "_ips", o.rank, o.nproc > 1))
+	if err != nil {
+		return
+	}
+	if o.rank == 0 && o.nproc > 1 {
+		err = g.save_pvtu(o, tidx, "_ips")
+		if err != nil {
+			return
+		}
+	}
+
+	// pvd files
+	o.times = append(o.times, o.Dom.Sol.T)
+	o.tidxs = append(o.tidxs, tidx)
+	if o.rank == 0 {
+		for _, label := range []string{"", "_ips"} {
+			var buf bytes.Buffer
+			io.Ff(&buf, "<?xml version=\"1.0\"?>\n<VTKFile type=\"Collection\" version=\"0.1\" byte_order=\"LittleEndian\">\n<Collection>\n")
+			for i, t := range o.times {
+				io.Ff(&buf, "<DataSet timestep=\"%23.15e\" file=\"%s\" />\n", t, filepath.Base(o.path(o.tidxs[i], label, 0, false)))
+			}
+			io.Ff(&buf, "</Collection>\n</VTKFile>\n")
+			err = vtu_savefile(filepath.Join(o.Dirout, o.Fnkey+label+".pvd"), &buf)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// topology writes points and cells of the active elements in this processor
+func (o *VtuWriter) topology(f *vtuFile) {
+	lbb := o.lbb()
+	verts := o.Dom.Msh.Verts
+	cells := o.Dom.Msh.Cells
+	ndim := o.Dom.Msh.Ndim
+	X := make([]float64, 3*len(verts))
+	for i, v := range verts {
+		for j := 0; j < ndim; j++ {
+			X[3*i+j] = v.C[j]
+		}
+	}
+	var conn, offsets []int32
+	var types []uint8
+	for _, e := range o.Dom.Elems {
+		cell := cells[e.Id()]
+		vtk, nverts := vtu_cell(cell, lbb)
+		for j := 0; j < nverts; j++ {
+			conn = append(conn, int32(cell.Verts[j]))
+		}
+		offsets = append(offsets, int32(len(conn)))
+		types = append(types, uint8(vtk))
+	}
+	f.npts = len(verts)
+	f.ncells = len(o.Dom.Elems)
+	f.points(X)
+	f.cells(conn, offsets, types)
+}
+
+// nodal_data writes the primary variables and extrapolated values at vertices
+func (o *VtuWriter) nodal_data(f *vtuFile, exvals []map[string]float64) {
+
+	// all keys
+	keyset := make(map[string]bool)
+	for _, n := range o.Dom.Nodes {
+		for _, dof := range n.Dofs {
+			keyset[dof.Key] = true
+		}
+	}
+	nverts := len(o.Dom.Msh.Verts)
+
+	// primary variables and derivatives
+	sol := o.Dom.Sol
+	for _, group := range vtu_groups(keyset, false) {
+		name, keys := group[0], group[1:]
+		o.nodal_array(f, name, keys, sol.Y)
+		if !fem.Global.Sim.Data.Steady && o.Dom.Dof2Tnum[keys[0]] == 2 {
+			o.nodal_array(f, name+"_vel", keys, sol.Dydt)
+			o.nodal_array(f, name+"_acc", keys, sol.D2ydt2)
+		}
+	}
+
+	// extrapolated values
+	if exvals == nil {
+		return
+	}
+	exset := make(map[string]bool)
+	for _, key := range o.Extrap {
+		exset["ex_"+key] = true
+	}
+	for _, group := range vtu_groups(exset, false) {
+		name, keys := group[0], group[1:]
+		ncomp := len(keys)
+		vals := make([]float64, ncomp*nverts)
+		for i := 0; i < nverts; i++ {
+			for j, key := range keys {
+				vals[ncomp*i+j] = exvals[i][key]
+			}
+		}
+		f.array(&f.pdata, &f.parrs, name, ncomp, vals)
+	}
+}
+
+// nodal_array writes an array with nodal values taken from Y, Dydt or D2ydt2
+func (o *VtuWriter) nodal_array(f *vtuFile, name string, keys []string, Y []float64) {
+	ncomp := len(keys)
+	vals := make([]float64, ncomp*len(o.Dom.Msh.Verts))
+	for i, n := range o.Dom.Vid2node {
+		if n == nil {
+			continue
+		}
+		for j, key := range keys {
+			if eq := n.GetEq(key); eq >= 0 {
+				vals[ncomp*i+j] = Y[eq]
+			}
+		}
+	}
+	f.array(&f.pdata, &f.parrs, name, ncomp, vals)
+}
+
+// cells_data writes the ids and (positive) tags of elements
+func (o *VtuWriter) cells_data(f *vtuFile) {
+	cells := o.Dom.Msh.Cells
+	eids := make([]int32, len(o.Dom.Elems))
+	tags := make([]int32, len(o.Dom.Elems))
+	for i, e := range o.Dom.Elems {
+		eids[i] = int32(e.Id())
+		tags[i] = int32(iabs(cells[e.Id()].Tag))
+	}
+	f.array(&f.cdata, &f.carrs, "eid", 1, eids)
+	f.array(&f.cdata, &f.carrs, "tag", 1, tags)
+}
+
+// ips_data writes the integration points, their values and the ids and tags of their elements
+func (o *VtuWriter) ips_data(f *vtuFile, ips []*fem.OutIpData) {
+
+	// topology
+	nip := len(ips)
+	X := make([]float64, 3*nip)
+	conn := make([]int32, nip)
+	offsets := make([]int32, nip)
+	types := make([]uint8, nip)
+	for i, p := range ips {
+		copy(X[3*i:], p.X)
+		conn[i] = int32(i)
+		offsets[i] = int32(i + 1)
+		types[i] = shp.VTK_VERTEX
+	}
+	f.npts = nip
+	f.ncells = nip
+	f.points(X)
+	f.cells(conn, offsets, types)
+
+	// values
+	allvals := make([]map[string]float64, nip)
+	keyset := make(map[string]bool)
+	for i, p := range ips {
+		allvals[i] = p.Calc(o.Dom.Sol)
+		for key := range allvals[i] {
+			keyset[key] = true
+		}
+	}
+	for _, group := range vtu_groups(keyset, true) {
+		name, keys := group[0], group[1:]
+		ncomp := len(keys)
+		vals := make([]float64, ncomp*nip)
+		for i := 0; i < nip; i++ {
+			for j, key := range keys {
+				vals[ncomp*i+j] = allvals[i][key]
+			}
+		}
+		f.array(&f.pdata, &f.parrs, name, ncomp, vals)
+	}
+
+	// elements
+	cells := o.Dom.Msh.Cells
+	eids := make([]int32, nip)
+	tags := make([]int32, nip)
+	for i, p := range ips {
+		eids[i] = int32(p.Eid)
+		tags[i] = int32(iabs(cells[p.Eid].Tag))
+	}
+	f.array(&f.cdata, &f.carrs, "eid", 1, eids)
+	f.array(&f.cdata, &f.carrs, "tag", 1, tags)
+}
+
+// lbb tells whether cells must be drawn with their basic type because of the LBB condition
+func (o *VtuWriter) lbb() bool {
+	if fem.Global.Sim.Data.NoLBB {
+		return false
+	}
+	return o.Dom.YandC["ux"] && (o.Dom.YandC["pl"] || o.Dom.YandC["pg"])
+}
+
+// path returns the path of a VTU file
+func (o *VtuWriter) path(tidx int, label string, rank int, piece bool) string {
+	switch {
+	case piece:
+		return filepath.Join(o.Dirout, io.Sf("%s_p%d%s_%06d.vtu", o.Fnkey, rank, label, tidx))
+	case o.nproc > 1:
+		return filepath.Join(o.Dirout, io.Sf("%s%s_%06d.pvtu", o.Fnkey, label, tidx))
+	}
+	return filepath.Join(o.Dirout, io.Sf("%s%s_%06d.vtu", o.Fnkey, label, tidx))
+}
+
+// vtu_cell returns the VTK code and number of vertices used to draw a cell
+//  Note: cubic and quartic cells are drawn with their corner vertices only; rod-joint and
+//        zero-thickness interface cells are drawn as poly-vertices
+func vtu_cell(cell *inp.Cell, lbb bool) (vtk, nverts int) {
+	ctype := cell.Type
+	switch {
+	case ctype == "joint" || inp.IjointFaceType(ctype) != "":
+		return shp.VTK_POLY_VERTEX, len(cell.Verts)
+	case ctype == "lin4" || ctype == "lin5":
+		return shp.VTK_LINE, 2
+	case lbb || shp.GetVtkCode(ctype) == shp.VTK_POLY_VERTEX:
+		ctype = shp.GetBasicType(ctype)
+	}
+	return shp.GetVtkCode(ctype), shp.GetNverts(ctype)
+}
+
+// vtu_groups groups keys into arrays; e.g. "ux", "uy", "uz" => {"u", "ux", "uy", "uz"}
+//  Output: groups -- [ngroups] {name, key0, key1, ...} sorted by name
+func vtu_groups(keyset map[string]bool, withsig bool) (groups [][]string) {
+	var keys []string
+	for key := range keyset {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	done := make(map[string]bool)
+	if withsig && keyset["sx"] {
+		groups = append(groups, append([]string{"sig"}, vtuSigKeys...))
+		for _, key := range vtuSigKeys {
+			done[key] = true
+		}
+	}
+	for _, key := range keys {
+		if done[key] {
+			continue
+		}
+		if strings.HasSuffix(key, "x") && len(key) > 1 {
+			base := key[:len(key)-1]
+			if keyset[base+"y"] {
+				group := []string{base, base + "x", base + "y", base + "z"}
+				groups = append(groups, group)
+				for _, k := range group[1:] {
+					done[k] = true
+				}
+				continue
+			}
+		}
+		groups = append(groups, []string{key, key})
+		done[key] = true
+	}
+	sort.Sort(vtuGroupsByName(groups))
+	return
+}
+
+// vtu_savefile saves buffer to file
+func vtu_savefile(fnpath string, buf *bytes.Buffer) error {
+	return ioutil.WriteFile(fnpath, buf.Bytes(), 0644)
+}
+
+// vtuGroupsByName sorts groups by name
+type vtuGroupsByName [][]string
+
+func (o vtuGroupsByName) Len() int           { return len(o) }
+func (o vtuGroupsByName) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o vtuGroupsByName) Less(i, j int) bool { return o[i][0] < o[j][0] }
+
+// vtuFile methods //////////////////////////////////////////////////////////////////////////////////
+
+// array adds a data array with appended data
+//  data -- []float64, []int32 or []uint8
+func (o *vtuFile) array(xml *bytes.Buffer, descs *[]string, name string, ncomp int, data interface{}) {
+	var typ string
+	switch data.(type) {
+	case []float64:
+		typ = vtuTypes["float64"]
+	case []int32:
+		typ = vtuTypes["int32"]
+	case []uint8:
+		typ = vtuTypes["uint8"]
+	default:
+		chk.Panic("cannot handle data type %T in VTU file", data)
+	}
+	var raw bytes.Buffer
+	binary.Write(&raw, binary.LittleEndian, data)
+	var hdr bytes.Buffer
+	binary.Write(&hdr, binary.LittleEndian, uint32(raw.Len()))
+	desc := io.Sf("type=\"%s\" Name=\"%s\" NumberOfComponents=\"%d\"", typ, name, ncomp)
+	io.Ff(xml, "<DataArray %s format=\"appended\" offset=\"%d\" />\n", desc, o.app.Len())
+	if descs != nil {
+		*descs = append(*descs, desc)
+	}
+	if o.b64 {
+		o.app.WriteString(base64.StdEncoding.EncodeToString(hdr.Bytes()))
+		o.app.WriteString(base64.StdEncoding.EncodeToString(raw.Bytes()))
+		return
+	}
+	o.app.Write(hdr.Bytes())
+	o.app.Write(raw.Bytes())
+}
+
+// points adds the coordinates of points; X = [x0, y0, z0, x1, y1, z1, ...]
+func (o *vtuFile) points(X []float64) {
+	io.Ff(&o.geom, "<Points>\n")
+	o.array(&o.geom, nil, "coords", 3, X)
+	io.Ff(&o.geom, "</Points>\n<Cells>\n")
+}
+
+// cells adds the connectivities, offsets and types of cells
+func (o *vtuFile) cells(conn, offsets []int32, types []uint8) {
+	o.array(&o.geom, nil, "connectivity", 1, conn)
+	o.array(&o.geom, nil, "offsets", 1, offsets)
+	o.array(&o.geom, nil, "types", 1, types)
+	io.Ff(&o.geom, "</Cells>\n")
+}
+
+// save saves VTU file
+func (o *vtuFile) save(fnpath string) (err error) {
+	var buf bytes.Buffer
+	io.Ff(&buf, "<?xml version=\"1.0\"?>\n<VTKFile type=\"UnstructuredGrid\" version=\"0.1\" byte_order=\"LittleEndian\" header_type=\"UInt32\">\n<UnstructuredGrid>\n")
+	io.Ff(&buf, "<Piece NumberOfPoints=\"%d\" NumberOfCells=\"%d\">\n", o.npts, o.ncells)
+	buf.Write(o.geom.Bytes())
+	io.Ff(&buf, "<PointData>\n")
+	buf.Write(o.pdata.Bytes())
+	io.Ff(&buf, "</PointData>\n<CellData>\n")
+	buf.Write(o.cdata.Bytes())
+	io.Ff(&buf, "</CellData>\n</Piece>\n</UnstructuredGrid>\n")
+	encoding := "raw"
+	if o.b64 {
+		encoding = "base64"
+	}
+	io.Ff(&buf, "<AppendedData encoding=\"%s\">\n_", encoding)
+	buf.Write(o.app.Bytes())
+	io.Ff(&buf, "\n</AppendedData>\n</VTKFile>\n")
+	return vtu_savefile(fnpath, &buf)
+}
+
+// save_pvtu saves the PVTU file referencing the pieces of all processors
+func (o *vtuFile) save_pvtu(w *VtuWriter, tidx int, label string) (err error) {
+	var buf bytes.Buffer
+	io.Ff(&buf, "<?xml version=\"1.0\"?>\n<VTKFile type=\"PUnstructuredGrid\" version=\"0.1\" byte_order=\"LittleEndian\" header_type=\"UInt32\">\n<PUnstructuredGrid GhostLevel=\"0\">\n")
+	io.Ff(&buf, "<PPoints>\n<PDataArray type=\"Float64\" NumberOfComponents=\"3\" />\n</PPoints>\n<PPointData>\n")
+	for _, desc := range o.parrs {
+		io.Ff(&buf, "<PDataArray %s />\n", desc)
+	}
+	io.Ff(&buf, "</PPointData>\n<PCellData>\n")
+	for _, desc := range o.carrs {
+		io.Ff(&buf, "<PDataArray %s />\n", desc)
+	}
+	io.Ff(&buf, "</PCellData>\n")
+	for rank := 0; rank < w.nproc; rank++ {
+		io.Ff(&buf, "<Piece Source=\"%s\" />\n", filepath.Base(w.path(tidx, label, rank, true)))
+	}
+	io.Ff(&buf, "</PUnstructuredGrid>\n</VTKFile>\n")
+	fnpath := w.path(tidx, label, 0, false)
+	return vtu_savefile(fnpath, &buf)
+}
@@ -17,6 +17,7 @@ import (
 const (
 	INVMAP_TOL = 1.0e-10 // tolerance for inverse mapping function
 	INVMAP_NIT = 25      // maximum number of iterations for inverse mapping
+	INSIDE_TOL = 1.0e-8  // tolerance for checking whether natural coordinates are inside element
 )
 
 // InvMap computes the natural coordinates r, given the real coordinate y
@@ -25,6 +26,10 @@ const (
 //   x[ndim][nverts+?] -- coordinates matrix of solid element
 //  Output:
 //   r[3] -- are the natural coordinates of given point
+//  Note: the first trial is the centroid of the reference element; e.g. {0,0,0} for qua/hex and
+//        {1/3,1/3,0} or {1/4,1/4,1/4} for tri/tet. Converged values within INVMAP_TOL of the
+//        boundaries of the reference element are snapped onto them; thus points on faces, edges
+//        or vertices are located exactly. Use IsInside to check whether y is inside the element.
 func (o *Shape) InvMap(r, y []float64, x [][]float64) (err error) {
 
 	// check
@@ -36,6 +41,11 @@ func (o *Shape) InvMap(r, y []float64, x [][]float64) (err error) {
 	e := make([]float64, o.Gndim)  // residual
 	δr := make([]float64, o.Gndim) // corrector
 	r[0], r[1], r[2] = 0, 0, 0     // first trial
+	if o.IsSimplex() {
+		for i := 0; i < o.Gndim; i++ {
+			r[i] = 1.0 / float64(o.Gndim+1)
+		}
+	}
 	it := 0
 	derivs := true
 	for it = 0; it < INVMAP_NIT; it++ {
@@ -80,16 +90,6 @@ func (o *Shape) InvMap(r, y []float64, x [][]float64) (err error) {
 		for i := 0; i < o.Gndim; i++ {
 			r[i] += δr[i]
 			δRnorm += δr[i] * δr[i]
-			// fix r outside range
-			if r[i] < -1.0 || r[i] > 1.0 {
-				if math.Abs(r[i]-(-1.0)) < INVMAP_TOL {
-					r[i] = -1.0
-				}
-				if math.Abs(r[i]-1.0) < INVMAP_TOL {
-					r[i] = 1.0
-				}
-			}
-
 		}
 		if math.Sqrt(δRnorm) < INVMAP_TOL {
 			break
@@ -98,11 +98,53 @@ func (o *Shape) InvMap(r, y []float64, x [][]float64) (err error) {
 
 	// check
 	if it == INVMAP_NIT {
-		return
+		return chk.Err("Inverse mapping did not converge after %d iterations. y=%v\n", INVMAP_NIT, y[:o.Gndim])
+	}
+
+	// fix r near the boundaries of the reference element
+	snap := func(v, bound float64) float64 {
+		if math.Abs(v-bound) < INVMAP_TOL {
+			return bound
+		}
+		return v
+	}
+	for i := 0; i < o.Gndim; i++ {
+		if o.IsSimplex() {
+			r[i] = snap(snap(r[i], 0), 1)
+		} else {
+			r[i] = snap(snap(r[i], -1), 1)
+		}
 	}
 	return
 }
 
+// IsSimplex returns whether this shape is a triangle or a tetrahedron; i.e. the natural
+// coordinates are in [0,1] with r+s(+t) <= 1 instead of in [-1,1]
+func (o *Shape) IsSimplex() bool {
+	return o.BasicType == "tri3" || o.BasicType == "tet4"
+}
+
+// IsInside checks whether natural coordinates r are inside the reference element (including its
+// boundaries) considering a tolerance tol; e.g. INSIDE_TOL
+func (o *Shape) IsInside(r []float64, tol float64) bool {
+	if o.IsSimplex() {
+		sum := 0.0
+		for i := 0; i < o.Gndim; i++ {
+			if r[i] < -tol {
+				return false
+			}
+			sum += r[i]
+		}
+		return sum <= 1.0+tol
+	}
+	for i := 0; i < o.Gndim; i++ {
+		if math.Abs(r[i]) > 1.0+tol {
+			return false
+		}
+	}
+	return true
+}
+
 // GetNodesNatCoordsMat returns the matrix (ξ) with natural coordinates of nodes,
 // augmented by one column which is filled with ones [nverts][ndim+1]
 func (o *Shape) GetNodesNatCoordsMat() (ξ [][]float64) {
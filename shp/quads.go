@@ -59,7 +59,7 @@ func init() {
 	qua9.FaceType = "lin3"
 	qua9.Gndim = 2
 	qua9.Nverts = 9
-	qua9.VtkCode = VTK_POLY_VERTEX
+	qua9.VtkCode = VTK_BIQUADRATIC_QUAD
 	qua9.FaceNverts = 3
 	qua9.FaceLocalV = [][]int{{0, 1, 4}, {1, 2, 5}, {2, 3, 6}, {3, 0, 7}}
 	qua9.NatCoords = [][]float64{
@@ -65,6 +65,18 @@ func Get(geoType string) *Shape {
 	return s
 }
 
+// GetCopy returns a new Shape structure with its own scratchpad; e.g. for concurrent computations
+//  Note: returns nil on errors
+func GetCopy(geoType string) *Shape {
+	s, ok := factory[geoType]
+	if !ok {
+		return nil
+	}
+	c := *s
+	c.init_scratchpad()
+	return &c
+}
+
 // IpRealCoords returns the real coordinates (y) of an integration point
 func (o *Shape) IpRealCoords(x [][]float64, ip *Ipoint) (y []float64) {
 	ndim := len(x)
@@ -70,3 +70,73 @@ func Test_imap(tst *testing.T) {
 		io.PfGreen("OK\n")
 	}
 }
+
+func Test_imap02(tst *testing.T) {
+
+	//utl.Tsilent = false
+	chk.PrintTitle("Test imap02. points on boundaries and outside")
+
+	for _, name := range []string{"qua8", "tri6", "hex8", "tet4", "tet10"} {
+		shape := factory[name]
+		gndim := shape.Gndim
+		nverts := shape.Nverts
+		io.Pfyel("--------------------------------- %-6s---------------------------------\n", name)
+
+		// real coordinates: scaled and translated reference element
+		C := la.MatAlloc(gndim, nverts)
+		for i := 0; i < gndim; i++ {
+			for j := 0; j < nverts; j++ {
+				C[i][j] = 2.0*shape.NatCoords[i][j] + 1.0
+			}
+		}
+
+		// vertices and centroids of faces
+		r := make([]float64, 3)
+		x := make([]float64, 3)
+		for j := 0; j < nverts; j++ {
+			for i := 0; i < gndim; i++ {
+				x[i] = C[i][j]
+			}
+			err := shape.InvMap(r, x, C)
+			if err != nil {
+				tst.Errorf("InvMap failed: %v\n", err)
+				return
+			}
+			for i := 0; i < gndim; i++ {
+				chk.Scalar(tst, io.Sf("vertex %d: r%d", j, i), 1e-15, r[i], shape.NatCoords[i][j])
+			}
+			if !shape.IsInside(r, INSIDE_TOL) {
+				tst.Errorf("vertex %d should be inside\n", j)
+			}
+		}
+		for f, lverts := range shape.FaceLocalV {
+			la.VecFill(x, 0)
+			for _, l := range lverts {
+				for i := 0; i < gndim; i++ {
+					x[i] += C[i][l] / float64(len(lverts))
+				}
+			}
+			err := shape.InvMap(r, x, C)
+			if err != nil {
+				tst.Errorf("InvMap failed: %v\n", err)
+				return
+			}
+			if !shape.IsInside(r, INSIDE_TOL) {
+				tst.Errorf("centroid of face %d should be inside. r=%v\n", f, r)
+			}
+		}
+
+		// point outside
+		for i := 0; i < gndim; i++ {
+			x[i] = C[i][0] - 0.1
+		}
+		err := shape.InvMap(r, x, C)
+		if err != nil {
+			tst.Errorf("InvMap failed: %v\n", err)
+			return
+		}
+		if shape.IsInside(r, INSIDE_TOL) {
+			tst.Errorf("point %v should be outside. r=%v\n", x[:gndim], r)
+		}
+	}
+}
@@ -24,4 +24,5 @@ const (
 	VTK_QUADRATIC_QUAD       = 23
 	VTK_QUADRATIC_TETRA      = 24
 	VTK_QUADRATIC_HEXAHEDRON = 25
+	VTK_BIQUADRATIC_QUAD     = 28
 )
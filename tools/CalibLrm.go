@@ -0,0 +1,89 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"flag"
+	"path"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/mreten"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/plt"
+)
+
+func main() {
+
+	// input data
+	calfn := "lrm-calib.cal"
+	dirout := "/tmp/gofem"
+	doplot := true
+
+	// parse flags
+	flag.Parse()
+	if len(flag.Args()) > 0 {
+		calfn = flag.Arg(0)
+	}
+	if len(flag.Args()) > 1 {
+		dirout = flag.Arg(1)
+	}
+	if len(flag.Args()) > 2 {
+		doplot = io.Atob(flag.Arg(2))
+	}
+
+	// print input data
+	io.Pf("\nInput data\n")
+	io.Pf("==========\n")
+	io.Pf("  calfn  = %30s // calibration filename\n", calfn)
+	io.Pf("  dirout = %30s // directory for output\n", dirout)
+	io.Pf("  doplot = %30v // plot results\n", doplot)
+	io.Pf("\n")
+
+	// read calibration data and measurements
+	cal, err := mreten.ReadCalibrator(path.Dir(calfn), path.Base(calfn))
+	if err != nil {
+		io.PfRed("cannot read calibration data:\n%v\n", err)
+		return
+	}
+
+	// run
+	err = cal.Run()
+	if err != nil {
+		io.PfRed("calibration failed:\n%v\n", err)
+		return
+	}
+	io.Pfgreen("cost = %g\n", cal.Cost)
+	for _, f := range cal.Free {
+		io.Pfgreen("%8s = %g\n", f.N, cal.Prms.Find(f.N).V)
+	}
+
+	// write materials file
+	mdb := inp.MatDb{Materials: inp.MatsData{&inp.Material{
+		Name:  cal.Name,
+		Desc:  cal.Desc,
+		Model: cal.Model,
+		Prms:  cal.Prms,
+	}}}
+	fn := io.FnKey(path.Base(calfn)) + ".mat"
+	io.WriteFileSD(dirout, fn, mdb.String())
+
+	// plot
+	if doplot {
+		sl, err := cal.Predict()
+		if err != nil {
+			io.PfRed("cannot compute predictions:\n%v\n", err)
+			return
+		}
+		fmts := []string{"'b", "'r", "'g", "'m", "'c", "'k"}
+		for i, p := range cal.Paths {
+			f := fmts[i%len(fmts)]
+			plt.Plot(p.Pc, p.Sl, io.Sf("%so', label='%s (data)', clip_on=0", f, p.Desc))
+			plt.Plot(p.Pc, sl[i], io.Sf("%s-', label='%s (%s)', clip_on=0", f, p.Desc, cal.Model))
+		}
+		mreten.PlotEnd(true)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"flag"
+	"path"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/msolid"
+	"github.com/cpmech/gosl/io"
+)
+
+func main() {
+
+	// input data
+	calfn := "sld-calib.cal"
+	dirout := "/tmp/gofem"
+	doplot := true
+
+	// parse flags
+	flag.Parse()
+	if len(flag.Args()) > 0 {
+		calfn = flag.Arg(0)
+	}
+	if len(flag.Args()) > 1 {
+		dirout = flag.Arg(1)
+	}
+	if len(flag.Args()) > 2 {
+		doplot = io.Atob(flag.Arg(2))
+	}
+
+	// print input data
+	io.Pf("\nInput data\n")
+	io.Pf("==========\n")
+	io.Pf("  calfn  = %30s // calibration filename\n", calfn)
+	io.Pf("  dirout = %30s // directory for output\n", dirout)
+	io.Pf("  doplot = %30v // plot results\n", doplot)
+	io.Pf("\n")
+
+	// read calibration data and measurements
+	cal, err := msolid.ReadCalibrator(path.Dir(calfn), path.Base(calfn))
+	if err != nil {
+		io.PfRed("cannot read calibration data:\n%v\n", err)
+		return
+	}
+
+	// run
+	err = cal.Run()
+	if err != nil {
+		io.PfRed("calibration failed:\n%v\n", err)
+		return
+	}
+	io.Pfgreen("cost = %g\n", cal.Cost)
+	for _, f := range cal.Free {
+		io.Pfgreen("%8s = %g\n", f.N, cal.Prms.Find(f.N).V)
+	}
+
+	// write materials file
+	mdb := inp.MatDb{Materials: inp.MatsData{&inp.Material{
+		Name:  cal.Name,
+		Desc:  cal.Desc,
+		Model: cal.Model,
+		Prms:  cal.Prms,
+	}}}
+	fn := io.FnKey(path.Base(calfn)) + ".mat"
+	io.WriteFileSD(dirout, fn, mdb.String())
+
+	// plot
+	if doplot {
+		err = cal.Plot(dirout, io.FnKey(path.Base(calfn)))
+		if err != nil {
+			io.PfRed("cannot plot results:\n%v\n", err)
+		}
+	}
+}